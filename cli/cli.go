@@ -50,6 +50,44 @@ func FindStringArg(name string, args []string) *string {
 	return nil
 }
 
+func FindStringArgs(name string, args []string) []string {
+	var values []string
+	for _, arg := range args {
+		searchArgPrefix := name + "="
+		if strings.HasPrefix(arg, searchArgPrefix) {
+			value := strings.TrimPrefix(arg, searchArgPrefix)
+			if value != "" {
+				values = append(values, value)
+			}
+		}
+	}
+
+	return values
+}
+
+// RemoveArg returns arguments without the flag, so the flag can be before the command argument
+func RemoveArg(name string, args []string) []string {
+	var result []string
+	for _, arg := range args {
+		if arg != name {
+			result = append(result, arg)
+		}
+	}
+
+	return result
+}
+
+// SplitPassArgs returns arguments before "--" and arguments after it (they are passed to INSTEAD)
+func SplitPassArgs(args []string) ([]string, []string) {
+	for i, arg := range args {
+		if arg == "--" {
+			return args[:i], args[i+1:]
+		}
+	}
+
+	return args, nil
+}
+
 func FmtTitle(name string) string {
 	return name
 }
@@ -66,3 +66,15 @@ func TestFindStringlArg(t *testing.T) {
 	nilVal2 := FindStringArg("--lang", strings.Split("list --repository=official", " "))
 	assert.Nil(t, nilVal2)
 }
+
+func TestFindStringArgs(t *testing.T) {
+	args := strings.Split("list --set=lang=en --installed --set=gtk.main_width=800 --set=", " ")
+	assert.Equal(t, []string{"lang=en", "gtk.main_width=800"}, FindStringArgs("--set", args))
+
+	assert.Empty(t, FindStringArgs("--set", strings.Split("list --lang=en", " ")))
+}
+
+func TestLinkArgs(t *testing.T) {
+	args := linkArgs([]string{"open", "insteadman://install/cat-lady", "--portable"})
+	assert.Equal(t, []string{"install", "cat-lady", "--portable"}, args)
+}
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/fatih/color"
+	"github.com/jhekasoft/insteadman3/core/i18n"
+	"github.com/jhekasoft/insteadman3/core/manager"
+)
+
+// printDebugReport prints Lua errors of the debug session and the log file (it can be attached to
+// the bug report), exit code is 1 if INSTEAD has crashed
+func printDebugReport(report *manager.DebugReport) {
+	fmt.Println()
+
+	if len(report.Errors) > 0 {
+		fmt.Println(color.New(color.Bold).Sprintf(i18n.T("Lua errors (%d):"), len(report.Errors)))
+		for i, luaError := range report.Errors {
+			fmt.Printf("%d. %s\n", i+1, color.RedString(luaError.Message))
+			for _, line := range luaError.Traceback {
+				fmt.Printf("       %s\n", line)
+			}
+		}
+	} else {
+		fmt.Println(i18n.T("Lua errors haven't found."))
+	}
+
+	fmt.Printf(i18n.T("Debug log: %s")+"\n", FmtName(report.LogFile))
+
+	if report.Crashed {
+		fmt.Println(color.RedString(i18n.T("INSTEAD has crashed (exit code %d)."), report.ExitCode))
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,414 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"sort"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/jhekasoft/insteadman3/core/configurator"
+	"github.com/jhekasoft/insteadman3/core/gamedev"
+	"github.com/jhekasoft/insteadman3/core/i18n"
+	"github.com/jhekasoft/insteadman3/core/manager"
+)
+
+// dev runs commands for the game authors ("dev run [dir]"), games aren't installed by them
+func dev(m *manager.Manager, args []string) {
+	devArgs := args[1:]
+
+	switch strings.ToLower(GetCommand(devArgs)) {
+	case "run":
+		devRun(m, devArgs)
+
+	case "package":
+		devPackage(m, devArgs)
+
+	case "validate":
+		devValidate(devArgs)
+
+	case "watch":
+		devWatch(m, devArgs)
+
+	case "publish":
+		devPublish(m, devArgs)
+
+	case "site":
+		devSite(devArgs)
+
+	case "new":
+		devNew(devArgs)
+
+	case "test":
+		devTest(m, devArgs)
+
+	case "serve":
+		devServe(devArgs)
+
+	case "l10n":
+		devL10n(devArgs)
+
+	default:
+		printHelpAndExit()
+	}
+}
+
+// devDir returns game directory from the arguments (current directory by default)
+func devDir(args []string) string {
+	if arg := GetCommandArg(args); arg != nil && !strings.HasPrefix(*arg, "-") {
+		return *arg
+	}
+
+	return "."
+}
+
+// devRun runs the game from the directory (current directory by default), INSTEAD output is printed.
+// INSTEAD is run in debug mode with "--debug".
+func devRun(m *manager.Manager, args []string) {
+	dir := devDir(RemoveArg("--debug", args))
+
+	if FindBoolArg("--debug", args) {
+		fmt.Printf(i18n.T("Running game from %s in debug mode...")+"\n", FmtName(dir))
+		report, e := m.DebugGameDir(dir, os.Stdout)
+		ExitIfError(e)
+		printDebugReport(report)
+		return
+	}
+
+	fmt.Printf(i18n.T("Running game from %s...")+"\n", FmtName(dir))
+
+	e := m.RunGameDir(dir, os.Stdout, os.Stderr)
+	ExitIfError(e)
+}
+
+// devPackage packs the game to the archives for the repositories ("--format=zip,idf", "--output=dir")
+func devPackage(m *manager.Manager, args []string) {
+	options := gamedev.PackOptions{Interpreter: m.InterpreterCommand()}
+	if formats := FindStringArg("--format", args); formats != nil {
+		options.Formats = strings.Split(*formats, ",")
+	}
+	if output := FindStringArg("--output", args); output != nil {
+		options.OutputDir = *output
+	}
+
+	md, archives, e := gamedev.Pack(devDir(args), options)
+	ExitIfError(e)
+
+	fmt.Printf(i18n.T("%s %s has packed:")+"\n", FmtName(md.Title), FmtVersion(md.Version))
+	for _, archive := range archives {
+		fmt.Printf("%s\n    "+i18n.T("Size: %s bytes")+"\n    SHA-256: %s\n", archive.Path,
+			FmtSize(strconv.FormatInt(archive.Size, 10)), archive.SHA256)
+	}
+}
+
+// devValidate prints problems of the game directory or archive, exit code is 1 if there are errors
+func devValidate(args []string) {
+	problems, e := gamedev.Validate(devDir(args))
+	ExitIfError(e)
+
+	errorsCount := 0
+	for _, problem := range problems {
+		if problem.Severity == gamedev.SeverityError {
+			errorsCount++
+			fmt.Println(color.RedString(problem.String()))
+		} else {
+			fmt.Println(color.YellowString(problem.String()))
+		}
+	}
+
+	if len(problems) == 0 {
+		fmt.Println(i18n.T("No problems have found."))
+		return
+	}
+
+	fmt.Printf(i18n.T("%d errors, %d warnings")+"\n", errorsCount, len(problems)-errorsCount)
+	if errorsCount > 0 {
+		os.Exit(1)
+	}
+}
+
+// devPublish uploads the game and the updated feed to the repository ("--repository=name", "--lang=en,ru").
+// Repository is required if publishing is configured for several repositories.
+func devPublish(m *manager.Manager, args []string) {
+	var repository *configurator.Repository
+	repositoryName := FindStringArg("--repository", args)
+	for _, repo := range m.Config().Repositories {
+		if repo.Publish == nil || repositoryName != nil && repo.Name != *repositoryName {
+			continue
+		}
+		if repository != nil {
+			ExitIfError(errors.New(i18n.T("publishing is configured for several repositories, use --repository=[name]")))
+		}
+		repo := repo
+		repository = &repo
+	}
+	if repository == nil {
+		ExitIfError(gamedev.ErrNoPublish)
+	}
+
+	options := gamedev.PublishOptions{}
+	if langs := FindStringArg("--lang", args); langs != nil {
+		options.Langs = strings.Split(*langs, ",")
+	}
+
+	fmt.Printf(i18n.T("Publishing to %s...")+"\n", FmtName(repository.Name))
+
+	game, e := gamedev.Publish(devDir(args), *repository, options)
+	ExitIfError(e)
+
+	fmt.Printf(i18n.T("%s %s has published:")+"\n%s\n    "+i18n.T("Size: %s bytes")+"\n", FmtName(game.Title),
+		FmtVersion(game.Version), game.Url, FmtSize(strconv.Itoa(game.Size)))
+}
+
+// devWatch runs the game and restarts it when files of the game have changed ("--interval=1s")
+func devWatch(m *manager.Manager, args []string) {
+	dir := devDir(args)
+	interval := gamedev.WatchInterval
+	if value := FindStringArg("--interval", args); value != nil {
+		var e error
+		interval, e = time.ParseDuration(*value)
+		ExitIfError(e)
+	}
+
+	if !manager.IsGameDir(dir) {
+		ExitIfError(manager.ErrNotGameDir)
+	}
+
+	// stop kills INSTEAD of the current run (if it's still running) and waits for its exit
+	stop := func() {}
+	start := func() {
+		fmt.Printf(i18n.T("Running game from %s...")+"\n", FmtName(dir))
+
+		cmd, e := m.StartGameDir(dir, os.Stdout, os.Stderr)
+		if e != nil {
+			fmt.Println(color.RedString(i18n.T("Error: %v"), e))
+			stop = func() {}
+			return
+		}
+
+		killed := new(int32)
+		done := make(chan struct{})
+		go func() {
+			e := cmd.Wait()
+			if atomic.LoadInt32(killed) == 0 {
+				if e != nil {
+					fmt.Println(color.RedString(i18n.T("INSTEAD has exited: %v"), e))
+				}
+				fmt.Println(i18n.T("Waiting for changes..."))
+			}
+			close(done)
+		}()
+
+		stop = func() {
+			atomic.StoreInt32(killed, 1)
+			cmd.Process.Kill()
+			<-done
+		}
+	}
+
+	start()
+
+	e := gamedev.Watch(dir, interval, nil, func(files []string) {
+		fmt.Printf(i18n.T("Changed: %s")+"\n", strings.Join(files, ", "))
+
+		stop()
+		start()
+	})
+	ExitIfError(e)
+}
+
+// devSite generates static site of the repository from the feed file or URL ("--output=dir", "--title=Games")
+func devSite(args []string) {
+	source := GetCommandArg(args)
+	if source == nil || strings.HasPrefix(*source, "-") {
+		printHelpAndExit()
+	}
+
+	var feed *gamedev.Feed
+	var e error
+	if strings.HasPrefix(*source, "http://") || strings.HasPrefix(*source, "https://") {
+		feed, e = gamedev.DownloadFeed(*source)
+	} else {
+		feed, e = gamedev.ReadFeed(*source)
+	}
+	ExitIfError(e)
+
+	outputDir := "site"
+	if output := FindStringArg("--output", args); output != nil {
+		outputDir = *output
+	}
+	options := gamedev.SiteOptions{}
+	if title := FindStringArg("--title", args); title != nil {
+		options.Title = *title
+	}
+
+	e = gamedev.GenerateSite(feed, outputDir, options)
+	ExitIfError(e)
+
+	fmt.Printf(i18n.N("Site with %d game has generated in %s", "Site with %d games has generated in %s", len(feed.Games))+"\n",
+		len(feed.Games), FmtName(outputDir))
+}
+
+// devNew creates the new game in the directory ("--title=", "--author=", "--lang=en,ru", "--stead2")
+func devNew(args []string) {
+	dir := GetCommandArg(args)
+	if dir == nil || strings.HasPrefix(*dir, "-") {
+		printHelpAndExit()
+	}
+
+	options := gamedev.ProjectOptions{Stead2: FindBoolArg("--stead2", args)}
+	if title := FindStringArg("--title", args); title != nil {
+		options.Title = *title
+	}
+	if author := FindStringArg("--author", args); author != nil {
+		options.Author = *author
+	}
+	if langs := FindStringArg("--lang", args); langs != nil {
+		options.Langs = strings.Split(*langs, ",")
+	}
+
+	e := gamedev.CreateProject(*dir, options)
+	ExitIfError(e)
+
+	fmt.Printf(i18n.T("Game has created in %s, run it by \"dev run %s\"")+"\n", FmtName(*dir), *dir)
+}
+
+// devTest plays test scripts of the game by the headless interpreter ("--script=file", "--timeout=30s",
+// "--verbose" prints output of the interpreter), exit code is 1 if tests have failed
+func devTest(m *manager.Manager, args []string) {
+	dir := devDir(args)
+	options := gamedev.TestOptions{Interpreter: m.Config().HeadlessInterpreter}
+	if value := FindStringArg("--timeout", args); value != nil {
+		var e error
+		options.Timeout, e = time.ParseDuration(*value)
+		ExitIfError(e)
+	}
+
+	scripts := FindStringArgs("--script", args)
+	if len(scripts) == 0 {
+		var e error
+		scripts, e = gamedev.TestScripts(dir)
+		ExitIfError(e)
+	}
+
+	failed := 0
+	for _, script := range scripts {
+		result, e := gamedev.RunTest(dir, script, options)
+		ExitIfError(e)
+
+		if result.Passed() {
+			fmt.Printf("%s %s\n", color.GreenString("PASS"), script)
+		} else {
+			failed++
+			fmt.Printf("%s %s\n", color.RedString("FAIL"), script)
+			for _, failure := range result.Failures {
+				location := script
+				if failure.Line > 0 {
+					location += ":" + strconv.Itoa(failure.Line)
+				}
+				fmt.Printf("    %s: %s\n", location, failure.Message)
+			}
+		}
+
+		if FindBoolArg("--verbose", args) {
+			fmt.Println(result.Output)
+		}
+	}
+
+	fmt.Printf(i18n.T("%d passed, %d failed")+"\n", len(scripts)-failed, failed)
+	if failed > 0 {
+		os.Exit(1)
+	}
+}
+
+// devServe serves directory of the games as the repository ("--addr=:8780", "--title=Games")
+func devServe(args []string) {
+	s := gamedev.NewRepositoryServer(devDir(args))
+	if title := FindStringArg("--title", args); title != nil {
+		s.Title = *title
+	}
+	s.OnError = func(name string, e error) {
+		fmt.Println(color.YellowString(i18n.T("Game %s is skipped: %v"), name, e))
+	}
+
+	addr := gamedev.DefaultServeAddr
+	if value := FindStringArg("--addr", args); value != nil {
+		addr = *value
+	}
+
+	// Packed games are removed after Ctrl+C
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt)
+	go func() {
+		<-interrupt
+		s.Close()
+		os.Exit(0)
+	}()
+
+	host := addr
+	if strings.HasPrefix(host, ":") {
+		host = "localhost" + host
+	}
+	fmt.Printf(i18n.T("Repository is served on %s (use IP address of this computer in LAN)")+"\n"+
+		i18n.T("Press Ctrl+C to stop.")+"\n", FmtURL("http://"+host+"/"+gamedev.SiteFeedXml))
+
+	e := s.ListenAndServe(addr)
+	s.Close()
+	ExitIfError(e)
+}
+
+// devL10n prints translation coverage of the declared languages ("--primary=ru", "--langs=en,ru"),
+// exit code is 1 if translations are incomplete
+func devL10n(args []string) {
+	primary := ""
+	if value := FindStringArg("--primary", args); value != nil {
+		primary = *value
+	}
+	var langs []string
+	if value := FindStringArg("--langs", args); value != nil {
+		langs = strings.Split(*value, ",")
+	}
+
+	report, e := gamedev.LocalizationCoverage(devDir(args), primary, langs)
+	ExitIfError(e)
+
+	fmt.Printf(i18n.T("Primary language: %s")+"\n", FmtLang(report.Primary))
+	if len(report.Langs) == 0 {
+		fmt.Println(i18n.T("There aren't other languages, declare them by $Name(lang) tags or --langs=[en,ru]."))
+	}
+
+	incomplete := 0
+	for _, coverage := range report.Langs {
+		status := color.GreenString(i18n.T("complete"))
+		if !coverage.Complete() {
+			incomplete++
+			status = color.RedString(i18n.T("incomplete"))
+		}
+		fmt.Printf("%s: "+i18n.T("%d%% (%d of %d strings)")+", %s\n", FmtLang(coverage.Lang), coverage.Percent(),
+			coverage.Translated, coverage.Strings, status)
+
+		if coverage.NoTitle {
+			fmt.Printf("    "+i18n.T("there isn't \"-- $Name(%s): ...$\" in the main file")+"\n", coverage.Lang)
+		}
+		for _, file := range coverage.MissingFiles {
+			fmt.Printf("    %s: "+i18n.T("file is missing")+"\n", file)
+		}
+		files := make([]string, 0, len(coverage.MissingStrings))
+		for file := range coverage.MissingStrings {
+			files = append(files, file)
+		}
+		sort.Strings(files)
+		for _, file := range files {
+			fmt.Printf("    %s: "+i18n.T("strings are missing: %s")+"\n", file, strings.Join(coverage.MissingStrings[file], ", "))
+		}
+	}
+
+	if incomplete > 0 {
+		os.Exit(1)
+	}
+}
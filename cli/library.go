@@ -0,0 +1,103 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/jhekasoft/insteadman3/core/i18n"
+	"github.com/jhekasoft/insteadman3/core/manager"
+)
+
+const defaultHistoryLimit = 20
+
+// findGameOrExit returns the game by the keyword of the command
+func findGameOrExit(m *manager.Manager, keyword string) manager.Game {
+	games, e := m.GetSortedGames()
+	ExitIfError(e)
+
+	filteredGames := manager.FilterGames(games, &keyword, nil, nil, false)
+
+	return getOrExitIfNoGame(filteredGames, keyword)
+}
+
+func favorite(m *manager.Manager, args []string) {
+	remove := FindBoolArg("--remove", args)
+	keyword := GetCommandArg(RemoveArg("--remove", args))
+	if keyword == nil {
+		printHelpAndExit()
+	}
+
+	game := findGameOrExit(m, *keyword)
+
+	e := m.DB.SetFavorite(game.Name, !remove)
+	ExitIfError(e)
+
+	if remove {
+		fmt.Printf(i18n.T("Game %s has removed from the favorites.")+"\n", FmtName(game.Title))
+	} else {
+		fmt.Printf(i18n.T("Game %s has added to the favorites.")+"\n", FmtName(game.Title))
+	}
+}
+
+func tag(m *manager.Manager, args []string) {
+	clearTags := FindBoolArg("--clear", args)
+	args = RemoveArg("--clear", args)
+	keyword := GetCommandArg(args)
+	if keyword == nil {
+		printHelpAndExit()
+	}
+
+	game := findGameOrExit(m, *keyword)
+
+	tags := args[2:]
+	if len(tags) == 0 && !clearTags {
+		tags, e := m.DB.GameTags(game.Name)
+		ExitIfError(e)
+		if len(tags) == 0 {
+			fmt.Printf(i18n.T("Game %s hasn't tags.")+"\n", FmtName(game.Title))
+			return
+		}
+		fmt.Println(FmtLang("#" + strings.Join(tags, " #")))
+		return
+	}
+
+	e := m.DB.SetTags(game.Name, tags)
+	ExitIfError(e)
+
+	fmt.Printf(i18n.T("Tags of the game %s have saved.")+"\n", FmtName(game.Title))
+}
+
+func printTags(m *manager.Manager) {
+	tags, e := m.DB.Tags()
+	ExitIfError(e)
+
+	for _, tag := range tags {
+		fmt.Println(FmtLang(tag))
+	}
+}
+
+func history(m *manager.Manager, args []string) {
+	limit := defaultHistoryLimit
+	if value := FindStringArg("--limit", args); value != nil {
+		var e error
+		limit, e = strconv.Atoi(*value)
+		if e != nil || limit < 0 {
+			fmt.Println("Error: --limit should be a number")
+			os.Exit(1)
+		}
+	}
+
+	plays, e := m.DB.History(limit)
+	ExitIfError(e)
+
+	if len(plays) == 0 {
+		fmt.Println(i18n.T("Games haven't played yet."))
+		return
+	}
+
+	for _, play := range plays {
+		fmt.Printf("%s %s\n", play.Time.Format("2006-01-02 15:04"), FmtName(play.Name))
+	}
+}
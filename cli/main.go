@@ -1,25 +1,62 @@
 package main
 
 import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
+	"path/filepath"
 	"strings"
 
 	"github.com/fatih/color"
 	"github.com/jhekasoft/insteadman3/core/configurator"
+	"github.com/jhekasoft/insteadman3/core/crashreport"
+	"github.com/jhekasoft/insteadman3/core/fileassoc"
+	"github.com/jhekasoft/insteadman3/core/gamedev"
+	"github.com/jhekasoft/insteadman3/core/i18n"
 	"github.com/jhekasoft/insteadman3/core/interpreterfinder"
+	"github.com/jhekasoft/insteadman3/core/interpreterinstaller"
 	"github.com/jhekasoft/insteadman3/core/manager"
+	"github.com/jhekasoft/insteadman3/core/migration"
+	"github.com/jhekasoft/insteadman3/core/notify"
+	"github.com/jhekasoft/insteadman3/core/scheduler"
+	"github.com/jhekasoft/insteadman3/core/selfupdate"
+	"github.com/jhekasoft/insteadman3/core/server"
+	"github.com/jhekasoft/insteadman3/core/shortcuts"
+	"github.com/jhekasoft/insteadman3/core/telemetry"
 	"github.com/jhekasoft/insteadman3/core/utils"
+	"github.com/pyk/byten"
 )
 
 var version = "3"
 
+const i18nDomain = "insteadman"
+
 func main() {
 	m, c := initManagerAndConfigurator()
+	i18n.Init(c.DataLocalePath(), i18nDomain, m.Config().Lang)
+
+	reporter := crashreport.New("insteadman-cli", version, m)
+	reporter.CaptureLog()
+	defer reporter.Handle()
+	offerCrashReports(reporter)
+
 	needRepositoriesUpdate := !m.HasDownloadedRepositories()
 	argsWithoutProg := os.Args[1:]
 	command := strings.ToLower(GetCommand(argsWithoutProg))
 
+	if c.FirstRun && command != "migrate" {
+		offerMigration(c)
+	}
+
+	// insteadman://install/<game> and insteadman://run/<game> links are the same commands
+	if command == "open" {
+		argsWithoutProg = linkArgs(argsWithoutProg)
+		command = GetCommand(argsWithoutProg)
+	}
+
 	switch command {
 	case "list":
 	case "search":
@@ -29,8 +66,8 @@ func main() {
 		}
 
 	case "run":
-	case "install":
-		m, _ = checkInterpreterAndReinit(m, c)
+	case "install", "daemon":
+		m, c = checkInterpreterAndReinit(m, c)
 	}
 
 	runCommand(command, argsWithoutProg, m, c)
@@ -59,20 +96,56 @@ func runCommand(command string, args []string, m *manager.Manager, c *configurat
 	case "remove":
 		remove(m, args)
 
+	case "favorite":
+		favorite(m, args)
+
+	case "tag":
+		tag(m, args)
+
+	case "tags":
+		printTags(m)
+
+	case "history":
+		history(m, args)
+
 	case "findinterpreter":
 		findInterpreter(m, c)
 
+	case "installinterpreter":
+		installInterpreter(m, c)
+
+	case "interpreters":
+		interpreters(m, c, args)
+
 	case "repositories":
 		repositories(m)
 
 	case "langs":
 		langs(m)
 
+	case "movegames":
+		moveGames(m, c, args)
+
 	case "configpath":
 		printConfigPath(c)
 
+	case "migrate":
+		migrate(c)
+
+	case "config":
+		configCommand(m, c, args)
+
+	case "daemon":
+		daemon(m, c, args)
+
 	case "version":
-		printVersion()
+		printVersion(m, args)
+
+	case "telemetry":
+		printTelemetry(m, args)
+
+	case "dev":
+		dev(m, args)
 
 	default:
 		printHelpAndExit()
@@ -81,46 +154,45 @@ func runCommand(command string, args []string, m *manager.Manager, c *configurat
 
 // -- Commands -----------------------------------
 func update(m *manager.Manager) {
-	fmt.Println("Updating repositories...")
-	errors := m.UpdateRepositories()
+	fmt.Println(i18n.T("Updating repositories..."))
+	errors := backend(m).UpdateRepositories()
 
 	if errors != nil {
-		fmt.Println("There are errors:")
+		fmt.Println(i18n.T("There are errors:"))
 	}
 	for _, e := range errors {
 		fmt.Printf("%s\n", e)
 	}
 
-	fmt.Println("Repositories have updated.")
+	fmt.Println(i18n.T("Repositories have updated."))
+
+	// Install counts are sent with the updating if user has enabled telemetry
+	m.SendTelemetry()
 }
 
 func list(m *manager.Manager, args []string) {
-	games, e := m.GetSortedGamesByDateDesc()
-	ExitIfError(e)
-
 	// Parse args without "list" command
-	repository, lang, onlyInstalled := getGamesFilterValues(args[1:])
+	query := getGamesQuery(args[1:])
+	query.SortBy = manager.SortByDateDesc
 
-	if repository != nil || lang != nil || onlyInstalled {
-		games = manager.FilterGames(games, nil, repository, lang, onlyInstalled)
-	}
+	games, e := m.QueryGames(query)
+	ExitIfError(e)
 
 	printGames(games)
 }
 
 func search(m *manager.Manager, args []string) {
-	games, e := m.GetSortedGames()
-	ExitIfError(e)
-
 	keyword := GetCommandArg(args)
 	if keyword == nil {
 		printHelpAndExit()
 	}
 
 	// Parse args without "search [keyword]" command
-	repository, lang, onlyInstalled := getGamesFilterValues(args[2:])
+	query := getGamesQuery(args[2:])
+	query.Keyword = *keyword
 
-	filteredGames := manager.FilterGames(games, keyword, repository, lang, onlyInstalled)
+	filteredGames, e := m.QueryGames(query)
+	ExitIfError(e)
 
 	printGames(filteredGames)
 }
@@ -138,24 +210,51 @@ func install(m *manager.Manager, args []string) {
 
 	game := getOrExitIfNoGame(filteredGames, *keyword)
 
-	fmt.Printf("Downloading and installing game %s...", FmtName(game.Title))
+	fmt.Printf(i18n.T("Downloading and installing game %s..."), FmtName(game.Title))
 
 	installProgress := func(size uint64) {
 		percents := utils.Percents(size, uint64(game.Size))
-		fmt.Printf("\rDownloading and installing game %s... %s", FmtName(game.Title), color.GreenString(percents))
+		fmt.Printf("\r"+i18n.T("Downloading and installing game %s...")+" %s", FmtName(game.Title), color.GreenString(percents))
+	}
+
+	e = backend(m).InstallGameContext(context.Background(), &game, installProgress, nil)
+	ExitIfError(e)
+
+	fmt.Printf("\n"+i18n.T("Game %s has installed.")+"\n", FmtName(game.Title))
+}
+
+func moveGames(m *manager.Manager, c *configurator.Configurator, args []string) {
+	newPath := GetCommandArg(args)
+	if newPath == nil {
+		printHelpAndExit()
 	}
 
-	e = m.InstallGame(&game, installProgress)
+	fmt.Printf(i18n.T("Moving games to %s..."), FmtName(*newPath))
+
+	moveProgress := func(copied, total uint64) {
+		percents := utils.Percents(copied, total)
+		fmt.Printf("\r"+i18n.T("Moving games to %s...")+" %s", FmtName(*newPath), color.GreenString(percents))
+	}
+
+	e := m.RelocateGamesDir(*newPath, moveProgress, c.SaveConfig)
+
+	// Games have moved, but some old files are left
+	var cleanupErr *manager.RelocateCleanupError
+	if errors.As(e, &cleanupErr) {
+		fmt.Print("\n" + color.YellowString(i18n.T("Warning: %v"), cleanupErr))
+		e = nil
+	}
 	ExitIfError(e)
 
-	fmt.Printf("\nGame %s has installed.\n", FmtName(game.Title))
+	fmt.Printf("\n"+i18n.T("Games have moved to %s.")+"\n", FmtName(m.Config().CalculatedGamesPath))
 }
 
 func show(m *manager.Manager, args []string) {
 	games, e := m.GetSortedGames()
 	ExitIfError(e)
 
-	keyword := GetCommandArg(args)
+	diff := FindBoolArg("--diff", args)
+	keyword := GetCommandArg(RemoveArg("--diff", args))
 	if keyword == nil {
 		printHelpAndExit()
 	}
@@ -166,33 +265,79 @@ func show(m *manager.Manager, args []string) {
 
 	installedTxt := ""
 	if game.Installed {
-		installedTxt = FmtInstalled("[installed]")
+		installedTxt = FmtInstalled("[" + i18n.T("installed") + "]")
 	}
 
 	// Print game information
 	fmt.Printf(
 		"%s (%s) %s %s\n",
 		FmtTitle(game.Title), FmtName(game.Name), FmtSize(game.HumanSize()), installedTxt)
-	fmt.Printf("Version: %s\n", FmtVersion(game.HumanVersion()))
+	fmt.Printf(i18n.T("Version: %s")+"\n", FmtVersion(game.HumanVersion()))
 	if game.Languages != nil {
-		fmt.Printf("Languages: %s\n", FmtLang(strings.Join(game.Languages, ", ")))
+		fmt.Printf(i18n.T("Languages: %s")+"\n", FmtLang(strings.Join(game.Languages, ", ")))
 	}
 	if game.RepositoryName != "" {
-		fmt.Printf("Repository: %s\n", FmtRepo(game.RepositoryName))
+		fmt.Printf(i18n.T("Repository: %s")+"\n", FmtRepo(game.RepositoryName))
 	}
 	if game.Descurl != "" {
-		fmt.Printf("More: %s\n", FmtURL(game.Descurl))
+		fmt.Printf(i18n.T("More: %s")+"\n", FmtURL(game.Descurl))
 	}
 	if game.Description != "" {
-		fmt.Printf("\n"+color.New(color.Bold).Sprint("Descriprion")+":\n%s\n", game.Description)
+		fmt.Printf("\n"+color.New(color.Bold).Sprint(i18n.T("Description"))+":\n%s\n", game.Description)
+	}
+
+	if diff {
+		if !game.Installed {
+			fmt.Println("\n" + i18n.T("Game isn't installed, there isn't version to compare."))
+			os.Exit(1)
+		}
+
+		gameDiff, e := m.DiffGameVersions(&game, "", "")
+		ExitIfError(e)
+		printGameDiff(gameDiff)
 	}
 }
 
+// printGameDiff prints added (+), removed (-) and changed (~) files of the new version
+func printGameDiff(diff *manager.GameDiff) {
+	fmt.Printf("\n"+color.New(color.Bold).Sprint(i18n.T("Changes"))+" %s → %s:\n",
+		FmtVersion(diff.OldVersion), FmtVersion(diff.NewVersion))
+
+	if diff.Empty() {
+		fmt.Println(i18n.T("Files are the same."))
+		return
+	}
+
+	for _, change := range diff.Added {
+		fmt.Printf("%s %s %s\n", color.GreenString("+"), change.Path, FmtSize(byten.Size(change.NewSize)))
+	}
+	for _, change := range diff.Removed {
+		fmt.Printf("%s %s %s\n", color.RedString("-"), change.Path, FmtSize(byten.Size(change.OldSize)))
+	}
+	for _, change := range diff.Changed {
+		fmt.Printf("%s %s %s → %s\n", color.YellowString("~"), change.Path,
+			FmtSize(byten.Size(change.OldSize)), FmtSize(byten.Size(change.NewSize)))
+	}
+
+	sign := "+"
+	size := diff.SizeDelta
+	if size < 0 {
+		sign = "-"
+		size = -size
+	}
+	fmt.Printf(i18n.T("Added: %d, removed: %d, changed: %d, size: %s")+"\n",
+		len(diff.Added), len(diff.Removed), len(diff.Changed), FmtSize(sign+byten.Size(size)))
+}
+
 func run(m *manager.Manager, args []string) {
 	games, e := m.GetSortedGames()
 	ExitIfError(e)
 
-	keyword := GetCommandArg(args)
+	args, passArgs := SplitPassArgs(args)
+	debug := FindBoolArg("--debug", args)
+	fresh := FindBoolArg("--fresh", args)
+	keep := FindBoolArg("--keep", args)
+	keyword := GetCommandArg(RemoveArg("--keep", RemoveArg("--fresh", RemoveArg("--debug", args))))
 	if keyword == nil {
 		printHelpAndExit()
 	}
@@ -202,16 +347,70 @@ func run(m *manager.Manager, args []string) {
 	game := getOrExitIfNoGame(filteredGames, *keyword)
 
 	if !game.Installed {
-		fmt.Printf("Game %s isn't installed.\n", FmtName(game.Title))
-		fmt.Printf("Please run for installation:\n"+
-			"insteadman install %s\n", game.Name)
+		fmt.Printf(i18n.T("Game %s isn't installed.")+"\n", FmtName(game.Title))
+		fmt.Printf(i18n.T("Please run for installation:")+"\ninsteadman install %s\n", game.Name)
 		os.Exit(1)
 	}
 
-	e = m.RunGame(&game)
+	appDataDir := ""
+	if fresh {
+		appDataDir, e = m.UseTempAppData()
+		ExitIfError(e)
+		fmt.Printf(i18n.T("Temporary INSTEAD data (saves, settings): %s")+"\n", FmtName(appDataDir))
+	}
+
+	if debug {
+		fmt.Printf(i18n.T("Running %s game in debug mode...")+"\n", FmtName(game.Title))
+		report, e := m.DebugGame(&game, os.Stdout)
+		removeTempAppData(appDataDir, keep)
+		ExitIfError(e)
+		printDebugReport(report)
+		return
+	}
+
+	if len(passArgs) > 0 || fresh {
+		// Arguments and INSTEAD data of this run aren't sent to the daemon, the game is run by the CLI
+		e = m.RunGameArgs(&game, passArgs)
+	} else {
+		e = backend(m).RunGame(&game)
+	}
+	if e != nil {
+		removeTempAppData(appDataDir, keep)
+	}
 	ExitIfError(e)
 
-	fmt.Printf("Running %s game...\n", FmtName(game.Title))
+	fmt.Printf(i18n.T("Running %s game...")+"\n", FmtName(game.Title))
+
+	// Game files are served for the browser until exit
+	if webRunner, ok := m.CurrentRunner().(*manager.WebRunner); ok {
+		fmt.Printf(i18n.T("Game is running in the browser: %s")+"\n"+i18n.T("Press Ctrl+C to stop.")+"\n", FmtURL(webRunner.URL))
+		webRunner.Wait()
+	}
+
+	// Temporary data is removed after exit of INSTEAD
+	if fresh {
+		if m.CurrentRunningCmd != nil {
+			m.CurrentRunningCmd.Wait()
+		}
+		removeTempAppData(appDataDir, keep)
+	}
+}
+
+// removeTempAppData removes temporary INSTEAD data of the "run --fresh" (it's kept with --keep)
+func removeTempAppData(dir string, keep bool) {
+	if dir == "" {
+		return
+	}
+
+	if keep {
+		fmt.Printf(i18n.T("Temporary INSTEAD data has kept: %s")+"\n", FmtName(dir))
+		return
+	}
+
+	e := os.RemoveAll(dir)
+	if e != nil {
+		fmt.Printf(i18n.T("Error: %v")+"\n", e)
+	}
 }
 
 func remove(m *manager.Manager, args []string) {
@@ -227,34 +426,137 @@ func remove(m *manager.Manager, args []string) {
 
 	game := getOrExitIfNoGame(filteredGames, *keyword)
 
-	fmt.Printf("Removing game %s...\n", FmtName(game.Title))
+	fmt.Printf(i18n.T("Removing game %s...")+"\n", FmtName(game.Title))
 
-	e = m.RemoveGame(&game)
+	e = backend(m).RemoveGame(&game)
 	ExitIfError(e)
 
-	fmt.Printf("Game %s has removed.\n", FmtName(game.Title))
+	fmt.Printf(i18n.T("Game %s has removed.")+"\n", FmtName(game.Title))
+}
+
+// backend returns client of the running daemon (games are changed by it) or the manager
+func backend(m *manager.Manager) manager.Backend {
+	client, e := server.Dial("")
+	if e != nil {
+		return m
+	}
+
+	return client
 }
 
 func findInterpreter(m *manager.Manager, c *configurator.Configurator) {
 	path := m.InterpreterFinder.Find()
 
+	if path == nil && offerPackageInstall(m) {
+		path = m.InterpreterFinder.Find()
+	}
+
 	if path == nil {
-		fmt.Println("INSTEAD has not found. Please add it in config.yml (interpreter_command)")
+		fmt.Println(i18n.T("INSTEAD has not found. Please add it in config.yml (interpreter_command)\n" +
+			"or download and install it by: insteadman installinterpreter"))
 		return
 	}
 
-	fmt.Printf("INSTEAD has found: %s\n", *path)
+	fmt.Printf(i18n.T("INSTEAD has found: %s")+"\n", *path)
+
+	_, e := m.InterpreterFinder.Validate(*path)
+	if e != nil {
+		fmt.Printf(i18n.T("INSTEAD can't be used: %v")+"\n", e)
+		return
+	}
 
-	m.Config.InterpreterCommand = *path
-	e := c.SaveConfig(m.Config)
+	e = c.Set("interpreter_command", *path)
+	ExitIfError(e)
+	e = c.SaveConfig(m.Config())
 	ExitIfError(e)
 
-	fmt.Println("Path has saved")
+	fmt.Println(i18n.T("Path has saved"))
+}
+
+// offerPackageInstall suggests installing INSTEAD by the package manager. Returns true if it has installed.
+func offerPackageInstall(m *manager.Manager) bool {
+	suggestions := m.InterpreterFinder.SuggestInstall(context.Background())
+	if len(suggestions) < 1 {
+		return false
+	}
+
+	fmt.Println(i18n.T("INSTEAD can be installed by the package manager:"))
+	for _, suggestion := range suggestions {
+		fmt.Println("  " + FmtName(suggestion.Command()))
+	}
+
+	fmt.Printf(i18n.T("Run %s? [y/N]")+" ", suggestions[0].Command())
+	answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+	if strings.ToLower(strings.TrimSpace(answer)) != "y" {
+		return false
+	}
+
+	cmd := suggestions[0].Cmd()
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	e := cmd.Run()
+	if e != nil {
+		fmt.Printf(i18n.T("Error: %v")+"\n", e)
+		return false
+	}
+
+	return true
+}
+
+func installInterpreter(m *manager.Manager, c *configurator.Configurator) {
+	installer := interpreterinstaller.Installer{DataDir: m.Config().CalculatedInsteadManPath}
+
+	fmt.Println(i18n.T("Downloading and installing INSTEAD..."))
+
+	installProgress := func(downloaded, total uint64) {
+		percents := utils.Percents(downloaded, total)
+		fmt.Printf("\r"+i18n.T("Downloading and installing INSTEAD...")+" %s", color.GreenString(percents))
+	}
+
+	path, e := installer.Install(installProgress)
+	ExitIfError(e)
+
+	e = c.Set("use_builtin_interpreter", true)
+	ExitIfError(e)
+	e = c.SaveConfig(m.Config())
+	ExitIfError(e)
+
+	fmt.Printf("\n"+i18n.T("INSTEAD has installed: %s")+"\n", path)
+}
+
+func interpreters(m *manager.Manager, c *configurator.Configurator, args []string) {
+	subCommand := GetCommandArg(args)
+	if subCommand != nil && strings.ToLower(*subCommand) == "detect" {
+		fmt.Println(i18n.T("Detecting INSTEAD interpreters..."))
+
+		added := m.RegisterInterpreters(m.InterpreterFinder.FindAll())
+		e := c.SaveConfig(m.Config())
+		ExitIfError(e)
+
+		fmt.Printf(i18n.N("%d new interpreter has registered.", "%d new interpreters have registered.", len(added))+"\n",
+			len(added))
+	}
+
+	for _, interpreter := range m.Config().Interpreters {
+		defaultTxt := ""
+		if interpreter.Name == m.Config().DefaultInterpreter {
+			defaultTxt = FmtInstalled("[" + i18n.T("default") + "]")
+		}
+
+		fmt.Printf("%s, %s "+FmtVersion("%s")+" %s\n", FmtName(interpreter.Name), interpreter.Command,
+			interpreter.Version, defaultTxt)
+	}
 }
 
 func repositories(m *manager.Manager) {
 	for _, repo := range m.GetRepositories() {
-		fmt.Printf("%s (%s)\n", FmtRepo(repo.Name), repo.Url)
+		disabledTxt := ""
+		if repo.Disabled {
+			disabledTxt = " [" + i18n.T("disabled") + "]"
+		}
+
+		fmt.Printf("%s (%s)%s\n", FmtRepo(repo.Name), repo.Url, disabledTxt)
 	}
 }
 
@@ -267,8 +569,208 @@ func langs(m *manager.Manager) {
 	}
 }
 
-func printVersion() {
+func migrate(c *configurator.Configurator) {
+	path := migration.FindInsteadMan2Config(c.LegacyInsteadManDir())
+	if path == "" {
+		fmt.Println(i18n.T("InsteadMan 2 configuration has not found."))
+		return
+	}
+
+	fmt.Printf(i18n.T("Importing InsteadMan 2 configuration %s...")+"\n", path)
+
+	e := migration.ImportFile(path, c)
+	ExitIfError(e)
+
+	fmt.Println(i18n.T("Configuration has imported."))
+}
+
+func configCommand(m *manager.Manager, c *configurator.Configurator, args []string) {
+	subCommand := ""
+	if len(args) > 1 {
+		subCommand = strings.ToLower(args[1])
+	}
+
+	switch subCommand {
+	case "list":
+		for _, key := range configurator.Keys() {
+			value, e := c.Get(key)
+			ExitIfError(e)
+			fmt.Printf("%s: %s\n", FmtName(key), configValueString(value))
+		}
+
+	case "show":
+		if !FindBoolArg("--effective", args) {
+			configCommand(m, c, []string{args[0], "list"})
+			return
+		}
+
+		values, e := c.Effective()
+		ExitIfError(e)
+		for _, value := range values {
+			fmt.Printf("%s: %s %s\n", FmtName(value.Key), configValueString(value.Value), FmtRepo("["+value.Source+"]"))
+		}
+
+	case "get":
+		if len(args) < 3 {
+			printHelpAndExit()
+		}
+
+		value, e := c.Get(args[2])
+		ExitIfError(e)
+		fmt.Println(configValueString(value))
+
+	case "set":
+		if len(args) < 4 {
+			printHelpAndExit()
+		}
+
+		e := c.Set(args[2], args[3])
+		ExitIfError(e)
+
+		e = c.SaveConfig(m.Config())
+		ExitIfError(e)
+
+		fmt.Printf(i18n.T("%s has saved.")+"\n", FmtName(args[2]))
+
+	case "backups":
+		backups, e := c.ListBackups()
+		ExitIfError(e)
+
+		for _, backup := range backups {
+			fmt.Printf("%s (%s)\n", FmtName(filepath.Base(backup.Path)), backup.Time.Format("2006-01-02 15:04:05"))
+		}
+
+	case "restore":
+		backupPath := ""
+		if len(args) > 2 {
+			backups, e := c.ListBackups()
+			ExitIfError(e)
+
+			for _, backup := range backups {
+				if filepath.Base(backup.Path) == args[2] {
+					backupPath = backup.Path
+					break
+				}
+			}
+
+			if backupPath == "" {
+				ExitIfError(fmt.Errorf(i18n.T("backup %s hasn't been found"), args[2]))
+			}
+		}
+
+		_, e := c.RestoreBackup(backupPath)
+		ExitIfError(e)
+
+		fmt.Println(i18n.T("Config has restored."))
+
+	default:
+		printHelpAndExit()
+	}
+}
+
+func daemon(m *manager.Manager, c *configurator.Configurator, args []string) {
+	addr := server.DefaultAddr
+	if value := FindStringArg("--addr", args); value != nil {
+		addr = *value
+	}
+
+	rpcAddr := server.DefaultRPCAddr
+	if value := FindStringArg("--rpc-addr", args); value != nil {
+		rpcAddr = *value
+	}
+
+	// Daemon works in the background, so it alerts about installed games and updates by the system notifications
+	m.Notifier = &notify.System{}
+
+	s := server.New(m, c)
+	s.Token = os.Getenv(server.TokenEnv)
+	if token := FindStringArg("--token", args); token != nil {
+		s.Token = *token
+	}
+	ExitIfError(s.CheckAddr(addr))
+	ExitIfError(s.CheckRPCAddr(rpcAddr))
+
+	// Repositories refresh, cache eviction and other periodic jobs are run while the daemon is working
+	jobs := scheduler.New(m.SchedulerStateFile())
+	jobs.OnError = func(job string, e error) {
+		fmt.Printf(i18n.T("Job %s error: %v")+"\n", job, e)
+	}
+	for _, job := range m.Jobs() {
+		jobs.Add(job)
+	}
+	jobs.Start()
+
+	// Front ends and CLI commands use the daemon if it's running
+	go func() {
+		e := s.ServeRPC(rpcAddr)
+		fmt.Printf(i18n.T("JSON-RPC error: %v")+"\n", e)
+	}()
+
+	fmt.Printf(i18n.T("InsteadMan API is listening on %s")+"\n"+i18n.T("Press Ctrl+C to stop.")+"\n",
+		FmtURL("http://"+addr+"/api/"))
+	e := s.ListenAndServe(addr)
+	ExitIfError(e)
+}
+
+func configValueString(value interface{}) string {
+	if str, ok := value.(string); ok {
+		return str
+	}
+
+	data, e := json.Marshal(value)
+	if e != nil {
+		return fmt.Sprint(value)
+	}
+
+	return string(data)
+}
+
+func printVersion(m *manager.Manager, args []string) {
 	fmt.Println(version)
+
+	if !FindBoolArg("--check", args) {
+		return
+	}
+
+	update, e := selfupdate.CheckForAppUpdate(m.CacheDir(), version)
+	ExitIfError(e)
+
+	if update.Available {
+		fmt.Printf(i18n.T("New version %s is available: %s")+"\n", FmtName(update.Release.Version),
+			FmtURL(update.Release.Url))
+	} else {
+		fmt.Println(i18n.T("InsteadMan is up to date."))
+	}
+}
+
+// printTelemetry prints reports which would be sent to the repositories or sends them ("send")
+func printTelemetry(m *manager.Manager, args []string) {
+	action := GetCommandArg(args)
+	if action != nil && *action == "send" {
+		if !m.Config().Telemetry {
+			fmt.Println(i18n.T("Telemetry is disabled."))
+			os.Exit(1)
+		}
+
+		errs := m.Telemetry.Send(m.Config().Repositories, true)
+		for _, e := range errs {
+			fmt.Printf("%s\n", e)
+		}
+		if errs != nil {
+			os.Exit(1)
+		}
+
+		fmt.Println(i18n.T("Statistics have sent."))
+		return
+	}
+
+	if m.Config().Telemetry {
+		fmt.Println(i18n.T("Telemetry is enabled (disable: insteadman config set telemetry false)."))
+	} else {
+		fmt.Println(i18n.T("Telemetry is disabled (enable: insteadman config set telemetry true)."))
+	}
+	fmt.Println(i18n.T("Reports which would be sent to the repositories:"))
+	fmt.Println(m.Telemetry.Preview(m.Config().Repositories))
 }
 
 func printConfigPath(c *configurator.Configurator) {
@@ -285,52 +787,147 @@ func printHelpAndExit() {
 `
 
 	color.Cyan(asciiArt)
-	fmt.Printf("\n"+color.New(color.Bold).Sprint("InsteadMan CLI")+" %s — INSTEAD games manager (launcher)\n\n", version)
-	fmt.Print(color.New(color.FgCyan, color.Bold).Sprint("Usage") + ":\n" +
-		"    insteadman-cli [command] [keyword]\n\n" +
+	fmt.Printf("\n"+color.New(color.Bold).Sprint("InsteadMan CLI")+" %s — %s\n\n", version,
+		i18n.T("INSTEAD games manager (launcher)"))
+	fmt.Print(color.New(color.FgCyan, color.Bold).Sprint(i18n.T("Usage")) + ":\n" +
+		"    insteadman-cli [command] [keyword] [--portable]\n\n" +
+
+		color.New(color.FgCyan, color.Bold).Sprint(i18n.T("Commands")) + ":\n" +
+
+		helpCommand("update", "", i18n.T("Update game's repositories")) +
+
+		helpCommand("list", " --repo=[name] --lang=[lang] --installed --favorites --tag=[tag]",
+			i18n.T("Print list of games with filtering")) +
+
+		helpCommand("search", " [keyword] --repo=[name] --lang=[lang] --installed --favorites --tag=[tag]",
+			i18n.T("Search game by name and title with filtering")) +
+
+		helpCommand("show", " [keyword] [--diff]",
+			i18n.T("Show information about game by keyword (--diff: compare files of the installed version with\n"+
+				"the repository version, the archive is downloaded)")) +
+
+		helpCommand("install", " [keyword]", i18n.T("Install game by keyword")) +
+
+		helpCommand("run", " [keyword] [--debug] [--fresh] [--keep] [-- INSTEAD arguments]",
+			i18n.T("Run game by keyword (--debug: run INSTEAD in debug mode, keep its output in the log and print\n"+
+				"Lua errors, the log can be attached to the bug report). Arguments after -- are passed to INSTEAD.\n"+
+				"--fresh: run with temporary INSTEAD data (saves, settings) like the first time, it's removed\n"+
+				"after exit (--keep: don't remove it)")) +
+
+		helpCommand("remove", " [keyword]", i18n.T("Remove game by keyword")) +
+
+		helpCommand("favorite", " [keyword] [--remove]",
+			i18n.T("Add game to the favorites (--remove: remove it from the favorites)")) +
+
+		helpCommand("tag", " [keyword] [tags...] [--clear]",
+			i18n.T("Set tags of the game (print them if tags aren't passed, --clear: remove tags)")) +
+
+		helpCommand("tags", "", i18n.T("Print tags of the games")) +
+
+		helpCommand("history", " [--limit=20]", i18n.T("Print last played games")) +
+
+		helpCommand("open", " [insteadman://install|run/game]",
+			i18n.T("Install or run game by the link of the website")) +
+
+		helpCommand("findInterpreter", "", i18n.T("Find INSTEAD interpreter and save path to the config")) +
+
+		helpCommand("installInterpreter", "",
+			i18n.T("Download INSTEAD from the official releases and use it as built-in interpreter")) +
+
+		helpCommand("interpreters", " [detect]",
+			i18n.T("Print registered INSTEAD interpreters (detect: find and register all interpreters)")) +
 
-		color.New(color.FgCyan, color.Bold).Sprint("Commands") + ":\n" +
+		helpCommand("repositories", "", i18n.T("Print available repositories")) +
 
-		color.New(color.FgCyan, color.Bold).Sprint("update") +
-		"\n    Update game's repositories\n" +
+		helpCommand("langs", "", i18n.T("Print available game languages")) +
 
-		color.New(color.FgCyan, color.Bold).Sprint("list") + color.CyanString(" --repo=[name] --lang=[lang] --installed") +
-		"\n    Print list of games with filtering\n" +
+		helpCommand("moveGames", " [path]", i18n.T("Move installed games to the new games directory")) +
 
-		color.New(color.FgCyan, color.Bold).Sprint("search") + color.CyanString(" [keyword] --repo=[name] --lang=[lang] --installed") +
-		"\n    Search game by name and title with filtering\n" +
+		helpCommand("configPath", "", i18n.T("Print config path")) +
 
-		color.New(color.FgCyan, color.Bold).Sprint("show") + color.CyanString(" [keyword]") +
-		"\n    Show information about game by keyword\n" +
+		helpCommand("config", " list|get [key]|set [key] [value]",
+			i18n.T("Print or change config values (keys are like \"lang\" or \"gtk.main_width\")")) +
 
-		color.New(color.FgCyan, color.Bold).Sprint("install") + color.CyanString(" [keyword]") +
-		"\n    Install game by keyword\n" +
+		helpCommand("config show", " [--effective]",
+			i18n.T("Print config values (--effective: values which are used with defaults, environment\n"+
+				"variables, flags and calculated paths, the source is printed for each value)")) +
 
-		color.New(color.FgCyan, color.Bold).Sprint("run") + color.CyanString(" [keyword]") +
-		"\n    Run game by keyword\n" +
+		helpCommand("config", " backups|restore [backup]",
+			i18n.T("Print config backups or restore config from the backup (the latest by default)")) +
 
-		color.New(color.FgCyan, color.Bold).Sprint("remove") + color.CyanString(" [keyword]") +
-		"\n    Remove game by keyword\n" +
+		helpCommand("daemon", " --addr=[host:port] --rpc-addr=[host:port] --token=[token]",
+			fmt.Sprintf(i18n.T("Serve HTTP API for scripts and remote controls (%s by default,\n"+
+				"token is required in the \"Authorization: Bearer\" header if it's set) and JSON-RPC for\n"+
+				"front ends (%s). Not localhost addresses require the token. Token is read\n"+
+				"from %s too. GUI and CLI change games by the running daemon.\n"+
+				"Repositories are refreshed daily, old cache is removed and saves are backed up (saves_backup)"),
+				server.DefaultAddr, server.DefaultRPCAddr, server.TokenEnv)) +
 
-		color.New(color.FgCyan, color.Bold).Sprint("findInterpreter") +
-		"\n    Find INSTEAD interpreter and save path to the config\n" +
+		helpCommand("migrate", "", i18n.T("Import configuration of InsteadMan 2")) +
 
-		color.New(color.FgCyan, color.Bold).Sprint("repositories") +
-		"\n    Print available repositories\n" +
+		helpCommand("telemetry", " [send]",
+			i18n.T("Print anonymous statistics (install counts of the games, version and OS) which would be sent\n"+
+				"to the repositories with \"stats_url\" if \"telemetry\" is enabled (send: send them now)")) +
 
-		color.New(color.FgCyan, color.Bold).Sprint("langs") +
-		"\n    Print available game languages\n" +
+		helpCommand("dev new", " [dir] --title=[title] --author=[author] --lang=[en,ru] --stead2",
+			i18n.T("Create the new game: main file with metadata, gfx and mus directories, .gitignore (texts are\n"+
+				"in the first language: en, ru or uk)")) +
 
-		color.New(color.FgCyan, color.Bold).Sprint("configPath") +
-		"\n    Print config path\n" +
+		helpCommand("dev run", " [dir] [--debug]",
+			i18n.T("Run the game in development from the directory (current directory by default) without\n"+
+				"installing, INSTEAD output is printed to the terminal (--debug: like run --debug)")) +
 
-		color.New(color.FgCyan, color.Bold).Sprint("version") +
-		"\n    Print current version of the application\n\n" +
+		helpCommand("dev package", " [dir] --format=zip,idf --output=[dir]",
+			i18n.T("Pack the game to the archives for the repository (zip by default, INSTEAD builds idf).\n"+
+				"VCS and temp files are skipped, $Name and $Version of the main file are required")) +
 
-		"More info: " + FmtURL("http://jhekasoft.github.io/insteadman/") + "\n")
+		helpCommand("dev validate", " [dir or archive]",
+			i18n.T("Check metadata of the main file, files which are referenced by the Lua code (missing files,\n"+
+				"absolute paths, case of the names) and layout of the zip archive")) +
+
+		helpCommand("dev test", " [dir] --script=[file] --timeout=[duration] --verbose",
+			i18n.T("Play tests/*.test scripts of the game by the headless interpreter (instead-cli, see\n"+
+				"headless_interpreter config key) and check the expected texts, exit code is 1 if tests fail")) +
+
+		helpCommand("dev l10n", " [dir] --primary=[lang] --langs=[en,ru]",
+			i18n.T("Compare language variants of the game files (lang/ru.lua, intro_en.lua, gfx/en/...) with the\n"+
+				"primary language and print missing files and strings, languages are declared by $Name(lang)")) +
+
+		helpCommand("dev watch", " [dir] --interval=[duration]",
+			i18n.T("Run the game and restart it when Lua files or assets have changed (files are checked\n"+
+				"every 500ms by default)")) +
+
+		helpCommand("dev publish", " [dir] --repository=[name] --lang=[en,ru]",
+			i18n.T("Pack the game to zip and upload it with the updated feed to the repository (\"publish\" of the\n"+
+				"repository in the config: SFTP, WebDAV or HTTP endpoint)")) +
+
+		helpCommand("dev serve", " [dir] --addr=[host:port] --title=[title]",
+			fmt.Sprintf(i18n.T("Serve directory of the games (game directories and zip archives) as the repository for\n"+
+				"playtesters (%s by default), games are packed again after changes"), gamedev.DefaultServeAddr)) +
+
+		helpCommand("dev site", " [feed file or url] --output=[dir] --title=[title]",
+			i18n.T("Generate static HTML catalog of the repository (game pages, language filters) with XML and\n"+
+				"JSON feeds, it can be hosted on GitHub Pages (\"site\" directory by default)")) +
+
+		helpCommand("version", " [--check]",
+			i18n.T("Print current version of the application (--check: check for the new release on GitHub)")) + "\n" +
+
+		helpCommand("--portable", "",
+			i18n.T("Keep config, cache, games and INSTEAD data in the application directory\n"+
+				"(or create \"portable\" file near the executable)")) + "\n" +
+
+		helpCommand("--set", "=[key]=[value]", i18n.T("Override config value for this run (the value isn't saved)")) + "\n" +
+
+		i18n.T("More info:") + " " + FmtURL("http://jhekasoft.github.io/insteadman/") + "\n")
 	os.Exit(1)
 }
 
+// helpCommand returns help of the command, lines of the description are indented
+func helpCommand(name, args, description string) string {
+	return color.New(color.FgCyan, color.Bold).Sprint(name) + color.CyanString(args) + "\n    " +
+		strings.Replace(description, "\n", "\n    ", -1) + "\n"
+}
+
 // -- Commands -----------------------------------
 
 func initManagerAndConfigurator() (*manager.Manager, *configurator.Configurator) {
@@ -340,15 +937,55 @@ func initManagerAndConfigurator() (*manager.Manager, *configurator.Configurator)
 	currentDir, e := utils.BinAbsDir(executablePath)
 	ExitIfError(e)
 
-	c := configurator.Configurator{FilePath: "", CurrentDir: currentDir, Version: version}
+	portable := FindBoolArg("--portable", os.Args[1:])
+
+	c := configurator.Configurator{
+		FilePath:   "",
+		CurrentDir: currentDir,
+		Version:    version,
+		Portable:   portable,
+		Flags:      configFlags(os.Args[1:]),
+	}
 	config, e := c.GetConfig()
 	ExitIfError(e)
 
-	finder := &interpreterfinder.InterpreterFinder{CurrentDir: currentDir}
+	finder := &interpreterfinder.InterpreterFinder{CurrentDir: currentDir, DataDir: config.CalculatedInsteadManPath}
 
-	m := manager.Manager{Config: config, InterpreterFinder: finder}
+	m := manager.New(c.Holder(), finder)
+	m.Shortcuts = &shortcuts.Creator{
+		Executable: executablePath,
+		IconsDir:   filepath.Join(config.CalculatedInsteadManPath, "shortcuts"),
+	}
+	m.Telemetry = &telemetry.Stats{Dir: config.CalculatedInsteadManPath, AppVersion: version}
+	m.DB = manager.NewGameDB(m.GameDBFile())
 
-	return &m, &c
+	return m, &c
+}
+
+// linkArgs converts "open insteadman://<action>/<game>" arguments to the "<action> <game>" command arguments
+func linkArgs(args []string) []string {
+	link := GetCommandArg(args)
+	if link == nil {
+		printHelpAndExit()
+	}
+
+	l, e := fileassoc.ParseLink(*link)
+	ExitIfError(e)
+
+	return append([]string{l.Action, l.Game}, args[2:]...)
+}
+
+// configFlags returns config values from the "--set=key=value" arguments
+func configFlags(args []string) map[string]string {
+	flags := make(map[string]string)
+	for _, arg := range FindStringArgs("--set", args) {
+		keyValue := strings.SplitN(arg, "=", 2)
+		if len(keyValue) == 2 {
+			flags[keyValue[0]] = keyValue[1]
+		}
+	}
+
+	return flags
 }
 
 func checkInterpreterAndReinit(m *manager.Manager, c *configurator.Configurator) (*manager.Manager, *configurator.Configurator) {
@@ -360,11 +997,50 @@ func checkInterpreterAndReinit(m *manager.Manager, c *configurator.Configurator)
 	return m, c
 }
 
+func offerMigration(c *configurator.Configurator) {
+	path := migration.FindInsteadMan2Config(c.LegacyInsteadManDir())
+	if path == "" {
+		return
+	}
+
+	fmt.Printf(i18n.T("InsteadMan 2 configuration has found: %s")+"\n", path)
+	fmt.Println(i18n.T("Please run for importing repositories, INSTEAD path and games path:") + "\n" +
+		"insteadman migrate")
+}
+
+// offerCrashReports prints URL of the pre-filled GitHub issue if the last run has crashed
+func offerCrashReports(r *crashreport.Reporter) {
+	if !r.Enabled() {
+		return
+	}
+
+	paths, e := r.Pending()
+	if e != nil || len(paths) == 0 {
+		return
+	}
+
+	issue, e := crashreport.IssueURL(paths[0])
+	if e == nil {
+		fmt.Printf(i18n.T("InsteadMan has crashed last time, crash report: %s")+"\n", paths[0])
+		fmt.Printf(i18n.T("Please report the issue: %s")+"\n\n", FmtURL(issue))
+	}
+
+	for _, path := range paths {
+		crashreport.Dismiss(path)
+	}
+}
+
 func printGames(games []manager.Game) {
 	for _, game := range games {
 		installed := ""
 		if game.Installed {
-			installed = FmtInstalled("[installed]")
+			installed = FmtInstalled("[" + i18n.T("installed") + "]")
+		}
+		if game.Favorite {
+			installed += " " + color.YellowString("["+i18n.T("favorite")+"]")
+		}
+		if len(game.Tags) > 0 {
+			installed += " " + FmtLang("#"+strings.Join(game.Tags, " #"))
 		}
 		fmt.Printf(
 			"%s, %s, %s "+FmtLang("%v")+" %s\n",
@@ -375,7 +1051,7 @@ func printGames(games []manager.Game) {
 
 func getOrExitIfNoGame(filteredGames []manager.Game, keyword string) manager.Game {
 	if len(filteredGames) < 1 {
-		fmt.Printf("Game %s has not found\n", FmtName(keyword))
+		fmt.Printf(i18n.T("Game %s has not found")+"\n", FmtName(keyword))
 		os.Exit(1)
 	}
 
@@ -388,10 +1064,20 @@ func getOrExitIfNoGame(filteredGames []manager.Game, keyword string) manager.Gam
 	return filteredGames[0]
 }
 
-func getGamesFilterValues(args []string) (*string, *string, bool) {
-	repository := FindStringArg("--repository", args)
-	lang := FindStringArg("--lang", args)
-	onlyInstalled := FindBoolArg("--installed", args)
+// getGamesQuery returns filters of the games: --repository, --lang, --installed, --favorites and --tag
+func getGamesQuery(args []string) manager.GameQuery {
+	var query manager.GameQuery
+	if repository := FindStringArg("--repository", args); repository != nil {
+		query.Repository = *repository
+	}
+	if lang := FindStringArg("--lang", args); lang != nil {
+		query.Lang = *lang
+	}
+	if tag := FindStringArg("--tag", args); tag != nil {
+		query.Tag = *tag
+	}
+	query.Installed = FindBoolArg("--installed", args)
+	query.Favorite = FindBoolArg("--favorites", args)
 
-	return repository, lang, onlyInstalled
+	return query
 }
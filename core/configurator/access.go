@@ -0,0 +1,351 @@
+package configurator
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Config values are accessed by the dotted keys which are the same as in the config file,
+// for example "lang", "gtk.main_width" or "games.oldgame.args".
+// Values of the environment variables like INSTEADMAN_LANG or INSTEADMAN_GTK_MAIN_WIDTH override values of the file,
+// values of the flags (Configurator.Flags) override both of them.
+
+const envPrefix = "INSTEADMAN_"
+
+var (
+	// ErrUnknownKey is returned for the key which isn't exist in the config
+	ErrUnknownKey = errors.New("unknown config key")
+	// ErrNotRead is returned if config is accessed before GetConfig
+	ErrNotRead = errors.New("config hasn't been read")
+)
+
+// Get returns config value by the dotted key
+func (c *Configurator) Get(key string) (interface{}, error) {
+	config := c.Config()
+	if config == nil {
+		return nil, ErrNotRead
+	}
+
+	return configValue(config, key)
+}
+
+func configValue(config *InsteadmanConfig, key string) (interface{}, error) {
+	v, e := getPath(reflect.ValueOf(config), splitKey(key))
+	if e != nil {
+		return nil, keyError(key, e)
+	}
+
+	return v.Interface(), nil
+}
+
+// Set sets config value by the dotted key and notifies OnChange subscribers.
+// String values are parsed to the type of the key (JSON is used for lists and maps).
+// Value is set in the copy of the config which replaces current one (see Holder), calculated paths are updated.
+// Config isn't saved, use SaveConfig for it.
+func (c *Configurator) Set(key string, value interface{}) error {
+	if c.holder == nil {
+		return ErrNotRead
+	}
+
+	config, e := c.holder.Update(func(config *InsteadmanConfig) error {
+		e := setPath(reflect.ValueOf(config), splitKey(key), value)
+		if e != nil {
+			return e
+		}
+
+		c.calculatePaths(config)
+		return nil
+	})
+	if e != nil {
+		return keyError(key, e)
+	}
+
+	c.mutex.Lock()
+	delete(c.overrides, key)
+	c.mutex.Unlock()
+
+	newValue, _ := configValue(config, key)
+	for _, f := range c.changeSubscribers {
+		f(key, newValue)
+	}
+
+	return nil
+}
+
+// OnChange adds function which is called after changing config value by Set
+func (c *Configurator) OnChange(f func(key string, value interface{})) {
+	c.changeSubscribers = append(c.changeSubscribers, f)
+}
+
+// Keys returns all the config keys (nested structs are expanded, lists and maps are single keys)
+func Keys() []string {
+	return typeKeys(reflect.TypeOf(InsteadmanConfig{}), "")
+}
+
+// EnvName returns name of the environment variable which overrides config key
+func EnvName(key string) string {
+	return envPrefix + strings.ToUpper(strings.Replace(key, ".", "_", -1))
+}
+
+// override is a config value which has been set by the environment variable or by the flag
+type override struct {
+	original interface{} // value of the file
+	source   string
+}
+
+// applyEnvOverrides sets config values from the environment variables
+func applyEnvOverrides(config *InsteadmanConfig, overrides map[string]override) error {
+	for _, key := range Keys() {
+		envValue, ok := os.LookupEnv(EnvName(key))
+		if !ok {
+			continue
+		}
+
+		e := applyOverride(config, overrides, key, envValue, SourceEnv)
+		if e != nil {
+			return errors.New(EnvName(key) + ": " + e.Error())
+		}
+	}
+
+	return nil
+}
+
+// applyFlagOverrides sets config values from the flags (see Configurator.Flags)
+func applyFlagOverrides(config *InsteadmanConfig, overrides map[string]override, flags map[string]string) error {
+	for key, value := range flags {
+		e := applyOverride(config, overrides, key, value, SourceFlag)
+		if e != nil {
+			return keyError(key, e)
+		}
+	}
+
+	return nil
+}
+
+// applyOverride sets config value and remembers original one to not save overridden values to the config file
+func applyOverride(config *InsteadmanConfig, overrides map[string]override, key, value, source string) error {
+	path := splitKey(key)
+	original, e := getPath(reflect.ValueOf(config), path)
+	if e != nil {
+		return e
+	}
+	originalValue := original.Interface()
+
+	e = setPath(reflect.ValueOf(config), path, value)
+	if e != nil {
+		return e
+	}
+
+	if previous, ok := overrides[key]; ok {
+		originalValue = previous.original
+	}
+	overrides[key] = override{original: originalValue, source: source}
+
+	return nil
+}
+
+// configForSaving returns copy of the config with InsteadMan version, without values of the environment
+// variables and flags. Paths inside the executable directory are relative in portable mode.
+func (c *Configurator) configForSaving(config *InsteadmanConfig) *InsteadmanConfig {
+	configCopy := config.Copy()
+
+	// Write InsteadMan version to config
+	if c.Version != "" {
+		configCopy.Version = c.Version
+	}
+
+	for key, o := range c.overrides {
+		setPath(reflect.ValueOf(configCopy), splitKey(key), o.original)
+	}
+
+	// Game configs which have been added by overrides only
+	for name, gameConfig := range configCopy.Games {
+		if reflect.DeepEqual(gameConfig, GameConfig{}) {
+			delete(configCopy.Games, name)
+		}
+	}
+
+	configCopy.GamesPath = c.portableConfigPath(configCopy.GamesPath)
+	configCopy.InsteadManPath = c.portableConfigPath(configCopy.InsteadManPath)
+	configCopy.CachePath = c.portableConfigPath(configCopy.CachePath)
+
+	return configCopy
+}
+
+func splitKey(key string) []string {
+	return strings.Split(strings.TrimSpace(key), ".")
+}
+
+func keyError(key string, e error) error {
+	if e == ErrUnknownKey {
+		return errors.New(ErrUnknownKey.Error() + ": " + key)
+	}
+
+	return errors.New(key + ": " + e.Error())
+}
+
+func jsonName(field reflect.StructField) string {
+	name := strings.Split(field.Tag.Get("json"), ",")[0]
+	if name == "-" || field.PkgPath != "" {
+		return ""
+	}
+	if name == "" {
+		return field.Name
+	}
+
+	return name
+}
+
+func typeKeys(t reflect.Type, prefix string) (keys []string) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name := jsonName(field)
+		if name == "" {
+			continue
+		}
+
+		if field.Type.Kind() == reflect.Struct {
+			keys = append(keys, typeKeys(field.Type, prefix+name+".")...)
+		} else {
+			keys = append(keys, prefix+name)
+		}
+	}
+
+	return
+}
+
+func structField(v reflect.Value, name string) reflect.Value {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		if jsonName(t.Field(i)) == name {
+			return v.Field(i)
+		}
+	}
+
+	return reflect.Value{}
+}
+
+func getPath(v reflect.Value, path []string) (reflect.Value, error) {
+	if len(path) == 0 {
+		return v, nil
+	}
+
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() {
+			return reflect.Value{}, ErrUnknownKey
+		}
+		return getPath(v.Elem(), path)
+	case reflect.Struct:
+		field := structField(v, path[0])
+		if !field.IsValid() {
+			return reflect.Value{}, ErrUnknownKey
+		}
+		return getPath(field, path[1:])
+	case reflect.Map:
+		elem := v.MapIndex(reflect.ValueOf(path[0]))
+		if !elem.IsValid() {
+			// Not configured map element has zero value
+			elem = reflect.Zero(v.Type().Elem())
+		}
+		return getPath(elem, path[1:])
+	}
+
+	return reflect.Value{}, ErrUnknownKey
+}
+
+func setPath(v reflect.Value, path []string, value interface{}) error {
+	if len(path) == 0 {
+		return assignValue(v, value)
+	}
+
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() {
+			return ErrUnknownKey
+		}
+		return setPath(v.Elem(), path, value)
+	case reflect.Struct:
+		field := structField(v, path[0])
+		if !field.IsValid() {
+			return ErrUnknownKey
+		}
+		return setPath(field, path[1:], value)
+	case reflect.Map:
+		// Map elements aren't addressable, so change copy of the element and put it back
+		key := reflect.ValueOf(path[0])
+		elem := reflect.New(v.Type().Elem()).Elem()
+		if existing := v.MapIndex(key); existing.IsValid() {
+			elem.Set(existing)
+		}
+
+		e := setPath(elem, path[1:], value)
+		if e != nil {
+			return e
+		}
+
+		if v.IsNil() {
+			v.Set(reflect.MakeMap(v.Type()))
+		}
+		v.SetMapIndex(key, elem)
+		return nil
+	}
+
+	return ErrUnknownKey
+}
+
+func assignValue(v reflect.Value, value interface{}) error {
+	if str, ok := value.(string); ok && v.Kind() != reflect.String {
+		return parseValue(v, str)
+	}
+
+	if value == nil {
+		v.Set(reflect.Zero(v.Type()))
+		return nil
+	}
+
+	rv := reflect.ValueOf(value)
+	if !rv.Type().AssignableTo(v.Type()) {
+		return errors.New("invalid value type " + rv.Type().String() + ", must be " + v.Type().String())
+	}
+
+	v.Set(rv)
+	return nil
+}
+
+func parseValue(v reflect.Value, str string) error {
+	switch v.Kind() {
+	case reflect.Bool:
+		b, e := strconv.ParseBool(str)
+		if e != nil {
+			return errors.New("invalid boolean value: " + str)
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, e := strconv.ParseInt(str, 10, v.Type().Bits())
+		if e != nil {
+			return errors.New("invalid integer value: " + str)
+		}
+		v.SetInt(i)
+	case reflect.Float32, reflect.Float64:
+		f, e := strconv.ParseFloat(str, v.Type().Bits())
+		if e != nil {
+			return errors.New("invalid number value: " + str)
+		}
+		v.SetFloat(f)
+	default:
+		// Lists, maps and structs are passed as JSON
+		newValue := reflect.New(v.Type())
+		e := json.Unmarshal([]byte(str), newValue.Interface())
+		if e != nil {
+			return errors.New("invalid JSON value: " + e.Error())
+		}
+		v.Set(newValue.Elem())
+	}
+
+	return nil
+}
@@ -0,0 +1,162 @@
+package configurator
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	backupsDirName    = "backups"
+	backupsMaxCount   = 5
+	backupTimeFormat  = "20060102-150405.000"
+	backupsNamePrefix = "config-"
+)
+
+// volatileKeys are config keys which are changed by the front ends all the time (window size, last filter,
+// recent games), config isn't backed up if only they are changed
+var volatileKeys = []string{"version", "gtk.main_width", "gtk.main_height", "gtk.filter_repository",
+	"gtk.filter_lang", "gtk.filter_installed", "gtk.recent_games"}
+
+// Backup is a copy of the config file which has been made before rewriting
+type Backup struct {
+	Path string
+	Time time.Time
+}
+
+func (c *Configurator) backupsDir() string {
+	return filepath.Join(filepath.Dir(c.FilePath), backupsDirName)
+}
+
+// ListBackups returns config backups (newest first)
+func (c *Configurator) ListBackups() ([]Backup, error) {
+	files, e := filepath.Glob(filepath.Join(c.backupsDir(), backupsNamePrefix+"*"))
+	if e != nil {
+		return nil, e
+	}
+
+	var backups []Backup
+	for _, path := range files {
+		name := strings.TrimPrefix(filepath.Base(path), backupsNamePrefix)
+		name = strings.TrimSuffix(name, filepath.Ext(name))
+
+		backupTime, e := time.ParseInLocation(backupTimeFormat, name, time.Local)
+		if e != nil {
+			continue
+		}
+
+		backups = append(backups, Backup{Path: path, Time: backupTime})
+	}
+
+	sort.Slice(backups, func(i, j int) bool {
+		return backups[i].Time.After(backups[j].Time)
+	})
+
+	return backups, nil
+}
+
+// RestoreBackup replaces config file with the backup (current config is backed up too) and reads it
+func (c *Configurator) RestoreBackup(backupPath string) (*InsteadmanConfig, error) {
+	if backupPath == "" {
+		backups, e := c.ListBackups()
+		if e != nil {
+			return nil, e
+		}
+		if len(backups) == 0 {
+			return nil, errors.New("there are no config backups")
+		}
+		backupPath = backups[0].Path
+	}
+
+	data, e := ioutil.ReadFile(backupPath)
+	if e != nil {
+		return nil, e
+	}
+
+	e = c.backupCurrentConfig(data)
+	if e != nil {
+		return nil, e
+	}
+
+	e = ioutil.WriteFile(c.FilePath, data, 0644)
+	if e != nil {
+		return nil, e
+	}
+
+	return c.GetConfig()
+}
+
+// backupCurrentConfig backs up config file if it is different from the new data (not only by the volatile keys)
+func (c *Configurator) backupCurrentConfig(newData []byte) error {
+	data, e := ioutil.ReadFile(c.FilePath)
+	if os.IsNotExist(e) {
+		return nil
+	}
+	if e != nil {
+		return e
+	}
+
+	if bytes.Equal(data, newData) || c.volatileChanged(data, newData) {
+		return nil
+	}
+
+	return c.backupConfigData(data)
+}
+
+// volatileChanged checks that config data are different only by the volatile keys
+func (c *Configurator) volatileChanged(data, newData []byte) bool {
+	var values, newValues map[string]interface{}
+	if unmarshalConfig(c.FilePath, data, &values) != nil || unmarshalConfig(c.FilePath, newData, &newValues) != nil {
+		return false
+	}
+
+	for _, key := range volatileKeys {
+		deleteDataKey(values, splitKey(key))
+		deleteDataKey(newValues, splitKey(key))
+	}
+
+	return reflect.DeepEqual(values, newValues)
+}
+
+func deleteDataKey(data map[string]interface{}, path []string) {
+	if len(path) == 1 {
+		delete(data, path[0])
+		return
+	}
+
+	if nested, ok := data[path[0]].(map[string]interface{}); ok {
+		deleteDataKey(nested, path[1:])
+	}
+}
+
+// backupConfigData writes config data to the backups directory and removes old backups
+func (c *Configurator) backupConfigData(data []byte) error {
+	backupsDir := c.backupsDir()
+	e := os.MkdirAll(backupsDir, os.ModePerm)
+	if e != nil {
+		return e
+	}
+
+	name := backupsNamePrefix + time.Now().Format(backupTimeFormat) + filepath.Ext(c.FilePath)
+	e = ioutil.WriteFile(filepath.Join(backupsDir, name), data, 0644)
+	if e != nil {
+		return e
+	}
+
+	backups, e := c.ListBackups()
+	if e != nil {
+		return e
+	}
+
+	for i := backupsMaxCount; i < len(backups); i++ {
+		os.Remove(backups[i].Path)
+	}
+
+	return nil
+}
@@ -4,23 +4,41 @@ import (
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"strings"
+	"sync"
 
 	"github.com/ghodss/yaml"
 	"github.com/jhekasoft/insteadman3/core/utils"
 )
 
 type InsteadmanConfig struct {
-	Repositories             []Repository `json:"repositories"`
-	InterpreterCommand       string       `json:"interpreter_command"`
-	Version                  string       `json:"version"`
-	UseBuiltinInterpreter    bool         `json:"use_builtin_interpreter"`
-	Lang                     string       `json:"lang"`
-	CheckUpdateOnStart       bool         `json:"check_update_on_start"`
-	GamesPath                string       `json:"games_path"`
-	InsteadManPath           string       `json:"insteadman_path"`
-	Gtk                      Gtk          `json:"gtk"`
-	CalculatedGamesPath      string       `json:"-"`
-	CalculatedInsteadManPath string       `json:"-"`
+	ConfigVersion            int                   `json:"config_version"`
+	Repositories             []Repository          `json:"repositories"`
+	InterpreterCommand       string                `json:"interpreter_command"`
+	Interpreters             []Interpreter         `json:"interpreters,omitempty"`
+	DefaultInterpreter       string                `json:"default_interpreter,omitempty"`
+	HeadlessInterpreter      string                `json:"headless_interpreter,omitempty"`
+	InterpreterArgs          []string              `json:"interpreter_args,omitempty"`
+	Version                  string                `json:"version"`
+	UseBuiltinInterpreter    bool                  `json:"use_builtin_interpreter"`
+	Lang                     string                `json:"lang"`
+	CheckUpdateOnStart       bool                  `json:"check_update_on_start"`
+	CrashReports             bool                  `json:"crash_reports,omitempty"`
+	Shortcuts                bool                  `json:"shortcuts,omitempty"`
+	FileAssociations         bool                  `json:"file_associations,omitempty"`
+	Telemetry                bool                  `json:"telemetry,omitempty"`
+	Notifications            bool                  `json:"notifications,omitempty"`
+	SavesBackup              bool                  `json:"saves_backup,omitempty"`
+	GamesPath                string                `json:"games_path"`
+	InsteadManPath           string                `json:"insteadman_path"`
+	CachePath                string                `json:"cache_path"`
+	Gtk                      Gtk                   `json:"gtk"`
+	Games                    map[string]GameConfig `json:"games,omitempty"`
+	Sandbox                  string                `json:"sandbox,omitempty"`
+	CalculatedGamesPath      string                `json:"-"`
+	CalculatedInsteadManPath string                `json:"-"`
+	CalculatedCachePath      string                `json:"-"`
+	CalculatedAppDataPath    string                `json:"-"`
 }
 
 func ExpandInterpreterCommand(command string) string {
@@ -28,6 +46,11 @@ func ExpandInterpreterCommand(command string) string {
 		return ""
 	}
 
+	// Command with arguments (like "flatpak run ...") or template isn't a path
+	if (strings.ContainsAny(command, " \t") || strings.Contains(command, "{{")) && !utils.PathExist(command) {
+		return command
+	}
+
 	path, e := filepath.Abs(command)
 	if e != nil {
 		return command
@@ -36,15 +59,87 @@ func ExpandInterpreterCommand(command string) string {
 	return path
 }
 
+// Interpreter is a registered INSTEAD interpreter (system, built-in, flatpak, legacy 2.x build etc.)
+type Interpreter struct {
+	Name    string `json:"name"`
+	Command string `json:"command"`
+	Version string `json:"version,omitempty"`
+}
+
+// FindInterpreter returns registered interpreter by the name (nil if it isn't registered)
+func (c *InsteadmanConfig) FindInterpreter(name string) *Interpreter {
+	for i := range c.Interpreters {
+		if c.Interpreters[i].Name == name {
+			return &c.Interpreters[i]
+		}
+	}
+
+	return nil
+}
+
+// GameConfig overrides running options for the game (map key of InsteadmanConfig.Games is a game name).
+// Interpreter is a name of the registered interpreter, InterpreterCommand has priority over it.
+// Args are added after InsteadmanConfig.InterpreterArgs (default arguments of all games).
+type GameConfig struct {
+	Interpreter        string            `json:"interpreter,omitempty"`
+	InterpreterCommand string            `json:"interpreter_command,omitempty"`
+	Args               []string          `json:"args,omitempty"`
+	Env                map[string]string `json:"env,omitempty"`
+	Sandbox            string            `json:"sandbox,omitempty"`
+}
+
+// GameConfig returns running options for the game by the game name
+func (c *InsteadmanConfig) GameConfig(name string) GameConfig {
+	return c.Games[name]
+}
+
+// GameSandbox returns sandbox of the game (game option overrides the global one)
+func (c *InsteadmanConfig) GameSandbox(name string) string {
+	if sandbox := c.GameConfig(name).Sandbox; sandbox != "" {
+		return sandbox
+	}
+
+	return c.Sandbox
+}
+
 type Repository struct {
 	Name string `json:"name"`
 	Url  string `json:"url"`
+	// Disabled repository is kept in the config, but it isn't downloaded
+	Disabled bool `json:"disabled,omitempty"`
+	// StatsUrl receives install counts of the repository games if telemetry is enabled
+	StatsUrl string `json:"stats_url,omitempty"`
+	// Publish is set for the repository which is maintained by the user ("dev publish")
+	Publish *Publish `json:"publish,omitempty"`
+}
+
+// Publish is an upload target of the repository. Archives and the feed are uploaded by SFTP ("sftp" method,
+// "sftp://user@host/path" url), by PUT to WebDAV directory ("webdav") or by POST to HTTP endpoint ("http").
+// Password isn't kept in the config, it's read from INSTEADMAN_PUBLISH_PASSWORD (SFTP uses SSH keys).
+type Publish struct {
+	Method   string `json:"method"`
+	Url      string `json:"url"`
+	Username string `json:"username,omitempty"`
+	// DownloadUrl is a public URL of the uploaded archives, directory of the repository url by default
+	DownloadUrl string `json:"download_url,omitempty"`
+	// FeedName is a file name of the uploaded feed, name from the repository url by default
+	FeedName string `json:"feed_name,omitempty"`
 }
 
 type Gtk struct {
 	HideSidebar bool `json:"hide_sidebar"`
 	MainWidth   int  `json:"main_width"`
 	MainHeight  int  `json:"main_height"`
+
+	// Last selected filter of the game list
+	FilterRepository string `json:"filter_repository,omitempty"`
+	FilterLang       string `json:"filter_lang,omitempty"`
+	FilterInstalled  bool   `json:"filter_installed,omitempty"`
+
+	// Icon in the notification area (tray) with quick actions
+	StatusIcon     bool     `json:"status_icon,omitempty"`
+	MinimizeToTray bool     `json:"minimize_to_tray,omitempty"`
+	RecentGames    []string `json:"recent_games,omitempty"` // IDs of the last run games, recent is the first
 }
 
 const (
@@ -61,41 +156,46 @@ type Configurator struct {
 	DataPath   string
 	LocalePath string
 	Version    string
+	// Portable keeps config, cache, games and INSTEAD data in the CurrentDir (see IsPortable)
+	Portable bool
+	// FirstRun is set by GetConfig when config file hasn't existed and has been created from the skeleton
+	FirstRun bool
+	// Flags override config values by the dotted keys (for example command line "--set=lang=en"), they aren't saved
+	Flags map[string]string
+
+	watcher           *Watcher
+	holder            *Holder // config which has been read by GetConfig (or reloaded by the watcher)
+	mutex             sync.Mutex
+	fileData          map[string]interface{}
+	overrides         map[string]override
+	changeSubscribers []func(key string, value interface{})
 }
 
-func (c *Configurator) insteadManDir() string {
-	localPath := filepath.Join(c.CurrentDir, configName)
-	_, e := os.Stat(localPath)
-	exists := !os.IsNotExist(e)
+// Holder returns holder of the current config, it's shared with the manager. It's nil before GetConfig.
+func (c *Configurator) Holder() *Holder {
+	return c.holder
+}
 
-	if exists && e == nil {
-		return c.CurrentDir
+// Config returns current config (nil before GetConfig)
+func (c *Configurator) Config() *InsteadmanConfig {
+	if c.holder == nil {
+		return nil
 	}
 
-	insteadManDir := filepath.Join(insteadDir(), insteadManDirName)
-	os.MkdirAll(insteadManDir, os.ModePerm)
-
-	return insteadManDir
+	return c.holder.Config()
 }
 
 func (c *Configurator) findConfigFileName() string {
-	return filepath.Join(c.insteadManDir(), configName)
-}
+	configDir := c.configDir()
 
-func (c *Configurator) gamesDir() string {
-	localPath := filepath.Join(c.CurrentDir, gamesDirName)
-
-	_, e := os.Stat(localPath)
-	exists := !os.IsNotExist(e)
-
-	if exists && e == nil {
-		return localPath
+	for _, name := range configNames {
+		path := filepath.Join(configDir, name)
+		if utils.PathExist(path) && !isInsteadMan2Config(path) {
+			return path
+		}
 	}
 
-	gamesDir := filepath.Join(insteadDir(), gamesDirName)
-	os.MkdirAll(gamesDir, os.ModePerm)
-
-	return gamesDir
+	return filepath.Join(configDir, configName)
 }
 
 func (c *Configurator) sceletonConfigPath() string {
@@ -164,6 +264,20 @@ func (c *Configurator) writeSkeleton() error {
 		return e
 	}
 
+	// Skeleton is YAML, convert it if config has another format
+	if configFormat(c.FilePath) != formatYaml {
+		var config *InsteadmanConfig
+		e = yaml.Unmarshal(configData, &config)
+		if e != nil {
+			return e
+		}
+
+		configData, e = marshalConfig(c.FilePath, config)
+		if e != nil {
+			return e
+		}
+	}
+
 	return ioutil.WriteFile(c.FilePath, configData, 0644)
 }
 
@@ -180,48 +294,134 @@ func (c *Configurator) GetConfig() (*InsteadmanConfig, error) {
 		if e != nil {
 			return nil, e
 		}
+		c.FirstRun = true
 	}
 
-	file, e := ioutil.ReadFile(c.FilePath)
+	cf, e := c.readConfig()
 	if e != nil {
 		return nil, e
 	}
-	// fmt.Printf("%s\n", string(file))
 
-	var config *InsteadmanConfig
-	yaml.Unmarshal(file, &config)
+	c.setState(cf)
 
-	// Fix default language for old config
-	// Default language was "ru", set it to empty value (system language)
-	if config.Version == "3.0.0" {
-		config.Lang = ""
+	// Rewrite upgraded config keeping the original one
+	if cf.migrated {
+		// Original config is backed up by SaveConfig
+		e = c.SaveConfig(cf.config)
+		if e != nil {
+			return nil, e
+		}
 	}
 
+	return cf.config, nil
+}
+
+// setState replaces current config and values of the file by the read config
+func (c *Configurator) setState(cf configFile) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.fileData = cf.fileData
+	c.overrides = cf.overrides
+	if c.holder == nil {
+		c.holder = NewHolder(cf.config)
+	} else {
+		c.holder.Replace(cf.config)
+	}
+}
+
+// configFile is a config which has been read from the file
+type configFile struct {
+	config    *InsteadmanConfig
+	data      []byte                 // original content of the file
+	fileData  map[string]interface{} // upgraded values of the file
+	migrated  bool
+	overrides map[string]override
+}
+
+// readConfig reads, upgrades and calculates config without changing configurator state
+func (c *Configurator) readConfig() (cf configFile, e error) {
+	cf.data, e = ioutil.ReadFile(c.FilePath)
+	if e != nil {
+		return
+	}
+	// fmt.Printf("%s\n", string(cf.data))
+
+	var data map[string]interface{}
+	e = unmarshalConfig(c.FilePath, cf.data, &data)
+	if e != nil {
+		return
+	}
+	if data == nil {
+		data = make(map[string]interface{})
+	}
+
+	cf.migrated = migrateConfigData(data)
+
+	config, e := configFromData(data)
+	if e != nil {
+		return
+	}
+
+	cf.fileData = data
+	cf.overrides = make(map[string]override)
+
+	e = applyEnvOverrides(config, cf.overrides)
+	if e != nil {
+		return
+	}
+
+	e = applyFlagOverrides(config, cf.overrides, c.Flags)
+	if e != nil {
+		return
+	}
+
+	c.calculatePaths(config)
+	config.CalculatedAppDataPath = c.appDataDir()
+
+	cf.config = config
+	return
+}
+
+// calculatePaths sets Calculated* paths by the paths of the config
+func (c *Configurator) calculatePaths(config *InsteadmanConfig) {
 	// TODO: make Calculated* fields like GetInterpreterCommand() func, but like "lazy vars"
 
-	config.CalculatedGamesPath = config.GamesPath
+	config.CalculatedGamesPath = c.resolveConfigPath(config.GamesPath)
 	if config.CalculatedGamesPath == "" {
 		config.CalculatedGamesPath = c.gamesDir()
 	}
 
-	config.CalculatedInsteadManPath = config.InsteadManPath
+	config.CalculatedInsteadManPath = c.resolveConfigPath(config.InsteadManPath)
 	if config.CalculatedInsteadManPath == "" {
-		config.CalculatedInsteadManPath = c.insteadManDir()
+		config.CalculatedInsteadManPath = c.dataDir()
 	}
 
-	return config, nil
+	config.CalculatedCachePath = c.resolveConfigPath(config.CachePath)
+	if config.CalculatedCachePath == "" {
+		config.CalculatedCachePath = c.cacheDir(c.resolveConfigPath(config.InsteadManPath))
+	}
 }
 
+// SaveConfig writes config to the file, config isn't changed (InsteadMan version is written to the file)
 func (c *Configurator) SaveConfig(config *InsteadmanConfig) error {
-	// Write InsteadMan version to config
-	if c.Version != "" {
-		config.Version = c.Version
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	bytes, e := marshalConfig(c.FilePath, c.configForSaving(config))
+	if e != nil {
+		return e
 	}
 
-	bytes, e := yaml.Marshal(config)
+	e = c.backupCurrentConfig(bytes)
 	if e != nil {
 		return e
 	}
 
-	return ioutil.WriteFile(c.FilePath, bytes, 0644)
+	e = ioutil.WriteFile(c.FilePath, bytes, 0644)
+	if e == nil && c.watcher != nil {
+		c.watcher.skipCurrent()
+	}
+
+	return e
 }
@@ -0,0 +1,104 @@
+// +build !windows,!darwin
+
+package configurator
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/jhekasoft/insteadman3/core/utils"
+)
+
+// XDG Base Directory Specification is used for InsteadMan directories on Linux and BSD.
+// Old ~/.instead/insteadman config is moved to the XDG config directory and old ~/.instead/games
+// directory is used while there is no games directory in the XDG data directory.
+
+func insteadDir() string {
+	return filepath.Join(os.Getenv("HOME"), ".instead")
+}
+
+func xdgDir(env, defaultHomeRelPath string) string {
+	dir := os.Getenv(env)
+	if dir == "" || !filepath.IsAbs(dir) {
+		dir = filepath.Join(os.Getenv("HOME"), defaultHomeRelPath)
+	}
+
+	return filepath.Join(dir, insteadManDirName)
+}
+
+func userConfigDir() string {
+	configDir := mkdir(xdgDir("XDG_CONFIG_HOME", ".config"))
+	migrateLegacyConfig(configDir)
+
+	return configDir
+}
+
+func userDataDir() string {
+	return mkdir(xdgDir("XDG_DATA_HOME", filepath.Join(".local", "share")))
+}
+
+func userCacheDir() string {
+	return mkdir(xdgDir("XDG_CACHE_HOME", ".cache"))
+}
+
+func userGamesDir() string {
+	gamesDir := filepath.Join(xdgDir("XDG_DATA_HOME", filepath.Join(".local", "share")), gamesDirName)
+
+	legacyGamesDir := filepath.Join(insteadDir(), gamesDirName)
+	if !utils.PathExist(gamesDir) && utils.PathExist(legacyGamesDir) {
+		return legacyGamesDir
+	}
+
+	return mkdir(gamesDir)
+}
+
+// migrateLegacyConfig moves config from the ~/.instead/insteadman if there isn't config in the XDG config dir.
+// InsteadMan 2 config is kept there, it's imported by the migration package.
+func migrateLegacyConfig(configDir string) {
+	for _, name := range configNames {
+		path := filepath.Join(configDir, name)
+		if utils.PathExist(path) && !isInsteadMan2Config(path) {
+			return
+		}
+	}
+
+	legacyDir := filepath.Join(insteadDir(), insteadManDirName)
+	for _, name := range configNames {
+		legacyPath := filepath.Join(legacyDir, name)
+		if utils.PathExist(legacyPath) && !isInsteadMan2Config(legacyPath) {
+			moveFile(legacyPath, filepath.Join(configDir, name))
+			return
+		}
+	}
+}
+
+func moveFile(src, dst string) error {
+	if os.Rename(src, dst) == nil {
+		return nil
+	}
+
+	// Rename doesn't work between different filesystems
+	in, e := os.Open(src)
+	if e != nil {
+		return e
+	}
+	defer in.Close()
+
+	out, e := os.Create(dst)
+	if e != nil {
+		return e
+	}
+
+	_, e = io.Copy(out, in)
+	if closeErr := out.Close(); e == nil {
+		e = closeErr
+	}
+	if e != nil {
+		os.Remove(dst)
+		return e
+	}
+
+	in.Close()
+	return os.Remove(src)
+}
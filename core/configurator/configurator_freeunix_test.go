@@ -0,0 +1,64 @@
+// +build !windows,!darwin
+
+package configurator
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jhekasoft/insteadman3/core/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestXdgDirsAndLegacyMigration(t *testing.T) {
+	home, e := ioutil.TempDir("", "insteadman")
+	assert.NoError(t, e)
+	defer os.RemoveAll(home)
+
+	defer os.Setenv("HOME", os.Getenv("HOME"))
+	defer os.Setenv("XDG_CONFIG_HOME", os.Getenv("XDG_CONFIG_HOME"))
+	os.Setenv("HOME", home)
+	os.Setenv("XDG_CONFIG_HOME", filepath.Join(home, "xdg-config"))
+
+	legacyDir := filepath.Join(home, ".instead", insteadManDirName)
+	assert.NoError(t, os.MkdirAll(legacyDir, os.ModePerm))
+	assert.NoError(t, os.MkdirAll(filepath.Join(home, ".instead", gamesDirName), os.ModePerm))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(legacyDir, configName), []byte("lang: uk\n"), 0644))
+
+	configDir := userConfigDir()
+	assert.Equal(t, filepath.Join(home, "xdg-config", insteadManDirName), configDir)
+	assert.True(t, utils.PathExist(filepath.Join(configDir, configName)))
+	assert.False(t, utils.PathExist(filepath.Join(legacyDir, configName)))
+
+	assert.Equal(t, filepath.Join(home, ".cache", insteadManDirName), userCacheDir())
+	assert.Equal(t, filepath.Join(home, ".local", "share", insteadManDirName), userDataDir())
+
+	// Legacy games directory is used while there is no XDG games directory
+	assert.Equal(t, filepath.Join(home, ".instead", gamesDirName), userGamesDir())
+}
+
+func TestInsteadMan2ConfigIsntMigrated(t *testing.T) {
+	home, e := ioutil.TempDir("", "insteadman")
+	assert.NoError(t, e)
+	defer os.RemoveAll(home)
+
+	defer os.Setenv("HOME", os.Getenv("HOME"))
+	defer os.Setenv("XDG_CONFIG_HOME", os.Getenv("XDG_CONFIG_HOME"))
+	os.Setenv("HOME", home)
+	os.Setenv("XDG_CONFIG_HOME", filepath.Join(home, "xdg-config"))
+
+	legacyDir := filepath.Join(home, ".instead", insteadManDirName)
+	assert.NoError(t, os.MkdirAll(legacyDir, os.ModePerm))
+	oldConfig := filepath.Join(legacyDir, insteadMan2ConfigName)
+	assert.NoError(t, ioutil.WriteFile(oldConfig, []byte(`{"lang": "uk", "repositories": []}`), 0644))
+
+	// InsteadMan 2 config is left for the import
+	configDir := userConfigDir()
+	assert.True(t, utils.PathExist(oldConfig))
+	assert.False(t, utils.PathExist(filepath.Join(configDir, insteadMan2ConfigName)))
+
+	c := Configurator{}
+	assert.Equal(t, filepath.Join(configDir, configName), c.findConfigFileName())
+}
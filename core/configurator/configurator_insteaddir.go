@@ -0,0 +1,25 @@
+// +build windows darwin
+
+package configurator
+
+import (
+	"path/filepath"
+)
+
+// On Windows and macOS all the InsteadMan directories are placed inside INSTEAD directory
+
+func userConfigDir() string {
+	return mkdir(filepath.Join(insteadDir(), insteadManDirName))
+}
+
+func userDataDir() string {
+	return userConfigDir()
+}
+
+func userCacheDir() string {
+	return mkdir(filepath.Join(userConfigDir(), cacheDirName))
+}
+
+func userGamesDir() string {
+	return mkdir(filepath.Join(insteadDir(), gamesDirName))
+}
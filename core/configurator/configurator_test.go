@@ -1,8 +1,14 @@
 package configurator
 
 import (
-	"github.com/stretchr/testify/assert"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
 )
 
 const configFilePath = "../../resources/testdata/insteadman/config.yml"
@@ -33,3 +39,323 @@ func TestGetSkeletonConfig(t *testing.T) {
 	assert.NoError(t, e)
 	assert.NotEmpty(t, config.Repositories)
 }
+
+func TestConfigFormats(t *testing.T) {
+	dir, e := ioutil.TempDir("", "insteadman")
+	assert.NoError(t, e)
+	defer os.RemoveAll(dir)
+
+	for _, name := range []string{"config.json", "config.toml", "config.yml"} {
+		configurator := Configurator{FilePath: filepath.Join(dir, name), CurrentDir: "../../", Version: "3.1.2"}
+		config, e := configurator.GetConfig()
+		assert.NoError(t, e)
+		assert.True(t, configurator.FirstRun)
+		assert.NotEmpty(t, config.Repositories)
+
+		config.Lang = "uk"
+		config.Gtk.MainWidth = 800
+		e = configurator.SaveConfig(config)
+		assert.NoError(t, e)
+
+		configurator = Configurator{FilePath: filepath.Join(dir, name)}
+		config, e = configurator.GetConfig()
+		assert.NoError(t, e)
+		assert.Equal(t, "uk", config.Lang)
+		assert.Equal(t, 800, config.Gtk.MainWidth)
+		assert.NotEmpty(t, config.Repositories)
+	}
+}
+
+func TestMigrateConfig(t *testing.T) {
+	dir, e := ioutil.TempDir("", "insteadman")
+	assert.NoError(t, e)
+	defer os.RemoveAll(dir)
+
+	oldConfig := []byte("lang: ru\nversion: 3.0.0\nrepositories:\n- name: test\n  url: http://example.com/test.xml\n")
+	filePath := filepath.Join(dir, "config.yml")
+	e = ioutil.WriteFile(filePath, oldConfig, 0644)
+	assert.NoError(t, e)
+
+	configurator := Configurator{FilePath: filePath}
+	config, e := configurator.GetConfig()
+	assert.NoError(t, e)
+	assert.Equal(t, currentConfigVersion, config.ConfigVersion)
+	assert.Equal(t, "", config.Lang)
+	assert.NotEmpty(t, config.Repositories)
+
+	// Original config is kept
+	backups, e := configurator.ListBackups()
+	assert.NoError(t, e)
+	assert.Len(t, backups, 1)
+	backupData, e := ioutil.ReadFile(backups[0].Path)
+	assert.NoError(t, e)
+	assert.Equal(t, oldConfig, backupData)
+
+	// Migrated config has been saved
+	configurator = Configurator{FilePath: filePath}
+	config, e = configurator.GetConfig()
+	assert.NoError(t, e)
+	assert.Equal(t, currentConfigVersion, config.ConfigVersion)
+}
+
+func TestPortable(t *testing.T) {
+	dir, e := ioutil.TempDir("", "insteadman")
+	assert.NoError(t, e)
+	defer os.RemoveAll(dir)
+
+	configurator := Configurator{CurrentDir: dir}
+	assert.False(t, configurator.IsPortable())
+
+	e = ioutil.WriteFile(filepath.Join(dir, portableMarkerName), []byte{}, 0644)
+	assert.NoError(t, e)
+	assert.True(t, configurator.IsPortable())
+
+	e = ioutil.WriteFile(filepath.Join(dir, configName), []byte("games_path: my_games\n"), 0644)
+	assert.NoError(t, e)
+
+	config, e := configurator.GetConfig()
+	assert.NoError(t, e)
+	assert.Equal(t, filepath.Join(dir, configName), configurator.FilePath)
+	assert.Equal(t, filepath.Join(dir, "my_games"), config.CalculatedGamesPath)
+	assert.Equal(t, dir, config.CalculatedInsteadManPath)
+	assert.Equal(t, filepath.Join(dir, appDataDirName), config.CalculatedAppDataPath)
+
+	// Paths inside the executable directory are saved relative
+	assert.NoError(t, configurator.Set("games_path", filepath.Join(dir, "other_games")))
+	assert.Equal(t, filepath.Join(dir, "other_games"), configurator.Config().CalculatedGamesPath)
+	assert.NoError(t, configurator.Set("cache_path", filepath.Join(filepath.Dir(dir), "cache")))
+	assert.NoError(t, configurator.SaveConfig(configurator.Config()))
+	configData, e := ioutil.ReadFile(configurator.FilePath)
+	assert.NoError(t, e)
+	assert.Contains(t, string(configData), "games_path: other_games\n")
+	assert.Contains(t, string(configData), "cache_path: "+filepath.Join(filepath.Dir(dir), "cache")+"\n")
+}
+
+func TestWatch(t *testing.T) {
+	dir, e := ioutil.TempDir("", "insteadman")
+	assert.NoError(t, e)
+	defer os.RemoveAll(dir)
+
+	filePath := filepath.Join(dir, configName)
+	assert.NoError(t, ioutil.WriteFile(filePath, []byte("config_version: 1\nlang: ru\n"), 0644))
+
+	configurator := Configurator{FilePath: filePath}
+	config, e := configurator.GetConfig()
+	assert.NoError(t, e)
+
+	watcher := configurator.Watch(10 * time.Millisecond)
+	defer watcher.Stop()
+
+	reloaded := make(chan *InsteadmanConfig, 1)
+	watcher.Subscribe(func(config *InsteadmanConfig) {
+		reloaded <- config
+	})
+
+	// Own changes aren't notified
+	config.Lang = "en"
+	assert.NoError(t, configurator.SaveConfig(config))
+	time.Sleep(50 * time.Millisecond)
+	assert.Empty(t, reloaded)
+
+	// External changes
+	modTime := time.Now().Add(time.Second)
+	assert.NoError(t, ioutil.WriteFile(filePath, []byte("config_version: 1\nlang: uk\n"), 0644))
+	assert.NoError(t, os.Chtimes(filePath, modTime, modTime))
+
+	select {
+	case config = <-reloaded:
+		assert.Equal(t, "uk", config.Lang)
+		// Configurator uses reloaded config
+		assert.Equal(t, config, configurator.Config())
+		value, e := configurator.Get("lang")
+		assert.NoError(t, e)
+		assert.Equal(t, "uk", value)
+	case <-time.After(time.Second):
+		t.Error("config hasn't been reloaded")
+	}
+}
+
+func TestGetSet(t *testing.T) {
+	dir, e := ioutil.TempDir("", "insteadman")
+	assert.NoError(t, e)
+	defer os.RemoveAll(dir)
+
+	filePath := filepath.Join(dir, configName)
+	assert.NoError(t, ioutil.WriteFile(filePath, []byte("config_version: 1\nlang: ru\n"), 0644))
+
+	defer os.Unsetenv("INSTEADMAN_GTK_MAIN_WIDTH")
+	os.Setenv("INSTEADMAN_GTK_MAIN_WIDTH", "1024")
+
+	configurator := Configurator{FilePath: filePath}
+	config, e := configurator.GetConfig()
+	assert.NoError(t, e)
+	assert.Equal(t, 1024, config.Gtk.MainWidth)
+
+	var changedKeys []string
+	configurator.OnChange(func(key string, value interface{}) {
+		changedKeys = append(changedKeys, key)
+	})
+
+	value, e := configurator.Get("lang")
+	assert.NoError(t, e)
+	assert.Equal(t, "ru", value)
+
+	assert.NoError(t, configurator.Set("gtk.hide_sidebar", "true"))
+	assert.True(t, configurator.Config().Gtk.HideSidebar)
+	// Config which has been got before isn't changed
+	assert.False(t, config.Gtk.HideSidebar)
+
+	assert.NoError(t, configurator.Set("games.oldgame.args", `["-nosound"]`))
+	assert.Equal(t, []string{"-nosound"}, configurator.Config().GameConfig("oldgame").Args)
+	assert.Nil(t, config.GameConfig("oldgame").Args)
+
+	assert.NoError(t, configurator.Set("use_builtin_interpreter", false))
+	assert.Error(t, configurator.Set("gtk.main_height", "big"))
+	assert.Error(t, configurator.Set("lang", 10))
+	assert.Error(t, configurator.Set("unknown.key", "1"))
+	_, e = configurator.Get("unknown")
+	assert.Error(t, e)
+
+	assert.Equal(t, []string{"gtk.hide_sidebar", "games.oldgame.args", "use_builtin_interpreter"}, changedKeys)
+
+	// Environment value isn't saved
+	config = configurator.Config()
+	assert.NoError(t, configurator.SaveConfig(config))
+	configData, e := ioutil.ReadFile(filePath)
+	assert.NoError(t, e)
+	assert.Contains(t, string(configData), "main_width: 0")
+	assert.Contains(t, string(configData), "hide_sidebar: true")
+	assert.Equal(t, 1024, config.Gtk.MainWidth)
+}
+
+func TestConfigCopy(t *testing.T) {
+	config := &InsteadmanConfig{
+		Repositories:    []Repository{{Name: "official", Publish: &Publish{Method: "sftp"}}},
+		Interpreters:    []Interpreter{{Name: "system", Version: "3.3.0"}},
+		InterpreterArgs: []string{"-nosound"},
+		Games:           map[string]GameConfig{"oldgame": {Args: []string{"-debug"}, Env: map[string]string{"LANG": "C"}}},
+	}
+
+	configCopy := config.Copy()
+	assert.Equal(t, config, configCopy)
+
+	configCopy.Repositories[0].Publish.Method = "http"
+	configCopy.Interpreters[0].Version = "3.4.0"
+	configCopy.InterpreterArgs[0] = "-fullscreen"
+	configCopy.Games["oldgame"].Args[0] = "-nosound"
+	configCopy.Games["oldgame"].Env["LANG"] = "ru_RU"
+	configCopy.Games["newgame"] = GameConfig{}
+
+	assert.Equal(t, "sftp", config.Repositories[0].Publish.Method)
+	assert.Equal(t, "3.3.0", config.Interpreters[0].Version)
+	assert.Equal(t, []string{"-nosound"}, config.InterpreterArgs)
+	assert.Equal(t, []string{"-debug"}, config.Games["oldgame"].Args)
+	assert.Equal(t, "C", config.Games["oldgame"].Env["LANG"])
+	assert.Len(t, config.Games, 1)
+}
+
+func TestHolderUpdate(t *testing.T) {
+	config := &InsteadmanConfig{Lang: "ru"}
+	holder := NewHolder(config)
+
+	newConfig, e := holder.Update(func(config *InsteadmanConfig) error {
+		config.Lang = "en"
+		return nil
+	})
+	assert.NoError(t, e)
+	assert.Equal(t, "en", newConfig.Lang)
+	assert.Equal(t, newConfig, holder.Config())
+	assert.Equal(t, "ru", config.Lang)
+
+	// Config isn't replaced if the change has failed
+	_, e = holder.Update(func(config *InsteadmanConfig) error {
+		config.Lang = "uk"
+		return errors.New("save error")
+	})
+	assert.Error(t, e)
+	assert.Equal(t, "en", holder.Config().Lang)
+}
+
+func TestBackupAndRestore(t *testing.T) {
+	dir, e := ioutil.TempDir("", "insteadman")
+	assert.NoError(t, e)
+	defer os.RemoveAll(dir)
+
+	filePath := filepath.Join(dir, configName)
+	assert.NoError(t, ioutil.WriteFile(filePath, []byte("config_version: 1\nlang: ru\n"), 0644))
+
+	configurator := Configurator{FilePath: filePath}
+	config, e := configurator.GetConfig()
+	assert.NoError(t, e)
+
+	for _, lang := range []string{"en", "uk", "en", "uk", "en", "uk", "en"} {
+		config.Lang = lang
+		assert.NoError(t, configurator.SaveConfig(config))
+		time.Sleep(2 * time.Millisecond)
+	}
+
+	// Only last backups are kept
+	backups, e := configurator.ListBackups()
+	assert.NoError(t, e)
+	assert.Len(t, backups, backupsMaxCount)
+
+	// Config without changes isn't backed up
+	assert.NoError(t, configurator.SaveConfig(config))
+	newBackups, e := configurator.ListBackups()
+	assert.NoError(t, e)
+	assert.Equal(t, backups, newBackups)
+
+	// Window size and recent games don't push out backups
+	config.Gtk.MainWidth = 1024
+	config.Gtk.RecentGames = []string{"official/lost/ru"}
+	assert.NoError(t, configurator.SaveConfig(config))
+	newBackups, e = configurator.ListBackups()
+	assert.NoError(t, e)
+	assert.Equal(t, backups, newBackups)
+
+	config, e = configurator.RestoreBackup("")
+	assert.NoError(t, e)
+	assert.Equal(t, "uk", config.Lang)
+}
+
+func TestEffective(t *testing.T) {
+	dir, e := ioutil.TempDir("", "insteadman")
+	assert.NoError(t, e)
+	defer os.RemoveAll(dir)
+
+	filePath := filepath.Join(dir, configName)
+	assert.NoError(t, ioutil.WriteFile(filePath, []byte("config_version: 1\nlang: ru\ngtk:\n  main_height: 600\n"), 0644))
+
+	defer os.Unsetenv("INSTEADMAN_GTK_MAIN_WIDTH")
+	os.Setenv("INSTEADMAN_GTK_MAIN_WIDTH", "1024")
+
+	configurator := Configurator{
+		FilePath: filePath,
+		Flags:    map[string]string{"gtk.main_width": "800", "games.oldgame.args": `["-nosound"]`},
+	}
+	config, e := configurator.GetConfig()
+	assert.NoError(t, e)
+	assert.Equal(t, 800, config.Gtk.MainWidth)
+
+	values, e := configurator.Effective()
+	assert.NoError(t, e)
+
+	sources := make(map[string]string)
+	for _, value := range values {
+		sources[value.Key] = value.Source
+	}
+
+	assert.Equal(t, SourceFile, sources["lang"])
+	assert.Equal(t, SourceFile, sources["gtk.main_height"])
+	assert.Equal(t, SourceDefault, sources["gtk.hide_sidebar"])
+	assert.Equal(t, SourceFlag, sources["gtk.main_width"])
+	assert.Equal(t, SourceFlag, sources["games.oldgame.args"])
+	assert.Equal(t, SourceCalculated, sources["calculated.games_path"])
+
+	// Neither environment nor flag values are saved
+	assert.NoError(t, configurator.SaveConfig(config))
+	configData, e := ioutil.ReadFile(filePath)
+	assert.NoError(t, e)
+	assert.Contains(t, string(configData), "main_width: 0")
+	assert.NotContains(t, string(configData), "oldgame")
+}
@@ -0,0 +1,109 @@
+package configurator
+
+// Sources of the effective config values
+const (
+	SourceDefault    = "default"
+	SourceFile       = "file"
+	SourceEnv        = "env"
+	SourceFlag       = "flag"
+	SourceCalculated = "calculated"
+)
+
+// EffectiveValue is a config value which is used by the application and the source of it
+type EffectiveValue struct {
+	Key    string
+	Value  interface{}
+	Source string
+}
+
+// Effective returns merged config (defaults, file values, environment variables and flags)
+// and calculated paths with the source of each value. GetConfig has to be called before.
+func (c *Configurator) Effective() ([]EffectiveValue, error) {
+	config := c.Config()
+	if config == nil {
+		return nil, ErrNotRead
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	var values []EffectiveValue
+	for _, key := range Keys() {
+		value, e := configValue(config, key)
+		if e != nil {
+			return nil, e
+		}
+
+		values = append(values, EffectiveValue{Key: key, Value: value, Source: c.keySource(key)})
+	}
+
+	// Flag overrides of the nested keys (like "games.oldgame.args") aren't in the Keys()
+	for key, o := range c.overrides {
+		if o.source != SourceFlag || isKey(key) {
+			continue
+		}
+
+		value, e := configValue(config, key)
+		if e != nil {
+			return nil, e
+		}
+
+		values = append(values, EffectiveValue{Key: key, Value: value, Source: SourceFlag})
+	}
+
+	portableSource := SourceCalculated
+	if c.Portable {
+		portableSource = SourceFlag
+	}
+
+	values = append(values,
+		EffectiveValue{Key: "portable", Value: c.IsPortable(), Source: portableSource},
+		EffectiveValue{Key: "config_path", Value: c.FilePath, Source: SourceCalculated},
+		EffectiveValue{Key: "calculated.games_path", Value: config.CalculatedGamesPath, Source: SourceCalculated},
+		EffectiveValue{Key: "calculated.insteadman_path", Value: config.CalculatedInsteadManPath, Source: SourceCalculated},
+		EffectiveValue{Key: "calculated.cache_path", Value: config.CalculatedCachePath, Source: SourceCalculated},
+		EffectiveValue{Key: "calculated.app_data_path", Value: config.CalculatedAppDataPath, Source: SourceCalculated},
+	)
+
+	return values, nil
+}
+
+func (c *Configurator) keySource(key string) string {
+	if o, ok := c.overrides[key]; ok {
+		return o.source
+	}
+
+	if hasDataKey(c.fileData, splitKey(key)) {
+		return SourceFile
+	}
+
+	return SourceDefault
+}
+
+func isKey(key string) bool {
+	for _, k := range Keys() {
+		if k == key {
+			return true
+		}
+	}
+
+	return false
+}
+
+func hasDataKey(data map[string]interface{}, path []string) bool {
+	value, ok := data[path[0]]
+	if !ok {
+		return false
+	}
+
+	if len(path) == 1 {
+		return true
+	}
+
+	nested, ok := value.(map[string]interface{})
+	if !ok {
+		return false
+	}
+
+	return hasDataKey(nested, path[1:])
+}
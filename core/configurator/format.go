@@ -0,0 +1,153 @@
+package configurator
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/ghodss/yaml"
+)
+
+// Config file formats are detected by the extension. YAML is used for unknown extensions.
+const (
+	formatYaml = "yaml"
+	formatJson = "json"
+	formatToml = "toml"
+)
+
+// configNames are the config file names which are searched in the InsteadMan directory (in the order of priority)
+var configNames = []string{configName, "config.yaml", "config.json", "config.toml"}
+
+// insteadMan2ConfigName is a config file name of InsteadMan 2 which is kept in the same directory,
+// it's imported by the migration package and isn't read as InsteadMan 3 config
+const insteadMan2ConfigName = "config.json"
+
+// insteadMan3Keys are keys which are always saved to InsteadMan 3 config and aren't in InsteadMan 2 config
+var insteadMan3Keys = []string{"config_version", "version", "use_builtin_interpreter", "insteadman_path", "gtk"}
+
+// isInsteadMan2Config checks that the file is InsteadMan 2 config (config.json without InsteadMan 3 keys)
+func isInsteadMan2Config(path string) bool {
+	if filepath.Base(path) != insteadMan2ConfigName {
+		return false
+	}
+
+	data, e := ioutil.ReadFile(path)
+	if e != nil {
+		return false
+	}
+
+	var values map[string]interface{}
+	if json.Unmarshal(data, &values) != nil {
+		return false
+	}
+
+	for _, key := range insteadMan3Keys {
+		if _, ok := values[key]; ok {
+			return false
+		}
+	}
+
+	return true
+}
+
+func configFormat(path string) string {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		return formatJson
+	case ".toml":
+		return formatToml
+	default:
+		return formatYaml
+	}
+}
+
+func marshalConfig(path string, v interface{}) ([]byte, error) {
+	switch configFormat(path) {
+	case formatJson:
+		return json.MarshalIndent(v, "", "  ")
+	case formatToml:
+		// TOML is encoded through JSON representation so struct json tags are used as keys
+		data, e := toJsonMap(v)
+		if e != nil {
+			return nil, e
+		}
+
+		buf := new(bytes.Buffer)
+		e = toml.NewEncoder(buf).Encode(data)
+		return buf.Bytes(), e
+	default:
+		return yaml.Marshal(v)
+	}
+}
+
+func unmarshalConfig(path string, data []byte, v interface{}) error {
+	switch configFormat(path) {
+	case formatJson:
+		return json.Unmarshal(data, v)
+	case formatToml:
+		var tomlData map[string]interface{}
+		e := toml.Unmarshal(data, &tomlData)
+		if e != nil {
+			return e
+		}
+
+		jsonData, e := json.Marshal(tomlData)
+		if e != nil {
+			return e
+		}
+
+		return json.Unmarshal(jsonData, v)
+	default:
+		return yaml.Unmarshal(data, v)
+	}
+}
+
+// toJsonMap converts value to the map by its JSON representation.
+// Null values are dropped and integer numbers are kept as integers (TOML has no null and distinguishes numbers).
+func toJsonMap(v interface{}) (map[string]interface{}, error) {
+	jsonData, e := json.Marshal(v)
+	if e != nil {
+		return nil, e
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(jsonData))
+	decoder.UseNumber()
+
+	var data map[string]interface{}
+	e = decoder.Decode(&data)
+	if e != nil {
+		return nil, e
+	}
+
+	return normalizeJsonValue(data).(map[string]interface{}), nil
+}
+
+func normalizeJsonValue(v interface{}) interface{} {
+	switch value := v.(type) {
+	case map[string]interface{}:
+		for key, el := range value {
+			if el == nil {
+				delete(value, key)
+				continue
+			}
+			value[key] = normalizeJsonValue(el)
+		}
+		return value
+	case []interface{}:
+		for i, el := range value {
+			value[i] = normalizeJsonValue(el)
+		}
+		return value
+	case json.Number:
+		if i, e := value.Int64(); e == nil {
+			return i
+		}
+		f, _ := value.Float64()
+		return f
+	default:
+		return v
+	}
+}
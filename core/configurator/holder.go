@@ -0,0 +1,104 @@
+package configurator
+
+import "sync"
+
+// Holder keeps current config which is shared by the configurator and the manager. Config isn't changed
+// in place: changes are made in the copy which replaces current config, so config which has been got
+// by Config() can be read without locking.
+type Holder struct {
+	mutex       sync.RWMutex
+	updateMutex sync.Mutex // updates are serialized, so concurrent changes aren't lost
+	config      *InsteadmanConfig
+}
+
+func NewHolder(config *InsteadmanConfig) *Holder {
+	return &Holder{config: config}
+}
+
+// Config returns current config
+func (h *Holder) Config() *InsteadmanConfig {
+	h.mutex.RLock()
+	defer h.mutex.RUnlock()
+
+	return h.config
+}
+
+// Replace replaces current config by the new one (reloaded config)
+func (h *Holder) Replace(config *InsteadmanConfig) {
+	h.mutex.Lock()
+	h.config = config
+	h.mutex.Unlock()
+}
+
+// Update changes copy of the current config by f and replaces current config by it.
+// Config isn't replaced if f returns error. Config is read by the others during f, so f can wait for them.
+func (h *Holder) Update(f func(config *InsteadmanConfig) error) (*InsteadmanConfig, error) {
+	h.updateMutex.Lock()
+	defer h.updateMutex.Unlock()
+
+	config := h.Config().Copy()
+	e := f(config)
+	if e != nil {
+		return nil, e
+	}
+
+	h.Replace(config)
+
+	return config, nil
+}
+
+// Copy returns deep copy of the config
+func (c *InsteadmanConfig) Copy() *InsteadmanConfig {
+	if c == nil {
+		return &InsteadmanConfig{}
+	}
+
+	config := *c
+
+	if c.Repositories != nil {
+		config.Repositories = make([]Repository, len(c.Repositories))
+		copy(config.Repositories, c.Repositories)
+		for i, repository := range config.Repositories {
+			if repository.Publish != nil {
+				publish := *repository.Publish
+				config.Repositories[i].Publish = &publish
+			}
+		}
+	}
+
+	if c.Interpreters != nil {
+		config.Interpreters = make([]Interpreter, len(c.Interpreters))
+		copy(config.Interpreters, c.Interpreters)
+	}
+
+	config.InterpreterArgs = copyStrings(c.InterpreterArgs)
+	config.Gtk.RecentGames = copyStrings(c.Gtk.RecentGames)
+
+	if c.Games != nil {
+		config.Games = make(map[string]GameConfig, len(c.Games))
+		for name, gameConfig := range c.Games {
+			gameConfig.Args = copyStrings(gameConfig.Args)
+			if gameConfig.Env != nil {
+				env := make(map[string]string, len(gameConfig.Env))
+				for key, value := range gameConfig.Env {
+					env[key] = value
+				}
+				gameConfig.Env = env
+			}
+			config.Games[name] = gameConfig
+		}
+	}
+
+	return &config
+}
+
+func copyStrings(values []string) []string {
+	if values == nil {
+		return nil
+	}
+
+	result := make([]string, len(values))
+	copy(result, values)
+
+	return result
+}
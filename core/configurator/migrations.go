@@ -0,0 +1,66 @@
+package configurator
+
+import (
+	"encoding/json"
+)
+
+// currentConfigVersion is a version of the config schema.
+// Increase it and add migration to the configMigrations if config keys are renamed or restructured.
+const currentConfigVersion = 1
+
+// configMigration upgrades raw config data (keys are the same as in the config file) to the version
+type configMigration struct {
+	version int
+	migrate func(data map[string]interface{})
+}
+
+var configMigrations = []configMigration{
+	// Default language was "ru" in 3.0.0, set it to empty value (system language)
+	{version: 1, migrate: func(data map[string]interface{}) {
+		if data["version"] == "3.0.0" {
+			data["lang"] = ""
+		}
+	}},
+}
+
+func configDataVersion(data map[string]interface{}) int {
+	switch version := data["config_version"].(type) {
+	case float64:
+		return int(version)
+	case int64:
+		return int(version)
+	case int:
+		return version
+	}
+
+	return 0
+}
+
+// migrateConfigData applies all the migrations which are newer than config version.
+// Returns true if config data has been changed.
+func migrateConfigData(data map[string]interface{}) bool {
+	version := configDataVersion(data)
+	if version >= currentConfigVersion {
+		return false
+	}
+
+	for _, migration := range configMigrations {
+		if migration.version > version {
+			migration.migrate(data)
+		}
+	}
+
+	data["config_version"] = currentConfigVersion
+
+	return true
+}
+
+func configFromData(data map[string]interface{}) (config *InsteadmanConfig, e error) {
+	jsonData, e := json.Marshal(data)
+	if e != nil {
+		return
+	}
+
+	e = json.Unmarshal(jsonData, &config)
+	return
+}
@@ -0,0 +1,126 @@
+package configurator
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/jhekasoft/insteadman3/core/utils"
+)
+
+// InsteadMan paths are resolved in the order:
+//   1. Portable mode: everything is kept in the executable directory (CurrentDir).
+//   2. Local mode: config.yml or games directory are placed near the executable.
+//   3. User directories of the platform (see userConfigDir(), userDataDir(), userCacheDir(), userGamesDir()).
+
+const (
+	// portableMarkerName is a file name near the executable which turns portable mode on
+	portableMarkerName = "portable"
+	appDataDirName     = "appdata"
+	cacheDirName       = "cache"
+)
+
+// IsPortable returns true if portable mode is turned on by the flag or by the marker file near the executable
+func (c *Configurator) IsPortable() bool {
+	if c.Portable {
+		return true
+	}
+
+	return c.CurrentDir != "" && utils.PathExist(filepath.Join(c.CurrentDir, portableMarkerName))
+}
+
+// LegacyInsteadManDir returns InsteadMan directory inside INSTEAD directory (~/.instead/insteadman).
+// It's used by InsteadMan 2 and by the old InsteadMan 3 versions.
+func (c *Configurator) LegacyInsteadManDir() string {
+	return filepath.Join(insteadDir(), insteadManDirName)
+}
+
+func (c *Configurator) isLocal() bool {
+	return c.IsPortable() || utils.PathExist(filepath.Join(c.CurrentDir, configName))
+}
+
+func (c *Configurator) configDir() string {
+	if c.isLocal() {
+		return c.CurrentDir
+	}
+
+	return userConfigDir()
+}
+
+func (c *Configurator) dataDir() string {
+	if c.isLocal() {
+		return c.CurrentDir
+	}
+
+	return userDataDir()
+}
+
+func (c *Configurator) cacheDir(insteadManPath string) string {
+	if insteadManPath != "" {
+		return filepath.Join(insteadManPath, cacheDirName)
+	}
+
+	if c.isLocal() {
+		return filepath.Join(c.CurrentDir, cacheDirName)
+	}
+
+	return userCacheDir()
+}
+
+func (c *Configurator) gamesDir() string {
+	localPath := filepath.Join(c.CurrentDir, gamesDirName)
+
+	if c.IsPortable() {
+		os.MkdirAll(localPath, os.ModePerm)
+		return localPath
+	}
+
+	if utils.PathExist(localPath) {
+		return localPath
+	}
+
+	return userGamesDir()
+}
+
+// appDataDir returns INSTEAD data directory (saves, settings) for portable mode.
+// Empty value means default INSTEAD data directory in the user profile.
+func (c *Configurator) appDataDir() string {
+	if !c.IsPortable() {
+		return ""
+	}
+
+	appDataDir := filepath.Join(c.CurrentDir, appDataDirName)
+	os.MkdirAll(appDataDir, os.ModePerm)
+
+	return appDataDir
+}
+
+// resolveConfigPath resolves path from the config. Relative paths are relative to the executable
+// directory in portable mode (drive letter or mount point of the removable drive can be changed).
+func (c *Configurator) resolveConfigPath(path string) string {
+	if path == "" || !c.IsPortable() || filepath.IsAbs(path) {
+		return path
+	}
+
+	return filepath.Join(c.CurrentDir, path)
+}
+
+// portableConfigPath returns path for saving to the config: path inside the executable directory
+// is relative in portable mode (see resolveConfigPath)
+func (c *Configurator) portableConfigPath(path string) string {
+	if path == "" || !filepath.IsAbs(path) || !c.IsPortable() {
+		return path
+	}
+
+	relPath, e := filepath.Rel(c.CurrentDir, path)
+	if e != nil || relPath == ".." || strings.HasPrefix(relPath, ".."+string(filepath.Separator)) {
+		return path
+	}
+
+	return relPath
+}
+
+func mkdir(path string) string {
+	os.MkdirAll(path, os.ModePerm)
+	return path
+}
@@ -0,0 +1,102 @@
+package configurator
+
+import (
+	"os"
+	"sync"
+	"time"
+)
+
+// Watcher checks config file periodically and notifies subscribers when the file has been changed
+// by another application (changes saved by SaveConfig are skipped).
+// Reloaded config replaces current config of the configurator (and of the manager which shares it),
+// then subscribers are called from the watcher goroutine with the new config.
+type Watcher struct {
+	configurator *Configurator
+	interval     time.Duration
+	modTime      time.Time
+	subscribers  []func(config *InsteadmanConfig)
+	mutex        sync.Mutex
+	stop         chan struct{}
+}
+
+// Watch starts watching for the config file changes
+func (c *Configurator) Watch(interval time.Duration) *Watcher {
+	w := &Watcher{configurator: c, interval: interval, stop: make(chan struct{})}
+	w.modTime = w.fileModTime()
+	c.watcher = w
+
+	go w.run()
+
+	return w
+}
+
+// Subscribe adds function which is called with the reloaded config
+func (w *Watcher) Subscribe(f func(config *InsteadmanConfig)) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	w.subscribers = append(w.subscribers, f)
+}
+
+// Stop stops watching
+func (w *Watcher) Stop() {
+	close(w.stop)
+}
+
+func (w *Watcher) run() {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-w.stop:
+			return
+		case <-ticker.C:
+			w.check()
+		}
+	}
+}
+
+func (w *Watcher) check() {
+	modTime := w.fileModTime()
+
+	w.mutex.Lock()
+	changed := !modTime.IsZero() && !modTime.Equal(w.modTime)
+	w.modTime = modTime
+	subscribers := w.subscribers
+	w.mutex.Unlock()
+
+	if !changed {
+		return
+	}
+
+	cf, e := w.configurator.readConfig()
+	if e != nil {
+		return
+	}
+
+	// Get, Set and Effective use reloaded config too
+	w.configurator.setState(cf)
+
+	for _, f := range subscribers {
+		f(cf.config)
+	}
+}
+
+// skipCurrent remembers current file state to skip changes which are made by SaveConfig
+func (w *Watcher) skipCurrent() {
+	modTime := w.fileModTime()
+
+	w.mutex.Lock()
+	w.modTime = modTime
+	w.mutex.Unlock()
+}
+
+func (w *Watcher) fileModTime() time.Time {
+	info, e := os.Stat(w.configurator.FilePath)
+	if e != nil {
+		return time.Time{}
+	}
+
+	return info.ModTime()
+}
@@ -0,0 +1,206 @@
+package crashreport
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"net/url"
+	"os"
+	"path/filepath"
+	"runtime"
+	"runtime/debug"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/ghodss/yaml"
+	"github.com/jhekasoft/insteadman3/core/configurator"
+	"github.com/jhekasoft/insteadman3/core/manager"
+)
+
+const (
+	dirName    = "crashes"
+	reportExt  = ".md"
+	offeredExt = ".offered"
+	logLines   = 100
+
+	issueURL = "https://github.com/jhekasoft/insteadman3/issues/new"
+	// maxIssueBody keeps URL of the new issue in the browser limits
+	maxIssueBody = 6000
+
+	panicPrefix = "Panic: "
+	hiddenValue = "***"
+)
+
+// Reporter writes crash report of the panic to the InsteadMan dir. It's opt-in:
+// reports are written only if crash_reports is enabled in the config.
+type Reporter struct {
+	App     string // "insteadman-gtk", "insteadman-cli"
+	Version string
+	Manager *manager.Manager // current config is taken from the manager (it can be reloaded)
+	Log     *LogTail
+}
+
+func New(app, version string, m *manager.Manager) *Reporter {
+	return &Reporter{App: app, Version: version, Manager: m, Log: NewLogTail(logLines)}
+}
+
+// Enabled returns true if crash reports are enabled in the config
+func (r *Reporter) Enabled() bool {
+	return r.Manager != nil && r.Manager.Config() != nil && r.Manager.Config().CrashReports
+}
+
+// Dir returns directory of the crash reports
+func (r *Reporter) Dir() string {
+	return filepath.Join(r.Manager.Config().CalculatedInsteadManPath, dirName)
+}
+
+// CaptureLog keeps recent lines of the standard logger for the crash report
+func (r *Reporter) CaptureLog() {
+	log.SetOutput(io.MultiWriter(log.Writer(), r.Log))
+}
+
+// Handle is deferred in main(), it writes crash report of the panic and continues panicking
+func (r *Reporter) Handle() {
+	v := recover()
+	if v == nil {
+		return
+	}
+
+	if r.Enabled() {
+		path, e := r.Write(v, debug.Stack())
+		if e == nil {
+			fmt.Fprintf(os.Stderr, "Crash report has written: %s\n", path)
+		}
+	}
+
+	panic(v)
+}
+
+// Write writes crash report with version, OS, stack, recent log lines and sanitized config
+func (r *Reporter) Write(v interface{}, stack []byte) (string, error) {
+	dir := r.Dir()
+	e := os.MkdirAll(dir, os.ModePerm)
+	if e != nil {
+		return "", e
+	}
+
+	report := "InsteadMan crash report\n\n" +
+		"App: " + r.App + " " + r.Version + "\n" +
+		"OS: " + runtime.GOOS + "/" + runtime.GOARCH + "\n" +
+		"Time: " + time.Now().Format(time.RFC3339) + "\n" +
+		panicPrefix + firstLine(fmt.Sprint(v)) + "\n\n" +
+		"### Stack\n\n```\n" + string(stack) + "```\n\n" +
+		"### Log\n\n```\n" + r.Log.String() + "```\n\n" +
+		"### Config\n\n```yaml\n" + sanitizedConfig(r.Manager.Config()) + "```\n"
+
+	path := filepath.Join(dir, "crash-"+time.Now().Format("20060102-150405")+reportExt)
+	e = ioutil.WriteFile(path, []byte(sanitize(report)), 0600)
+	if e != nil {
+		return "", e
+	}
+
+	return path, nil
+}
+
+// Pending returns paths of the crash reports which haven't offered for reporting (newest is first)
+func (r *Reporter) Pending() ([]string, error) {
+	paths, e := filepath.Glob(filepath.Join(r.Dir(), "crash-*"+reportExt))
+	if e != nil {
+		return nil, e
+	}
+
+	sort.Sort(sort.Reverse(sort.StringSlice(paths)))
+	return paths, nil
+}
+
+// Dismiss marks the crash report as offered, file is kept in the dir
+func Dismiss(path string) error {
+	return os.Rename(path, path+offeredExt)
+}
+
+// IssueURL returns URL of the new GitHub issue which is pre-filled by the crash report
+func IssueURL(path string) (string, error) {
+	data, e := ioutil.ReadFile(path)
+	if e != nil {
+		return "", e
+	}
+	report := string(data)
+
+	title := "Crash report"
+	for _, line := range strings.Split(report, "\n") {
+		if strings.HasPrefix(line, panicPrefix) {
+			title = "Crash: " + strings.TrimPrefix(line, panicPrefix)
+			break
+		}
+	}
+
+	body := truncate(report, maxIssueBody)
+	if len(body) < len(report) {
+		body += "\n...\n\nFull report: " + filepath.Base(path) + "\n"
+	}
+
+	values := url.Values{}
+	values.Set("title", title)
+	values.Set("body", body)
+
+	return issueURL + "?" + values.Encode(), nil
+}
+
+// sanitizedConfig hides environment variables of the games, they can contain private values
+func sanitizedConfig(config *configurator.InsteadmanConfig) string {
+	if config == nil {
+		return ""
+	}
+
+	c := *config
+	c.Games = make(map[string]configurator.GameConfig, len(config.Games))
+	for name, game := range config.Games {
+		if len(game.Env) > 0 {
+			env := make(map[string]string, len(game.Env))
+			for key := range game.Env {
+				env[key] = hiddenValue
+			}
+			game.Env = env
+		}
+		c.Games[name] = game
+	}
+
+	data, e := yaml.Marshal(c)
+	if e != nil {
+		return ""
+	}
+
+	return string(data)
+}
+
+// sanitize replaces home directory (it contains user name) with "~"
+func sanitize(txt string) string {
+	for _, env := range []string{"HOME", "USERPROFILE"} {
+		home := os.Getenv(env)
+		if len(home) > 1 {
+			txt = strings.Replace(txt, home, "~", -1)
+		}
+	}
+
+	return txt
+}
+
+func firstLine(txt string) string {
+	return strings.SplitN(txt, "\n", 2)[0]
+}
+
+// truncate cuts text to the max bytes without breaking UTF-8 characters
+func truncate(txt string, max int) string {
+	if len(txt) <= max {
+		return txt
+	}
+
+	for max > 0 && !utf8.RuneStart(txt[max]) {
+		max--
+	}
+
+	return txt[:max]
+}
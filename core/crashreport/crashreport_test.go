@@ -0,0 +1,88 @@
+package crashreport
+
+import (
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jhekasoft/insteadman3/core/configurator"
+	"github.com/jhekasoft/insteadman3/core/manager"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReporter(t *testing.T) {
+	dir, e := ioutil.TempDir("", "insteadman")
+	assert.NoError(t, e)
+	defer os.RemoveAll(dir)
+
+	config := &configurator.InsteadmanConfig{
+		CalculatedInsteadManPath: dir,
+		Games: map[string]configurator.GameConfig{
+			"testgame": {Env: map[string]string{"TOKEN": "secret"}},
+		},
+	}
+	r := New("insteadman-test", "3.0.0", manager.New(configurator.NewHolder(config), nil))
+	r.Log.Write([]byte("Installing game...\n"))
+
+	// Disabled reporter doesn't write reports
+	func() {
+		defer func() { assert.Equal(t, "test crash", recover()) }()
+		defer r.Handle()
+		panic("test crash")
+	}()
+	paths, e := r.Pending()
+	assert.NoError(t, e)
+	assert.Empty(t, paths)
+
+	config.CrashReports = true
+	func() {
+		defer func() { recover() }()
+		defer r.Handle()
+		panic("test crash")
+	}()
+	paths, e = r.Pending()
+	assert.NoError(t, e)
+	assert.Len(t, paths, 1)
+
+	data, e := ioutil.ReadFile(paths[0])
+	assert.NoError(t, e)
+	report := string(data)
+	assert.Contains(t, report, "App: insteadman-test 3.0.0")
+	assert.Contains(t, report, "Panic: test crash")
+	assert.Contains(t, report, "Installing game...")
+	assert.Contains(t, report, "TOKEN: '***'")
+	assert.False(t, strings.Contains(report, "secret"))
+
+	issue, e := IssueURL(paths[0])
+	assert.NoError(t, e)
+	u, e := url.Parse(issue)
+	assert.NoError(t, e)
+	assert.Equal(t, "Crash: test crash", u.Query().Get("title"))
+	assert.Contains(t, u.Query().Get("body"), "Panic: test crash")
+
+	assert.NoError(t, Dismiss(paths[0]))
+	paths, e = r.Pending()
+	assert.NoError(t, e)
+	assert.Empty(t, paths)
+
+	// Offered report is kept
+	offered, e := filepath.Glob(filepath.Join(dir, dirName, "*"+offeredExt))
+	assert.NoError(t, e)
+	assert.Len(t, offered, 1)
+}
+
+func TestLogTail(t *testing.T) {
+	tail := NewLogTail(2)
+	tail.Write([]byte("first\nsecond\nthi"))
+	tail.Write([]byte("rd\n"))
+
+	assert.Equal(t, "second\nthird\n", tail.String())
+}
+
+func TestTruncate(t *testing.T) {
+	assert.Equal(t, "abc", truncate("abc", 5))
+	assert.Equal(t, "a", truncate("aщ", 2))
+}
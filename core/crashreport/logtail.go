@@ -0,0 +1,46 @@
+package crashreport
+
+import (
+	"strings"
+	"sync"
+)
+
+// LogTail is a log writer which keeps recent lines
+type LogTail struct {
+	mutex   sync.Mutex
+	lines   []string
+	max     int
+	partial string
+}
+
+func NewLogTail(max int) *LogTail {
+	return &LogTail{max: max}
+}
+
+func (t *LogTail) Write(p []byte) (int, error) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	lines := strings.Split(t.partial+string(p), "\n")
+	t.partial = lines[len(lines)-1]
+
+	t.lines = append(t.lines, lines[:len(lines)-1]...)
+	if len(t.lines) > t.max {
+		t.lines = t.lines[len(t.lines)-t.max:]
+	}
+
+	return len(p), nil
+}
+
+// String returns recent lines, every line ends with "\n"
+func (t *LogTail) String() string {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	txt := ""
+	for _, line := range t.lines {
+		txt += line + "\n"
+	}
+
+	return txt
+}
@@ -0,0 +1,30 @@
+// Package desktopentry helps to write freedesktop.org desktop entries (game shortcuts, file associations)
+package desktopentry
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// DataDir returns XDG data directory of the user
+func DataDir() string {
+	dir := os.Getenv("XDG_DATA_HOME")
+	if dir == "" || !filepath.IsAbs(dir) {
+		dir = filepath.Join(os.Getenv("HOME"), ".local", "share")
+	}
+
+	return dir
+}
+
+// Value escapes string value of the desktop entry
+func Value(value string) string {
+	replacer := strings.NewReplacer(`\`, `\\`, "\n", `\n`, "\t", `\t`, "\r", `\r`)
+	return replacer.Replace(value)
+}
+
+// ExecArg quotes argument of the Exec key
+func ExecArg(arg string) string {
+	replacer := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "`", "\\`", `$`, `\$`, `%`, `%%`)
+	return Value(`"` + replacer.Replace(arg) + `"`)
+}
@@ -0,0 +1,31 @@
+package desktopentry
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDataDir(t *testing.T) {
+	defer os.Setenv("XDG_DATA_HOME", os.Getenv("XDG_DATA_HOME"))
+
+	os.Setenv("XDG_DATA_HOME", "/tmp/data")
+	assert.Equal(t, "/tmp/data", DataDir())
+
+	// Relative path is ignored
+	os.Setenv("XDG_DATA_HOME", "data")
+	assert.Equal(t, filepath.Join(os.Getenv("HOME"), ".local", "share"), DataDir())
+}
+
+func TestValue(t *testing.T) {
+	assert.Equal(t, `Game\nTitle`, Value("Game\nTitle"))
+	assert.Equal(t, `C:\\Games`, Value(`C:\Games`))
+}
+
+func TestExecArg(t *testing.T) {
+	assert.Equal(t, `"/home/user/My \\$Games/insteadman"`, ExecArg("/home/user/My $Games/insteadman"))
+	assert.Equal(t, `"100%%"`, ExecArg("100%"))
+	assert.Equal(t, `"say \\"hi\\""`, ExecArg(`say "hi"`))
+}
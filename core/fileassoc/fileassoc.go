@@ -0,0 +1,41 @@
+// Package fileassoc registers InsteadMan as an application which opens INSTEAD games (.idf files and zip
+// archives) from the file manager and insteadman:// links from the browser. The application gets path
+// of the opened file or the link as an argument.
+package fileassoc
+
+import "errors"
+
+const (
+	// progID is a file type of the INSTEAD games on Windows
+	progID = "InsteadMan.Game"
+	// mimeType is a MIME type of the .idf files on Linux and BSD
+	mimeType = "application/x-instead-idf"
+	idfExt   = ".idf"
+)
+
+// ErrUnsupported is returned when file associations can't be registered on this system
+var ErrUnsupported = errors.New("file associations aren't supported on this system")
+
+// Supported returns true if associations can be registered on this system
+func Supported() bool {
+	return supported
+}
+
+// Register makes the executable default application of the .idf files and insteadman:// links and adds it to
+// the applications which can open zip archives
+func Register(executable string) error {
+	if !supported {
+		return ErrUnsupported
+	}
+
+	return register(executable)
+}
+
+// Unregister removes associations of the Register
+func Unregister() error {
+	if !supported {
+		return ErrUnsupported
+	}
+
+	return unregister()
+}
@@ -0,0 +1,15 @@
+// +build darwin
+
+package fileassoc
+
+// Finder opens files by the Apple Events which aren't handled by the GTK version
+
+const supported = false
+
+func register(executable string) error {
+	return ErrUnsupported
+}
+
+func unregister() error {
+	return ErrUnsupported
+}
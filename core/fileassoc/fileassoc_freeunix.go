@@ -0,0 +1,94 @@
+// +build !windows,!darwin
+
+package fileassoc
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	"github.com/jhekasoft/insteadman3/core/desktopentry"
+)
+
+// MIME type of the .idf files and hidden desktop entry which opens them are added to the XDG data
+// directory of the user. Databases are updated by the shared-mime-info and xdg-utils tools if they are installed.
+
+const (
+	supported       = true
+	desktopFileName = "insteadman-gtk-open.desktop"
+	mimeFileName    = "insteadman-idf.xml"
+	schemeMimeType  = "x-scheme-handler/" + Scheme
+)
+
+const mimeInfo = `<?xml version="1.0" encoding="UTF-8"?>
+<mime-info xmlns="http://www.freedesktop.org/standards/shared-mime-info">
+  <mime-type type="` + mimeType + `">
+    <comment>INSTEAD game</comment>
+    <glob pattern="*` + idfExt + `"/>
+  </mime-type>
+</mime-info>
+`
+
+func register(executable string) error {
+	mimeDir := filepath.Join(desktopentry.DataDir(), "mime")
+	applicationsDir := filepath.Join(desktopentry.DataDir(), "applications")
+
+	e := os.MkdirAll(filepath.Join(mimeDir, "packages"), os.ModePerm)
+	if e != nil {
+		return e
+	}
+	e = ioutil.WriteFile(filepath.Join(mimeDir, "packages", mimeFileName), []byte(mimeInfo), 0644)
+	if e != nil {
+		return e
+	}
+
+	// Entry isn't shown in the menu, InsteadMan menu entry is installed by the package
+	entry := "[Desktop Entry]\n" +
+		"Version=1.0\n" +
+		"Type=Application\n" +
+		"Name=InsteadMan\n" +
+		"Comment=Install INSTEAD game\n" +
+		"Exec=" + desktopentry.ExecArg(executable) + " %u\n" +
+		"Icon=insteadman\n" +
+		"Terminal=false\n" +
+		"NoDisplay=true\n" +
+		"MimeType=" + mimeType + ";application/zip;" + schemeMimeType + ";\n"
+
+	e = os.MkdirAll(applicationsDir, os.ModePerm)
+	if e != nil {
+		return e
+	}
+	e = ioutil.WriteFile(filepath.Join(applicationsDir, desktopFileName), []byte(entry), 0644)
+	if e != nil {
+		return e
+	}
+
+	updateDatabases(mimeDir, applicationsDir)
+	exec.Command("xdg-mime", "default", desktopFileName, mimeType, schemeMimeType).Run()
+
+	return nil
+}
+
+func unregister() error {
+	mimeDir := filepath.Join(desktopentry.DataDir(), "mime")
+	applicationsDir := filepath.Join(desktopentry.DataDir(), "applications")
+
+	for _, path := range []string{
+		filepath.Join(mimeDir, "packages", mimeFileName),
+		filepath.Join(applicationsDir, desktopFileName),
+	} {
+		if e := os.Remove(path); e != nil && !os.IsNotExist(e) {
+			return e
+		}
+	}
+
+	updateDatabases(mimeDir, applicationsDir)
+
+	return nil
+}
+
+func updateDatabases(mimeDir, applicationsDir string) {
+	exec.Command("update-mime-database", mimeDir).Run()
+	exec.Command("update-desktop-database", applicationsDir).Run()
+}
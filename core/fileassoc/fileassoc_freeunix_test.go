@@ -0,0 +1,41 @@
+// +build !windows,!darwin
+
+package fileassoc
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jhekasoft/insteadman3/core/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegister(t *testing.T) {
+	dir, e := ioutil.TempDir("", "insteadman")
+	assert.NoError(t, e)
+	defer os.RemoveAll(dir)
+
+	// xdg-mime changes mimeapps.list of the config directory
+	for _, name := range []string{"XDG_DATA_HOME", "XDG_CONFIG_HOME"} {
+		defer os.Setenv(name, os.Getenv(name))
+		os.Setenv(name, dir)
+	}
+
+	assert.True(t, Supported())
+	assert.NoError(t, Register("/opt/insteadman/insteadman-gtk"))
+
+	entry, e := ioutil.ReadFile(filepath.Join(dir, "applications", desktopFileName))
+	assert.NoError(t, e)
+	assert.Contains(t, string(entry), "Exec=\"/opt/insteadman/insteadman-gtk\" %u\n")
+	assert.Contains(t, string(entry), "MimeType=application/x-instead-idf;application/zip;x-scheme-handler/insteadman;\n")
+	assert.True(t, utils.PathExist(filepath.Join(dir, "mime", "packages", mimeFileName)))
+
+	assert.NoError(t, Unregister())
+	assert.False(t, utils.PathExist(filepath.Join(dir, "applications", desktopFileName)))
+	assert.False(t, utils.PathExist(filepath.Join(dir, "mime", "packages", mimeFileName)))
+
+	// Second removing doesn't fail
+	assert.NoError(t, Unregister())
+}
@@ -0,0 +1,36 @@
+package fileassoc
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseLink(t *testing.T) {
+	l, e := ParseLink("insteadman://install/cat-lady")
+	assert.NoError(t, e)
+	assert.Equal(t, &Link{Action: ActionInstall, Game: "cat-lady"}, l)
+
+	l, e = ParseLink("InsteadMan://RUN/cat-lady/?from=forum")
+	assert.NoError(t, e)
+	assert.Equal(t, &Link{Action: ActionRun, Game: "cat-lady"}, l)
+
+	l, e = ParseLink("insteadman:install/instead_tutorial3")
+	assert.NoError(t, e)
+	assert.Equal(t, &Link{Action: ActionInstall, Game: "instead_tutorial3"}, l)
+
+	for _, link := range []string{
+		"insteadman://remove/cat-lady",
+		"insteadman://install/",
+		"insteadman://install/../config",
+		"insteadman://install/cat-lady/extra",
+		"insteadman://run/%2E%2E",
+		"http://install/cat-lady",
+	} {
+		_, e = ParseLink(link)
+		assert.Equal(t, ErrInvalidLink, e, link)
+	}
+
+	assert.True(t, IsLink("insteadman://run/cat-lady"))
+	assert.False(t, IsLink("/home/user/cat-lady.zip"))
+}
@@ -0,0 +1,112 @@
+// +build windows
+
+package fileassoc
+
+import (
+	"syscall"
+
+	"golang.org/x/sys/windows/registry"
+)
+
+// File type of the games is registered in the classes of the current user, so it doesn't require
+// administrator rights. Setup registers the same file type for all users.
+
+const (
+	supported  = true
+	classesKey = `Software\Classes\`
+	zipKey     = `.zip\OpenWithProgids`
+
+	shcneAssocChanged = 0x08000000
+)
+
+type registryValue struct {
+	key, name, value string
+}
+
+func register(executable string) error {
+	values := []registryValue{
+		{progID, "", "INSTEAD game"},
+		{progID + `\DefaultIcon`, "", executable + ",0"},
+		{progID + `\shell\open\command`, "", `"` + executable + `" "%1"`},
+		{idfExt, "", progID},
+		{zipKey, progID, ""},
+		{Scheme, "", "URL:InsteadMan"},
+		{Scheme, "URL Protocol", ""},
+		{Scheme + `\DefaultIcon`, "", executable + ",0"},
+		{Scheme + `\shell\open\command`, "", `"` + executable + `" "%1"`},
+	}
+
+	for _, v := range values {
+		key, _, e := registry.CreateKey(registry.CURRENT_USER, classesKey+v.key, registry.SET_VALUE)
+		if e != nil {
+			return e
+		}
+
+		e = key.SetStringValue(v.name, v.value)
+		key.Close()
+		if e != nil {
+			return e
+		}
+	}
+
+	notifyAssocChanged()
+
+	return nil
+}
+
+func unregister() error {
+	for _, key := range []string{progID, Scheme} {
+		e := deleteKey(key)
+		if e != nil {
+			return e
+		}
+	}
+
+	// .idf is left to another application if it has been changed
+	deleteValue(idfExt, "", progID)
+	deleteValue(zipKey, progID, "")
+
+	notifyAssocChanged()
+
+	return nil
+}
+
+// deleteKey deletes the key of the registered type, subkeys are removed before their parents
+func deleteKey(key string) error {
+	for _, path := range []string{
+		key + `\shell\open\command`,
+		key + `\shell\open`,
+		key + `\shell`,
+		key + `\DefaultIcon`,
+		key,
+	} {
+		e := registry.DeleteKey(registry.CURRENT_USER, classesKey+path)
+		if e != nil && e != registry.ErrNotExist {
+			return e
+		}
+	}
+
+	return nil
+}
+
+// deleteValue deletes the value if it's equal to the expected one
+func deleteValue(path, name, expected string) {
+	key, e := registry.OpenKey(registry.CURRENT_USER, classesKey+path, registry.QUERY_VALUE|registry.SET_VALUE)
+	if e != nil {
+		return
+	}
+	defer key.Close()
+
+	value, _, e := key.GetStringValue(name)
+	if e == nil && value == expected {
+		key.DeleteValue(name)
+	}
+}
+
+// notifyAssocChanged makes Explorer reload file types
+func notifyAssocChanged() {
+	proc := syscall.NewLazyDLL("shell32.dll").NewProc("SHChangeNotify")
+	if proc.Find() == nil {
+		proc.Call(shcneAssocChanged, 0, 0, 0)
+	}
+}
@@ -0,0 +1,64 @@
+package fileassoc
+
+import (
+	"errors"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// Scheme is a scheme of the links to the games ("insteadman://install/cat-lady")
+const Scheme = "insteadman"
+
+// Link actions
+const (
+	ActionInstall = "install"
+	ActionRun     = "run"
+)
+
+// ErrInvalidLink is returned when the link hasn't known action or game name
+var ErrInvalidLink = errors.New("invalid insteadman:// link")
+
+var gameNameRegexp = regexp.MustCompile(`^[A-Za-z0-9_.\-]+$`)
+
+// Link is an action with the game which is opened from the website
+type Link struct {
+	Action string
+	Game   string // game name
+}
+
+// IsLink returns true if the argument is a link of the Scheme
+func IsLink(arg string) bool {
+	return strings.HasPrefix(strings.ToLower(arg), Scheme+":")
+}
+
+// ParseLink parses "insteadman://<action>/<game name>" link, query and trailing slash are ignored
+func ParseLink(link string) (*Link, error) {
+	u, e := url.Parse(link)
+	if e != nil || !strings.EqualFold(u.Scheme, Scheme) {
+		return nil, ErrInvalidLink
+	}
+
+	// "insteadman://install/game" has action as the host, "insteadman:install/game" is opaque
+	path := u.Opaque
+	if path == "" {
+		path = u.Host + u.Path
+	}
+
+	parts := strings.Split(strings.Trim(path, "/"), "/")
+	if len(parts) != 2 {
+		return nil, ErrInvalidLink
+	}
+
+	action := strings.ToLower(parts[0])
+	if action != ActionInstall && action != ActionRun {
+		return nil, ErrInvalidLink
+	}
+
+	name, e := url.PathUnescape(parts[1])
+	if e != nil || !gameNameRegexp.MatchString(name) || strings.Trim(name, ".") == "" {
+		return nil, ErrInvalidLink
+	}
+
+	return &Link{Action: action, Game: name}, nil
+}
@@ -0,0 +1,101 @@
+package gamedev
+
+import (
+	"encoding/xml"
+	"errors"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/jhekasoft/insteadman3/core/manager"
+)
+
+// FeedVersion is a version of the repository feed format
+const FeedVersion = "1.0"
+
+// Feed is the XML list of the repository games which is read by InsteadMan ("<game_list>")
+type Feed struct {
+	XMLName xml.Name                 `xml:"game_list"`
+	Version string                   `xml:"version,attr,omitempty"`
+	Games   []manager.RepositoryGame `xml:"game"`
+}
+
+// ParseFeed parses XML of the repository
+func ParseFeed(data []byte) (*Feed, error) {
+	feed := &Feed{}
+	e := xml.Unmarshal(data, feed)
+	if e != nil {
+		return nil, e
+	}
+
+	return feed, nil
+}
+
+// ReadFeed reads the feed from the file
+func ReadFeed(fileName string) (*Feed, error) {
+	data, e := ioutil.ReadFile(fileName)
+	if e != nil {
+		return nil, e
+	}
+
+	return ParseFeed(data)
+}
+
+// DownloadFeed downloads the repository feed, empty feed is returned if it doesn't exist yet (new repository)
+func DownloadFeed(url string) (*Feed, error) {
+	resp, e := http.Get(url)
+	if e != nil {
+		return nil, e
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return &Feed{Version: FeedVersion}, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.New(url + ": " + resp.Status)
+	}
+
+	data, e := ioutil.ReadAll(resp.Body)
+	if e != nil {
+		return nil, e
+	}
+
+	return ParseFeed(data)
+}
+
+// SetGame replaces the game with the same name or adds the new one
+func (f *Feed) SetGame(game manager.RepositoryGame) {
+	for i := range f.Games {
+		if f.Games[i].Name == game.Name {
+			f.Games[i] = game
+			return
+		}
+	}
+
+	f.Games = append(f.Games, game)
+}
+
+// FindGame returns the game by the name (nil if it isn't in the feed)
+func (f *Feed) FindGame(name string) *manager.RepositoryGame {
+	for i := range f.Games {
+		if f.Games[i].Name == name {
+			return &f.Games[i]
+		}
+	}
+
+	return nil
+}
+
+// Marshal returns XML of the feed
+func (f *Feed) Marshal() ([]byte, error) {
+	if f.Version == "" {
+		f.Version = FeedVersion
+	}
+
+	data, e := xml.MarshalIndent(f, "", "    ")
+	if e != nil {
+		return nil, e
+	}
+
+	return append([]byte(xml.Header), append(data, '\n')...), nil
+}
@@ -0,0 +1,155 @@
+// Package gamedev has tools for the game authors: reading of the game metadata, packaging of the game
+// directory to the archives for the repositories etc.
+package gamedev
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+var (
+	ErrNoMainFile = errors.New("there isn't main.lua or main3.lua in the game directory")
+	ErrNoTitle    = errors.New("there isn't \"-- $Name: ...$\" in the main file")
+	ErrNoVersion  = errors.New("there isn't \"-- $Version: ...$\" in the main file")
+)
+
+// mainFiles are STEAD2 and STEAD3 main files of the game
+var mainFiles = []string{"main.lua", "main3.lua"}
+
+var metadataRegexp = regexp.MustCompile(`(?im)^\s*--\s*\$(\w+):\s*(.*?)\s*\$`)
+
+// ignoredNames are VCS, IDE and OS files which aren't packaged
+var ignoredNames = []string{
+	".git", ".gitignore", ".gitattributes", ".gitmodules", ".gitkeep", ".hg", ".hgignore", ".svn", ".bzr", "CVS",
+	".idea", ".vscode", ".DS_Store", "__MACOSX", "Thumbs.db", "desktop.ini",
+}
+
+// ignoredPatterns are backup and temp files of the editors
+var ignoredPatterns = []string{"*~", "*.swp", "*.swo", "*.bak", "*.tmp", "#*#", ".#*", "*.test"}
+
+// Metadata is the game info from the "-- $Name: ...$" comments of the main file
+type Metadata struct {
+	Name     string // game directory name
+	MainFile string // main.lua or main3.lua
+	Title    string
+	Version  string
+	Author   string
+	Info     string
+}
+
+// Stead3 checks that game is STEAD3 game (main3.lua)
+func (md *Metadata) Stead3() bool {
+	return md.MainFile == "main3.lua"
+}
+
+// ReadMetadata reads metadata of the game from its main file
+func ReadMetadata(dir string) (*Metadata, error) {
+	dir, e := filepath.Abs(dir)
+	if e != nil {
+		return nil, e
+	}
+
+	md := &Metadata{Name: filepath.Base(dir)}
+	var data []byte
+	for _, mainFile := range mainFiles {
+		data, e = ioutil.ReadFile(filepath.Join(dir, mainFile))
+		if e == nil {
+			md.MainFile = mainFile
+			break
+		}
+	}
+	if md.MainFile == "" {
+		return nil, ErrNoMainFile
+	}
+	md.parse(data)
+
+	return md, nil
+}
+
+// parse sets metadata from the content of the main file
+func (md *Metadata) parse(data []byte) {
+	for _, matches := range metadataRegexp.FindAllStringSubmatch(string(data), -1) {
+		switch strings.ToLower(matches[1]) {
+		case "name":
+			md.Title = matches[2]
+		case "version":
+			md.Version = matches[2]
+		case "author":
+			md.Author = matches[2]
+		case "info":
+			md.Info = matches[2]
+		}
+	}
+}
+
+// Validate checks metadata which is required for the repositories
+func (md *Metadata) Validate() error {
+	if md.Title == "" {
+		return ErrNoTitle
+	}
+	if md.Version == "" {
+		return ErrNoVersion
+	}
+
+	return nil
+}
+
+// IsIgnored checks that the file (or directory) isn't a part of the game (VCS, IDE files etc.)
+func IsIgnored(name string) bool {
+	for _, ignoredName := range ignoredNames {
+		if strings.EqualFold(name, ignoredName) {
+			return true
+		}
+	}
+
+	for _, pattern := range ignoredPatterns {
+		if matched, _ := filepath.Match(pattern, name); matched {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Files returns relative paths (with slashes) of the game files, ignored files are skipped
+func Files(dir string) ([]string, error) {
+	var files []string
+	e := filepath.Walk(dir, func(path string, info os.FileInfo, e error) error {
+		if e != nil {
+			return e
+		}
+		if path == dir {
+			return nil
+		}
+
+		if IsIgnored(info.Name()) {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if info.IsDir() {
+			return nil
+		}
+
+		relPath, e := filepath.Rel(dir, path)
+		if e != nil {
+			return e
+		}
+		files = append(files, filepath.ToSlash(relPath))
+
+		return nil
+	})
+	if e != nil {
+		return nil, e
+	}
+
+	sort.Strings(files)
+
+	return files, nil
+}
@@ -0,0 +1,566 @@
+package gamedev
+
+import (
+	"archive/zip"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jhekasoft/insteadman3/core/configurator"
+	"github.com/stretchr/testify/assert"
+)
+
+func writeTestGame(t *testing.T, dir string, files map[string]string) {
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		assert.NoError(t, os.MkdirAll(filepath.Dir(path), os.ModePerm))
+		assert.NoError(t, ioutil.WriteFile(path, []byte(content), 0644))
+	}
+}
+
+func TestReadMetadata(t *testing.T) {
+	dir, e := ioutil.TempDir("", "insteadman")
+	assert.NoError(t, e)
+	defer os.RemoveAll(dir)
+
+	gameDir := filepath.Join(dir, "mygame")
+	_, e = ReadMetadata(gameDir)
+	assert.Equal(t, ErrNoMainFile, e)
+
+	writeTestGame(t, gameDir, map[string]string{
+		"main3.lua": "-- $Name: My game$\n-- $Version: 0.2 $\n--$Author: Author$\nrequire 'fmt'\n",
+	})
+
+	md, e := ReadMetadata(gameDir)
+	assert.NoError(t, e)
+	assert.Equal(t, &Metadata{Name: "mygame", MainFile: "main3.lua", Title: "My game", Version: "0.2",
+		Author: "Author"}, md)
+	assert.True(t, md.Stead3())
+	assert.NoError(t, md.Validate())
+
+	md.Version = ""
+	assert.Equal(t, ErrNoVersion, md.Validate())
+}
+
+func TestFiles(t *testing.T) {
+	dir, e := ioutil.TempDir("", "insteadman")
+	assert.NoError(t, e)
+	defer os.RemoveAll(dir)
+
+	writeTestGame(t, dir, map[string]string{
+		"main3.lua":       "",
+		"gfx/bg.png":      "png",
+		"gfx/.DS_Store":   "",
+		".git/HEAD":       "ref",
+		"main3.lua~":      "",
+		".vscode/a.json":  "{}",
+		"snd/Thumbs.db":   "",
+		"snd/music.ogg":   "ogg",
+		".gitignore":      "*.zip",
+		"locale/ru/a.lua": "",
+	})
+
+	files, e := Files(dir)
+	assert.NoError(t, e)
+	assert.Equal(t, []string{"gfx/bg.png", "locale/ru/a.lua", "main3.lua", "snd/music.ogg"}, files)
+}
+
+func TestPack(t *testing.T) {
+	dir, e := ioutil.TempDir("", "insteadman")
+	assert.NoError(t, e)
+	defer os.RemoveAll(dir)
+
+	gameDir := filepath.Join(dir, "mygame")
+	writeTestGame(t, gameDir, map[string]string{"main3.lua": "-- $Name: My game$\n"})
+
+	// Metadata is required
+	_, _, e = Pack(gameDir, PackOptions{OutputDir: gameDir})
+	assert.Equal(t, ErrNoVersion, e)
+
+	writeTestGame(t, gameDir, map[string]string{
+		"main3.lua": "-- $Name: My game$\n-- $Version: 1.0$\n",
+		".git/HEAD": "ref",
+	})
+
+	// Archive of the previous packaging isn't packed again
+	for i := 0; i < 2; i++ {
+		md, archives, e := Pack(gameDir, PackOptions{OutputDir: gameDir})
+		assert.NoError(t, e)
+		assert.Equal(t, "My game", md.Title)
+		assert.Len(t, archives, 1)
+		assert.Equal(t, filepath.Join(gameDir, "mygame-1.0.zip"), archives[0].Path)
+		assert.Len(t, archives[0].SHA256, 64)
+
+		info, e := os.Stat(archives[0].Path)
+		assert.NoError(t, e)
+		assert.Equal(t, info.Size(), archives[0].Size)
+
+		r, e := zip.OpenReader(archives[0].Path)
+		assert.NoError(t, e)
+		assert.Len(t, r.File, 1)
+		assert.Equal(t, "mygame/main3.lua", r.File[0].Name)
+		r.Close()
+	}
+
+	_, _, e = Pack(gameDir, PackOptions{Formats: []string{FormatIdf}, OutputDir: dir})
+	assert.Equal(t, ErrIdfInterpreter, e)
+
+	if runtime.GOOS == "windows" {
+		return
+	}
+
+	// INSTEAD builds .idf in the current directory
+	interpreterPath := filepath.Join(dir, "instead")
+	script := "#!/bin/sh\n[ \"$1\" = \"-idf\" ] && ls \"$2\" > \"$(basename \"$2\").idf\"\n"
+	assert.NoError(t, ioutil.WriteFile(interpreterPath, []byte(script), 0755))
+
+	_, archives, e := Pack(gameDir, PackOptions{Formats: []string{FormatIdf}, OutputDir: dir,
+		Interpreter: interpreterPath})
+	assert.NoError(t, e)
+	assert.Len(t, archives, 1)
+	data, e := ioutil.ReadFile(filepath.Join(dir, "mygame-1.0.idf"))
+	assert.NoError(t, e)
+	assert.Equal(t, "main3.lua\n", string(data))
+}
+
+func TestValidate(t *testing.T) {
+	dir, e := ioutil.TempDir("", "insteadman")
+	assert.NoError(t, e)
+	defer os.RemoveAll(dir)
+
+	gameDir := filepath.Join(dir, "My Game")
+	writeTestGame(t, gameDir, map[string]string{
+		"main3.lua": "-- $Name: My game$\n-- $Name(russian): Моя игра$\n-- $Version: beta$\n" +
+			"-- $Author: Author$\n-- $Info: Info$\n" +
+			"pic = 'gfx/bg.png'\n" +
+			"snd.music('snd/Music.ogg')\n" +
+			"img = \"/home/author/mygame/gfx/hero.png\"\n" +
+			"font = 'fonts/missing.ttf'\n",
+		"gfx/bg.png":    "png",
+		"snd/music.ogg": "ogg",
+	})
+
+	problems, e := Validate(gameDir)
+	assert.NoError(t, e)
+	var txts []string
+	for _, problem := range problems {
+		txts = append(txts, problem.String())
+	}
+	assert.Equal(t, []string{
+		"warning: directory name \"My Game\" is the game name in the repositories, lowercase latin letters, " +
+			"digits, \"-\" and \"_\" are recommended",
+		"main3.lua: warning: version \"beta\" isn't like \"1.0\", updates can't be found by it",
+		"main3.lua:2: warning: language \"russian\" of $Name should be two-letter code like \"ru\"",
+		"main3.lua:7: error: file snd/Music.ogg has found as snd/music.ogg, case of the path matters on Linux",
+		"main3.lua:8: error: absolute path /home/author/mygame/gfx/hero.png can't be found on other computers, " +
+			"use path relative to the game directory",
+		"main3.lua:9: error: file fonts/missing.ttf hasn't found",
+	}, txts)
+
+	// Archive layout is checked
+	zipPath := filepath.Join(dir, "mygame.zip")
+	f, e := os.Create(zipPath)
+	assert.NoError(t, e)
+	w := zip.NewWriter(f)
+	for _, name := range []string{"main3.lua", "mygame/main3.lua", "mygame/.DS_Store"} {
+		fw, e := w.Create(name)
+		assert.NoError(t, e)
+		fw.Write([]byte("-- $Name: My game$\n-- $Version: 1.0$\n-- $Author: Author$\n-- $Info: Info$\n"))
+	}
+	assert.NoError(t, w.Close())
+	assert.NoError(t, f.Close())
+
+	problems, e = Validate(zipPath)
+	assert.NoError(t, e)
+	assert.Len(t, problems, 3)
+	assert.Equal(t, SeverityError, problems[0].Severity)
+	assert.Contains(t, problems[0].Message, "root of the archive")
+	assert.Contains(t, problems[1].Message, ".DS_Store")
+	assert.Contains(t, problems[2].Message, "other files")
+}
+
+func TestWatch(t *testing.T) {
+	dir, e := ioutil.TempDir("", "insteadman")
+	assert.NoError(t, e)
+	defer os.RemoveAll(dir)
+
+	writeTestGame(t, dir, map[string]string{"main3.lua": "-- $Name: My game$\n", "gfx/bg.png": "png"})
+
+	changes := make(chan []string, 10)
+	stop := make(chan struct{})
+	done := make(chan error)
+	go func() {
+		done <- Watch(dir, 10*time.Millisecond, stop, func(files []string) {
+			changes <- files
+		})
+	}()
+
+	time.Sleep(30 * time.Millisecond)
+	writeTestGame(t, dir, map[string]string{"main3.lua": "-- $Name: My game 2$\n", "room.lua": "", ".git/HEAD": ""})
+	assert.NoError(t, os.Remove(filepath.Join(dir, "gfx", "bg.png")))
+
+	// Changes can be found by several checks
+	changed := make(map[string]bool)
+	timeout := time.After(5 * time.Second)
+	for len(changed) < 3 {
+		select {
+		case files := <-changes:
+			for _, file := range files {
+				changed[file] = true
+			}
+		case <-timeout:
+			t.Fatal("changes haven't found")
+		}
+	}
+	assert.Equal(t, map[string]bool{"gfx/bg.png": true, "main3.lua": true, "room.lua": true}, changed)
+
+	close(stop)
+	assert.NoError(t, <-done)
+}
+
+func TestPublish(t *testing.T) {
+	dir, e := ioutil.TempDir("", "insteadman")
+	assert.NoError(t, e)
+	defer os.RemoveAll(dir)
+
+	// WebDAV directory which is served as the repository
+	var mutex sync.Mutex
+	files := map[string][]byte{"games.xml": []byte("<game_list><game><name>other</name><title>Other</title>" +
+		"</game><game><name>mygame</name><version>0.1</version><image>http://host/mygame.png</image></game></game_list>")}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mutex.Lock()
+		defer mutex.Unlock()
+
+		name := strings.TrimPrefix(r.URL.Path, "/repo/")
+		switch r.Method {
+		case http.MethodPut:
+			if user, password, _ := r.BasicAuth(); user != "author" || password != "secret" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			files[name], _ = ioutil.ReadAll(r.Body)
+			w.WriteHeader(http.StatusCreated)
+		case http.MethodGet:
+			if data, ok := files[name]; ok {
+				w.Write(data)
+				return
+			}
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer ts.Close()
+
+	gameDir := filepath.Join(dir, "mygame")
+	writeTestGame(t, gameDir, map[string]string{
+		"main3.lua": "-- $Name: My game$\n-- $Version: 0.2$\n-- $Author: Author$\n-- $Info: About$\n",
+	})
+
+	repository := configurator.Repository{Name: "my", Url: ts.URL + "/repo/games.xml"}
+	_, e = Publish(gameDir, repository, PublishOptions{})
+	assert.Equal(t, ErrNoPublish, e)
+
+	repository.Publish = &configurator.Publish{Method: PublishWebdav, Url: ts.URL + "/repo", Username: "author"}
+	_, e = Publish(gameDir, repository, PublishOptions{})
+	assert.Error(t, e)
+
+	os.Setenv(PublishPasswordEnv, "secret")
+	defer os.Unsetenv(PublishPasswordEnv)
+
+	game, e := Publish(gameDir, repository, PublishOptions{Langs: []string{"en", "ru"}})
+	assert.NoError(t, e)
+	assert.Equal(t, ts.URL+"/repo/mygame-0.2.zip", game.Url)
+	assert.NotEmpty(t, files["mygame-0.2.zip"])
+	assert.Equal(t, len(files["mygame-0.2.zip"]), game.Size)
+
+	feed, e := ParseFeed(files["games.xml"])
+	assert.NoError(t, e)
+	assert.Equal(t, FeedVersion, feed.Version)
+	assert.Len(t, feed.Games, 2)
+	assert.Equal(t, "Other", feed.Games[0].Title)
+	assert.Equal(t, *game, feed.Games[1])
+	assert.Equal(t, "My game", game.Title)
+	assert.Equal(t, "0.2", game.Version)
+	assert.Equal(t, "Author", game.Author)
+	assert.Equal(t, "About", game.Description)
+	assert.Equal(t, "en,ru", game.Lang)
+	assert.Equal(t, "http://host/mygame.png", game.Image)
+
+	// New repository
+	delete(files, "games.xml")
+	game, e = Publish(gameDir, repository, PublishOptions{})
+	assert.NoError(t, e)
+	feed, e = ParseFeed(files["games.xml"])
+	assert.NoError(t, e)
+	assert.Equal(t, []string{"mygame"}, []string{feed.Games[0].Name})
+	assert.Equal(t, "", game.Lang)
+}
+
+func TestGenerateSite(t *testing.T) {
+	dir, e := ioutil.TempDir("", "insteadman")
+	assert.NoError(t, e)
+	defer os.RemoveAll(dir)
+
+	feed, e := ParseFeed([]byte("<game_list><game><name>second</name><title>Second &amp; last</title><version>1.0</version>" +
+		"<url>http://host/second.zip</url><size>2048</size><langs><lang>ru</lang><lang>en</lang></langs>" +
+		"<image>http://host/second.png</image><description>&lt;b&gt;About&lt;/b&gt;</description></game>" +
+		"<game><name>first</name><title>First</title><version>0.1</version><url>http://host/first.zip</url>" +
+		"<lang>en</lang></game></game_list>"))
+	assert.NoError(t, e)
+
+	assert.NoError(t, GenerateSite(feed, dir, SiteOptions{Title: "My games"}))
+
+	for _, name := range []string{"index.html", "lang-en.html", "lang-ru.html", "games/first.html",
+		"games/second.html", SiteFeedXml, SiteFeedJson} {
+		assert.FileExists(t, filepath.Join(dir, filepath.FromSlash(name)))
+	}
+
+	index, e := ioutil.ReadFile(filepath.Join(dir, "index.html"))
+	assert.NoError(t, e)
+	assert.Contains(t, string(index), "<h1>My games</h1>")
+	assert.True(t, strings.Index(string(index), "games/first.html") < strings.Index(string(index), "games/second.html"))
+
+	ru, e := ioutil.ReadFile(filepath.Join(dir, "lang-ru.html"))
+	assert.NoError(t, e)
+	assert.Contains(t, string(ru), "Second &amp; last")
+	assert.NotContains(t, string(ru), "games/first.html")
+
+	page, e := ioutil.ReadFile(filepath.Join(dir, "games", "second.html"))
+	assert.NoError(t, e)
+	assert.Contains(t, string(page), `src="http://host/second.png"`)
+	assert.Contains(t, string(page), `href="http://host/second.zip"`)
+	assert.Contains(t, string(page), "&lt;b&gt;About&lt;/b&gt;")
+	assert.Contains(t, string(page), `href="../lang-ru.html"`)
+
+	xmlFeed, e := ReadFeed(filepath.Join(dir, SiteFeedXml))
+	assert.NoError(t, e)
+	assert.Len(t, xmlFeed.Games, 2)
+
+	jsonData, e := ioutil.ReadFile(filepath.Join(dir, SiteFeedJson))
+	assert.NoError(t, e)
+	assert.Contains(t, string(jsonData), `"langs": [
+        "ru",
+        "en"
+      ]`)
+}
+
+func TestCreateProject(t *testing.T) {
+	dir, e := ioutil.TempDir("", "insteadman")
+	assert.NoError(t, e)
+	defer os.RemoveAll(dir)
+
+	gameDir := filepath.Join(dir, "mygame")
+	assert.NoError(t, CreateProject(gameDir, ProjectOptions{Author: "Me", Langs: []string{"ru", "en"}}))
+	assert.Equal(t, ErrProjectExists, CreateProject(gameDir, ProjectOptions{}))
+
+	md, e := ReadMetadata(gameDir)
+	assert.NoError(t, e)
+	assert.Equal(t, &Metadata{Name: "mygame", MainFile: "main3.lua", Title: "mygame", Version: "0.1", Author: "Me",
+		Info: "Описание игры"}, md)
+
+	main, e := ioutil.ReadFile(filepath.Join(gameDir, "main3.lua"))
+	assert.NoError(t, e)
+	assert.Contains(t, string(main), "-- $Name(en): mygame$")
+	assert.Contains(t, string(main), "Это начало вашей игры.")
+
+	problems, e := Validate(gameDir)
+	assert.NoError(t, e)
+	assert.Empty(t, problems)
+
+	files, e := Files(gameDir)
+	assert.NoError(t, e)
+	assert.Equal(t, []string{"main3.lua"}, files)
+	assert.FileExists(t, filepath.Join(gameDir, "gfx", ".gitkeep"))
+	assert.FileExists(t, filepath.Join(gameDir, ".gitignore"))
+
+	stead2Dir := filepath.Join(dir, "oldgame")
+	assert.NoError(t, CreateProject(stead2Dir, ProjectOptions{Title: "Old game", Stead2: true}))
+	md, e = ReadMetadata(stead2Dir)
+	assert.NoError(t, e)
+	assert.Equal(t, "main.lua", md.MainFile)
+	assert.Equal(t, "Old game", md.Title)
+	assert.Equal(t, "Author", md.Author)
+}
+
+func TestRunTest(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("shell script interpreter")
+	}
+
+	dir, e := ioutil.TempDir("", "insteadman")
+	assert.NoError(t, e)
+	defer os.RemoveAll(dir)
+
+	// Headless interpreter prints the room after the command
+	interpreterPath := filepath.Join(dir, "instead-cli")
+	script := "#!/bin/sh\n[ -f \"$1/main3.lua\" ] || exit 2\necho Kitchen\n" +
+		"while read cmd; do case $cmd in north) echo Hall;; crash) echo 'main3.lua:7: attempt to call a nil value';; " +
+		"wait) sleep 10;; *) echo \"Unknown: $cmd\";; esac; done\n"
+	assert.NoError(t, ioutil.WriteFile(interpreterPath, []byte(script), 0755))
+
+	gameDir := filepath.Join(dir, "mygame")
+	writeTestGame(t, gameDir, map[string]string{
+		"main3.lua":          "-- $Name: My game$\n",
+		"tests/pass.test":    "# walkthrough\n? Kitchen\n> north\n? Hall\n! Unknown\n",
+		"tests/fail.test":    "> north\n? Hall\n? Kitchen\n> crash\n> jump\n! Unknown\n",
+		"tests/timeout.test": "> wait\n",
+	})
+
+	scripts, e := TestScripts(gameDir)
+	assert.NoError(t, e)
+	assert.Equal(t, []string{filepath.Join(gameDir, "tests", "fail.test"), filepath.Join(gameDir, "tests", "pass.test"),
+		filepath.Join(gameDir, "tests", "timeout.test")}, scripts)
+
+	_, e = TestScripts(dir)
+	assert.Equal(t, ErrNoTests, e)
+
+	options := TestOptions{Interpreter: interpreterPath, Timeout: time.Second}
+
+	result, e := RunTest(gameDir, scripts[1], options)
+	assert.NoError(t, e)
+	assert.True(t, result.Passed(), result.Failures)
+	assert.Equal(t, "Kitchen\nHall\n", result.Output)
+
+	result, e = RunTest(gameDir, scripts[0], options)
+	assert.NoError(t, e)
+	assert.False(t, result.Passed())
+	assert.Equal(t, []TestFailure{
+		{Message: "main3.lua:7: attempt to call a nil value"},
+		{Line: 3, Message: "\"Kitchen\" hasn't found"},
+		{Line: 6, Message: "\"Unknown\" has found"},
+	}, result.Failures)
+
+	result, e = RunTest(gameDir, scripts[2], options)
+	assert.NoError(t, e)
+	assert.Equal(t, []TestFailure{{Message: "timeout 1s"}}, result.Failures)
+
+	files, e := Files(gameDir)
+	assert.NoError(t, e)
+	assert.Equal(t, []string{"main3.lua"}, files)
+
+	_, e = ParseTestScript(strings.NewReader("> look\nlook\n"))
+	assert.EqualError(t, e, "line 2: it should start with \">\", \"?\" or \"!\"")
+}
+
+func TestRepositoryServer(t *testing.T) {
+	dir, e := ioutil.TempDir("", "insteadman")
+	assert.NoError(t, e)
+	defer os.RemoveAll(dir)
+
+	gamesDir := filepath.Join(dir, "games")
+	writeTestGame(t, filepath.Join(gamesDir, "first"), map[string]string{
+		"main3.lua": "-- $Name: First$\n-- $Version: 0.1$\n-- $Info: About$\n",
+	})
+	writeTestGame(t, filepath.Join(gamesDir, "broken"), map[string]string{"main3.lua": "-- $Name: Broken$\n"})
+	writeTestGame(t, filepath.Join(dir, "second"), map[string]string{"main.lua": "-- $Name: Second$\n-- $Version: 1.0$\n"})
+	_, _, e = Pack(filepath.Join(dir, "second"), PackOptions{OutputDir: gamesDir})
+	assert.NoError(t, e)
+
+	s := NewRepositoryServer(gamesDir)
+	var errorGames []string
+	s.OnError = func(name string, e error) {
+		errorGames = append(errorGames, name)
+	}
+	defer s.Close()
+
+	ts := httptest.NewServer(s)
+	defer ts.Close()
+
+	getFeed := func() *Feed {
+		resp, e := http.Get(ts.URL + "/games.xml")
+		assert.NoError(t, e)
+		defer resp.Body.Close()
+		data, e := ioutil.ReadAll(resp.Body)
+		assert.NoError(t, e)
+		feed, e := ParseFeed(data)
+		assert.NoError(t, e)
+		return feed
+	}
+
+	feed := getFeed()
+	assert.Equal(t, []string{"broken"}, errorGames)
+	assert.Len(t, feed.Games, 2)
+	assert.Equal(t, "First", feed.Games[0].Title)
+	assert.Equal(t, "About", feed.Games[0].Description)
+	assert.Equal(t, ts.URL+"/files/first-0.1.zip", feed.Games[0].Url)
+	assert.Equal(t, "Second", feed.Games[1].Title)
+	assert.Equal(t, ts.URL+"/files/second-1.0.zip", feed.Games[1].Url)
+
+	resp, e := http.Get(feed.Games[0].Url)
+	assert.NoError(t, e)
+	data, e := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	assert.NoError(t, e)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, feed.Games[0].Size, len(data))
+
+	resp, e = http.Get(ts.URL + "/")
+	assert.NoError(t, e)
+	data, e = ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	assert.NoError(t, e)
+	assert.Contains(t, string(data), "games/first.html")
+
+	// New version is packed
+	writeTestGame(t, filepath.Join(gamesDir, "first"), map[string]string{
+		"main3.lua": "-- $Name: First$\n-- $Version: 0.2$\n",
+	})
+	feed = getFeed()
+	assert.Equal(t, "0.2", feed.Games[0].Version)
+	assert.Equal(t, ts.URL+"/files/first-0.2.zip", feed.Games[0].Url)
+}
+
+func TestLocalizationCoverage(t *testing.T) {
+	dir, e := ioutil.TempDir("", "insteadman")
+	assert.NoError(t, e)
+	defer os.RemoveAll(dir)
+
+	gameDir := filepath.Join(dir, "mygame")
+	writeTestGame(t, gameDir, map[string]string{"main3.lua": "-- $Name: Моя игра$\n"})
+	_, e = LocalizationCoverage(gameDir, "", nil)
+	assert.Equal(t, ErrNoLangs, e)
+
+	writeTestGame(t, gameDir, map[string]string{
+		"main3.lua":       "-- $Name: Моя игра$\n-- $Name(ru): Моя игра$\n-- $Name(uk): Моя гра$\n",
+		"lang/ru.lua":     "return {\n\tstart = \"Начало\";\n\t[\"go north\"] = [[На север]];\n\tend_text = 'Конец';\n}\n",
+		"lang/en.lua":     "return {\n\tstart = \"Beginning\";\n\tend_text = 'The end';\n}\n",
+		"lang/uk.lua":     "return {\n\tstart = \"Початок\";\n\t[\"go north\"] = [[На північ]];\n\tend_text = 'Кінець';\n}\n",
+		"intro_ru.lua":    "text = [[Введение]]\n",
+		"intro_uk.lua":    "text = [[Вступ]]\n",
+		"gfx/ru/logo.png": "png",
+		"gfx/uk/logo.png": "png",
+		"gfx/bg.png":      "png",
+	})
+
+	report, e := LocalizationCoverage(gameDir, "", []string{"en"})
+	assert.NoError(t, e)
+	assert.Equal(t, "ru", report.Primary)
+	assert.Len(t, report.Langs, 2)
+
+	en := report.Langs[0]
+	assert.Equal(t, "en", en.Lang)
+	assert.Equal(t, 4, en.Strings)
+	assert.Equal(t, 2, en.Translated)
+	assert.Equal(t, 50, en.Percent())
+	assert.True(t, en.NoTitle)
+	assert.Equal(t, []string{"gfx/en/logo.png", "intro_en.lua"}, en.MissingFiles)
+	assert.Equal(t, map[string][]string{"lang/en.lua": {"go north"}}, en.MissingStrings)
+	assert.False(t, en.Complete())
+
+	uk := report.Langs[1]
+	assert.Equal(t, "uk", uk.Lang)
+	assert.Equal(t, 100, uk.Percent())
+	assert.True(t, uk.Complete())
+
+	report, e = LocalizationCoverage(gameDir, "en", nil)
+	assert.NoError(t, e)
+	assert.Equal(t, "en", report.Primary)
+	assert.Equal(t, []string{"ru", "uk"}, []string{report.Langs[0].Lang, report.Langs[1].Lang})
+	assert.True(t, report.Langs[0].Complete())
+}
@@ -0,0 +1,214 @@
+package gamedev
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jhekasoft/insteadman3/core/interpreterfinder"
+	"github.com/jhekasoft/insteadman3/core/manager"
+)
+
+// Game tests are scripts ("tests/*.test" in the game directory) which are played by the headless interpreter:
+//
+//	# comment
+//	> look           command is sent to the game
+//	? Kitchen        text should be printed after the previous expected text
+//	! Game over      text shouldn't be printed at all
+//
+// Commands are sent to the interpreter at once, so expected texts are checked in order in the whole output.
+
+// DefaultHeadlessInterpreter is a console INSTEAD which reads commands from stdin
+const DefaultHeadlessInterpreter = "instead-cli"
+
+// TestTimeout is a default time limit of the test
+const TestTimeout = 30 * time.Second
+
+var luaLocationRegexp = regexp.MustCompile(`\.lua:\d+:`)
+
+// ErrNoTests is returned when there aren't test scripts in the game directory
+var ErrNoTests = errors.New("there aren't tests/*.test scripts in the game directory")
+
+// TestStep is a line of the test script
+type TestStep struct {
+	Line    int
+	Command string // command which is sent to the game
+	Expect  string // text which should be printed
+	Absent  string // text which shouldn't be printed
+}
+
+// TestFailure is a failed expectation of the test, Line is 0 for Lua errors and timeout
+type TestFailure struct {
+	Line    int
+	Message string
+}
+
+// TestResult is a result of the test script, Output is the output of the interpreter
+type TestResult struct {
+	Script   string
+	Output   string
+	Failures []TestFailure
+}
+
+// Passed checks that there aren't failures
+func (r *TestResult) Passed() bool {
+	return len(r.Failures) == 0
+}
+
+// TestOptions are options of the game tests
+type TestOptions struct {
+	// Interpreter is a headless INSTEAD command, game path is added to the arguments
+	// (or {{.GamePath}} of the template is used), DefaultHeadlessInterpreter by default
+	Interpreter string
+	Timeout     time.Duration // TestTimeout by default
+}
+
+// ParseTestScript reads steps of the test script
+func ParseTestScript(r io.Reader) ([]TestStep, error) {
+	var steps []TestStep
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		value := strings.TrimSpace(text[1:])
+		switch text[0] {
+		case '>':
+			steps = append(steps, TestStep{Line: line, Command: value})
+		case '?':
+			steps = append(steps, TestStep{Line: line, Expect: value})
+		case '!':
+			steps = append(steps, TestStep{Line: line, Absent: value})
+		default:
+			return nil, errors.New("line " + strconv.Itoa(line) + ": it should start with \">\", \"?\" or \"!\"")
+		}
+	}
+
+	return steps, scanner.Err()
+}
+
+// TestScripts returns test scripts of the game ("tests/*.test")
+func TestScripts(dir string) ([]string, error) {
+	scripts, e := filepath.Glob(filepath.Join(dir, "tests", "*.test"))
+	if e != nil {
+		return nil, e
+	}
+	if len(scripts) == 0 {
+		return nil, ErrNoTests
+	}
+	sort.Strings(scripts)
+
+	return scripts, nil
+}
+
+// RunTest plays the test script by the headless interpreter and checks its output
+func RunTest(dir, script string, options TestOptions) (*TestResult, error) {
+	dir, e := filepath.Abs(dir)
+	if e != nil {
+		return nil, e
+	}
+
+	f, e := os.Open(script)
+	if e != nil {
+		return nil, e
+	}
+	steps, e := ParseTestScript(f)
+	f.Close()
+	if e != nil {
+		return nil, errors.New(script + ": " + e.Error())
+	}
+
+	if options.Interpreter == "" {
+		options.Interpreter = DefaultHeadlessInterpreter
+	}
+	if options.Timeout == 0 {
+		options.Timeout = TestTimeout
+	}
+
+	var input strings.Builder
+	for _, step := range steps {
+		if step.Command != "" {
+			input.WriteString(step.Command + "\n")
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), options.Timeout)
+	defer cancel()
+
+	cmd, e := headlessCommand(options.Interpreter, dir)
+	if e != nil {
+		return nil, e
+	}
+	cmd.Stdin = strings.NewReader(input.String())
+	out, e := interpreterfinder.CombinedOutputContext(ctx, cmd)
+
+	result := &TestResult{Script: script, Output: string(out)}
+	if e == context.DeadlineExceeded {
+		result.Failures = append(result.Failures, TestFailure{Message: "timeout " + options.Timeout.String()})
+	} else if _, ok := e.(*exec.ExitError); ok {
+		result.Failures = append(result.Failures, TestFailure{Message: "interpreter has exited with error: " + e.Error()})
+	} else if e != nil {
+		return nil, e
+	}
+
+	// Texts of the game can have "error" word, so only errors with the location are failures
+	for _, luaError := range manager.ParseLuaErrors(result.Output) {
+		if len(luaError.Traceback) > 0 || luaLocationRegexp.MatchString(luaError.Message) {
+			result.Failures = append(result.Failures, TestFailure{Message: luaError.Message})
+		}
+	}
+
+	// Expected texts are found one after another
+	position := 0
+	for _, step := range steps {
+		switch {
+		case step.Expect != "":
+			i := strings.Index(result.Output[position:], step.Expect)
+			if i < 0 {
+				result.Failures = append(result.Failures, TestFailure{Line: step.Line,
+					Message: "\"" + step.Expect + "\" hasn't found"})
+				continue
+			}
+			position += i + len(step.Expect)
+		case step.Absent != "":
+			if strings.Contains(result.Output, step.Absent) {
+				result.Failures = append(result.Failures, TestFailure{Line: step.Line,
+					Message: "\"" + step.Absent + "\" has found"})
+			}
+		}
+	}
+
+	return result, nil
+}
+
+func headlessCommand(interpreter, dir string) (*exec.Cmd, error) {
+	if !interpreterfinder.IsCommandTemplate(interpreter) {
+		parts := interpreterfinder.SplitCommand(interpreter)
+		if len(parts) == 0 {
+			return nil, interpreterfinder.ErrEmptyCommand
+		}
+		return exec.Command(parts[0], append(parts[1:], dir)...), nil
+	}
+
+	parts, e := interpreterfinder.ExpandCommandTemplate(interpreter, interpreterfinder.CommandData{
+		GameName:  filepath.Base(dir),
+		GamePath:  dir,
+		GamesPath: filepath.Dir(dir),
+	})
+	if e != nil {
+		return nil, e
+	}
+
+	return exec.Command(parts[0], parts[1:]...), nil
+}
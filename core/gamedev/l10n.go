@@ -0,0 +1,245 @@
+package gamedev
+
+import (
+	"errors"
+	"io/ioutil"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/jhekasoft/insteadman3/core/utils"
+)
+
+// Language variants of the game are files which have the language code in the path: "lang/ru.lua",
+// "texts_en.lua", "gfx/en/title.png". Languages are declared by "-- $Name(ru): ...$" tags of the main file.
+
+// ErrNoLangs is returned when the game hasn't declared languages
+var ErrNoLangs = errors.New("there aren't \"-- $Name(lang): ...$\" tags in the main file, languages aren't declared")
+
+var (
+	langNameRegexp = regexp.MustCompile(`(?m)^\s*--\s*\$Name\(([^)]*)\):`)
+	// string keys of the Lua tables: key = "text", ["key"] = [[text]]
+	stringKeyRegexp = regexp.MustCompile(`(?m)^\s*(?:\[\s*["']([^"']+)["']\s*\]|([A-Za-z_]\w*))\s*=\s*(?:"|'|\[=*\[)`)
+)
+
+// LangCoverage is a translation of the game to the language compared to the primary language
+type LangCoverage struct {
+	Lang string
+	// Strings are strings of the primary language, Translated are strings which the language has
+	Strings    int
+	Translated int
+	// NoTitle is true if there isn't "$Name(lang)" tag in the main file
+	NoTitle bool
+	// MissingFiles are files of the primary language without this language variant
+	MissingFiles []string
+	// MissingStrings are keys which aren't translated by the files of the language
+	MissingStrings map[string][]string
+}
+
+// Complete checks that all files and strings are translated
+func (c *LangCoverage) Complete() bool {
+	return !c.NoTitle && len(c.MissingFiles) == 0 && len(c.MissingStrings) == 0
+}
+
+// Percent returns percent of the translated strings
+func (c *LangCoverage) Percent() int {
+	if c.Strings == 0 {
+		return 100
+	}
+
+	return c.Translated * 100 / c.Strings
+}
+
+// LocalizationReport is a coverage of the declared languages
+type LocalizationReport struct {
+	Primary string
+	Langs   []LangCoverage
+}
+
+// langVariant is a language of the file, Group is its path with "{lang}" instead of the language code
+type langVariant struct {
+	Group string
+	Lang  string
+}
+
+// LocalizationCoverage compares language variants of the game files with the primary language. Languages
+// are declared by the main file (langs are added to them), primary language is the language with the most
+// strings by default.
+func LocalizationCoverage(dir, primary string, langs []string) (*LocalizationReport, error) {
+	dir, e := filepath.Abs(dir)
+	if e != nil {
+		return nil, e
+	}
+
+	md, e := ReadMetadata(dir)
+	if e != nil {
+		return nil, e
+	}
+	mainData, e := ioutil.ReadFile(filepath.Join(dir, md.MainFile))
+	if e != nil {
+		return nil, e
+	}
+
+	titleLangs := make(map[string]bool)
+	for _, matches := range langNameRegexp.FindAllStringSubmatch(string(mainData), -1) {
+		titleLangs[strings.TrimSpace(matches[1])] = true
+		langs = appendLang(langs, matches[1])
+	}
+	if primary != "" {
+		langs = appendLang(langs, primary)
+	}
+	if len(langs) == 0 {
+		return nil, ErrNoLangs
+	}
+	sort.Strings(langs)
+
+	files, e := Files(dir)
+	if e != nil {
+		return nil, e
+	}
+
+	// Keys of the strings by the language and the group of the files
+	keys := make(map[string]map[string][]string)
+	for _, lang := range langs {
+		keys[lang] = make(map[string][]string)
+	}
+	for _, file := range files {
+		variant := fileLangVariant(file, langs)
+		if variant == nil {
+			continue
+		}
+
+		var fileKeys []string
+		if strings.ToLower(path.Ext(file)) == ".lua" {
+			data, e := ioutil.ReadFile(filepath.Join(dir, filepath.FromSlash(file)))
+			if e != nil {
+				return nil, e
+			}
+			fileKeys = stringKeys(data)
+		}
+		keys[variant.Lang][variant.Group] = append(keys[variant.Lang][variant.Group], fileKeys...)
+	}
+
+	if primary == "" {
+		primary = langs[0]
+		for _, lang := range langs {
+			if countKeys(keys[lang]) > countKeys(keys[primary]) {
+				primary = lang
+			}
+		}
+	}
+
+	report := &LocalizationReport{Primary: primary}
+	for _, lang := range langs {
+		if lang == primary {
+			continue
+		}
+
+		coverage := LangCoverage{Lang: lang, Strings: countKeys(keys[primary]), NoTitle: !titleLangs[lang]}
+		for group, primaryKeys := range keys[primary] {
+			langKeys, ok := keys[lang][group]
+			if !ok {
+				coverage.MissingFiles = append(coverage.MissingFiles, strings.Replace(group, "{lang}", lang, -1))
+				continue
+			}
+
+			translated := make(map[string]bool)
+			for _, key := range langKeys {
+				translated[key] = true
+			}
+			for _, key := range uniqueStrings(primaryKeys) {
+				if translated[key] {
+					coverage.Translated++
+					continue
+				}
+				if coverage.MissingStrings == nil {
+					coverage.MissingStrings = make(map[string][]string)
+				}
+				file := strings.Replace(group, "{lang}", lang, -1)
+				coverage.MissingStrings[file] = append(coverage.MissingStrings[file], key)
+			}
+		}
+		sort.Strings(coverage.MissingFiles)
+
+		report.Langs = append(report.Langs, coverage)
+	}
+
+	return report, nil
+}
+
+// fileLangVariant returns language variant of the file if there is the language code in its path
+// ("ru/intro.lua", "lang/ru.lua", "intro_ru.lua", "intro-ru.lua", "intro.ru.lua")
+func fileLangVariant(file string, langs []string) *langVariant {
+	parts := strings.Split(file, "/")
+	for i, part := range parts {
+		name := part
+		ext := ""
+		if i == len(parts)-1 {
+			ext = path.Ext(part)
+			name = strings.TrimSuffix(part, ext)
+		}
+
+		for _, lang := range langs {
+			prefix := ""
+			switch {
+			case name == lang:
+			case strings.HasSuffix(name, "_"+lang), strings.HasSuffix(name, "-"+lang), strings.HasSuffix(name, "."+lang):
+				prefix = name[:len(name)-len(lang)]
+			default:
+				continue
+			}
+
+			groupParts := append([]string(nil), parts...)
+			groupParts[i] = prefix + "{lang}" + ext
+			return &langVariant{Group: strings.Join(groupParts, "/"), Lang: lang}
+		}
+	}
+
+	return nil
+}
+
+func stringKeys(data []byte) []string {
+	var keys []string
+	for _, matches := range stringKeyRegexp.FindAllStringSubmatch(string(data), -1) {
+		if matches[1] != "" {
+			keys = append(keys, matches[1])
+		} else {
+			keys = append(keys, matches[2])
+		}
+	}
+
+	return keys
+}
+
+func uniqueStrings(values []string) []string {
+	var result []string
+	exists := make(map[string]bool)
+	for _, value := range values {
+		if !exists[value] {
+			exists[value] = true
+			result = append(result, value)
+		}
+	}
+
+	return result
+}
+
+func countKeys(groups map[string][]string) int {
+	count := 0
+	for _, keys := range groups {
+		count += len(uniqueStrings(keys))
+	}
+
+	return count
+}
+
+func appendLang(langs []string, lang string) []string {
+	lang = strings.TrimSpace(lang)
+	if lang == "" || utils.ExistsString(langs, lang) {
+		return langs
+	}
+
+	return append(langs, lang)
+}
@@ -0,0 +1,261 @@
+package gamedev
+
+import (
+	"archive/zip"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/jhekasoft/insteadman3/core/interpreterfinder"
+)
+
+// Archive formats
+const (
+	FormatZip = "zip"
+	FormatIdf = "idf"
+)
+
+// ErrIdfInterpreter is returned when .idf is packed without INSTEAD
+var ErrIdfInterpreter = errors.New("INSTEAD is required for packing .idf")
+
+var unsafeNameRegexp = regexp.MustCompile(`[^\w.-]+`)
+
+// PackOptions are options of the game packaging
+type PackOptions struct {
+	Formats   []string // FormatZip by default
+	OutputDir string   // current directory by default
+	// Interpreter is INSTEAD command which builds .idf ("-idf" argument)
+	Interpreter string
+}
+
+// Archive is a packed game, Size and SHA256 are used in the repository
+type Archive struct {
+	Path   string
+	Format string
+	Size   int64
+	SHA256 string
+}
+
+// Pack validates metadata of the game and packs its directory to the archives ("name-version.zip" and
+// "name-version.idf"). Zip archive has the game directory inside, like archives of the repositories.
+func Pack(dir string, options PackOptions) (*Metadata, []Archive, error) {
+	dir, e := filepath.Abs(dir)
+	if e != nil {
+		return nil, nil, e
+	}
+
+	md, e := ReadMetadata(dir)
+	if e != nil {
+		return nil, nil, e
+	}
+	e = md.Validate()
+	if e != nil {
+		return md, nil, e
+	}
+
+	files, e := Files(dir)
+	if e != nil {
+		return md, nil, e
+	}
+
+	outputDir := options.OutputDir
+	if outputDir == "" {
+		outputDir = "."
+	}
+	outputDir, e = filepath.Abs(outputDir)
+	if e != nil {
+		return md, nil, e
+	}
+	e = os.MkdirAll(outputDir, os.ModePerm)
+	if e != nil {
+		return md, nil, e
+	}
+
+	formats := options.Formats
+	if len(formats) == 0 {
+		formats = []string{FormatZip}
+	}
+
+	files = skipArchives(files, unsafeNameRegexp.ReplaceAllString(md.Name, "_"))
+	baseName := unsafeNameRegexp.ReplaceAllString(md.Name+"-"+md.Version, "_")
+
+	var archives []Archive
+	for _, format := range formats {
+		path := filepath.Join(outputDir, baseName+"."+format)
+
+		switch format {
+		case FormatZip:
+			e = packZip(dir, md.Name, files, path)
+		case FormatIdf:
+			e = packIdf(dir, md.Name, files, path, options.Interpreter)
+		default:
+			e = errors.New("unknown archive format " + format)
+		}
+		if e != nil {
+			os.Remove(path)
+			return md, archives, e
+		}
+
+		archive, e := archiveInfo(path, format)
+		if e != nil {
+			return md, archives, e
+		}
+		archives = append(archives, *archive)
+	}
+
+	return md, archives, nil
+}
+
+// skipArchives skips archives of the previous packaging ("name-version.zip") in the game directory
+func skipArchives(files []string, baseName string) []string {
+	var result []string
+	for _, file := range files {
+		isArchive := false
+		for _, format := range []string{FormatZip, FormatIdf} {
+			if matched, _ := path.Match(baseName+"-*."+format, file); matched {
+				isArchive = true
+			}
+		}
+		if !isArchive {
+			result = append(result, file)
+		}
+	}
+
+	return result
+}
+
+func packZip(dir, name string, files []string, path string) error {
+	out, e := os.Create(path)
+	if e != nil {
+		return e
+	}
+	defer out.Close()
+
+	w := zip.NewWriter(out)
+	for _, file := range files {
+		e = addZipFile(w, filepath.Join(dir, filepath.FromSlash(file)), name+"/"+file)
+		if e != nil {
+			return e
+		}
+	}
+
+	e = w.Close()
+	if e != nil {
+		return e
+	}
+
+	return out.Close()
+}
+
+func addZipFile(w *zip.Writer, path, name string) error {
+	in, e := os.Open(path)
+	if e != nil {
+		return e
+	}
+	defer in.Close()
+
+	info, e := in.Stat()
+	if e != nil {
+		return e
+	}
+
+	header, e := zip.FileInfoHeader(info)
+	if e != nil {
+		return e
+	}
+	header.Name = name
+	header.Method = zip.Deflate
+
+	f, e := w.CreateHeader(header)
+	if e != nil {
+		return e
+	}
+
+	_, e = io.Copy(f, in)
+	return e
+}
+
+// packIdf copies game files without ignored ones to the temp directory and builds .idf by INSTEAD.
+// INSTEAD writes "<directory name>.idf" to the current directory.
+func packIdf(dir, name string, files []string, path, interpreter string) error {
+	if interpreter == "" {
+		return ErrIdfInterpreter
+	}
+
+	tempDir, e := ioutil.TempDir("", "insteadman-idf")
+	if e != nil {
+		return e
+	}
+	defer os.RemoveAll(tempDir)
+
+	gameDir := filepath.Join(tempDir, name)
+	for _, file := range files {
+		e = copyFile(filepath.Join(dir, filepath.FromSlash(file)), filepath.Join(gameDir, filepath.FromSlash(file)))
+		if e != nil {
+			return e
+		}
+	}
+
+	cmd := interpreterfinder.Command(interpreter, "-idf", gameDir)
+	cmd.Dir = tempDir
+	out, e := cmd.CombinedOutput()
+	if e != nil {
+		return errors.New(e.Error() + "; " + strings.TrimSpace(string(out)))
+	}
+
+	idfPath := filepath.Join(tempDir, name+".idf")
+	if _, e := os.Stat(idfPath); e != nil {
+		return errors.New("INSTEAD hasn't built .idf: " + strings.TrimSpace(string(out)))
+	}
+
+	return copyFile(idfPath, path)
+}
+
+func copyFile(src, dst string) error {
+	e := os.MkdirAll(filepath.Dir(dst), os.ModePerm)
+	if e != nil {
+		return e
+	}
+
+	in, e := os.Open(src)
+	if e != nil {
+		return e
+	}
+	defer in.Close()
+
+	out, e := os.Create(dst)
+	if e != nil {
+		return e
+	}
+	defer out.Close()
+
+	_, e = io.Copy(out, in)
+	if e != nil {
+		return e
+	}
+
+	return out.Close()
+}
+
+func archiveInfo(path, format string) (*Archive, error) {
+	f, e := os.Open(path)
+	if e != nil {
+		return nil, e
+	}
+	defer f.Close()
+
+	hash := sha256.New()
+	size, e := io.Copy(hash, f)
+	if e != nil {
+		return nil, e
+	}
+
+	return &Archive{Path: path, Format: format, Size: size, SHA256: hex.EncodeToString(hash.Sum(nil))}, nil
+}
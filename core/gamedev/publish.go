@@ -0,0 +1,272 @@
+package gamedev
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"io/ioutil"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/jhekasoft/insteadman3/core/configurator"
+	"github.com/jhekasoft/insteadman3/core/manager"
+)
+
+// Publish methods of the repository
+const (
+	PublishSftp   = "sftp"
+	PublishWebdav = "webdav"
+	PublishHttp   = "http"
+)
+
+// PublishPasswordEnv is an environment variable with the password of WebDAV or HTTP endpoint
+const PublishPasswordEnv = "INSTEADMAN_PUBLISH_PASSWORD"
+
+const defaultFeedName = "games.xml"
+
+// ErrNoPublish is returned when publishing isn't configured for the repository
+var ErrNoPublish = errors.New("publishing isn't configured for the repository")
+
+// PublishOptions are options of the game publishing
+type PublishOptions struct {
+	// Langs of the game, languages of the game in the feed are kept by default
+	Langs []string
+	// Interpreter isn't required, zip archive is published
+	Interpreter string
+}
+
+// Publish packs the game to zip, uploads it to the repository and uploads the feed with the new version
+// of the game. Current feed is downloaded by the repository url.
+func Publish(dir string, repository configurator.Repository, options PublishOptions) (*manager.RepositoryGame, error) {
+	if repository.Publish == nil || repository.Publish.Url == "" {
+		return nil, ErrNoPublish
+	}
+	upload, e := uploader(repository.Publish)
+	if e != nil {
+		return nil, e
+	}
+
+	tempDir, e := ioutil.TempDir("", "insteadman-publish")
+	if e != nil {
+		return nil, e
+	}
+	defer os.RemoveAll(tempDir)
+
+	md, archives, e := Pack(dir, PackOptions{Formats: []string{FormatZip}, OutputDir: tempDir})
+	if e != nil {
+		return nil, e
+	}
+	archive := archives[0]
+
+	feed, e := DownloadFeed(repository.Url)
+	if e != nil {
+		return nil, e
+	}
+
+	game := manager.RepositoryGame{Name: md.Name}
+	if existingGame := feed.FindGame(md.Name); existingGame != nil {
+		game = *existingGame
+	}
+	game.Title = md.Title
+	game.Version = md.Version
+	game.Url = downloadUrl(repository) + filepath.Base(archive.Path)
+	game.Size = int(archive.Size)
+	game.Date = time.Now().Format("2006-01-02")
+	if md.Author != "" {
+		game.Author = md.Author
+	}
+	if md.Info != "" {
+		game.Description = md.Info
+	}
+	if len(options.Langs) > 0 {
+		game.Lang = strings.Join(options.Langs, ",")
+		game.Langs = nil
+	}
+	feed.SetGame(game)
+
+	feedData, e := feed.Marshal()
+	if e != nil {
+		return nil, e
+	}
+	feedPath := filepath.Join(tempDir, feedName(repository))
+	e = ioutil.WriteFile(feedPath, feedData, 0644)
+	if e != nil {
+		return nil, e
+	}
+
+	// Archive is uploaded first, so the feed doesn't refer to the missing file
+	e = upload(archive.Path)
+	if e != nil {
+		return nil, e
+	}
+	e = upload(feedPath)
+	if e != nil {
+		return nil, e
+	}
+
+	return &game, nil
+}
+
+// downloadUrl returns base URL of the uploaded archives (with the trailing slash)
+func downloadUrl(repository configurator.Repository) string {
+	if repository.Publish.DownloadUrl != "" {
+		return strings.TrimSuffix(repository.Publish.DownloadUrl, "/") + "/"
+	}
+
+	return repository.Url[:strings.LastIndex(repository.Url, "/")+1]
+}
+
+func feedName(repository configurator.Repository) string {
+	if repository.Publish.FeedName != "" {
+		return repository.Publish.FeedName
+	}
+
+	u, e := url.Parse(repository.Url)
+	if e == nil && strings.ToLower(path.Ext(u.Path)) == ".xml" {
+		return path.Base(u.Path)
+	}
+
+	return defaultFeedName
+}
+
+// uploader returns function which uploads the file to the repository (file name is kept)
+func uploader(publish *configurator.Publish) (func(fileName string) error, error) {
+	method := publish.Method
+	if method == "" && strings.HasPrefix(publish.Url, "sftp://") {
+		method = PublishSftp
+	}
+
+	switch method {
+	case PublishSftp:
+		return func(fileName string) error {
+			return uploadSftp(publish, fileName)
+		}, nil
+	case PublishWebdav, "":
+		return func(fileName string) error {
+			return uploadWebdav(publish, fileName)
+		}, nil
+	case PublishHttp:
+		return func(fileName string) error {
+			return uploadHttp(publish, fileName)
+		}, nil
+	}
+
+	return nil, errors.New("unknown publish method " + method)
+}
+
+// uploadSftp uploads the file by sftp command (OpenSSH), "sftp://user@host:port/path" is an absolute
+// path and "sftp://user@host/~/path" is a path in the home directory
+func uploadSftp(publish *configurator.Publish, fileName string) error {
+	u, e := url.Parse(publish.Url)
+	if e != nil {
+		return e
+	}
+
+	host := u.Hostname()
+	if u.User != nil && u.User.Username() != "" {
+		host = u.User.Username() + "@" + host
+	} else if publish.Username != "" {
+		host = publish.Username + "@" + host
+	}
+
+	args := []string{"-b", "-"}
+	if u.Port() != "" {
+		args = append(args, "-P", u.Port())
+	}
+	args = append(args, host)
+
+	remoteDir := strings.TrimPrefix(u.Path, "/~/")
+	if remoteDir == "" {
+		remoteDir = "."
+	}
+
+	// "-" prefix ignores error of the existing directory
+	batch := "-mkdir " + sftpQuote(remoteDir) + "\n" +
+		"put " + sftpQuote(fileName) + " " + sftpQuote(path.Join(remoteDir, filepath.Base(fileName))) + "\n"
+
+	cmd := exec.Command("sftp", args...)
+	cmd.Stdin = strings.NewReader(batch)
+	out, e := cmd.CombinedOutput()
+	if e != nil {
+		return errors.New("sftp: " + e.Error() + "; " + strings.TrimSpace(string(out)))
+	}
+
+	return nil
+}
+
+func sftpQuote(s string) string {
+	return "\"" + strings.Replace(strings.Replace(s, "\\", "\\\\", -1), "\"", "\\\"", -1) + "\""
+}
+
+// uploadWebdav uploads the file by PUT to the WebDAV directory
+func uploadWebdav(publish *configurator.Publish, fileName string) error {
+	f, e := os.Open(fileName)
+	if e != nil {
+		return e
+	}
+	defer f.Close()
+
+	req, e := http.NewRequest(http.MethodPut, strings.TrimSuffix(publish.Url, "/")+"/"+filepath.Base(fileName), f)
+	if e != nil {
+		return e
+	}
+
+	return doPublishRequest(publish, req)
+}
+
+// uploadHttp uploads the file by POST (multipart form with "file" field) to the HTTP endpoint
+func uploadHttp(publish *configurator.Publish, fileName string) error {
+	f, e := os.Open(fileName)
+	if e != nil {
+		return e
+	}
+	defer f.Close()
+
+	body := &bytes.Buffer{}
+	w := multipart.NewWriter(body)
+	part, e := w.CreateFormFile("file", filepath.Base(fileName))
+	if e != nil {
+		return e
+	}
+	_, e = io.Copy(part, f)
+	if e != nil {
+		return e
+	}
+	e = w.Close()
+	if e != nil {
+		return e
+	}
+
+	req, e := http.NewRequest(http.MethodPost, publish.Url, body)
+	if e != nil {
+		return e
+	}
+	req.Header.Set("Content-Type", w.FormDataContentType())
+
+	return doPublishRequest(publish, req)
+}
+
+func doPublishRequest(publish *configurator.Publish, req *http.Request) error {
+	if publish.Username != "" {
+		req.SetBasicAuth(publish.Username, os.Getenv(PublishPasswordEnv))
+	}
+
+	resp, e := http.DefaultClient.Do(req)
+	if e != nil {
+		return e
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return errors.New(req.URL.String() + ": " + resp.Status)
+	}
+
+	return nil
+}
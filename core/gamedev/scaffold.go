@@ -0,0 +1,146 @@
+package gamedev
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+)
+
+// ErrProjectExists is returned when the directory of the new game isn't empty
+var ErrProjectExists = errors.New("directory of the game already exists and it isn't empty")
+
+// ScaffoldDirs are directories of the images and music of the new game
+var ScaffoldDirs = []string{"gfx", "mus"}
+
+// ProjectOptions are options of the new game
+type ProjectOptions struct {
+	Title  string // directory name by default
+	Author string
+	// Langs of the game, texts of the first language are used ("en", "ru" or "uk"), title is localized
+	// for others by "$Name(lang)" tags
+	Langs []string
+	// Stead2 creates main.lua for INSTEAD 2 instead of main3.lua
+	Stead2 bool
+}
+
+type scaffoldTexts struct {
+	Info  string
+	Start string
+	Text  string
+}
+
+var scaffoldLangTexts = map[string]scaffoldTexts{
+	"en": {"Description of the game", "Beginning", "This is the beginning of your game. Write the story here!"},
+	"ru": {"Описание игры", "Начало", "Это начало вашей игры. Напишите здесь свою историю!"},
+	"uk": {"Опис гри", "Початок", "Це початок вашої гри. Напишіть тут свою історію!"},
+}
+
+type scaffoldData struct {
+	ProjectOptions
+	scaffoldTexts
+	OtherLangs []string
+}
+
+var stead3Template = template.Must(template.New("main3.lua").Parse(`-- $Name: {{.Title}}$
+{{- range .OtherLangs}}
+-- $Name({{.}}): {{$.Title}}$
+{{- end}}
+-- $Version: 0.1$
+-- $Author: {{.Author}}$
+-- $Info: {{.Info}}$
+
+require "fmt"
+
+room {
+	nam = "main";
+	disp = "{{.Start}}";
+	dsc = [[{{.Text}}]];
+}
+`))
+
+var stead2Template = template.Must(template.New("main.lua").Parse(`-- $Name: {{.Title}}$
+{{- range .OtherLangs}}
+-- $Name({{.}}): {{$.Title}}$
+{{- end}}
+-- $Version: 0.1$
+-- $Author: {{.Author}}$
+-- $Info: {{.Info}}$
+
+instead_version "1.9.1"
+
+main = room {
+	nam = "{{.Start}}",
+	dsc = [[{{.Text}}]],
+};
+`))
+
+const scaffoldGitignore = `*.zip
+*.idf
+*~
+*.swp
+.DS_Store
+Thumbs.db
+`
+
+// CreateProject creates minimal game in the directory: main file with metadata, directories for
+// the images and music, .gitignore. Game can be run at once.
+func CreateProject(dir string, options ProjectOptions) error {
+	dir, e := filepath.Abs(dir)
+	if e != nil {
+		return e
+	}
+
+	if files, e := ioutil.ReadDir(dir); e == nil && len(files) > 0 {
+		return ErrProjectExists
+	}
+
+	data := scaffoldData{ProjectOptions: options}
+	if data.Title == "" {
+		data.Title = filepath.Base(dir)
+	}
+	if data.Author == "" {
+		data.Author = "Author"
+	}
+	if len(data.Langs) == 0 {
+		data.Langs = []string{"en"}
+	}
+	texts, ok := scaffoldLangTexts[data.Langs[0]]
+	if !ok {
+		texts = scaffoldLangTexts["en"]
+	}
+	data.scaffoldTexts = texts
+	data.OtherLangs = data.Langs[1:]
+
+	for _, scaffoldDir := range ScaffoldDirs {
+		e = os.MkdirAll(filepath.Join(dir, scaffoldDir), os.ModePerm)
+		if e != nil {
+			return e
+		}
+		// Git doesn't keep empty directories
+		e = ioutil.WriteFile(filepath.Join(dir, scaffoldDir, ".gitkeep"), nil, 0644)
+		if e != nil {
+			return e
+		}
+	}
+
+	e = ioutil.WriteFile(filepath.Join(dir, ".gitignore"), []byte(scaffoldGitignore), 0644)
+	if e != nil {
+		return e
+	}
+
+	mainTemplate := stead3Template
+	if options.Stead2 {
+		mainTemplate = stead2Template
+	}
+
+	var main strings.Builder
+	e = mainTemplate.Execute(&main, data)
+	if e != nil {
+		return e
+	}
+
+	return ioutil.WriteFile(filepath.Join(dir, mainTemplate.Name()), []byte(main.String()), 0644)
+}
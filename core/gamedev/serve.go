@@ -0,0 +1,254 @@
+package gamedev
+
+import (
+	"archive/zip"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/jhekasoft/insteadman3/core/manager"
+)
+
+// DefaultServeAddr is an address of the local repository ("dev serve"), it's available in LAN
+const DefaultServeAddr = ":8780"
+
+const serveFilesDir = "files"
+
+// RepositoryServer serves directory of the games (game directories and zip archives) as the repository:
+// feed games.xml, archives files/<name>.zip and static site of the repository. Game directories are packed,
+// the repository is rebuilt when files of the directory have changed.
+type RepositoryServer struct {
+	Dir   string
+	Title string
+	// OnError is called with errors of the games which are skipped (it can be nil)
+	OnError func(name string, e error)
+
+	mutex    sync.Mutex
+	state    map[string]fileState
+	buildDir string
+	feed     *Feed
+}
+
+// NewRepositoryServer returns server of the games directory
+func NewRepositoryServer(dir string) *RepositoryServer {
+	return &RepositoryServer{Dir: dir}
+}
+
+// ListenAndServe serves the repository on the address (DefaultServeAddr if it's empty)
+func (s *RepositoryServer) ListenAndServe(addr string) error {
+	if addr == "" {
+		addr = DefaultServeAddr
+	}
+
+	e := s.Build()
+	if e != nil {
+		return e
+	}
+
+	return http.ListenAndServe(addr, s)
+}
+
+// Close removes packed games and the site
+func (s *RepositoryServer) Close() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.buildDir == "" {
+		return nil
+	}
+	e := os.RemoveAll(s.buildDir)
+	s.buildDir = ""
+	s.state = nil
+
+	return e
+}
+
+// Build packs the games and generates the feed and the site if files of the directory have changed
+func (s *RepositoryServer) Build() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	state, e := filesState(s.Dir)
+	if e != nil {
+		return e
+	}
+	if s.buildDir != "" && equalFilesState(s.state, state) {
+		return nil
+	}
+
+	buildDir, e := ioutil.TempDir("", "insteadman-serve")
+	if e != nil {
+		return e
+	}
+	feed, e := s.build(buildDir)
+	if e != nil {
+		os.RemoveAll(buildDir)
+		return e
+	}
+
+	if s.buildDir != "" {
+		os.RemoveAll(s.buildDir)
+	}
+	s.buildDir = buildDir
+	s.feed = feed
+	s.state = state
+
+	return nil
+}
+
+func (s *RepositoryServer) build(buildDir string) (*Feed, error) {
+	filesDir := filepath.Join(buildDir, serveFilesDir)
+	e := os.MkdirAll(filesDir, os.ModePerm)
+	if e != nil {
+		return nil, e
+	}
+
+	infos, e := ioutil.ReadDir(s.Dir)
+	if e != nil {
+		return nil, e
+	}
+
+	feed := &Feed{Version: FeedVersion}
+	for _, info := range infos {
+		if IsIgnored(info.Name()) {
+			continue
+		}
+
+		path := filepath.Join(s.Dir, info.Name())
+		var md *Metadata
+		var archive *Archive
+		switch {
+		case info.IsDir():
+			var archives []Archive
+			md, archives, e = Pack(path, PackOptions{OutputDir: filesDir})
+			if e == nil {
+				archive = &archives[0]
+			}
+		case strings.ToLower(filepath.Ext(info.Name())) == ".zip":
+			md, e = zipMetadata(path)
+			if e == nil {
+				e = md.Validate()
+			}
+			if e == nil {
+				e = copyFile(path, filepath.Join(filesDir, info.Name()))
+			}
+			if e == nil {
+				archive, e = archiveInfo(filepath.Join(filesDir, info.Name()), FormatZip)
+			}
+		default:
+			continue
+		}
+		if e != nil {
+			if s.OnError != nil {
+				s.OnError(info.Name(), e)
+			}
+			continue
+		}
+
+		feed.Games = append(feed.Games, manager.RepositoryGame{
+			Name:        md.Name,
+			Title:       md.Title,
+			Version:     md.Version,
+			Url:         "/" + serveFilesDir + "/" + filepath.Base(archive.Path),
+			Size:        int(archive.Size),
+			Author:      md.Author,
+			Description: md.Info,
+			Date:        info.ModTime().Format("2006-01-02"),
+		})
+	}
+	sort.Slice(feed.Games, func(i, j int) bool {
+		return feed.Games[i].Name < feed.Games[j].Name
+	})
+
+	return feed, GenerateSite(feed, buildDir, SiteOptions{Title: s.Title})
+}
+
+// ServeHTTP serves the feed with absolute URLs of the archives (host of the request is used), so
+// the repository can be added by LAN address too
+func (s *RepositoryServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !strings.HasPrefix(r.URL.Path, "/"+serveFilesDir+"/") {
+		e := s.Build()
+		if e != nil {
+			http.Error(w, e.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+
+	s.mutex.Lock()
+	if s.feed == nil {
+		s.mutex.Unlock()
+		http.NotFound(w, r)
+		return
+	}
+	buildDir := s.buildDir
+	feed := *s.feed
+	s.mutex.Unlock()
+
+	if r.URL.Path != "/"+SiteFeedXml {
+		http.FileServer(http.Dir(buildDir)).ServeHTTP(w, r)
+		return
+	}
+
+	feed.Games = append([]manager.RepositoryGame(nil), feed.Games...)
+	for i := range feed.Games {
+		feed.Games[i].Url = "http://" + r.Host + feed.Games[i].Url
+	}
+	data, e := feed.Marshal()
+	if e != nil {
+		http.Error(w, e.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/xml; charset=utf-8")
+	w.Write(data)
+}
+
+// zipMetadata reads metadata of the game from the main file in the archive ("name/main3.lua")
+func zipMetadata(fileName string) (*Metadata, error) {
+	r, e := zip.OpenReader(fileName)
+	if e != nil {
+		return nil, e
+	}
+	defer r.Close()
+
+	for _, f := range r.File {
+		parts := strings.Split(strings.TrimPrefix(f.Name, "/"), "/")
+		if len(parts) != 2 || !isMainFile(parts[1]) {
+			continue
+		}
+
+		in, e := f.Open()
+		if e != nil {
+			return nil, e
+		}
+		data, e := ioutil.ReadAll(in)
+		in.Close()
+		if e != nil {
+			return nil, e
+		}
+
+		md := &Metadata{Name: parts[0], MainFile: parts[1]}
+		md.parse(data)
+
+		return md, nil
+	}
+
+	return nil, ErrNoMainFile
+}
+
+func equalFilesState(a, b map[string]fileState) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for file, state := range a {
+		if b[file] != state {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,252 @@
+package gamedev
+
+import (
+	"encoding/json"
+	"html"
+	"html/template"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/jhekasoft/insteadman3/core/manager"
+	"github.com/jhekasoft/insteadman3/core/utils"
+)
+
+// Files of the generated site, game pages are "games/<name>.html" and language pages are "lang-<lang>.html"
+const (
+	SiteFeedXml  = "games.xml"
+	SiteFeedJson = "games.json"
+	siteIndex    = "index.html"
+	siteGamesDir = "games"
+)
+
+// SiteOptions are options of the static site of the repository
+type SiteOptions struct {
+	Title string // "Games" by default
+}
+
+type siteGame struct {
+	Game      manager.RepositoryGame
+	Page      string
+	Languages []string
+	Size      string
+}
+
+type sitePage struct {
+	Title     string
+	Root      string // relative path to the site root ("" or "../")
+	Lang      string // language filter of the list
+	Languages []string
+	Games     []siteGame
+	Game      *siteGame
+}
+
+// jsonFeedGame is a game of JSON feed, fields are the same as in XML feed
+type jsonFeedGame struct {
+	Name        string   `json:"name"`
+	Title       string   `json:"title"`
+	Version     string   `json:"version"`
+	Url         string   `json:"url"`
+	Size        int      `json:"size,omitempty"`
+	Languages   []string `json:"langs,omitempty"`
+	Descurl     string   `json:"descurl,omitempty"`
+	Author      string   `json:"author,omitempty"`
+	Description string   `json:"description,omitempty"`
+	Image       string   `json:"image,omitempty"`
+	Date        string   `json:"date,omitempty"`
+}
+
+// GenerateSite writes browsable catalog of the repository to the directory: index page with the games,
+// pages of the languages and the games, XML and JSON feeds. Directory can be hosted on GitHub Pages.
+func GenerateSite(feed *Feed, outputDir string, options SiteOptions) error {
+	if options.Title == "" {
+		options.Title = "Games"
+	}
+
+	e := os.MkdirAll(filepath.Join(outputDir, siteGamesDir), os.ModePerm)
+	if e != nil {
+		return e
+	}
+
+	feedData, e := feed.Marshal()
+	if e != nil {
+		return e
+	}
+	e = ioutil.WriteFile(filepath.Join(outputDir, SiteFeedXml), feedData, 0644)
+	if e != nil {
+		return e
+	}
+
+	games := make([]siteGame, 0, len(feed.Games))
+	jsonGames := make([]jsonFeedGame, 0, len(feed.Games))
+	var languages []string
+	for _, game := range feed.Games {
+		game.Title = html.UnescapeString(game.Title)
+		game.Description = html.UnescapeString(game.Description)
+		g := siteGame{
+			Game:      game,
+			Page:      siteGamesDir + "/" + unsafeNameRegexp.ReplaceAllString(game.Name, "_") + ".html",
+			Languages: gameLanguages(game),
+		}
+		g.Size = (*manager.Game)(&game).HumanSize()
+		games = append(games, g)
+
+		for _, lang := range g.Languages {
+			if !utils.ExistsString(languages, lang) {
+				languages = append(languages, lang)
+			}
+		}
+
+		jsonGames = append(jsonGames, jsonFeedGame{Name: game.Name, Title: game.Title, Version: game.Version,
+			Url: game.Url, Size: game.Size, Languages: g.Languages, Descurl: game.Descurl, Author: game.Author,
+			Description: game.Description, Image: game.Image, Date: game.Date})
+	}
+	sort.SliceStable(games, func(i, j int) bool {
+		return strings.ToLower(games[i].Game.Title) < strings.ToLower(games[j].Game.Title)
+	})
+	sort.Strings(languages)
+
+	jsonData, e := json.MarshalIndent(struct {
+		Version string         `json:"version"`
+		Games   []jsonFeedGame `json:"games"`
+	}{feed.Version, jsonGames}, "", "  ")
+	if e != nil {
+		return e
+	}
+	e = ioutil.WriteFile(filepath.Join(outputDir, SiteFeedJson), jsonData, 0644)
+	if e != nil {
+		return e
+	}
+
+	page := sitePage{Title: options.Title, Languages: languages, Games: games}
+	e = writeSitePage(filepath.Join(outputDir, siteIndex), page)
+	if e != nil {
+		return e
+	}
+
+	for _, lang := range languages {
+		filteredPage := page
+		filteredPage.Lang = lang
+		filteredPage.Games = nil
+		for _, game := range games {
+			if utils.ExistsString(game.Languages, lang) {
+				filteredPage.Games = append(filteredPage.Games, game)
+			}
+		}
+
+		e = writeSitePage(filepath.Join(outputDir, langPage(lang)), filteredPage)
+		if e != nil {
+			return e
+		}
+	}
+
+	for i := range games {
+		gamePage := sitePage{Title: games[i].Game.Title, Root: "../", Languages: languages, Game: &games[i]}
+		e = writeSitePage(filepath.Join(outputDir, filepath.FromSlash(games[i].Page)), gamePage)
+		if e != nil {
+			return e
+		}
+	}
+
+	return nil
+}
+
+// gameLanguages returns languages of the game ("langs" list or comma separated "lang")
+func gameLanguages(game manager.RepositoryGame) []string {
+	langs := game.Langs
+	if len(langs) == 0 {
+		langs = strings.Split(game.Lang, ",")
+	}
+
+	var languages []string
+	for _, lang := range langs {
+		if lang = strings.TrimSpace(lang); lang != "" {
+			languages = append(languages, lang)
+		}
+	}
+
+	return languages
+}
+
+// langPage returns path of the language page
+func langPage(lang string) string {
+	return "lang-" + unsafeNameRegexp.ReplaceAllString(lang, "_") + ".html"
+}
+
+func writeSitePage(fileName string, page sitePage) error {
+	f, e := os.Create(fileName)
+	if e != nil {
+		return e
+	}
+	defer f.Close()
+
+	e = siteTemplate.Execute(f, page)
+	if e != nil {
+		return e
+	}
+
+	return f.Close()
+}
+
+var siteTemplate = template.Must(template.New("site").Funcs(template.FuncMap{"langPage": langPage}).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{.Title}}</title>
+<style>
+body { font-family: sans-serif; max-width: 960px; margin: 0 auto; padding: 1em; color: #222; }
+a { color: #2a6db0; }
+nav a { margin-right: 0.5em; }
+nav a.active { font-weight: bold; text-decoration: none; color: #222; }
+.games { list-style: none; padding: 0; }
+.games li { display: flex; gap: 1em; padding: 0.75em 0; border-bottom: 1px solid #ddd; }
+.games img { width: 160px; height: 120px; object-fit: cover; }
+.info { color: #666; font-size: 0.9em; }
+.screenshot { max-width: 100%; }
+.download { display: inline-block; margin: 1em 0; padding: 0.5em 1em; background: #2a6db0; color: #fff; text-decoration: none; }
+</style>
+</head>
+<body>
+{{- $root := .Root}}
+<nav>
+<a href="{{$root}}index.html"{{if and (not .Lang) (not .Game)}} class="active"{{end}}>All</a>
+{{- range .Languages}}
+<a href="{{$root}}{{langPage .}}"{{if eq . $.Lang}} class="active"{{end}}>{{.}}</a>
+{{- end}}
+<a href="{{$root}}games.xml">XML</a>
+<a href="{{$root}}games.json">JSON</a>
+</nav>
+{{- with .Game}}
+<h1>{{.Game.Title}}</h1>
+<p class="info">{{.Game.Version}}{{if .Game.Author}} · {{.Game.Author}}{{end}}{{if .Languages}} · {{range $i, $lang := .Languages}}{{if $i}}, {{end}}{{$lang}}{{end}}{{end}}{{if .Game.Date}} · {{.Game.Date}}{{end}}</p>
+{{- if .Game.Image}}
+<img class="screenshot" src="{{.Game.Image}}" alt="{{.Game.Title}}">
+{{- end}}
+{{- if .Game.Description}}
+<p>{{.Game.Description}}</p>
+{{- end}}
+<a class="download" href="{{.Game.Url}}">Download{{if .Size}} ({{.Size}}){{end}}</a>
+{{- if .Game.Descurl}}
+<p><a href="{{.Game.Descurl}}">More info</a></p>
+{{- end}}
+{{- else}}
+<h1>{{.Title}}</h1>
+<ul class="games">
+{{- range .Games}}
+<li>
+{{- if .Game.Image}}<a href="{{.Page}}"><img src="{{.Game.Image}}" alt=""></a>{{end}}
+<div>
+<a href="{{.Page}}">{{.Game.Title}}</a>
+<div class="info">{{.Game.Version}}{{if .Game.Author}} · {{.Game.Author}}{{end}}{{if .Languages}} · {{range $i, $lang := .Languages}}{{if $i}}, {{end}}{{$lang}}{{end}}{{end}}</div>
+<div><a href="{{.Game.Url}}">Download</a>{{if .Size}} <span class="info">{{.Size}}</span>{{end}}</div>
+</div>
+</li>
+{{- end}}
+</ul>
+{{- end}}
+</body>
+</html>
+`))
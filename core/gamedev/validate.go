@@ -0,0 +1,307 @@
+package gamedev
+
+import (
+	"archive/zip"
+	"bufio"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Severity of the validation problem. Errors break the game, warnings are recommendations.
+type Severity int
+
+const (
+	SeverityError Severity = iota
+	SeverityWarning
+)
+
+func (s Severity) String() string {
+	if s == SeverityWarning {
+		return "warning"
+	}
+	return "error"
+}
+
+// Problem is a validation problem of the game, File and Line are set if it's found in the file
+type Problem struct {
+	Severity Severity
+	File     string
+	Line     int
+	Message  string
+}
+
+func (p Problem) String() string {
+	location := p.File
+	if location != "" && p.Line > 0 {
+		location += ":" + strconv.Itoa(p.Line)
+	}
+	if location != "" {
+		location += ": "
+	}
+
+	return location + p.Severity.String() + ": " + p.Message
+}
+
+var (
+	gameNameRegexp = regexp.MustCompile(`^[a-z0-9][a-z0-9_.-]*$`)
+	versionRegexp  = regexp.MustCompile(`^\d+(\.\d+)*[\w.-]*$`)
+	// localized metadata like "-- $Name(ru): ...$"
+	langMetadataRegexp = regexp.MustCompile(`^\s*--\s*\$(\w+)\(([^)]*)\):`)
+	langRegexp         = regexp.MustCompile(`^[a-z]{2}$`)
+	// string literals with the assets ("gfx/bg.png")
+	assetRegexp   = regexp.MustCompile(`["']([^"'\n]+\.(?i:png|jpe?g|gif|bmp|ogg|mp3|wav|flac|xm|mod|s3m|it|ttf|otf))["']`)
+	absPathRegexp = regexp.MustCompile(`^(/|[A-Za-z]:[\\/]|\\\\)`)
+)
+
+// Validate checks the game directory or zip archive: metadata of the main file, assets which are referenced
+// by the Lua files and layout of the archive. Error is returned if the game can't be read.
+func Validate(gamePath string) ([]Problem, error) {
+	info, e := os.Stat(gamePath)
+	if e != nil {
+		return nil, e
+	}
+
+	if info.IsDir() {
+		return validateDir(gamePath)
+	}
+
+	switch strings.ToLower(filepath.Ext(gamePath)) {
+	case ".zip":
+		return validateZip(gamePath)
+	case ".idf":
+		return []Problem{{Severity: SeverityWarning, File: filepath.Base(gamePath),
+			Message: "content of .idf isn't checked, please validate the game directory"}}, nil
+	}
+
+	return []Problem{{Severity: SeverityError, File: filepath.Base(gamePath),
+		Message: "game should be a directory, .zip or .idf"}}, nil
+}
+
+func validateDir(dir string) ([]Problem, error) {
+	dir, e := filepath.Abs(dir)
+	if e != nil {
+		return nil, e
+	}
+
+	md, e := ReadMetadata(dir)
+	if e == ErrNoMainFile {
+		return []Problem{{Severity: SeverityError, Message: e.Error()}}, nil
+	}
+	if e != nil {
+		return nil, e
+	}
+
+	var problems []Problem
+	add := func(severity Severity, file string, line int, message string) {
+		problems = append(problems, Problem{Severity: severity, File: file, Line: line, Message: message})
+	}
+
+	if !gameNameRegexp.MatchString(md.Name) {
+		add(SeverityWarning, "", 0, "directory name \""+md.Name+"\" is the game name in the repositories, "+
+			"lowercase latin letters, digits, \"-\" and \"_\" are recommended")
+	}
+	if md.Title == "" {
+		add(SeverityError, md.MainFile, 0, ErrNoTitle.Error())
+	}
+	if md.Version == "" {
+		add(SeverityError, md.MainFile, 0, ErrNoVersion.Error())
+	} else if !versionRegexp.MatchString(md.Version) {
+		add(SeverityWarning, md.MainFile, 0, "version \""+md.Version+"\" isn't like \"1.0\", "+
+			"updates can't be found by it")
+	}
+	if md.Author == "" {
+		add(SeverityWarning, md.MainFile, 0, "there isn't \"-- $Author: ...$\" in the main file")
+	}
+	if md.Info == "" {
+		add(SeverityWarning, md.MainFile, 0, "there isn't \"-- $Info: ...$\" in the main file")
+	}
+
+	files, e := Files(dir)
+	if e != nil {
+		return nil, e
+	}
+
+	// Exact and lowercase paths of the files, case of the paths matters on Linux
+	exists := make(map[string]bool)
+	lowerFiles := make(map[string]string)
+	for _, file := range files {
+		exists[file] = true
+		lowerFiles[strings.ToLower(file)] = file
+	}
+
+	for _, file := range files {
+		if strings.ToLower(path.Ext(file)) != ".lua" {
+			continue
+		}
+
+		fileProblems, e := validateLuaFile(dir, file, exists, lowerFiles)
+		if e != nil {
+			return nil, e
+		}
+		problems = append(problems, fileProblems...)
+	}
+
+	return problems, nil
+}
+
+// validateLuaFile checks localized metadata and assets of the file
+func validateLuaFile(dir, file string, exists map[string]bool, lowerFiles map[string]string) ([]Problem, error) {
+	f, e := os.Open(filepath.Join(dir, filepath.FromSlash(file)))
+	if e != nil {
+		return nil, e
+	}
+	defer f.Close()
+
+	var problems []Problem
+	add := func(severity Severity, line int, message string) {
+		problems = append(problems, Problem{Severity: severity, File: file, Line: line, Message: message})
+	}
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		text := scanner.Text()
+
+		if matches := langMetadataRegexp.FindStringSubmatch(text); matches != nil &&
+			!langRegexp.MatchString(matches[2]) {
+			add(SeverityWarning, line, "language \""+matches[2]+"\" of $"+matches[1]+
+				" should be two-letter code like \"ru\"")
+		}
+
+		for _, matches := range assetRegexp.FindAllStringSubmatch(text, -1) {
+			asset := matches[1]
+			switch {
+			case absPathRegexp.MatchString(asset):
+				add(SeverityError, line, "absolute path "+asset+" can't be found on other computers, "+
+					"use path relative to the game directory")
+			case strings.Contains(asset, "\\"):
+				add(SeverityWarning, line, "path "+asset+" has backslashes, use \"/\"")
+			case exists[path.Clean(asset)]:
+			case lowerFiles[strings.ToLower(path.Clean(asset))] != "":
+				add(SeverityError, line, "file "+asset+" has found as "+lowerFiles[strings.ToLower(path.Clean(asset))]+
+					", case of the path matters on Linux")
+			default:
+				add(SeverityError, line, "file "+asset+" hasn't found")
+			}
+		}
+	}
+
+	return problems, scanner.Err()
+}
+
+// validateZip checks layout of the archive (there is the game directory with the main file inside it)
+// and validates the extracted game
+func validateZip(fileName string) ([]Problem, error) {
+	r, e := zip.OpenReader(fileName)
+	if e != nil {
+		return nil, e
+	}
+	defer r.Close()
+
+	archiveName := filepath.Base(fileName)
+	var problems []Problem
+	add := func(severity Severity, message string) {
+		problems = append(problems, Problem{Severity: severity, File: archiveName, Message: message})
+	}
+
+	gameDir := ""
+	topDirs := make(map[string]bool)
+	for _, f := range r.File {
+		name := strings.TrimPrefix(f.Name, "/")
+		parts := strings.Split(strings.TrimSuffix(name, "/"), "/")
+		topDirs[parts[0]] = true
+
+		for _, part := range parts {
+			if IsIgnored(part) {
+				add(SeverityWarning, "archive has "+f.Name+" which isn't a part of the game")
+				break
+			}
+		}
+
+		if len(parts) == 2 && isMainFile(parts[1]) {
+			gameDir = parts[0]
+		}
+		if len(parts) == 1 && isMainFile(parts[0]) {
+			add(SeverityError, "main file is in the root of the archive, game should be in the directory "+
+				"(like \"mygame/main3.lua\")")
+		}
+	}
+
+	if gameDir == "" {
+		add(SeverityError, "there isn't the game directory with main.lua or main3.lua")
+		return problems, nil
+	}
+	if len(topDirs) > 1 {
+		add(SeverityWarning, "archive has other files besides the "+gameDir+" directory")
+	}
+
+	// Game is validated after extracting
+	tempDir, e := ioutil.TempDir("", "insteadman-validate")
+	if e != nil {
+		return nil, e
+	}
+	defer os.RemoveAll(tempDir)
+
+	e = extractZipDir(r, gameDir, tempDir)
+	if e != nil {
+		return nil, e
+	}
+
+	dirProblems, e := validateDir(filepath.Join(tempDir, gameDir))
+	if e != nil {
+		return nil, e
+	}
+	for _, problem := range dirProblems {
+		problem.File = path.Join(archiveName, gameDir, problem.File)
+		problems = append(problems, problem)
+	}
+
+	return problems, nil
+}
+
+func isMainFile(name string) bool {
+	for _, mainFile := range mainFiles {
+		if name == mainFile {
+			return true
+		}
+	}
+
+	return false
+}
+
+// extractZipDir extracts files of the directory, unsafe paths ("../") are skipped
+func extractZipDir(r *zip.ReadCloser, dir, outputDir string) error {
+	for _, f := range r.File {
+		name := path.Clean(strings.TrimPrefix(f.Name, "/"))
+		if f.FileInfo().IsDir() || !strings.HasPrefix(name, dir+"/") || strings.Contains(name, "..") {
+			continue
+		}
+
+		in, e := f.Open()
+		if e != nil {
+			return e
+		}
+		data, e := ioutil.ReadAll(in)
+		in.Close()
+		if e != nil {
+			return e
+		}
+
+		outPath := filepath.Join(outputDir, filepath.FromSlash(name))
+		e = os.MkdirAll(filepath.Dir(outPath), os.ModePerm)
+		if e != nil {
+			return e
+		}
+		e = ioutil.WriteFile(outPath, data, 0644)
+		if e != nil {
+			return e
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,78 @@
+package gamedev
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// WatchInterval is an interval of the game files checking
+const WatchInterval = 500 * time.Millisecond
+
+type fileState struct {
+	modTime time.Time
+	size    int64
+}
+
+// Watch checks the game files (ignored files are skipped) periodically and calls changedF with the changed,
+// added and removed files until stop is closed
+func Watch(dir string, interval time.Duration, stop <-chan struct{}, changedF func(files []string)) error {
+	state, e := filesState(dir)
+	if e != nil {
+		return e
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return nil
+		case <-ticker.C:
+		}
+
+		newState, e := filesState(dir)
+		if e != nil {
+			// Files can be saved by the editor at this moment
+			continue
+		}
+
+		var changed []string
+		for file, fs := range newState {
+			if old, ok := state[file]; !ok || old != fs {
+				changed = append(changed, file)
+			}
+		}
+		for file := range state {
+			if _, ok := newState[file]; !ok {
+				changed = append(changed, file)
+			}
+		}
+		state = newState
+
+		if len(changed) > 0 {
+			sort.Strings(changed)
+			changedF(changed)
+		}
+	}
+}
+
+func filesState(dir string) (map[string]fileState, error) {
+	files, e := Files(dir)
+	if e != nil {
+		return nil, e
+	}
+
+	state := make(map[string]fileState)
+	for _, file := range files {
+		info, e := os.Stat(filepath.Join(dir, filepath.FromSlash(file)))
+		if e != nil {
+			continue
+		}
+		state[file] = fileState{modTime: info.ModTime(), size: info.Size()}
+	}
+
+	return state, nil
+}
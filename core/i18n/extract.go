@@ -0,0 +1,168 @@
+package i18n
+
+import (
+	"encoding/xml"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io/ioutil"
+	"strconv"
+	"strings"
+)
+
+// Message is a translatable message of the sources, Refs are like "gtk/ui/main.go:120"
+type Message struct {
+	Id     string
+	Plural string
+	Refs   []string
+}
+
+// Messages are translatable messages in the order of the first reference
+type Messages struct {
+	List  []*Message
+	index map[string]*Message
+}
+
+func (m *Messages) add(id, plural, ref string) {
+	if m.index == nil {
+		m.index = make(map[string]*Message)
+	}
+
+	if msg, ok := m.index[id]; ok {
+		msg.Refs = append(msg.Refs, ref)
+		if msg.Plural == "" {
+			msg.Plural = plural
+		}
+		return
+	}
+
+	msg := &Message{Id: id, Plural: plural, Refs: []string{ref}}
+	m.List = append(m.List, msg)
+	m.index[id] = msg
+}
+
+// ExtractGo adds messages of the i18n.T("...") and i18n.N("...", "...", n) calls with string literals
+// (concatenated literals are joined)
+func (m *Messages) ExtractGo(paths ...string) error {
+	fileSet := token.NewFileSet()
+
+	for _, path := range paths {
+		file, e := parser.ParseFile(fileSet, path, nil, 0)
+		if e != nil {
+			return e
+		}
+
+		ast.Inspect(file, func(node ast.Node) bool {
+			call, ok := node.(*ast.CallExpr)
+			if !ok {
+				return true
+			}
+
+			selector, ok := call.Fun.(*ast.SelectorExpr)
+			if !ok {
+				return true
+			}
+			pkg, ok := selector.X.(*ast.Ident)
+			if !ok || pkg.Name != "i18n" {
+				return true
+			}
+
+			ref := fileSet.Position(call.Pos())
+			refTxt := path + ":" + strconv.Itoa(ref.Line)
+
+			switch {
+			case selector.Sel.Name == "T" && len(call.Args) == 1:
+				if id, ok := stringLiteral(call.Args[0]); ok {
+					m.add(id, "", refTxt)
+				}
+			case selector.Sel.Name == "N" && len(call.Args) == 3:
+				id, ok := stringLiteral(call.Args[0])
+				plural, pluralOk := stringLiteral(call.Args[1])
+				if ok && pluralOk {
+					m.add(id, plural, refTxt)
+				}
+			}
+
+			return true
+		})
+	}
+
+	return nil
+}
+
+// stringLiteral returns value of the string literal or concatenation of the literals ("line\n" + "line")
+func stringLiteral(expr ast.Expr) (string, bool) {
+	if binary, ok := expr.(*ast.BinaryExpr); ok && binary.Op == token.ADD {
+		x, ok := stringLiteral(binary.X)
+		y, yOk := stringLiteral(binary.Y)
+		return x + y, ok && yOk
+	}
+
+	literal, ok := expr.(*ast.BasicLit)
+	if !ok || literal.Kind != token.STRING {
+		return "", false
+	}
+
+	value, e := strconv.Unquote(literal.Value)
+	return value, e == nil
+}
+
+// ExtractGlade adds messages of the translatable properties of the GtkBuilder files
+func (m *Messages) ExtractGlade(paths ...string) error {
+	for _, path := range paths {
+		data, e := ioutil.ReadFile(path)
+		if e != nil {
+			return e
+		}
+
+		decoder := xml.NewDecoder(strings.NewReader(string(data)))
+		translatable := false
+		var value string
+		for {
+			token, e := decoder.Token()
+			if e != nil {
+				break
+			}
+
+			switch t := token.(type) {
+			case xml.StartElement:
+				translatable = false
+				for _, attr := range t.Attr {
+					if attr.Name.Local == "translatable" && attr.Value == "yes" {
+						translatable = true
+					}
+				}
+				value = ""
+			case xml.CharData:
+				if translatable {
+					value += string(t)
+				}
+			case xml.EndElement:
+				if translatable && value != "" {
+					line, _ := decoder.InputPos()
+					m.add(value, "", path+":"+strconv.Itoa(line))
+				}
+				translatable = false
+			}
+		}
+	}
+
+	return nil
+}
+
+// Untranslated returns messages which aren't translated in the catalog entries (missing or fuzzy)
+func (m *Messages) Untranslated(entries []POEntry) []*Message {
+	translated := make(map[string]bool, len(entries))
+	for i := range entries {
+		translated[entries[i].Id] = entries[i].Translated()
+	}
+
+	var untranslated []*Message
+	for _, msg := range m.List {
+		if !translated[msg.Id] {
+			untranslated = append(untranslated, msg)
+		}
+	}
+
+	return untranslated
+}
@@ -0,0 +1,144 @@
+package i18n
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// ErrCatalogNotFound is returned when there isn't catalog of the language (messages aren't translated then)
+var ErrCatalogNotFound = errors.New("translation catalog hasn't found")
+
+// Catalog is a compiled gettext catalog (LC_MESSAGES/domain.mo) of the language.
+// Messages which aren't in the catalog are returned as is.
+type Catalog struct {
+	Language string
+	messages map[string][]string // msgid (singular) -> translation and its plural forms
+	plural   PluralRule
+}
+
+// NewCatalog returns empty catalog of the language (English messages are returned)
+func NewCatalog(language string) *Catalog {
+	return &Catalog{Language: language, messages: make(map[string][]string), plural: FindPluralRule(language)}
+}
+
+// LoadCatalog reads catalog from the locale dir ("ru_RU" is read from "ru_RU" or "ru" dir)
+func LoadCatalog(localeDir, domain, language string) (*Catalog, error) {
+	catalog := NewCatalog(language)
+
+	for _, lang := range []string{language, BaseLanguage(language)} {
+		if lang == "" {
+			continue
+		}
+
+		data, e := ioutil.ReadFile(filepath.Join(localeDir, lang, "LC_MESSAGES", domain+".mo"))
+		if os.IsNotExist(e) {
+			continue
+		}
+		if e != nil {
+			return catalog, e
+		}
+
+		catalog.messages, e = ReadMO(data)
+		return catalog, e
+	}
+
+	return catalog, ErrCatalogNotFound
+}
+
+// T translates message
+func (c *Catalog) T(message string) string {
+	if c == nil {
+		return message
+	}
+
+	if forms, ok := c.messages[message]; ok && forms[0] != "" {
+		return forms[0]
+	}
+
+	return message
+}
+
+// N translates message with plural forms ("%d game", "%d games") by the count
+func (c *Catalog) N(singular, plural string, n int) string {
+	if c != nil {
+		forms := c.messages[singular]
+		if form := c.plural(n); form < len(forms) && forms[form] != "" {
+			return forms[form]
+		}
+	}
+
+	if n == 1 {
+		return singular
+	}
+	return plural
+}
+
+// Default catalog which is used by the front ends
+
+var (
+	mutex         sync.RWMutex
+	current       = NewCatalog("")
+	currentDir    string
+	currentDomain string
+)
+
+// Init loads catalog of the language (system language if it's empty) for T and N.
+// Missing catalog isn't an error, messages aren't translated then.
+func Init(localeDir, domain, language string) error {
+	mutex.Lock()
+	currentDir, currentDomain = localeDir, domain
+	mutex.Unlock()
+
+	return SetLanguage(language)
+}
+
+// SetLanguage changes language of the translates after Init
+func SetLanguage(language string) error {
+	if language == "" {
+		language = DetectLanguage()
+	}
+
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	catalog, e := LoadCatalog(currentDir, currentDomain, language)
+	current = catalog
+	if e == ErrCatalogNotFound {
+		return nil
+	}
+
+	return e
+}
+
+// Language returns language of the loaded catalog
+func Language() string {
+	mutex.RLock()
+	defer mutex.RUnlock()
+
+	return current.Language
+}
+
+// T translates message by the catalog which has loaded by Init
+func T(message string) string {
+	mutex.RLock()
+	defer mutex.RUnlock()
+
+	return current.T(message)
+}
+
+// N translates message with plural forms by the catalog which has loaded by Init
+func N(singular, plural string, n int) string {
+	mutex.RLock()
+	defer mutex.RUnlock()
+
+	return current.N(singular, plural, n)
+}
+
+// BaseLanguage returns language without the territory ("pt" of "pt_BR")
+func BaseLanguage(language string) string {
+	return strings.SplitN(language, "_", 2)[0]
+}
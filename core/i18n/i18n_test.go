@@ -0,0 +1,158 @@
+package i18n
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const localeDir = "../../resources/locale"
+
+func TestLoadCatalog(t *testing.T) {
+	translates := map[string]map[string]string{
+		"uk_UA": {
+			"About":            "Про програму",
+			"%s Installing...": "%s Встановлення...",
+		},
+		"ru": {
+			"About":            "О программе",
+			"%s Installing...": "%s Установка...",
+		},
+	}
+
+	for lang, langTranslates := range translates {
+		catalog, e := LoadCatalog(localeDir, "insteadman", lang)
+		assert.NoError(t, e)
+
+		for key, mustBeTranslate := range langTranslates {
+			assert.Equal(t, mustBeTranslate, catalog.T(key))
+		}
+	}
+
+	// Plural forms of the catalog
+	catalog, e := LoadCatalog(localeDir, "insteadman", "ru")
+	assert.NoError(t, e)
+	assert.Equal(t, "Удалить %d игр?", catalog.N("Remove %d game?", "Remove %d games?", 5))
+
+	catalog, e = LoadCatalog(localeDir, "insteadman", "de")
+	assert.Equal(t, ErrCatalogNotFound, e)
+	assert.Equal(t, "About", catalog.T("About"))
+}
+
+func TestInit(t *testing.T) {
+	assert.NoError(t, Init(localeDir, "insteadman", "uk"))
+	assert.Equal(t, "uk", Language())
+	assert.Equal(t, "Про програму", T("About"))
+
+	os.Setenv("LANGUAGE", "ru_RU.UTF-8:en")
+	defer os.Unsetenv("LANGUAGE")
+	assert.NoError(t, SetLanguage(""))
+	assert.Equal(t, "ru_RU", Language())
+	assert.Equal(t, "О программе", T("About"))
+}
+
+func TestN(t *testing.T) {
+	catalog := NewCatalog("uk")
+	catalog.messages["%d game"] = []string{"%d гра", "%d гри", "%d ігор"}
+
+	counts := map[int]string{1: "%d гра", 3: "%d гри", 5: "%d ігор", 11: "%d ігор", 21: "%d гра", 104: "%d гри"}
+	for n, form := range counts {
+		assert.Equal(t, form, catalog.N("%d game", "%d games", n))
+	}
+
+	english := NewCatalog("en")
+	assert.Equal(t, "%d game", english.N("%d game", "%d games", 1))
+	assert.Equal(t, "%d games", english.N("%d game", "%d games", 0))
+}
+
+func TestNormalizeLanguage(t *testing.T) {
+	locales := map[string]string{
+		"ru_RU.UTF-8": "ru_RU",
+		"uk-UA":       "uk_UA",
+		"be_BY@latin": "be_BY",
+		"C":           "",
+		"POSIX":       "",
+		"":            "",
+	}
+
+	for locale, language := range locales {
+		assert.Equal(t, language, NormalizeLanguage(locale))
+	}
+	assert.Equal(t, "pt", BaseLanguage("pt_BR"))
+}
+
+func TestReadPO(t *testing.T) {
+	data, e := ioutil.ReadFile(filepath.Join(localeDir, "uk", "LC_MESSAGES", "insteadman.po"))
+	assert.NoError(t, e)
+
+	entries, e := ReadPO(data)
+	assert.NoError(t, e)
+	assert.True(t, len(entries) > 100)
+
+	po := `msgid ""
+msgstr "Language: ru\n"
+
+#, fuzzy
+msgid "About"
+msgstr "О программе"
+
+msgid "%d game"
+msgid_plural "%d games"
+msgstr[0] "%d игра"
+msgstr[1] "%d игры"
+msgstr[2] ""
+"%d игр"
+`
+	entries, e = ReadPO([]byte(po))
+	assert.NoError(t, e)
+	assert.Len(t, entries, 2)
+	assert.True(t, entries[0].Fuzzy)
+	assert.False(t, entries[0].Translated())
+	assert.Equal(t, "%d games", entries[1].Plural)
+	assert.Equal(t, []string{"%d игра", "%d игры", "%d игр"}, entries[1].Strs)
+	assert.True(t, entries[1].Translated())
+
+	_, e = ReadPO([]byte("msgid \"About\"\nunknown \"\"\n"))
+	assert.Error(t, e)
+}
+
+func TestExtract(t *testing.T) {
+	dir, e := ioutil.TempDir("", "insteadman")
+	assert.NoError(t, e)
+	defer os.RemoveAll(dir)
+
+	goPath := filepath.Join(dir, "main.go")
+	source := "package main\n\n" +
+		"func main() {\n" +
+		"\ti18n.T(\"About\")\n" +
+		"\ti18n.N(\"%d game\", \"%d games\", 2)\n" +
+		"\ti18n.T(variable)\n" +
+		"\ti18n.T(\"Long\\n\" +\n\t\t\"help\")\n" +
+		"\ti18n.T(\"Not \" + variable)\n" +
+		"\tfmt.Sprint(\"Not translatable\")\n" +
+		"}\n"
+	assert.NoError(t, ioutil.WriteFile(goPath, []byte(source), 0644))
+
+	gladePath := filepath.Join(dir, "main.glade")
+	glade := `<interface><object class="GtkLabel">` +
+		`<property name="label" translatable="yes">Games &amp; INSTEAD</property>` +
+		`<property name="name">label</property>` +
+		`</object></interface>`
+	assert.NoError(t, ioutil.WriteFile(gladePath, []byte(glade), 0644))
+
+	var messages Messages
+	assert.NoError(t, messages.ExtractGo(goPath))
+	assert.NoError(t, messages.ExtractGlade(gladePath))
+	assert.Len(t, messages.List, 4)
+	assert.Equal(t, "%d games", messages.List[1].Plural)
+	assert.Equal(t, []string{goPath + ":5"}, messages.List[1].Refs)
+	assert.Equal(t, "Long\nhelp", messages.List[2].Id)
+	assert.Equal(t, "Games & INSTEAD", messages.List[3].Id)
+
+	untranslated := messages.Untranslated([]POEntry{{Id: "About", Strs: []string{"Про програму"}}})
+	assert.Len(t, untranslated, 3)
+	assert.Equal(t, "%d game", untranslated[0].Id)
+}
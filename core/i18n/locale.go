@@ -0,0 +1,37 @@
+package i18n
+
+import (
+	"os"
+	"strings"
+)
+
+// localeEnvs are environment variables of the language in the order of priority
+var localeEnvs = []string{"LANGUAGE", "LC_ALL", "LC_MESSAGES", "LANG"}
+
+// DetectLanguage returns language of the user ("uk", "pt_BR"), it's empty if language is unknown
+func DetectLanguage() string {
+	for _, env := range localeEnvs {
+		// LANGUAGE is a list like "uk:ru:en"
+		for _, locale := range strings.Split(os.Getenv(env), ":") {
+			if language := NormalizeLanguage(locale); language != "" {
+				return language
+			}
+		}
+	}
+
+	return NormalizeLanguage(systemLocale())
+}
+
+// NormalizeLanguage returns language of the locale ("ru_RU.UTF-8" is "ru_RU", "uk-UA" is "uk_UA").
+// "C" and "POSIX" locales haven't language.
+func NormalizeLanguage(locale string) string {
+	locale = strings.SplitN(locale, ".", 2)[0]
+	locale = strings.SplitN(locale, "@", 2)[0]
+	locale = strings.Replace(strings.TrimSpace(locale), "-", "_", -1)
+
+	if locale == "C" || locale == "POSIX" {
+		return ""
+	}
+
+	return locale
+}
@@ -0,0 +1,18 @@
+// +build darwin
+
+package i18n
+
+import (
+	"os/exec"
+	"strings"
+)
+
+// systemLocale returns locale of the user settings ("uk_UA"), GUI applications haven't LANG on macOS
+func systemLocale() string {
+	out, e := exec.Command("defaults", "read", "-g", "AppleLocale").Output()
+	if e != nil {
+		return ""
+	}
+
+	return strings.TrimSpace(string(out))
+}
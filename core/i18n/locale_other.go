@@ -0,0 +1,8 @@
+// +build !windows,!darwin
+
+package i18n
+
+// systemLocale is empty, environment variables are the system locale
+func systemLocale() string {
+	return ""
+}
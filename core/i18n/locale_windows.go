@@ -0,0 +1,21 @@
+// +build windows
+
+package i18n
+
+import "golang.org/x/sys/windows/registry"
+
+// systemLocale returns locale of the user settings ("uk-UA")
+func systemLocale() string {
+	key, e := registry.OpenKey(registry.CURRENT_USER, `Control Panel\International`, registry.QUERY_VALUE)
+	if e != nil {
+		return ""
+	}
+	defer key.Close()
+
+	locale, _, e := key.GetStringValue("LocaleName")
+	if e != nil {
+		return ""
+	}
+
+	return locale
+}
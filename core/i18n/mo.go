@@ -0,0 +1,74 @@
+package i18n
+
+import (
+	"encoding/binary"
+	"errors"
+	"strings"
+)
+
+const (
+	moMagic = 0x950412de
+
+	// contextSeparator separates msgctxt and msgid in the catalog (contexts aren't used by InsteadMan)
+	contextSeparator = "\x04"
+	// pluralSeparator separates plural forms of msgid and msgstr
+	pluralSeparator = "\x00"
+)
+
+var errInvalidMO = errors.New("invalid .mo file")
+
+// ReadMO reads messages of the compiled gettext catalog, plural forms are separated.
+// Header (empty msgid) and messages with context are skipped.
+func ReadMO(data []byte) (map[string][]string, error) {
+	if len(data) < 20 {
+		return nil, errInvalidMO
+	}
+
+	var order binary.ByteOrder = binary.LittleEndian
+	if order.Uint32(data) != moMagic {
+		order = binary.BigEndian
+		if order.Uint32(data) != moMagic {
+			return nil, errInvalidMO
+		}
+	}
+
+	count := int(order.Uint32(data[8:]))
+	originals := int(order.Uint32(data[12:]))
+	translations := int(order.Uint32(data[16:]))
+
+	str := func(table, n int) (string, error) {
+		entry := table + n*8
+		if entry+8 > len(data) {
+			return "", errInvalidMO
+		}
+
+		length := int(order.Uint32(data[entry:]))
+		offset := int(order.Uint32(data[entry+4:]))
+		if offset+length > len(data) {
+			return "", errInvalidMO
+		}
+
+		return string(data[offset : offset+length]), nil
+	}
+
+	messages := make(map[string][]string, count)
+	for n := 0; n < count; n++ {
+		original, e := str(originals, n)
+		if e != nil {
+			return nil, e
+		}
+		translation, e := str(translations, n)
+		if e != nil {
+			return nil, e
+		}
+
+		if original == "" || strings.Contains(original, contextSeparator) {
+			continue
+		}
+
+		msgid := strings.SplitN(original, pluralSeparator, 2)[0]
+		messages[msgid] = strings.Split(translation, pluralSeparator)
+	}
+
+	return messages, nil
+}
@@ -0,0 +1,86 @@
+package i18n
+
+// PluralRule returns index of the plural form for the count
+type PluralRule func(n int) int
+
+var (
+	// pluralOne is a rule of the English and most of the European languages
+	pluralOne PluralRule = func(n int) int {
+		if n == 1 {
+			return 0
+		}
+		return 1
+	}
+
+	// pluralNone is a rule of the languages without plural forms
+	pluralNone PluralRule = func(n int) int {
+		return 0
+	}
+
+	pluralFrench PluralRule = func(n int) int {
+		if n > 1 {
+			return 1
+		}
+		return 0
+	}
+
+	// pluralSlavic is a rule of the Russian, Ukrainian and Belarusian
+	pluralSlavic PluralRule = func(n int) int {
+		if n%10 == 1 && n%100 != 11 {
+			return 0
+		}
+		if n%10 >= 2 && n%10 <= 4 && (n%100 < 10 || n%100 >= 20) {
+			return 1
+		}
+		return 2
+	}
+
+	pluralPolish PluralRule = func(n int) int {
+		if n == 1 {
+			return 0
+		}
+		if n%10 >= 2 && n%10 <= 4 && (n%100 < 10 || n%100 >= 20) {
+			return 1
+		}
+		return 2
+	}
+
+	pluralCzech PluralRule = func(n int) int {
+		if n == 1 {
+			return 0
+		}
+		if n >= 2 && n <= 4 {
+			return 1
+		}
+		return 2
+	}
+)
+
+// pluralRules are rules of the languages (same as Plural-Forms of their catalogs)
+var pluralRules = map[string]PluralRule{
+	"ru":    pluralSlavic,
+	"uk":    pluralSlavic,
+	"be":    pluralSlavic,
+	"pl":    pluralPolish,
+	"cs":    pluralCzech,
+	"sk":    pluralCzech,
+	"fr":    pluralFrench,
+	"pt_BR": pluralFrench,
+	"tr":    pluralFrench,
+	"ja":    pluralNone,
+	"ko":    pluralNone,
+	"zh":    pluralNone,
+	"vi":    pluralNone,
+}
+
+// FindPluralRule returns plural rule of the language, English rule is used for unknown languages
+func FindPluralRule(language string) PluralRule {
+	if rule, ok := pluralRules[language]; ok {
+		return rule
+	}
+	if rule, ok := pluralRules[BaseLanguage(language)]; ok {
+		return rule
+	}
+
+	return pluralOne
+}
@@ -0,0 +1,120 @@
+package i18n
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"strconv"
+	"strings"
+)
+
+// POEntry is a message of the gettext source catalog (.po)
+type POEntry struct {
+	Id     string
+	Plural string
+	// Strs are translation and its plural forms
+	Strs  []string
+	Fuzzy bool
+}
+
+// Translated returns true if all the forms are translated and translation isn't fuzzy
+func (e *POEntry) Translated() bool {
+	if e.Fuzzy || len(e.Strs) == 0 {
+		return false
+	}
+
+	for _, str := range e.Strs {
+		if str == "" {
+			return false
+		}
+	}
+
+	return true
+}
+
+// ReadPO reads messages of the .po file, header (empty msgid) and obsolete messages are skipped
+func ReadPO(data []byte) ([]POEntry, error) {
+	var entries []POEntry
+	var entry POEntry
+	var field *string
+
+	// Previous entry is finished by the blank line, comment or msgid after its msgstr
+	flush := func() {
+		if entry.Id != "" {
+			entries = append(entries, entry)
+		}
+		entry, field = POEntry{}, nil
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimSpace(scanner.Text())
+
+		switch {
+		case line == "":
+			flush()
+
+		case strings.HasPrefix(line, "#"):
+			// Comments, references and obsolete messages ("#~") are skipped
+			if len(entry.Strs) > 0 {
+				flush()
+			}
+			if strings.HasPrefix(line, "#,") && strings.Contains(line, "fuzzy") {
+				entry.Fuzzy = true
+			}
+
+		case strings.HasPrefix(line, `"`):
+			value, e := strconv.Unquote(line)
+			if field == nil || e != nil {
+				return nil, poError(lineNumber)
+			}
+			*field += value
+
+		default:
+			keyword, value, e := poKeyword(line)
+			if e != nil {
+				return nil, poError(lineNumber)
+			}
+
+			if (keyword == "msgctxt" || keyword == "msgid") && len(entry.Strs) > 0 {
+				flush()
+			}
+
+			switch {
+			case keyword == "msgctxt":
+				var context string
+				field = &context
+			case keyword == "msgid":
+				field = &entry.Id
+			case keyword == "msgid_plural":
+				field = &entry.Plural
+			case strings.HasPrefix(keyword, "msgstr"):
+				entry.Strs = append(entry.Strs, "")
+				field = &entry.Strs[len(entry.Strs)-1]
+			default:
+				return nil, poError(lineNumber)
+			}
+			*field = value
+		}
+	}
+	flush()
+
+	return entries, scanner.Err()
+}
+
+// poKeyword parses line like `msgstr[1] "value"`
+func poKeyword(line string) (keyword, value string, e error) {
+	parts := strings.SplitN(line, " ", 2)
+	if len(parts) != 2 {
+		return "", "", errors.New("invalid line")
+	}
+
+	value, e = strconv.Unquote(strings.TrimSpace(parts[1]))
+	return parts[0], value, e
+}
+
+func poError(line int) error {
+	return errors.New("invalid .po file at line " + strconv.Itoa(line))
+}
@@ -0,0 +1,142 @@
+package instance
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"net"
+	"time"
+)
+
+// ErrRunning is returned by Lock when another instance is running, it has got the arguments
+var ErrRunning = errors.New("another instance is running")
+
+// ErrNotInstance is returned by Send when something else is listening instead of the instance
+// and by Lock when another instance is locked, but it hasn't answered
+var ErrNotInstance = errors.New("instance hasn't answered")
+
+// errLocked is returned by listen when another instance holds the lock
+var errLocked = errors.New("instance is locked")
+
+const (
+	dialTimeout = time.Second
+	// lockWaitTimeout is a time of waiting for the instance which holds the lock, but doesn't listen yet
+	lockWaitTimeout  = 3 * time.Second
+	lockWaitInterval = 100 * time.Millisecond
+	// reply is an answer of the instance which has got the arguments
+	reply = "ok"
+)
+
+// Instance is a running instance of the front end which receives arguments of the next launches
+type Instance struct {
+	listener net.Listener // it unlocks the instance by closing
+	handler  func(args []string)
+}
+
+// Lock makes current process the single instance of the front end (name like "insteadman-gtk") which
+// is locked in the dir. If another instance is running, args are sent to it and ErrRunning is returned.
+// handler is called (not in the main goroutine) with arguments of the next launches.
+func Lock(dir, name string, args []string, handler func(args []string)) (*Instance, error) {
+	e := Send(dir, name, args)
+	if e == nil {
+		return nil, ErrRunning
+	}
+
+	listener, e := listen(dir, name)
+	if e == errLocked {
+		// Another instance is starting at the same time or it's hung
+		return nil, waitSend(dir, name, args)
+	}
+	if e != nil {
+		return nil, e
+	}
+
+	i := &Instance{listener: listener, handler: handler}
+	go i.serve()
+
+	return i, nil
+}
+
+// waitSend sends the arguments to the instance which is starting, ErrRunning is returned if it has got them
+func waitSend(dir, name string, args []string) error {
+	deadline := time.Now().Add(lockWaitTimeout)
+	for time.Now().Before(deadline) {
+		if Send(dir, name, args) == nil {
+			return ErrRunning
+		}
+		time.Sleep(lockWaitInterval)
+	}
+
+	return ErrNotInstance
+}
+
+// Send sends the arguments to the running instance, error is returned if it isn't running
+func Send(dir, name string, args []string) error {
+	conn, e := dial(dir, name)
+	if e != nil {
+		return e
+	}
+	defer conn.Close()
+
+	if args == nil {
+		args = []string{}
+	}
+	data, e := json.Marshal(args)
+	if e != nil {
+		return e
+	}
+
+	conn.SetDeadline(time.Now().Add(dialTimeout))
+	_, e = conn.Write(append(data, '\n'))
+	if e != nil {
+		return e
+	}
+
+	answer, e := bufio.NewReader(conn).ReadString('\n')
+	if e != nil || answer != reply+"\n" {
+		return ErrNotInstance
+	}
+
+	return nil
+}
+
+// Close unlocks the instance
+func (i *Instance) Close() error {
+	return i.listener.Close()
+}
+
+func (i *Instance) serve() {
+	for {
+		conn, e := i.listener.Accept()
+		if e != nil {
+			return
+		}
+
+		go i.receive(conn)
+	}
+}
+
+// receive reads line with JSON array of the arguments and replies to the sender
+func (i *Instance) receive(conn net.Conn) {
+	defer conn.Close()
+
+	conn.SetDeadline(time.Now().Add(dialTimeout))
+	data, e := bufio.NewReader(conn).ReadBytes('\n')
+	if e != nil {
+		return
+	}
+
+	var args []string
+	if json.Unmarshal(data, &args) != nil {
+		return
+	}
+
+	_, e = conn.Write([]byte(reply + "\n"))
+	if e != nil {
+		return
+	}
+
+	if i.handler != nil {
+		i.handler(args)
+	}
+}
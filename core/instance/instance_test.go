@@ -0,0 +1,41 @@
+package instance
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLock(t *testing.T) {
+	dir, e := ioutil.TempDir("", "insteadman")
+	assert.NoError(t, e)
+	defer os.RemoveAll(dir)
+
+	received := make(chan []string, 1)
+	first, e := Lock(dir, "test", nil, func(args []string) {
+		received <- args
+	})
+	assert.NoError(t, e)
+
+	// Second launch activates the first one
+	_, e = Lock(dir, "test", []string{"game.zip"}, nil)
+	assert.Equal(t, ErrRunning, e)
+
+	select {
+	case args := <-received:
+		assert.Equal(t, []string{"game.zip"}, args)
+	case <-time.After(5 * time.Second):
+		t.Fatal("arguments haven't received")
+	}
+
+	assert.NoError(t, first.Close())
+	assert.Error(t, Send(dir, "test", nil))
+
+	// Lock is free after closing
+	second, e := Lock(dir, "test", nil, nil)
+	assert.NoError(t, e)
+	assert.NoError(t, second.Close())
+}
@@ -0,0 +1,68 @@
+// +build !windows
+
+package instance
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"syscall"
+)
+
+// Unix instance listens the socket. It holds flock of the lock file while it's running,
+// so the socket is removed only when it's left by the crashed instance.
+
+func socketPath(dir, name string) string {
+	return filepath.Join(dir, name+".sock")
+}
+
+func lockPath(dir, name string) string {
+	return filepath.Join(dir, name+".lock")
+}
+
+// lockedListener releases the lock after closing the socket
+type lockedListener struct {
+	net.Listener
+	lock *os.File
+}
+
+func (l *lockedListener) Close() error {
+	// Unix socket is removed by closing
+	e := l.Listener.Close()
+	l.lock.Close()
+
+	return e
+}
+
+func listen(dir, name string) (net.Listener, error) {
+	lock, e := os.OpenFile(lockPath(dir, name), os.O_RDWR|os.O_CREATE, 0600)
+	if e != nil {
+		return nil, e
+	}
+
+	e = syscall.Flock(int(lock.Fd()), syscall.LOCK_EX|syscall.LOCK_NB)
+	if e != nil {
+		lock.Close()
+		if e == syscall.EWOULDBLOCK {
+			return nil, errLocked
+		}
+		return nil, e
+	}
+
+	// Socket of the crashed instance (lock is free)
+	path := socketPath(dir, name)
+	os.Remove(path)
+
+	listener, e := net.Listen("unix", path)
+	if e != nil {
+		lock.Close()
+		return nil, e
+	}
+	os.Chmod(path, 0600)
+
+	return &lockedListener{Listener: listener, lock: lock}, nil
+}
+
+func dial(dir, name string) (net.Conn, error) {
+	return net.DialTimeout("unix", socketPath(dir, name), dialTimeout)
+}
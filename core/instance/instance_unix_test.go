@@ -0,0 +1,39 @@
+// +build !windows
+
+package instance
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+
+	"github.com/jhekasoft/insteadman3/core/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLockStaleSocket(t *testing.T) {
+	dir, e := ioutil.TempDir("", "insteadman")
+	assert.NoError(t, e)
+	defer os.RemoveAll(dir)
+
+	// Socket of the hung instance isn't removed while it holds the lock
+	socket := socketPath(dir, "test")
+	assert.NoError(t, ioutil.WriteFile(socket, nil, 0600))
+	lock, e := os.OpenFile(filepath.Join(dir, "test.lock"), os.O_RDWR|os.O_CREATE, 0600)
+	assert.NoError(t, e)
+	assert.NoError(t, syscall.Flock(int(lock.Fd()), syscall.LOCK_EX|syscall.LOCK_NB))
+
+	_, e = Lock(dir, "test", nil, nil)
+	assert.Equal(t, ErrNotInstance, e)
+	assert.True(t, utils.PathExist(socket))
+
+	// Socket of the crashed instance is replaced
+	assert.NoError(t, lock.Close())
+	i, e := Lock(dir, "test", nil, nil)
+	assert.NoError(t, e)
+	assert.NoError(t, Send(dir, "test", nil))
+	assert.NoError(t, i.Close())
+	assert.False(t, utils.PathExist(socket))
+}
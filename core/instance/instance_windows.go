@@ -0,0 +1,55 @@
+// +build windows
+
+package instance
+
+import (
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Windows instance listens localhost, its address is kept in the file
+
+func addrPath(dir, name string) string {
+	return filepath.Join(dir, name+".addr")
+}
+
+// addrListener removes the address file after closing
+type addrListener struct {
+	net.Listener
+	path string
+}
+
+func (l *addrListener) Close() error {
+	e := l.Listener.Close()
+	os.Remove(l.path)
+
+	return e
+}
+
+func listen(dir, name string) (net.Listener, error) {
+	listener, e := net.Listen("tcp", "127.0.0.1:0")
+	if e != nil {
+		return nil, e
+	}
+
+	path := addrPath(dir, name)
+	e = ioutil.WriteFile(path, []byte(listener.Addr().String()), 0600)
+	if e != nil {
+		listener.Close()
+		return nil, e
+	}
+
+	return &addrListener{Listener: listener, path: path}, nil
+}
+
+func dial(dir, name string) (net.Conn, error) {
+	addr, e := ioutil.ReadFile(addrPath(dir, name))
+	if e != nil {
+		return nil, e
+	}
+
+	return net.DialTimeout("tcp", strings.TrimSpace(string(addr)), dialTimeout)
+}
@@ -0,0 +1,170 @@
+package interpreterfinder
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/jhekasoft/insteadman3/core/configurator"
+	"github.com/jhekasoft/insteadman3/core/utils"
+)
+
+// Discovered interpreters are cached in the data dir, so registry lookups, PATH walks and flatpak
+// queries aren't repeated on startup. Cache is invalidated when cached interpreter disappears,
+// it's rewritten by every FindAll scan (explicit "Detect") and interpreter found by Find is added to it.
+
+const discoveryCacheFileName = "interpreters-cache.json"
+
+type discoveryCache struct {
+	Interpreters []configurator.Interpreter `json:"interpreters"`
+}
+
+func (f *InterpreterFinder) discoveryCachePath() string {
+	if f.DataDir == "" {
+		return ""
+	}
+
+	return filepath.Join(f.DataDir, discoveryCacheFileName)
+}
+
+// CachedInterpreters returns interpreters from the discovery cache (nil if there isn't valid cache)
+func (f *InterpreterFinder) CachedInterpreters() []configurator.Interpreter {
+	path := f.discoveryCachePath()
+	if path == "" {
+		return nil
+	}
+
+	data, e := ioutil.ReadFile(path)
+	if e != nil {
+		return nil
+	}
+
+	var cache discoveryCache
+	if json.Unmarshal(data, &cache) != nil || len(cache.Interpreters) == 0 {
+		return nil
+	}
+
+	for _, interpreter := range cache.Interpreters {
+		if !commandExists(interpreter.Command) {
+			f.ClearCache()
+			return nil
+		}
+	}
+
+	return cache.Interpreters
+}
+
+// ClearCache removes discovery cache
+func (f *InterpreterFinder) ClearCache() error {
+	path := f.discoveryCachePath()
+	if path == "" || !utils.PathExist(path) {
+		return nil
+	}
+
+	return os.Remove(path)
+}
+
+func (f *InterpreterFinder) saveCache(interpreters []configurator.Interpreter) error {
+	path := f.discoveryCachePath()
+	if path == "" {
+		return nil
+	}
+
+	data, e := json.MarshalIndent(discoveryCache{Interpreters: interpreters}, "", "  ")
+	if e != nil {
+		return e
+	}
+
+	e = os.MkdirAll(f.DataDir, os.ModePerm)
+	if e != nil {
+		return e
+	}
+
+	return ioutil.WriteFile(path, data, 0644)
+}
+
+// cacheFound adds interpreter which has been found by Find to the discovery cache
+func (f *InterpreterFinder) cacheFound(source, command, version string) error {
+	interpreters := f.CachedInterpreters()
+	for _, interpreter := range interpreters {
+		if interpreter.Command == command {
+			return nil
+		}
+	}
+
+	name, ok := interpreterNames[source]
+	if !ok {
+		name = "system"
+	}
+
+	return f.saveCache(append(interpreters, configurator.Interpreter{
+		Name:    uniqueInterpreterName(interpreters, name),
+		Command: command,
+		Version: version,
+	}))
+}
+
+// findCached returns cached external (not built-in) interpreter command
+func (f *InterpreterFinder) findCached() string {
+	builtIn := f.FindBuiltIn()
+	if builtIn != "" {
+		builtIn = configurator.ExpandInterpreterCommand(builtIn)
+	}
+
+	for _, interpreter := range f.CachedInterpreters() {
+		if interpreter.Command != builtIn {
+			return interpreter.Command
+		}
+	}
+
+	return ""
+}
+
+// commandExists checks that interpreter file or executable of the command exists.
+// Flatpak application of the "flatpak run" command has to be installed too.
+func commandExists(command string) bool {
+	if path := commandFilePath(command); path != "" {
+		return utils.PathExist(path)
+	}
+
+	if IsAppBundle(command) {
+		return true
+	}
+
+	parts := SplitCommand(command)
+	if len(parts) == 0 {
+		return false
+	}
+
+	_, e := exec.LookPath(parts[0])
+	if e != nil {
+		return false
+	}
+
+	if appID := flatpakAppID(parts); appID != "" {
+		return exec.Command(parts[0], "info", appID).Run() == nil
+	}
+
+	return true
+}
+
+// flatpakAppID returns application ID of the "flatpak run" command (empty string for the other commands)
+func flatpakAppID(parts []string) string {
+	if len(parts) < 2 || strings.TrimSuffix(filepath.Base(parts[0]), ".exe") != "flatpak" || parts[1] != "run" {
+		return ""
+	}
+
+	for _, part := range parts[2:] {
+		if IsCommandTemplate(part) {
+			return ""
+		}
+		if !strings.HasPrefix(part, "-") {
+			return part
+		}
+	}
+
+	return ""
+}
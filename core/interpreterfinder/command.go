@@ -0,0 +1,150 @@
+package interpreterfinder
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/jhekasoft/insteadman3/core/utils"
+)
+
+// Interpreter command can be:
+//   - path of the executable;
+//   - command with arguments (like "flatpak run io.github.instead_hub.instead"), quotes are supported;
+//   - macOS application bundle (like /Applications/Instead.app). The executable inside Contents/MacOS is run,
+//     "open -a" is used if the executable can't be found;
+//   - template with placeholders (see template.go).
+
+const appBundleExt = ".app"
+
+var bundleExecutableRegexp = regexp.MustCompile(`<key>CFBundleExecutable</key>\s*<string>([^<]+)</string>`)
+
+// IsAppBundle checks that path is a macOS application bundle
+func IsAppBundle(path string) bool {
+	return strings.HasSuffix(strings.ToLower(filepath.Clean(path)), appBundleExt) && utils.PathExist(path)
+}
+
+// AppBundleExecutable returns path of the executable inside application bundle
+// (empty string if it isn't bundle or executable hasn't found)
+func AppBundleExecutable(bundlePath string) string {
+	if !IsAppBundle(bundlePath) {
+		return ""
+	}
+
+	macOSDir := filepath.Join(bundlePath, "Contents", "MacOS")
+	names := []string{"sdl-instead"}
+
+	plist, e := ioutil.ReadFile(filepath.Join(bundlePath, "Contents", "Info.plist"))
+	if e == nil {
+		if matches := bundleExecutableRegexp.FindSubmatch(plist); matches != nil {
+			names = append([]string{strings.TrimSpace(string(matches[1]))}, names...)
+		}
+	}
+
+	for _, name := range names {
+		path := filepath.Join(macOSDir, name)
+		if utils.PathExist(path) {
+			return path
+		}
+	}
+
+	return ""
+}
+
+// SplitCommand splits interpreter command to the executable and arguments.
+// Existing path is never split (it can contain spaces like "C:\Program Files\INSTEAD\sdl-instead.exe").
+func SplitCommand(command string) []string {
+	if command == "" || utils.PathExist(command) {
+		return []string{command}
+	}
+
+	var (
+		parts   []string
+		current strings.Builder
+		quote   rune
+		inPart  bool
+	)
+
+	for _, r := range command {
+		switch {
+		case quote != 0 && r == quote:
+			quote = 0
+		case quote != 0:
+			current.WriteRune(r)
+		case r == '"' || r == '\'':
+			quote = r
+			inPart = true
+		case r == ' ' || r == '\t':
+			if inPart {
+				parts = append(parts, current.String())
+				current.Reset()
+				inPart = false
+			}
+		default:
+			current.WriteRune(r)
+			inPart = true
+		}
+	}
+
+	if inPart {
+		parts = append(parts, current.String())
+	}
+
+	return parts
+}
+
+// Command returns command for running interpreter (see interpreter command kinds above)
+func Command(interpreterCommand string, args ...string) *exec.Cmd {
+	if !IsAppBundle(interpreterCommand) {
+		parts := SplitCommand(interpreterCommand)
+		if IsCommandTemplate(interpreterCommand) {
+			// Templated command is run without game placeholders
+			parts = commandTemplatePrefix(interpreterCommand)
+		}
+		if len(parts) == 0 {
+			parts = []string{interpreterCommand}
+		}
+
+		return exec.Command(parts[0], append(parts[1:], args...)...)
+	}
+
+	if executable := AppBundleExecutable(interpreterCommand); executable != "" {
+		return exec.Command(executable, args...)
+	}
+
+	// -W waits for the application exit like for the usual command
+	openArgs := append([]string{"-W", "-a", interpreterCommand, "--args"}, args...)
+	return exec.Command("open", openArgs...)
+}
+
+// CombinedOutputContext runs command like exec.Cmd.CombinedOutput, the process (with its children)
+// is killed when context is done.
+func CombinedOutputContext(ctx context.Context, cmd *exec.Cmd) ([]byte, error) {
+	var out bytes.Buffer
+	cmd.Stdout = &out
+	cmd.Stderr = &out
+	setProcessGroup(cmd)
+
+	e := cmd.Start()
+	if e != nil {
+		return nil, e
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- cmd.Wait()
+	}()
+
+	select {
+	case e = <-done:
+		return out.Bytes(), e
+	case <-ctx.Done():
+		// Don't wait for the process: children can keep output open
+		killProcessGroup(cmd)
+		return nil, ctx.Err()
+	}
+}
@@ -0,0 +1,96 @@
+package interpreterfinder
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAppBundle(t *testing.T) {
+	dir, e := ioutil.TempDir("", "insteadman")
+	assert.NoError(t, e)
+	defer os.RemoveAll(dir)
+
+	bundlePath := filepath.Join(dir, "Instead.app")
+	macOSDir := filepath.Join(bundlePath, "Contents", "MacOS")
+	assert.NoError(t, os.MkdirAll(macOSDir, os.ModePerm))
+
+	// There isn't executable, so "open -a" is used
+	assert.True(t, IsAppBundle(bundlePath))
+	assert.Equal(t, "", AppBundleExecutable(bundlePath))
+	cmd := Command(bundlePath, "-version")
+	assert.Equal(t, []string{"open", "-W", "-a", bundlePath, "--args", "-version"}, cmd.Args)
+
+	// Executable is taken from Info.plist
+	plist := "<plist><dict><key>CFBundleExecutable</key>\n<string>instead-launcher</string></dict></plist>"
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(bundlePath, "Contents", "Info.plist"), []byte(plist), 0644))
+	executablePath := filepath.Join(macOSDir, "instead-launcher")
+	assert.NoError(t, ioutil.WriteFile(executablePath, []byte{}, 0755))
+
+	assert.Equal(t, executablePath, AppBundleExecutable(bundlePath))
+	cmd = Command(bundlePath, "-version")
+	assert.Equal(t, []string{executablePath, "-version"}, cmd.Args)
+
+	// Usual command
+	assert.False(t, IsAppBundle(executablePath))
+	assert.Equal(t, []string{"sdl-instead", "-version"}, Command("sdl-instead", "-version").Args)
+}
+
+func TestSplitCommand(t *testing.T) {
+	commands := map[string][]string{
+		"/usr/bin/sdl-instead":                               {"/usr/bin/sdl-instead"},
+		"flatpak run io.github.instead_hub.instead":          {"flatpak", "run", "io.github.instead_hub.instead"},
+		`"/opt/my games/sdl-instead"  -nosound`:              {"/opt/my games/sdl-instead", "-nosound"},
+		`env LANG=ru_RU.UTF-8 '/opt/instead/sdl-instead' ""`: {"env", "LANG=ru_RU.UTF-8", "/opt/instead/sdl-instead", ""},
+	}
+
+	for command, mustBeParts := range commands {
+		assert.Equal(t, mustBeParts, SplitCommand(command))
+	}
+
+	assert.Equal(t, []string{"flatpak", "run", "app", "-version"}, Command("flatpak run app", "-version").Args)
+}
+
+func TestExpandCommandTemplate(t *testing.T) {
+	data := CommandData{
+		GameName:  "crossworlds",
+		GamePath:  "/home/user/my games/crossworlds",
+		GamesPath: "/home/user/my games",
+		Args:      CommandArgs{"-gamespath", "/home/user/my games", "-game", "crossworlds"},
+		ExtraArgs: CommandArgs{"-nosound", "-window"},
+	}
+
+	commands := map[string][]string{
+		"flatpak run org.instead.Instead -game {{.GamePath}} {{.ExtraArgs}}": {
+			"flatpak", "run", "org.instead.Instead", "-game", "/home/user/my games/crossworlds", "-nosound", "-window",
+		},
+		"sdl-instead {{ .Args }} -appdata {{.GamesPath}}/{{.GameName}}.saves {{.AppDataPath}}": {
+			"sdl-instead", "-gamespath", "/home/user/my games", "-game", "crossworlds",
+			"-appdata", "/home/user/my games/crossworlds.saves",
+		},
+		`sh -c "echo {{.ExtraArgs}}"`: {"sh", "-c", "echo -nosound -window"},
+	}
+
+	for command, mustBeParts := range commands {
+		assert.True(t, IsCommandTemplate(command))
+		parts, e := ExpandCommandTemplate(command, data)
+		assert.NoError(t, e)
+		assert.Equal(t, mustBeParts, parts)
+	}
+
+	_, e := ExpandCommandTemplate("sdl-instead {{.Unknown}}", data)
+	assert.Error(t, e)
+	_, e = ExpandCommandTemplate("{{.AppDataPath}}", data)
+	assert.Equal(t, ErrEmptyCommand, e)
+
+	// Command is run without game placeholders for checking
+	assert.Equal(t, []string{"flatpak", "run", "org.instead.Instead", "-version"},
+		Command("flatpak run org.instead.Instead -game {{.GamePath}}", "-version").Args)
+	assert.Equal(t, []string{"flatpak", "run", "--branch=stable", "org.instead.Instead", "-version"},
+		Command("flatpak run --branch=stable org.instead.Instead {{.GamePath}}", "-version").Args)
+	assert.Equal(t, []string{"sdl-instead", "-nosound", "-version"},
+		Command("sdl-instead -nosound {{.Args}}", "-version").Args)
+}
@@ -1,10 +1,14 @@
 package interpreterfinder
 
 import (
+	"bytes"
+	"context"
+	"errors"
 	"os"
-	"os/exec"
 	"path/filepath"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/jhekasoft/insteadman3/core/configurator"
 )
@@ -13,53 +17,208 @@ import (
 type InterpreterFinder struct {
 	// CurrentDir is a currently directory of the executable file
 	CurrentDir string
+	// DataDir is an InsteadMan data directory where INSTEAD can be installed (see interpreterinstaller)
+	DataDir string
+	// Strategies are detection methods (DefaultStrategies of the platform if it's nil)
+	Strategies []Strategy
+}
+
+// BuiltinPath returns path of the built-in INSTEAD inside the directory
+func BuiltinPath(dir string) string {
+	return filepath.Join(dir, builtinRelativeFilePath)
 }
 
 // HaveBuiltIn checks is there is built-in INSTEAD with InsteadMan
 func (f *InterpreterFinder) HaveBuiltIn() bool {
-	_, e := os.Stat(filepath.Join(f.CurrentDir, builtinRelativeFilePath))
-	exists := !os.IsNotExist(e)
+	return f.FindBuiltIn() != ""
+}
 
-	if exists && e == nil {
-		return true
+// FindBuiltIn returns built-in INSTEAD interpreter path (INSTEAD near the executable or installed to the data dir)
+func (f *InterpreterFinder) FindBuiltIn() string {
+	dirs := []string{f.CurrentDir}
+	if f.DataDir != "" {
+		dirs = append(dirs, f.DataDir)
 	}
 
-	return false
+	for _, dir := range dirs {
+		path := BuiltinPath(dir)
+		if _, e := os.Stat(path); e == nil {
+			return path
+		}
+	}
+
+	return ""
 }
 
-// FindBuiltIn returns built-in INSTEAD interpreter path
-func (f *InterpreterFinder) FindBuiltIn() (path string) {
-	if f.HaveBuiltIn() {
-		path = filepath.Join(f.CurrentDir, builtinRelativeFilePath)
+func (f *InterpreterFinder) strategies() []Strategy {
+	if f.Strategies != nil {
+		return f.Strategies
 	}
+
+	return DefaultStrategies()
+}
+
+// FindCandidates returns interpreter candidates of all the strategies (they aren't checked)
+func (f *InterpreterFinder) FindCandidates() (candidates []Candidate) {
+	for _, strategy := range f.strategies() {
+		candidates = append(candidates, strategy.Candidates(f)...)
+	}
+
 	return
 }
 
-// Find finds INSTEAD interpreter in the filesystem
+// Find finds external INSTEAD interpreter in the discovery cache or by the strategies (built-in isn't returned)
 func (f *InterpreterFinder) Find() *string {
-	// External interpreter
-	for _, path := range exactFilePaths() {
-		_, e := os.Stat(path)
-		exists := !os.IsNotExist(e)
+	if command := f.findCached(); command != "" {
+		return &command
+	}
+
+	for _, strategy := range f.strategies() {
+		if strategy.Source() == SourceBuiltin {
+			continue
+		}
+
+		for _, candidate := range strategy.Candidates(f) {
+			version := ""
+			if candidate.NeedsCheck {
+				var e error
+				if version, e = f.Check(candidate.Command); e != nil {
+					continue
+				}
+			}
 
-		if exists && e == nil {
-			return &path
+			command := candidate.Command
+			f.cacheFound(candidate.Source, command, version)
+			return &command
 		}
 	}
 
 	return nil
 }
 
+// interpreterNames are names of the found interpreters by the candidate source ("system" by default)
+var interpreterNames = map[string]string{
+	SourceBuiltin: "built-in",
+	SourceFlatpak: "flatpak",
+	SourceSnap:    "snap",
+}
+
+// FindAll finds all the checked INSTEAD interpreters of all the strategies.
+// Found interpreters are saved to the discovery cache.
+func (f *InterpreterFinder) FindAll() (interpreters []configurator.Interpreter) {
+	defer func() {
+		f.saveCache(interpreters)
+	}()
+
+	var resolvedCommands []string
+	add := func(name, command string) {
+		resolved := command
+		if path, e := filepath.EvalSymlinks(command); e == nil {
+			resolved = path
+		}
+		for _, c := range resolvedCommands {
+			if c == resolved {
+				return
+			}
+		}
+
+		version, e := f.Check(command)
+		if e != nil {
+			return
+		}
+
+		resolvedCommands = append(resolvedCommands, resolved)
+		interpreters = append(interpreters, configurator.Interpreter{
+			Name:    uniqueInterpreterName(interpreters, name),
+			Command: command,
+			Version: version,
+		})
+	}
+
+	for _, candidate := range f.FindCandidates() {
+		name, ok := interpreterNames[candidate.Source]
+		if !ok {
+			name = "system"
+		}
+
+		command := candidate.Command
+		if candidate.Source == SourceBuiltin {
+			command = configurator.ExpandInterpreterCommand(command)
+		}
+
+		add(name, command)
+	}
+
+	return
+}
+
+func uniqueInterpreterName(interpreters []configurator.Interpreter, name string) string {
+	uniqueName := name
+	for n := 2; ; n++ {
+		exists := false
+		for _, interpreter := range interpreters {
+			if interpreter.Name == uniqueName {
+				exists = true
+				break
+			}
+		}
+		if !exists {
+			return uniqueName
+		}
+
+		uniqueName = name + "-" + strconv.Itoa(n)
+	}
+}
+
+// CheckTimeout is a default timeout of the INSTEAD interpreter check
+const CheckTimeout = 10 * time.Second
+
+// ErrCheckTimeout is returned when INSTEAD interpreter hasn't answered in time (broken wrapper script etc.)
+var ErrCheckTimeout = errors.New("INSTEAD check has timed out")
+
 // Check checks the INSTEAD interpreter and returns version of INSTEAS
 // If INSTEAD could not be found returns error
 func (f *InterpreterFinder) Check(command string) (version string, e error) {
-	out, e := exec.Command(configurator.ExpandInterpreterCommand(command), "-version").Output()
+	ctx, cancel := context.WithTimeout(context.Background(), CheckTimeout)
+	defer cancel()
+
+	return f.CheckContext(ctx, command)
+}
+
+// CheckContext checks the INSTEAD interpreter like Check. The interpreter process (with its children)
+// is killed when context is done.
+func (f *InterpreterFinder) CheckContext(ctx context.Context, command string) (version string, e error) {
+	var out bytes.Buffer
+	cmd := Command(configurator.ExpandInterpreterCommand(command), "-version")
+	cmd.Stdout = &out
+	setProcessGroup(cmd)
+
+	e = cmd.Start()
 	if e != nil {
 		return "", e
 	}
 
+	done := make(chan error, 1)
+	go func() {
+		done <- cmd.Wait()
+	}()
+
+	select {
+	case e = <-done:
+		if e != nil {
+			return "", e
+		}
+	case <-ctx.Done():
+		// Don't wait for the process: children can keep output open
+		killProcessGroup(cmd)
+		if ctx.Err() == context.DeadlineExceeded {
+			return "", ErrCheckTimeout
+		}
+		return "", ctx.Err()
+	}
+
 	replacer := strings.NewReplacer("\n", "", "\r", "")
-	version = replacer.Replace(string(out))
+	version = replacer.Replace(out.String())
 
 	return
 }
@@ -2,23 +2,31 @@
 
 package interpreterfinder
 
-import (
-	"os/exec"
-	"strings"
-)
-
 const builtinRelativeFilePath = "instead/sdl-instead"
 
-func exactFilePaths() []string {
-	interpreterCommand := "instead"
-
-	out, e := exec.Command("which", interpreterCommand).Output()
-
-	if e != nil {
-		return []string{}
+func platformStrategies() []Strategy {
+	return []Strategy{
+		exactPathsStrategy{source: SourceExact, paths: exactFilePaths},
+		snapStrategy{dirs: []string{"/snap/bin", "/var/lib/snapd/snap/bin"}, names: []string{"instead"}},
+		flatpakStrategy{},
 	}
+}
 
-	path := strings.Replace(string(out), "\n", "", -1)
+func exactFilePaths() []string {
+	return []string{
+		// Debian-based distributions install games outside of the user's PATH
+		"/usr/games/sdl-instead",
+		"/usr/local/games/sdl-instead",
+	}
+}
 
-	return []string{path}
+func packageManagers() []packageManager {
+	return []packageManager{
+		{"apt", "apt-cache", []string{"show", "instead"}, []string{"sudo", "apt", "install", "instead"}},
+		{"dnf", "dnf", []string{"--cacheonly", "info", "instead"}, []string{"sudo", "dnf", "install", "instead"}},
+		{"pacman", "pacman", []string{"-Si", "instead"}, []string{"sudo", "pacman", "-S", "instead"}},
+		{"zypper", "zypper", []string{"--non-interactive", "search", "--match-exact", "instead"},
+			[]string{"sudo", "zypper", "install", "instead"}},
+		{"pkg", "pkg", []string{"rquery", "%n", "instead"}, []string{"sudo", "pkg", "install", "instead"}},
+	}
 }
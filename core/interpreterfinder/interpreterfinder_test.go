@@ -1,9 +1,19 @@
 package interpreterfinder
 
 import (
-	"github.com/stretchr/testify/assert"
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"regexp"
+	"runtime"
+	"strings"
 	"testing"
+	"time"
+
+	"github.com/jhekasoft/insteadman3/core/configurator"
+	"github.com/jhekasoft/insteadman3/core/utils"
+	"github.com/stretchr/testify/assert"
 )
 
 func TestFindAndCheckInterpreter(t *testing.T) {
@@ -17,3 +27,183 @@ func TestFindAndCheckInterpreter(t *testing.T) {
 	assert.NoError(t, e)
 	assert.Regexp(t, regexp.MustCompile("^\\d+.\\d+.\\d+"), version) // like "3.2.0"
 }
+
+func TestFindInPath(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("shell script can't be used as executable on Windows")
+	}
+
+	dir, e := ioutil.TempDir("", "insteadman")
+	assert.NoError(t, e)
+	defer os.RemoveAll(dir)
+
+	// Alternative binary name is found
+	commandPath := filepath.Join(dir, "sdl-instead")
+	assert.NoError(t, ioutil.WriteFile(commandPath, []byte("#!/bin/sh\necho 3.3.0\n"), 0755))
+
+	defer os.Setenv("PATH", os.Getenv("PATH"))
+	os.Setenv("PATH", dir)
+
+	candidates := pathStrategy{names: []string{"instead", "sdl-instead"}}.Candidates(new(InterpreterFinder))
+	assert.Equal(t, []Candidate{{Command: commandPath, Source: SourcePath}}, candidates)
+
+	finder := new(InterpreterFinder)
+	interpreterPath := finder.Find()
+	assert.NotNil(t, interpreterPath)
+	assert.Equal(t, commandPath, *interpreterPath)
+
+	// Both names are found, built-in INSTEAD is the first
+	insteadPath := filepath.Join(dir, "instead")
+	assert.NoError(t, ioutil.WriteFile(insteadPath, []byte("#!/bin/sh\necho 3.2.0\n"), 0755))
+	assert.NoError(t, os.MkdirAll(filepath.Join(dir, "instead-dir", "instead"), os.ModePerm))
+	builtinPath := filepath.Join(dir, "instead-dir", builtinRelativeFilePath)
+	assert.NoError(t, ioutil.WriteFile(builtinPath, []byte("#!/bin/sh\necho 3.3.1\n"), 0755))
+
+	finder = &InterpreterFinder{CurrentDir: filepath.Join(dir, "instead-dir")}
+	interpreters := finder.FindAll()
+	assert.Len(t, interpreters, 3)
+	assert.Equal(t, "built-in", interpreters[0].Name)
+	assert.Equal(t, "3.3.1", interpreters[0].Version)
+	assert.Equal(t, "system", interpreters[1].Name)
+	assert.Equal(t, insteadPath, interpreters[1].Command)
+	assert.Equal(t, "system-2", interpreters[2].Name)
+	assert.Equal(t, "3.3.0", interpreters[2].Version)
+}
+
+func TestCheckTimeout(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("shell script can't be used as executable on Windows")
+	}
+
+	dir, e := ioutil.TempDir("", "insteadman")
+	assert.NoError(t, e)
+	defer os.RemoveAll(dir)
+
+	// Broken wrapper script which hangs
+	commandPath := filepath.Join(dir, "sdl-instead")
+	assert.NoError(t, ioutil.WriteFile(commandPath, []byte("#!/bin/sh\nsleep 30\necho 3.3.0\n"), 0755))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, e = new(InterpreterFinder).CheckContext(ctx, commandPath)
+	assert.Equal(t, ErrCheckTimeout, e)
+	assert.True(t, time.Since(start) < 5*time.Second)
+}
+
+func TestDiscoveryCache(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("shell script interpreter")
+	}
+
+	dir, e := ioutil.TempDir("", "insteadman")
+	assert.NoError(t, e)
+	defer os.RemoveAll(dir)
+
+	interpreterPath := filepath.Join(dir, "bin", "instead")
+	assert.NoError(t, os.MkdirAll(filepath.Dir(interpreterPath), os.ModePerm))
+	assert.NoError(t, ioutil.WriteFile(interpreterPath, []byte("#!/bin/sh\necho 3.3.0\n"), 0755))
+
+	defer os.Setenv("PATH", os.Getenv("PATH"))
+	os.Setenv("PATH", filepath.Dir(interpreterPath))
+
+	finder := InterpreterFinder{DataDir: filepath.Join(dir, "data")}
+	assert.Nil(t, finder.CachedInterpreters())
+
+	// Interpreter found by Find is cached
+	path := finder.Find()
+	assert.NotNil(t, path)
+	cached := finder.CachedInterpreters()
+	assert.Len(t, cached, 1)
+	assert.Equal(t, interpreterPath, cached[0].Command)
+
+	interpreters := finder.FindAll()
+	assert.NotEmpty(t, interpreters)
+	assert.Equal(t, interpreters, finder.CachedInterpreters())
+
+	// Cached interpreter is found without PATH
+	os.Setenv("PATH", "")
+	path = finder.Find()
+	assert.NotNil(t, path)
+	assert.Equal(t, interpreterPath, *path)
+
+	// Cache is invalidated when interpreter disappears
+	assert.NoError(t, os.Remove(interpreterPath))
+	assert.Nil(t, finder.CachedInterpreters())
+	assert.False(t, utils.PathExist(filepath.Join(finder.DataDir, discoveryCacheFileName)))
+}
+
+func TestDiscoveryCacheFlatpak(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("shell script flatpak")
+	}
+
+	dir, e := ioutil.TempDir("", "insteadman")
+	assert.NoError(t, e)
+	defer os.RemoveAll(dir)
+
+	// Fake flatpak knows only installed application
+	flatpakPath := filepath.Join(dir, "bin", "flatpak")
+	assert.NoError(t, os.MkdirAll(filepath.Dir(flatpakPath), os.ModePerm))
+	assert.NoError(t, ioutil.WriteFile(flatpakPath,
+		[]byte("#!/bin/sh\n[ \"$1\" = info ] && [ \"$2\" = org.instead.Instead ]\n"), 0755))
+
+	defer os.Setenv("PATH", os.Getenv("PATH"))
+	os.Setenv("PATH", filepath.Dir(flatpakPath))
+
+	finder := InterpreterFinder{DataDir: filepath.Join(dir, "data")}
+	interpreters := []configurator.Interpreter{
+		{Name: "flatpak", Command: "flatpak run --filesystem=home org.instead.Instead"},
+	}
+	assert.NoError(t, finder.saveCache(interpreters))
+	assert.Equal(t, interpreters, finder.CachedInterpreters())
+
+	// Cache is invalidated when flatpak application is removed, flatpak itself is still installed
+	interpreters[0].Command = "flatpak run --filesystem=home org.instead.Removed"
+	assert.NoError(t, finder.saveCache(interpreters))
+	assert.Nil(t, finder.CachedInterpreters())
+
+	assert.Equal(t, "org.instead.Instead", flatpakAppID([]string{"flatpak", "run", "--branch=stable", "org.instead.Instead"}))
+	assert.Equal(t, "", flatpakAppID([]string{"flatpak", "list"}))
+}
+
+func TestCheckInfoCached(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("shell script interpreter")
+	}
+
+	dir, e := ioutil.TempDir("", "insteadman")
+	assert.NoError(t, e)
+	defer os.RemoveAll(dir)
+
+	// Interpreter counts its runs
+	runsPath := filepath.Join(dir, "runs")
+	commandPath := filepath.Join(dir, "sdl-instead")
+	writeInterpreter := func(version string) {
+		script := "#!/bin/sh\necho run >> " + runsPath + "\necho " + version + "\n"
+		assert.NoError(t, ioutil.WriteFile(commandPath, []byte(script), 0755))
+	}
+	runs := func() int {
+		data, _ := ioutil.ReadFile(runsPath)
+		return strings.Count(string(data), "run")
+	}
+
+	writeInterpreter("3.3.0")
+	finder := new(InterpreterFinder)
+	for i := 0; i < 3; i++ {
+		info, e := finder.CheckInfoCached(commandPath)
+		assert.NoError(t, e)
+		assert.Equal(t, "3.3.0", info.Version.String())
+	}
+	assert.Equal(t, 1, runs())
+
+	// Updated interpreter is checked again
+	writeInterpreter("3.4.0")
+	future := time.Now().Add(time.Minute)
+	assert.NoError(t, os.Chtimes(commandPath, future, future))
+	info, e := finder.CheckInfoCached(commandPath)
+	assert.NoError(t, e)
+	assert.Equal(t, "3.4.0", info.Version.String())
+	assert.Equal(t, 2, runs())
+}
@@ -3,13 +3,42 @@
 package interpreterfinder
 
 import (
+	"os"
+	"path/filepath"
+	"strings"
 	"syscall"
+
+	"golang.org/x/sys/windows/registry"
+)
+
+const (
+	builtinRelativeFilePath = "instead\\sdl-instead.exe"
+	interpreterFileName     = "sdl-instead.exe"
+	uninstallKeyPath        = "SOFTWARE\\Microsoft\\Windows\\CurrentVersion\\Uninstall"
 )
 
-const builtinRelativeFilePath = "instead\\sdl-instead.exe"
+func platformStrategies() []Strategy {
+	return []Strategy{
+		exactPathsStrategy{source: SourceRegistry, paths: registryFilePaths},
+		exactPathsStrategy{source: SourceExact, paths: exactFilePaths},
+	}
+}
 
+// exactFilePaths returns paths in the standard Program Files locations and well-known paths on every drive
 func exactFilePaths() []string {
-	paths := []string{}
+	var paths []string
+	addPath := func(path string) {
+		for _, p := range paths {
+			if strings.EqualFold(p, path) {
+				return
+			}
+		}
+		paths = append(paths, path)
+	}
+
+	for _, path := range programFilesPaths() {
+		addPath(path)
+	}
 
 	for _, drive := range getDrives() {
 		drivePaths := []string{
@@ -18,12 +47,104 @@ func exactFilePaths() []string {
 			drive + ":\\Program Files (x86)\\INSTEAD\\sdl-instead.exe",
 			drive + ":\\Program Files\\INSTEAD\\sdl-instead.exe",
 		}
-		paths = append(paths, drivePaths...)
+		for _, path := range drivePaths {
+			addPath(path)
+		}
 	}
 
 	return paths
 }
 
+// registryFilePaths finds INSTEAD in the uninstall entries of the installer.
+// Both 64-bit and 32-bit registry views are checked (32-bit INSTEAD on 64-bit Windows).
+func registryFilePaths() (paths []string) {
+	roots := []registry.Key{registry.LOCAL_MACHINE, registry.CURRENT_USER}
+	views := []uint32{registry.WOW64_64KEY, registry.WOW64_32KEY}
+
+	for _, root := range roots {
+		for _, view := range views {
+			paths = append(paths, uninstallEntriesPaths(root, view)...)
+		}
+	}
+
+	return
+}
+
+func uninstallEntriesPaths(root registry.Key, view uint32) (paths []string) {
+	uninstallKey, e := registry.OpenKey(root, uninstallKeyPath, registry.ENUMERATE_SUB_KEYS|view)
+	if e != nil {
+		return
+	}
+	defer uninstallKey.Close()
+
+	names, e := uninstallKey.ReadSubKeyNames(-1)
+	if e != nil {
+		return
+	}
+
+	for _, name := range names {
+		key, e := registry.OpenKey(uninstallKey, name, registry.QUERY_VALUE|view)
+		if e != nil {
+			continue
+		}
+
+		displayName, _, _ := key.GetStringValue("DisplayName")
+		installLocation, _, _ := key.GetStringValue("InstallLocation")
+		displayIcon, _, _ := key.GetStringValue("DisplayIcon")
+		key.Close()
+
+		if path := uninstallEntryPath(displayName, installLocation, displayIcon); path != "" {
+			paths = append(paths, path)
+		}
+	}
+
+	return
+}
+
+// uninstallEntryPath returns interpreter path from the uninstall entry values if it's INSTEAD entry
+func uninstallEntryPath(displayName, installLocation, displayIcon string) string {
+	if !strings.Contains(strings.ToUpper(displayName), "INSTEAD") ||
+		strings.Contains(strings.ToUpper(displayName), "INSTEADMAN") {
+		return ""
+	}
+
+	if installLocation != "" {
+		return filepath.Join(strings.Trim(installLocation, "\""), interpreterFileName)
+	}
+
+	// DisplayIcon is like "C:\Program Files\INSTEAD\sdl-instead.exe,0"
+	if displayIcon != "" {
+		icon := strings.Trim(strings.Split(displayIcon, ",")[0], "\"")
+		return filepath.Join(filepath.Dir(icon), interpreterFileName)
+	}
+
+	return ""
+}
+
+// programFilesPaths returns paths in the Program Files directories (they can be on the any drive)
+func programFilesPaths() (paths []string) {
+	envs := []string{"ProgramFiles", "ProgramFiles(x86)", "ProgramW6432"}
+
+	var dirs []string
+	for _, env := range envs {
+		if dir := os.Getenv(env); dir != "" {
+			dirs = append(dirs, dir)
+		}
+	}
+	if dir := os.Getenv("LOCALAPPDATA"); dir != "" {
+		dirs = append(dirs, filepath.Join(dir, "Programs"))
+	}
+
+	for _, dir := range dirs {
+		paths = append(paths,
+			filepath.Join(dir, "INSTEAD", interpreterFileName),
+			filepath.Join(dir, "Games", "INSTEAD", interpreterFileName),
+		)
+	}
+
+	return
+}
+
 // https://stackoverflow.com/a/23135463
 func getDrives() []string {
 	kernel32, _ := syscall.LoadLibrary("kernel32.dll")
@@ -49,3 +170,10 @@ func bitsToDrives(bitMap uint32) (drives []string) {
 
 	return drives
 }
+
+func packageManagers() []packageManager {
+	return []packageManager{
+		{"scoop", "scoop", []string{"info", "instead"}, []string{"scoop", "install", "instead"}},
+		{"chocolatey", "choco", []string{"info", "instead", "--exact"}, []string{"choco", "install", "instead"}},
+	}
+}
@@ -3,27 +3,27 @@
 package interpreterfinder
 
 import (
-	"os/exec"
-	"strings"
+	"os"
+	"path/filepath"
 )
 
 const builtinRelativeFilePath = "sdl-instead"
 
-func exactFilePaths() []string {
-	// Add /Application path
+func platformStrategies() []Strategy {
+	// Application bundle in the /Applications or ~/Applications
 	// Can be installed by: "brew install caskroom/cask/instead"
-	paths := []string{"/Applications/Instead.app/Contents/MacOS/sdl-instead"}
-
-	// Add command line path
-	// Can be installed by: "brew search instead"
-	interpreterCommand := "instead"
-	out, e := exec.Command("which", interpreterCommand).Output()
-
-	if e != nil {
-		return paths
+	// Command line version ("brew search instead") is found in the PATH
+	return []Strategy{
+		bundleStrategy{
+			dirs:  []string{"/Applications", filepath.Join(os.Getenv("HOME"), "Applications")},
+			names: []string{"Instead.app", "INSTEAD.app"},
+		},
 	}
-	commandPath := strings.Replace(string(out), "\n", "", -1)
-	paths = append(paths, commandPath)
+}
 
-	return paths
+func packageManagers() []packageManager {
+	return []packageManager{
+		{"brew", "brew", []string{"info", "--cask", "instead"}, []string{"brew", "install", "--cask", "instead"}},
+		{"brew", "brew", []string{"info", "instead"}, []string{"brew", "install", "instead"}},
+	}
 }
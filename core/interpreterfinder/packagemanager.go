@@ -0,0 +1,56 @@
+package interpreterfinder
+
+import (
+	"context"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// probeTimeout is a timeout of the package availability check (package manager can update its indexes)
+const probeTimeout = 15 * time.Second
+
+// packageManager describes how to check that INSTEAD package is available and how to install it
+type packageManager struct {
+	name        string
+	executable  string
+	probeArgs   []string // command exits with 0 if the package is available
+	installArgs []string
+}
+
+// InstallSuggestion is a command which installs INSTEAD by the package manager
+type InstallSuggestion struct {
+	PackageManager string
+	Args           []string
+}
+
+// Command returns command line for printing
+func (s InstallSuggestion) Command() string {
+	return strings.Join(s.Args, " ")
+}
+
+// Cmd returns install command (it can ask for the password by sudo)
+func (s InstallSuggestion) Cmd() *exec.Cmd {
+	return exec.Command(s.Args[0], s.Args[1:]...)
+}
+
+// SuggestInstall finds package managers which can install INSTEAD.
+// It's used when interpreter hasn't found.
+func (f *InterpreterFinder) SuggestInstall(ctx context.Context) (suggestions []InstallSuggestion) {
+	for _, pm := range packageManagers() {
+		if _, e := exec.LookPath(pm.executable); e != nil {
+			continue
+		}
+
+		probeCtx, cancel := context.WithTimeout(ctx, probeTimeout)
+		e := exec.CommandContext(probeCtx, pm.executable, pm.probeArgs...).Run()
+		cancel()
+		if e != nil {
+			continue
+		}
+
+		suggestions = append(suggestions, InstallSuggestion{PackageManager: pm.name, Args: pm.installArgs})
+	}
+
+	return
+}
@@ -0,0 +1,34 @@
+package interpreterfinder
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSuggestInstall(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("package managers of Linux are used in the test")
+	}
+
+	dir, e := ioutil.TempDir("", "insteadman")
+	assert.NoError(t, e)
+	defer os.RemoveAll(dir)
+
+	// Package is available for apt, but isn't for pacman
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "apt-cache"), []byte("#!/bin/sh\nexit 0\n"), 0755))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "pacman"), []byte("#!/bin/sh\nexit 1\n"), 0755))
+
+	defer os.Setenv("PATH", os.Getenv("PATH"))
+	os.Setenv("PATH", dir)
+
+	suggestions := new(InterpreterFinder).SuggestInstall(context.Background())
+	assert.Len(t, suggestions, 1)
+	assert.Equal(t, "apt", suggestions[0].PackageManager)
+	assert.Equal(t, "sudo apt install instead", suggestions[0].Command())
+}
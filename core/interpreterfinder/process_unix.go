@@ -0,0 +1,23 @@
+// +build !windows
+
+package interpreterfinder
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// setProcessGroup runs command in the new process group for killing it with children (wrapper scripts)
+func setProcessGroup(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+}
+
+func killProcessGroup(cmd *exec.Cmd) {
+	if cmd.Process == nil {
+		return
+	}
+
+	if syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL) != nil {
+		cmd.Process.Kill()
+	}
+}
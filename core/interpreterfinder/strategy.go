@@ -0,0 +1,188 @@
+package interpreterfinder
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// Candidate sources (provenance of the found interpreter)
+const (
+	SourceBuiltin  = "builtin"
+	SourcePath     = "path"
+	SourceExact    = "exact"
+	SourceRegistry = "registry"
+	SourceFlatpak  = "flatpak"
+	SourceSnap     = "snap"
+	SourceBundle   = "bundle"
+)
+
+// Candidate is an interpreter command which is found by the strategy
+type Candidate struct {
+	Command string
+	Source  string
+	// NeedsCheck is true when existence of the command doesn't mean that INSTEAD is installed
+	// (it has to be checked by -version)
+	NeedsCheck bool
+}
+
+// Strategy finds interpreter candidates by one detection method
+type Strategy interface {
+	Source() string
+	Candidates(f *InterpreterFinder) []Candidate
+}
+
+// DefaultStrategies returns strategies of the platform in the order of priority
+func DefaultStrategies() []Strategy {
+	strategies := []Strategy{
+		builtinStrategy{},
+		pathStrategy{names: []string{"instead", "sdl-instead"}},
+	}
+
+	return append(strategies, platformStrategies()...)
+}
+
+// builtinStrategy finds INSTEAD near the executable or installed to the data dir
+type builtinStrategy struct{}
+
+func (s builtinStrategy) Source() string {
+	return SourceBuiltin
+}
+
+func (s builtinStrategy) Candidates(f *InterpreterFinder) (candidates []Candidate) {
+	if path := f.FindBuiltIn(); path != "" {
+		candidates = append(candidates, Candidate{Command: path, Source: SourceBuiltin})
+	}
+
+	return
+}
+
+// pathStrategy finds INSTEAD executables in the PATH
+type pathStrategy struct {
+	names []string
+}
+
+func (s pathStrategy) Source() string {
+	return SourcePath
+}
+
+func (s pathStrategy) Candidates(f *InterpreterFinder) (candidates []Candidate) {
+	for _, name := range s.names {
+		if path, e := exec.LookPath(name); e == nil {
+			candidates = append(candidates, Candidate{Command: path, Source: SourcePath})
+		}
+	}
+
+	return
+}
+
+// exactPathsStrategy returns existing paths from the list (well-known paths, registry entries)
+type exactPathsStrategy struct {
+	source string
+	paths  func() []string
+}
+
+func (s exactPathsStrategy) Source() string {
+	return s.source
+}
+
+func (s exactPathsStrategy) Candidates(f *InterpreterFinder) (candidates []Candidate) {
+	for _, path := range s.paths() {
+		if _, e := os.Stat(path); e == nil {
+			candidates = append(candidates, Candidate{Command: path, Source: s.source})
+		}
+	}
+
+	return
+}
+
+// snapStrategy finds INSTEAD which is installed by Snap. Snap bin dirs aren't always in the PATH,
+// so commands are looked up in them too.
+type snapStrategy struct {
+	dirs  []string
+	names []string
+}
+
+func (s snapStrategy) Source() string {
+	return SourceSnap
+}
+
+func (s snapStrategy) Candidates(f *InterpreterFinder) (candidates []Candidate) {
+	for _, dir := range s.dirs {
+		for _, name := range s.names {
+			if path, e := exec.LookPath(filepath.Join(dir, name)); e == nil {
+				candidates = append(candidates, Candidate{Command: path, Source: SourceSnap})
+			}
+		}
+	}
+
+	return
+}
+
+// flatpakStrategy returns commands for running INSTEAD which is installed by Flatpak.
+// Sandboxed INSTEAD needs access to the games in the home directory.
+type flatpakStrategy struct {
+	// listApps returns installed application IDs separated by whitespaces
+	listApps func() (string, error)
+}
+
+func (s flatpakStrategy) Source() string {
+	return SourceFlatpak
+}
+
+func (s flatpakStrategy) Candidates(f *InterpreterFinder) (candidates []Candidate) {
+	listApps := s.listApps
+	if listApps == nil {
+		listApps = listFlatpakApps
+	}
+
+	out, e := listApps()
+	if e != nil {
+		return
+	}
+
+	for _, appID := range strings.Fields(out) {
+		lowerAppID := strings.ToLower(appID)
+		if strings.Contains(lowerAppID, "instead") && !strings.Contains(lowerAppID, "insteadman") {
+			candidates = append(candidates, Candidate{
+				Command:    "flatpak run --filesystem=home " + appID,
+				Source:     SourceFlatpak,
+				NeedsCheck: true,
+			})
+		}
+	}
+
+	return
+}
+
+func listFlatpakApps() (string, error) {
+	out, e := exec.Command("flatpak", "list", "--app", "--columns=application").Output()
+	return string(out), e
+}
+
+// bundleStrategy finds macOS application bundles. The executable inside the bundle is returned
+// if it's found, otherwise bundle is run by "open -a".
+type bundleStrategy struct {
+	dirs  []string
+	names []string
+}
+
+func (s bundleStrategy) Source() string {
+	return SourceBundle
+}
+
+func (s bundleStrategy) Candidates(f *InterpreterFinder) (candidates []Candidate) {
+	for _, dir := range s.dirs {
+		for _, name := range s.names {
+			bundlePath := filepath.Join(dir, name)
+			if executable := AppBundleExecutable(bundlePath); executable != "" {
+				candidates = append(candidates, Candidate{Command: executable, Source: SourceBundle})
+			} else if IsAppBundle(bundlePath) {
+				candidates = append(candidates, Candidate{Command: bundlePath, Source: SourceBundle})
+			}
+		}
+	}
+
+	return
+}
@@ -0,0 +1,150 @@
+package interpreterfinder
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// stubStrategy returns predefined candidates
+type stubStrategy struct {
+	source     string
+	candidates []Candidate
+}
+
+func (s stubStrategy) Source() string {
+	return s.source
+}
+
+func (s stubStrategy) Candidates(f *InterpreterFinder) []Candidate {
+	return s.candidates
+}
+
+func TestBuiltinStrategy(t *testing.T) {
+	dir, e := ioutil.TempDir("", "insteadman")
+	assert.NoError(t, e)
+	defer os.RemoveAll(dir)
+
+	finder := &InterpreterFinder{CurrentDir: filepath.Join(dir, "app"), DataDir: filepath.Join(dir, "data")}
+	assert.Empty(t, builtinStrategy{}.Candidates(finder))
+
+	// Installed to the data dir
+	builtinPath := BuiltinPath(finder.DataDir)
+	assert.NoError(t, os.MkdirAll(filepath.Dir(builtinPath), os.ModePerm))
+	assert.NoError(t, ioutil.WriteFile(builtinPath, []byte{}, 0755))
+
+	assert.Equal(t, []Candidate{{Command: builtinPath, Source: SourceBuiltin}}, builtinStrategy{}.Candidates(finder))
+}
+
+func TestExactPathsStrategy(t *testing.T) {
+	dir, e := ioutil.TempDir("", "insteadman")
+	assert.NoError(t, e)
+	defer os.RemoveAll(dir)
+
+	existingPath := filepath.Join(dir, "sdl-instead")
+	assert.NoError(t, ioutil.WriteFile(existingPath, []byte{}, 0755))
+
+	strategy := exactPathsStrategy{source: SourceRegistry, paths: func() []string {
+		return []string{filepath.Join(dir, "instead"), existingPath}
+	}}
+
+	assert.Equal(t, SourceRegistry, strategy.Source())
+	assert.Equal(t, []Candidate{{Command: existingPath, Source: SourceRegistry}}, strategy.Candidates(nil))
+}
+
+func TestSnapStrategy(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("executable bit is used in the test")
+	}
+
+	dir, e := ioutil.TempDir("", "insteadman")
+	assert.NoError(t, e)
+	defer os.RemoveAll(dir)
+
+	// Not executable file isn't a command
+	assert.NoError(t, os.MkdirAll(filepath.Join(dir, "snapd"), os.ModePerm))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "snapd", "instead"), []byte{}, 0644))
+	executablePath := filepath.Join(dir, "instead")
+	assert.NoError(t, ioutil.WriteFile(executablePath, []byte{}, 0755))
+
+	strategy := snapStrategy{dirs: []string{filepath.Join(dir, "snapd"), dir}, names: []string{"instead"}}
+
+	assert.Equal(t, SourceSnap, strategy.Source())
+	assert.Equal(t, []Candidate{{Command: executablePath, Source: SourceSnap}}, strategy.Candidates(nil))
+}
+
+func TestFlatpakStrategy(t *testing.T) {
+	strategy := flatpakStrategy{listApps: func() (string, error) {
+		return "org.gnome.Maps\nio.github.instead_hub.instead\nio.github.jhekasoft.insteadman\n", nil
+	}}
+
+	assert.Equal(t, []Candidate{{
+		Command:    "flatpak run --filesystem=home io.github.instead_hub.instead",
+		Source:     SourceFlatpak,
+		NeedsCheck: true,
+	}}, strategy.Candidates(nil))
+
+	// Flatpak isn't installed
+	strategy = flatpakStrategy{listApps: func() (string, error) {
+		return "", errors.New("flatpak isn't found")
+	}}
+	assert.Empty(t, strategy.Candidates(nil))
+}
+
+func TestBundleStrategy(t *testing.T) {
+	dir, e := ioutil.TempDir("", "insteadman")
+	assert.NoError(t, e)
+	defer os.RemoveAll(dir)
+
+	bundlePath := filepath.Join(dir, "INSTEAD.app")
+	assert.NoError(t, os.MkdirAll(filepath.Join(bundlePath, "Contents", "MacOS"), os.ModePerm))
+
+	strategy := bundleStrategy{dirs: []string{dir, filepath.Join(dir, "none")}, names: []string{"Instead.app", "INSTEAD.app"}}
+	candidates := strategy.Candidates(nil)
+
+	// Bundle without executable is run by "open -a" (case-insensitive filesystem finds both names)
+	assert.NotEmpty(t, candidates)
+	assert.Equal(t, SourceBundle, candidates[0].Source)
+
+	executablePath := filepath.Join(bundlePath, "Contents", "MacOS", "sdl-instead")
+	assert.NoError(t, ioutil.WriteFile(executablePath, []byte{}, 0755))
+	candidates = strategy.Candidates(nil)
+	assert.NotEmpty(t, candidates)
+	assert.Equal(t, "sdl-instead", filepath.Base(candidates[0].Command))
+}
+
+func TestFindByStrategies(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("shell script interpreter")
+	}
+
+	dir, e := ioutil.TempDir("", "insteadman")
+	assert.NoError(t, e)
+	defer os.RemoveAll(dir)
+
+	interpreterPath := filepath.Join(dir, "sdl-instead")
+	assert.NoError(t, ioutil.WriteFile(interpreterPath, []byte("#!/bin/sh\necho 3.3.0\n"), 0755))
+
+	finder := &InterpreterFinder{Strategies: []Strategy{
+		stubStrategy{SourceBuiltin, []Candidate{{Command: interpreterPath, Source: SourceBuiltin}}},
+		// Command which has to be checked is skipped if it doesn't respond
+		stubStrategy{SourceFlatpak, []Candidate{{Command: filepath.Join(dir, "broken"), Source: SourceFlatpak, NeedsCheck: true}}},
+		stubStrategy{SourceExact, []Candidate{{Command: interpreterPath, Source: SourceExact}}},
+	}}
+
+	assert.Len(t, finder.FindCandidates(), 3)
+
+	// Built-in interpreter isn't returned by Find
+	path := finder.Find()
+	assert.NotNil(t, path)
+	assert.Equal(t, interpreterPath, *path)
+
+	interpreters := finder.FindAll()
+	assert.Len(t, interpreters, 1)
+	assert.Equal(t, "built-in", interpreters[0].Name)
+}
@@ -0,0 +1,130 @@
+package interpreterfinder
+
+import (
+	"bytes"
+	"errors"
+	"regexp"
+	"strconv"
+	"strings"
+	"text/template"
+)
+
+// Interpreter command can be a template (text/template) with placeholders, for example:
+// "flatpak run org.instead.Instead -game {{.GamePath}} {{.ExtraArgs}}". Template is split to the arguments
+// before expanding, so values with spaces are kept as one argument. Argument which is only a list
+// placeholder ({{.Args}} or {{.ExtraArgs}}) is expanded to several arguments, empty arguments are skipped.
+// Default INSTEAD arguments aren't added to the templated command, use {{.Args}} for them.
+
+var templateActionRegexp = regexp.MustCompile(`{{.*?}}`)
+
+// ErrEmptyCommand is returned when templated interpreter command is expanded to nothing
+var ErrEmptyCommand = errors.New("interpreter command is empty")
+
+// CommandArgs is a list of the arguments, it's printed separated by spaces inside the argument
+type CommandArgs []string
+
+func (a CommandArgs) String() string {
+	return strings.Join(a, " ")
+}
+
+// CommandData is data of the interpreter command template
+type CommandData struct {
+	GameName    string
+	GamePath    string
+	GamesPath   string
+	AppDataPath string
+	// Args are default INSTEAD arguments (-gamespath, -game, -appdata)
+	Args CommandArgs
+	// ExtraArgs are arguments from the game config
+	ExtraArgs CommandArgs
+}
+
+// IsCommandTemplate checks that interpreter command has template placeholders
+func IsCommandTemplate(command string) bool {
+	return strings.Contains(command, "{{")
+}
+
+// ExpandCommandTemplate returns executable and arguments of the templated interpreter command
+func ExpandCommandTemplate(command string, data CommandData) ([]string, error) {
+	var parts []string
+	for _, word := range splitTemplate(command) {
+		switch placeholder(word) {
+		case "{{.Args}}":
+			parts = append(parts, data.Args...)
+			continue
+		case "{{.ExtraArgs}}":
+			parts = append(parts, data.ExtraArgs...)
+			continue
+		}
+
+		tmpl, e := template.New("command").Option("missingkey=error").Parse(word)
+		if e != nil {
+			return nil, e
+		}
+
+		var out bytes.Buffer
+		e = tmpl.Execute(&out, data)
+		if e != nil {
+			return nil, e
+		}
+
+		if out.Len() > 0 {
+			parts = append(parts, out.String())
+		}
+	}
+
+	if len(parts) == 0 {
+		return nil, ErrEmptyCommand
+	}
+
+	return parts, nil
+}
+
+// commandTemplatePrefix returns arguments of the templated command before the first placeholder
+// (option of the value placeholder like "-game" in "-game {{.GamePath}}" is skipped too).
+// It's used when command is run without game (checking, installing).
+func commandTemplatePrefix(command string) []string {
+	var parts []string
+	for _, word := range splitTemplate(command) {
+		if IsCommandTemplate(word) {
+			n := len(parts)
+			if n > 1 && !isListPlaceholder(word) && strings.HasPrefix(parts[n-1], "-") &&
+				!strings.Contains(parts[n-1], "=") {
+				parts = parts[:n-1]
+			}
+			break
+		}
+		parts = append(parts, word)
+	}
+
+	return parts
+}
+
+// placeholder returns argument without spaces, so "{{ .Args }}" is the same as "{{.Args}}"
+func placeholder(word string) string {
+	return strings.Join(strings.Fields(word), "")
+}
+
+// isListPlaceholder checks that argument is only a list placeholder which is expanded to several arguments
+func isListPlaceholder(word string) bool {
+	p := placeholder(word)
+	return p == "{{.Args}}" || p == "{{.ExtraArgs}}"
+}
+
+// splitTemplate splits command to the arguments, spaces inside placeholders don't split them
+func splitTemplate(command string) []string {
+	var actions []string
+	masked := templateActionRegexp.ReplaceAllStringFunc(command, func(action string) string {
+		actions = append(actions, action)
+		return "\x00" + strconv.Itoa(len(actions)-1) + "\x00"
+	})
+
+	parts := SplitCommand(masked)
+	for i := range parts {
+		for n, action := range actions {
+			parts[i] = strings.Replace(parts[i], "\x00"+strconv.Itoa(n)+"\x00", action, 1)
+		}
+	}
+
+	return parts
+}
@@ -0,0 +1,196 @@
+package interpreterfinder
+
+import (
+	"debug/elf"
+	"debug/macho"
+	"debug/pe"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/jhekasoft/insteadman3/core/configurator"
+)
+
+// Validation errors, they are distinct for explaining what's wrong in the settings
+var (
+	ErrNotExist          = errors.New("INSTEAD file doesn't exist")
+	ErrNotExecutable     = errors.New("INSTEAD file isn't executable")
+	ErrWrongArchitecture = errors.New("INSTEAD is built for another architecture")
+	ErrNotRespondVersion = errors.New("INSTEAD doesn't respond to -version")
+)
+
+// Validate checks that interpreter can be used before saving it: file is executable, its architecture
+// matches the system and it responds to -version. Returns version of INSTEAD.
+// Commands with arguments and templates are checked only by -version.
+func (f *InterpreterFinder) Validate(command string) (version string, e error) {
+	if path := commandFilePath(command); path != "" {
+		e = validateFile(path)
+		if e != nil {
+			return "", e
+		}
+	}
+
+	version, e = f.Check(command)
+	if e == ErrCheckTimeout {
+		return "", e
+	}
+	if e != nil || version == "" {
+		return "", ErrNotRespondVersion
+	}
+
+	return version, nil
+}
+
+// commandFilePath returns path of the executable file of the interpreter command
+// (empty string if command isn't a path)
+func commandFilePath(command string) string {
+	command = configurator.ExpandInterpreterCommand(command)
+	if command == "" || IsCommandTemplate(command) {
+		return ""
+	}
+
+	if IsAppBundle(command) {
+		return AppBundleExecutable(command)
+	}
+
+	parts := SplitCommand(command)
+	if len(parts) != 1 {
+		return ""
+	}
+
+	if !strings.ContainsRune(parts[0], os.PathSeparator) && !strings.ContainsRune(parts[0], '/') {
+		// Command from the PATH
+		if path, e := exec.LookPath(parts[0]); e == nil {
+			return path
+		}
+	}
+
+	return parts[0]
+}
+
+func validateFile(path string) error {
+	info, e := os.Stat(path)
+	if os.IsNotExist(e) {
+		return ErrNotExist
+	}
+	if e != nil {
+		return e
+	}
+
+	if info.IsDir() || !isExecutable(path, info) {
+		return ErrNotExecutable
+	}
+
+	arch := binaryArchs(path)
+	if arch != nil && !archCompatible(arch, runtime.GOOS, hostArch()) {
+		return ErrWrongArchitecture
+	}
+
+	return nil
+}
+
+func isExecutable(path string, info os.FileInfo) bool {
+	if runtime.GOOS == "windows" {
+		switch strings.ToLower(filepath.Ext(path)) {
+		case ".exe", ".com", ".bat", ".cmd":
+			return true
+		}
+		return false
+	}
+
+	return info.Mode().Perm()&0111 != 0
+}
+
+// binaryArchs returns architectures (GOARCH names) of the ELF, PE or Mach-O binary.
+// Returns nil if it isn't binary (script) or architecture is unknown.
+func binaryArchs(path string) []string {
+	if f, e := elf.Open(path); e == nil {
+		defer f.Close()
+		return knownArch(elfArchs[f.Machine])
+	}
+
+	if f, e := pe.Open(path); e == nil {
+		defer f.Close()
+		return knownArch(peArchs[f.Machine])
+	}
+
+	if f, e := macho.Open(path); e == nil {
+		defer f.Close()
+		return knownArch(machoArchs[f.Cpu])
+	}
+
+	// Universal binary contains several architectures
+	if f, e := macho.OpenFat(path); e == nil {
+		defer f.Close()
+		var archs []string
+		for _, arch := range f.Arches {
+			if name := machoArchs[arch.Cpu]; name != "" {
+				archs = append(archs, name)
+			}
+		}
+		return archs
+	}
+
+	return nil
+}
+
+func knownArch(arch string) []string {
+	if arch == "" {
+		return nil
+	}
+
+	return []string{arch}
+}
+
+var elfArchs = map[elf.Machine]string{
+	elf.EM_386:     "386",
+	elf.EM_X86_64:  "amd64",
+	elf.EM_ARM:     "arm",
+	elf.EM_AARCH64: "arm64",
+}
+
+var peArchs = map[uint16]string{
+	pe.IMAGE_FILE_MACHINE_I386:  "386",
+	pe.IMAGE_FILE_MACHINE_AMD64: "amd64",
+	pe.IMAGE_FILE_MACHINE_ARMNT: "arm",
+	pe.IMAGE_FILE_MACHINE_ARM64: "arm64",
+}
+
+var machoArchs = map[macho.Cpu]string{
+	macho.Cpu386:   "386",
+	macho.CpuAmd64: "amd64",
+	macho.CpuArm:   "arm",
+	macho.CpuArm64: "arm64",
+}
+
+// hostArch returns architecture of the system (32-bit InsteadMan can be run on 64-bit Windows)
+func hostArch() string {
+	if runtime.GOOS == "windows" && strings.EqualFold(os.Getenv("PROCESSOR_ARCHITEW6432"), "AMD64") {
+		return "amd64"
+	}
+
+	return runtime.GOARCH
+}
+
+// archCompatible checks that one of the binary architectures can be run on the host:
+// 64-bit binary can't be run on 32-bit system, 32-bit x86 binary can be run on 64-bit system
+// except macOS (32-bit applications aren't supported since macOS 10.15), x86-64 binary is run
+// by Rosetta 2 on Apple silicon
+func archCompatible(binaryArchs []string, goos, host string) bool {
+	for _, arch := range binaryArchs {
+		if arch == host {
+			return true
+		}
+		if arch == "386" && host == "amd64" && goos != "darwin" {
+			return true
+		}
+		if arch == "amd64" && host == "arm64" && goos == "darwin" {
+			return true
+		}
+	}
+
+	return false
+}
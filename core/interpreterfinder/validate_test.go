@@ -0,0 +1,60 @@
+package interpreterfinder
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidate(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("shell script interpreter")
+	}
+
+	dir, e := ioutil.TempDir("", "insteadman")
+	assert.NoError(t, e)
+	defer os.RemoveAll(dir)
+
+	finder := new(InterpreterFinder)
+
+	_, e = finder.Validate(filepath.Join(dir, "sdl-instead"))
+	assert.Equal(t, ErrNotExist, e)
+
+	notExecutablePath := filepath.Join(dir, "instead.txt")
+	assert.NoError(t, ioutil.WriteFile(notExecutablePath, []byte("3.3.0\n"), 0644))
+	_, e = finder.Validate(notExecutablePath)
+	assert.Equal(t, ErrNotExecutable, e)
+
+	silentPath := filepath.Join(dir, "silent-instead")
+	assert.NoError(t, ioutil.WriteFile(silentPath, []byte("#!/bin/sh\nexit 1\n"), 0755))
+	_, e = finder.Validate(silentPath)
+	assert.Equal(t, ErrNotRespondVersion, e)
+
+	interpreterPath := filepath.Join(dir, "instead")
+	assert.NoError(t, ioutil.WriteFile(interpreterPath, []byte("#!/bin/sh\necho 3.3.0\n"), 0755))
+	version, e := finder.Validate(interpreterPath)
+	assert.NoError(t, e)
+	assert.Equal(t, "3.3.0", version)
+}
+
+func TestBinaryArchs(t *testing.T) {
+	// Test binary is built for the current architecture
+	executable, e := os.Executable()
+	assert.NoError(t, e)
+	assert.Equal(t, []string{runtime.GOARCH}, binaryArchs(executable))
+	assert.Nil(t, binaryArchs("validate_test.go"))
+
+	assert.True(t, archCompatible([]string{"amd64"}, "linux", "amd64"))
+	assert.True(t, archCompatible([]string{"arm64", "amd64"}, "linux", "amd64"))
+	assert.False(t, archCompatible([]string{"amd64"}, "linux", "386"))
+	assert.False(t, archCompatible([]string{"arm64"}, "linux", "amd64"))
+	assert.True(t, archCompatible([]string{"386"}, "windows", "amd64"))
+	assert.False(t, archCompatible([]string{"386"}, "darwin", "amd64"))
+	// Rosetta 2
+	assert.True(t, archCompatible([]string{"amd64"}, "darwin", "arm64"))
+	assert.False(t, archCompatible([]string{"amd64"}, "linux", "arm64"))
+}
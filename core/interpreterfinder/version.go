@@ -0,0 +1,143 @@
+package interpreterfinder
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Version is a parsed INSTEAD version (like "3.2.0")
+type Version struct {
+	Major int
+	Minor int
+	Patch int
+}
+
+// Capabilities are features of the INSTEAD interpreter which depend on its version
+type Capabilities struct {
+	// Stead2 is an old games API (main.lua), it's supported by all the versions
+	Stead2 bool
+	// Stead3 is a new games API (main3.lua), it's supported since INSTEAD 3.0.0
+	Stead3 bool
+	// Install is an "-install" argument for the games installing from the archive
+	Install bool
+}
+
+// Info is an information about checked INSTEAD interpreter
+type Info struct {
+	// RawVersion is an output of "instead -version"
+	RawVersion   string
+	Version      Version
+	Capabilities Capabilities
+}
+
+var (
+	versionRegexp = regexp.MustCompile(`(\d+)(?:\.(\d+))?(?:\.(\d+))?`)
+
+	stead3Version  = Version{Major: 3}
+	installVersion = Version{Major: 1, Minor: 9}
+)
+
+// ParseVersion parses version like "3.2.0", "2.4" or "INSTEAD 3.3.2-dev"
+func ParseVersion(str string) (v Version, e error) {
+	matches := versionRegexp.FindStringSubmatch(str)
+	if matches == nil {
+		return v, errors.New("invalid INSTEAD version: " + str)
+	}
+
+	v.Major, _ = strconv.Atoi(matches[1])
+	v.Minor, _ = strconv.Atoi(matches[2])
+	v.Patch, _ = strconv.Atoi(matches[3])
+
+	return
+}
+
+// Compare returns -1 if v is older than other, 1 if v is newer and 0 if versions are equal
+func (v Version) Compare(other Version) int {
+	for _, d := range []int{v.Major - other.Major, v.Minor - other.Minor, v.Patch - other.Patch} {
+		if d < 0 {
+			return -1
+		}
+		if d > 0 {
+			return 1
+		}
+	}
+
+	return 0
+}
+
+func (v Version) String() string {
+	return fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Patch)
+}
+
+// VersionCapabilities returns capabilities of the INSTEAD version
+func VersionCapabilities(v Version) Capabilities {
+	return Capabilities{
+		Stead2:  true,
+		Stead3:  v.Compare(stead3Version) >= 0,
+		Install: v.Compare(installVersion) >= 0,
+	}
+}
+
+// CheckInfo checks the INSTEAD interpreter and returns parsed version and capabilities
+func (f *InterpreterFinder) CheckInfo(command string) (*Info, error) {
+	rawVersion, e := f.Check(command)
+	if e != nil {
+		return nil, e
+	}
+
+	version, e := ParseVersion(rawVersion)
+	if e != nil {
+		return nil, e
+	}
+
+	return &Info{RawVersion: rawVersion, Version: version, Capabilities: VersionCapabilities(version)}, nil
+}
+
+// Checked interpreters are cached by the command and modification time of the interpreter file,
+// so "instead -version" isn't run before every game run and installing
+var (
+	infoCacheMutex sync.Mutex
+	infoCache      = make(map[string]cachedInfo)
+)
+
+type cachedInfo struct {
+	modTime time.Time
+	info    Info
+}
+
+// CheckInfoCached returns info like CheckInfo, it's cached while the interpreter file isn't changed.
+// Commands without the file (flatpak) aren't cached.
+func (f *InterpreterFinder) CheckInfoCached(command string) (*Info, error) {
+	path := commandFilePath(command)
+	if path == "" {
+		return f.CheckInfo(command)
+	}
+	stat, e := os.Stat(path)
+	if e != nil {
+		return f.CheckInfo(command)
+	}
+
+	key := command + "\x00" + path
+	infoCacheMutex.Lock()
+	cached, ok := infoCache[key]
+	infoCacheMutex.Unlock()
+	if ok && cached.modTime.Equal(stat.ModTime()) {
+		return &cached.info, nil
+	}
+
+	info, e := f.CheckInfo(command)
+	if e != nil {
+		return nil, e
+	}
+
+	infoCacheMutex.Lock()
+	infoCache[key] = cachedInfo{modTime: stat.ModTime(), info: *info}
+	infoCacheMutex.Unlock()
+
+	return info, nil
+}
@@ -0,0 +1,34 @@
+package interpreterfinder
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseVersion(t *testing.T) {
+	versions := map[string]Version{
+		"3.2.0":               {3, 2, 0},
+		"2.4":                 {2, 4, 0},
+		"INSTEAD 3.3.2-dev\n": {3, 3, 2},
+	}
+
+	for str, mustBeVersion := range versions {
+		v, e := ParseVersion(str)
+		assert.NoError(t, e)
+		assert.Equal(t, mustBeVersion, v)
+	}
+
+	_, e := ParseVersion("unknown")
+	assert.Error(t, e)
+}
+
+func TestVersionCapabilities(t *testing.T) {
+	assert.Equal(t, -1, Version{2, 4, 1}.Compare(Version{3, 0, 0}))
+	assert.Equal(t, 1, Version{3, 0, 1}.Compare(Version{3, 0, 0}))
+	assert.Equal(t, 0, Version{3, 2, 0}.Compare(Version{3, 2, 0}))
+
+	assert.Equal(t, Capabilities{Stead2: true, Stead3: false, Install: true}, VersionCapabilities(Version{2, 4, 1}))
+	assert.Equal(t, Capabilities{Stead2: true, Stead3: true, Install: true}, VersionCapabilities(Version{3, 2, 0}))
+	assert.False(t, VersionCapabilities(Version{1, 8, 0}).Install)
+}
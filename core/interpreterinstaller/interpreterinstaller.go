@@ -0,0 +1,399 @@
+package interpreterinstaller
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/jhekasoft/insteadman3/core/interpreterfinder"
+)
+
+const releasesURL = "https://api.github.com/repos/instead-hub/instead/releases/latest"
+
+// ErrUnsupportedPlatform is returned when there isn't prebuilt INSTEAD which can be installed for the platform
+var ErrUnsupportedPlatform = errors.New("there isn't prebuilt INSTEAD for the platform, please install it manually")
+
+// Architecture names in the release asset names
+var archNames = map[string][]string{
+	"amd64": {"x86_64", "amd64", "x64", "win64"},
+	"386":   {"i386", "i686", "win32"},
+	"arm64": {"aarch64", "arm64"},
+	"arm":   {"armhf", "armv7"},
+}
+
+// Installer downloads prebuilt INSTEAD from the official releases and installs it to the DataDir
+// (InsteadMan data directory), interpreterfinder.InterpreterFinder finds it as built-in one
+type Installer struct {
+	DataDir string
+	// ReleasesURL is a URL of the latest release in the GitHub API format (official INSTEAD releases by default)
+	ReleasesURL string
+	// GOOS and GOARCH are the current platform by default
+	GOOS   string
+	GOARCH string
+}
+
+// Release is an INSTEAD release
+type Release struct {
+	Version string  `json:"tag_name"`
+	Assets  []Asset `json:"assets"`
+}
+
+// Asset is a downloadable file of the release
+type Asset struct {
+	Name string `json:"name"`
+	Url  string `json:"browser_download_url"`
+	Size uint64 `json:"size"`
+}
+
+func (i *Installer) goos() string {
+	if i.GOOS != "" {
+		return i.GOOS
+	}
+	return runtime.GOOS
+}
+
+func (i *Installer) goarch() string {
+	if i.GOARCH != "" {
+		return i.GOARCH
+	}
+	return runtime.GOARCH
+}
+
+// LatestRelease returns the latest INSTEAD release
+func (i *Installer) LatestRelease() (*Release, error) {
+	url := i.ReleasesURL
+	if url == "" {
+		url = releasesURL
+	}
+
+	resp, e := http.Get(url)
+	if e != nil {
+		return nil, e
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.New("INSTEAD releases aren't available: " + resp.Status)
+	}
+
+	var release *Release
+	e = json.NewDecoder(resp.Body).Decode(&release)
+	if e != nil {
+		return nil, e
+	}
+
+	return release, nil
+}
+
+// FindAsset returns asset which can be installed on the platform:
+// zip archive on Windows and AppImage on Linux (macOS and other platforms aren't supported)
+func (i *Installer) FindAsset(release *Release) (*Asset, error) {
+	var suffix, platformName string
+	switch i.goos() {
+	case "windows":
+		suffix, platformName = ".zip", "win"
+	case "linux":
+		suffix = ".appimage"
+	default:
+		return nil, ErrUnsupportedPlatform
+	}
+
+	// 32-bit INSTEAD can be run on 64-bit Windows
+	archs := []string{i.goarch()}
+	if i.goos() == "windows" && i.goarch() == "amd64" {
+		archs = append(archs, "386")
+	}
+
+	var platformAssets []*Asset
+	for n := range release.Assets {
+		name := strings.ToLower(release.Assets[n].Name)
+		if strings.HasSuffix(name, suffix) && strings.Contains(name, platformName) {
+			platformAssets = append(platformAssets, &release.Assets[n])
+		}
+	}
+
+	for _, arch := range archs {
+		for _, asset := range platformAssets {
+			if containsAny(strings.ToLower(asset.Name), archNames[arch]) {
+				return asset, nil
+			}
+		}
+	}
+
+	// Asset without architecture in the name is usually built for x86
+	if i.goarch() == "amd64" || i.goarch() == "386" {
+		for _, asset := range platformAssets {
+			if !hasArchName(strings.ToLower(asset.Name)) {
+				return asset, nil
+			}
+		}
+	}
+
+	return nil, ErrUnsupportedPlatform
+}
+
+// Install downloads the latest INSTEAD and installs it. Returns path of the installed interpreter.
+// progressF is called with downloaded and total bytes.
+func (i *Installer) Install(progressF func(downloaded, total uint64)) (string, error) {
+	release, e := i.LatestRelease()
+	if e != nil {
+		return "", e
+	}
+
+	asset, e := i.FindAsset(release)
+	if e != nil {
+		return "", e
+	}
+
+	return i.InstallAsset(asset, progressF)
+}
+
+// InstallAsset downloads and installs the release asset
+func (i *Installer) InstallAsset(asset *Asset, progressF func(downloaded, total uint64)) (string, error) {
+	interpreterPath := interpreterfinder.BuiltinPath(i.DataDir)
+	installDir := filepath.Dir(interpreterPath)
+	if filepath.Clean(installDir) == filepath.Clean(i.DataDir) {
+		// Built-in INSTEAD isn't placed in the separate directory (macOS bundle)
+		return "", ErrUnsupportedPlatform
+	}
+
+	e := os.MkdirAll(i.DataDir, os.ModePerm)
+	if e != nil {
+		return "", e
+	}
+
+	tempFile, e := ioutil.TempFile(i.DataDir, "instead-download-")
+	if e != nil {
+		return "", e
+	}
+	defer os.Remove(tempFile.Name())
+
+	e = download(tempFile, asset, progressF)
+	if closeErr := tempFile.Close(); e == nil {
+		e = closeErr
+	}
+	if e != nil {
+		return "", e
+	}
+
+	// INSTEAD is installed next to the previous installation, which is replaced only after success
+	tempDir, e := ioutil.TempDir(filepath.Dir(installDir), "instead-install-")
+	if e != nil {
+		return "", e
+	}
+	defer os.RemoveAll(tempDir)
+
+	newDir := filepath.Join(tempDir, filepath.Base(installDir))
+	if strings.HasSuffix(strings.ToLower(asset.Name), ".zip") {
+		e = installZip(tempFile.Name(), newDir, filepath.Base(interpreterPath))
+	} else {
+		e = installExecutable(tempFile.Name(), filepath.Join(newDir, filepath.Base(interpreterPath)))
+	}
+	if e != nil {
+		return "", e
+	}
+
+	e = replaceDir(installDir, newDir, filepath.Join(tempDir, "previous"))
+	if e != nil {
+		return "", e
+	}
+
+	return interpreterPath, nil
+}
+
+// replaceDir moves newDir to the dir, previous dir is moved to the backupDir and it's restored if moving fails
+func replaceDir(dir, newDir, backupDir string) error {
+	e := os.Rename(dir, backupDir)
+	if e != nil && !os.IsNotExist(e) {
+		return e
+	}
+	hasBackup := e == nil
+
+	e = os.Rename(newDir, dir)
+	if e != nil && hasBackup {
+		os.Rename(backupDir, dir)
+	}
+
+	return e
+}
+
+func download(out io.Writer, asset *Asset, progressF func(downloaded, total uint64)) error {
+	resp, e := http.Get(asset.Url)
+	if e != nil {
+		return e
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return errors.New(asset.Name + " downloading failed: " + resp.Status)
+	}
+
+	total := asset.Size
+	if total == 0 && resp.ContentLength > 0 {
+		total = uint64(resp.ContentLength)
+	}
+
+	var downloaded uint64
+	buf := make([]byte, 32*1024)
+	for {
+		n, readErr := resp.Body.Read(buf)
+		if n > 0 {
+			if _, e := out.Write(buf[:n]); e != nil {
+				return e
+			}
+			downloaded += uint64(n)
+			if progressF != nil {
+				progressF(downloaded, total)
+			}
+		}
+
+		if readErr == io.EOF {
+			return nil
+		}
+		if readErr != nil {
+			return readErr
+		}
+	}
+}
+
+// installExecutable installs single-file INSTEAD (AppImage)
+func installExecutable(src, interpreterPath string) error {
+	e := os.MkdirAll(filepath.Dir(interpreterPath), os.ModePerm)
+	if e != nil {
+		return e
+	}
+
+	return copyFile(src, interpreterPath, 0755)
+}
+
+// installZip extracts archive and installs directory which contains interpreter executable
+func installZip(src, installDir, executableName string) error {
+	extractDir, e := ioutil.TempDir(filepath.Dir(installDir), "instead-extract-")
+	if e != nil {
+		return e
+	}
+	defer os.RemoveAll(extractDir)
+
+	e = extractZip(src, extractDir)
+	if e != nil {
+		return e
+	}
+
+	// Executable can be in the root of the archive or in the subdirectory
+	executableDir := ""
+	filepath.Walk(extractDir, func(path string, info os.FileInfo, e error) error {
+		if e == nil && executableDir == "" && !info.IsDir() && strings.EqualFold(info.Name(), executableName) {
+			executableDir = filepath.Dir(path)
+		}
+		return nil
+	})
+
+	if executableDir == "" {
+		return errors.New(executableName + " hasn't found in the archive")
+	}
+
+	return os.Rename(executableDir, installDir)
+}
+
+func extractZip(src, dir string) error {
+	r, e := zip.OpenReader(src)
+	if e != nil {
+		return e
+	}
+	defer r.Close()
+
+	for _, f := range r.File {
+		path := filepath.Join(dir, f.Name)
+		// Skip files which are outside of the directory ("../" in the name)
+		if !strings.HasPrefix(path, filepath.Clean(dir)+string(os.PathSeparator)) {
+			continue
+		}
+
+		if f.FileInfo().IsDir() {
+			e = os.MkdirAll(path, os.ModePerm)
+			if e != nil {
+				return e
+			}
+			continue
+		}
+
+		e = os.MkdirAll(filepath.Dir(path), os.ModePerm)
+		if e != nil {
+			return e
+		}
+
+		e = extractZipFile(f, path)
+		if e != nil {
+			return e
+		}
+	}
+
+	return nil
+}
+
+func extractZipFile(f *zip.File, path string) error {
+	in, e := f.Open()
+	if e != nil {
+		return e
+	}
+	defer in.Close()
+
+	out, e := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, f.Mode()|0600)
+	if e != nil {
+		return e
+	}
+
+	_, e = io.Copy(out, in)
+	if closeErr := out.Close(); e == nil {
+		e = closeErr
+	}
+
+	return e
+}
+
+func copyFile(src, dst string, perm os.FileMode) error {
+	in, e := os.Open(src)
+	if e != nil {
+		return e
+	}
+	defer in.Close()
+
+	out, e := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, perm)
+	if e != nil {
+		return e
+	}
+
+	_, e = io.Copy(out, in)
+	if closeErr := out.Close(); e == nil {
+		e = closeErr
+	}
+
+	return e
+}
+
+func hasArchName(name string) bool {
+	for _, names := range archNames {
+		if containsAny(name, names) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func containsAny(s string, substrings []string) bool {
+	for _, substring := range substrings {
+		if strings.Contains(s, substring) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,117 @@
+package interpreterinstaller
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/jhekasoft/insteadman3/core/interpreterfinder"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFindAsset(t *testing.T) {
+	release := &Release{Version: "3.3.2", Assets: []Asset{
+		{Name: "instead_3.3.2.tar.gz"},
+		{Name: "instead-3.3.2-win32.zip"},
+		{Name: "INSTEAD-3.3.2-aarch64.AppImage"},
+		{Name: "INSTEAD-3.3.2-x86_64.AppImage"},
+		{Name: "Instead-3.3.2.dmg"},
+	}}
+
+	platforms := []struct {
+		goos, goarch, assetName string
+	}{
+		{"windows", "386", "instead-3.3.2-win32.zip"},
+		{"windows", "amd64", "instead-3.3.2-win32.zip"},
+		{"linux", "amd64", "INSTEAD-3.3.2-x86_64.AppImage"},
+		{"linux", "arm64", "INSTEAD-3.3.2-aarch64.AppImage"},
+	}
+
+	for _, platform := range platforms {
+		installer := Installer{GOOS: platform.goos, GOARCH: platform.goarch}
+		asset, e := installer.FindAsset(release)
+		assert.NoError(t, e)
+		assert.Equal(t, platform.assetName, asset.Name)
+	}
+
+	installer := Installer{GOOS: "darwin", GOARCH: "amd64"}
+	_, e := installer.FindAsset(release)
+	assert.Equal(t, ErrUnsupportedPlatform, e)
+}
+
+func TestInstall(t *testing.T) {
+	if runtime.GOOS == "darwin" {
+		t.Skip("INSTEAD isn't installed on macOS")
+	}
+
+	// Archive with INSTEAD in the subdirectory
+	executableName := filepath.Base(interpreterfinder.BuiltinPath(""))
+	var archive bytes.Buffer
+	w := zip.NewWriter(&archive)
+	for name, content := range map[string]string{
+		"instead-3.3.2/" + executableName:      "instead",
+		"instead-3.3.2/stead/stead3/stead.lua": "stead",
+		"../outside.txt":                       "outside",
+	} {
+		f, e := w.Create(name)
+		assert.NoError(t, e)
+		f.Write([]byte(content))
+	}
+	assert.NoError(t, w.Close())
+
+	mux := http.NewServeMux()
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	mux.HandleFunc("/releases/latest", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(Release{Version: "3.3.2", Assets: []Asset{
+			{Name: "instead-3.3.2-win32.zip", Url: server.URL + "/instead.zip", Size: uint64(archive.Len())},
+		}})
+	})
+	archiveData := archive.Bytes()
+	mux.HandleFunc("/instead.zip", func(w http.ResponseWriter, r *http.Request) {
+		w.Write(archiveData)
+	})
+
+	dir, e := ioutil.TempDir("", "insteadman")
+	assert.NoError(t, e)
+	defer os.RemoveAll(dir)
+	dataDir := filepath.Join(dir, "data")
+
+	installer := Installer{DataDir: dataDir, ReleasesURL: server.URL + "/releases/latest", GOOS: "windows", GOARCH: "386"}
+
+	var downloaded, total uint64
+	path, e := installer.Install(func(d, t uint64) {
+		downloaded, total = d, t
+	})
+	assert.NoError(t, e)
+	assert.Equal(t, interpreterfinder.BuiltinPath(dataDir), path)
+	assert.Equal(t, uint64(archive.Len()), downloaded)
+	assert.Equal(t, total, downloaded)
+	assert.FileExists(t, filepath.Join(filepath.Dir(path), "stead", "stead3", "stead.lua"))
+	assert.False(t, fileExists(filepath.Join(dataDir, "outside.txt")))
+
+	finder := interpreterfinder.InterpreterFinder{DataDir: dataDir}
+	assert.Equal(t, path, finder.FindBuiltIn())
+
+	// Installed INSTEAD is kept if the new one hasn't installed
+	archiveData = []byte("broken")
+	_, e = installer.Install(nil)
+	assert.Error(t, e)
+	assert.FileExists(t, path)
+	files, e := ioutil.ReadDir(dataDir)
+	assert.NoError(t, e)
+	assert.Len(t, files, 1)
+}
+
+func fileExists(path string) bool {
+	_, e := os.Stat(path)
+	return e == nil
+}
@@ -0,0 +1,81 @@
+package manager
+
+import (
+	"archive/zip"
+	"context"
+	"errors"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// ErrNotGameArchive is returned when the file isn't .idf file or zip archive of the INSTEAD game
+var ErrNotGameArchive = errors.New("file isn't INSTEAD game archive")
+
+// gameMainFiles are files which are placed in the root directory of the INSTEAD game
+var gameMainFiles = []string{"main.lua", "main3.lua"}
+
+// GameArchiveName checks the local .idf file or zip archive (it's opened from the file manager) and returns
+// name of the game which is installed from it
+func GameArchiveName(fileName string) (string, error) {
+	switch strings.ToLower(filepath.Ext(fileName)) {
+	case ".idf":
+		return ArchiveGameName(fileName), nil
+	case ".zip":
+		return zipGameName(fileName)
+	}
+
+	return "", ErrNotGameArchive
+}
+
+// zipGameName returns game directory of the zip archive ("crossworlds/main.lua" is "crossworlds")
+func zipGameName(fileName string) (string, error) {
+	r, e := zip.OpenReader(fileName)
+	if e != nil {
+		return "", e
+	}
+	defer r.Close()
+
+	for _, f := range r.File {
+		dir, file := path.Split(f.Name)
+		if !isGameMainFile(file) {
+			continue
+		}
+
+		dir = strings.Trim(dir, "/")
+		if dir == "" {
+			return ArchiveGameName(fileName), nil
+		}
+		if !strings.Contains(dir, "/") {
+			return dir, nil
+		}
+	}
+
+	return "", ErrNotGameArchive
+}
+
+func isGameMainFile(file string) bool {
+	for _, mainFile := range gameMainFiles {
+		if file == mainFile {
+			return true
+		}
+	}
+
+	return false
+}
+
+// InstallGameFromFile installs the game from the local .idf file or zip archive. Use GameArchiveName
+// to show the game before installing.
+func (m *Manager) InstallGameFromFile(ctx context.Context, fileName string, phaseF func(InstallPhase)) error {
+	gameName, e := GameArchiveName(fileName)
+	if e != nil {
+		return e
+	}
+
+	e = m.installArchive(ctx, m.InterpreterCommand(), gameName, fileName, nil, phaseF)
+	if e == nil {
+		m.Publish(GameInstalled{Game: Game{Name: gameName}})
+	}
+
+	return e
+}
@@ -0,0 +1,19 @@
+package manager
+
+import (
+	"context"
+)
+
+// Backend changes games and repositories. It's implemented by Manager and by the client of the running
+// daemon (see core/server), so several front ends don't change the same files at the same time.
+type Backend interface {
+	UpdateRepositories() []error
+	InstallGameContext(ctx context.Context, game *Game, progressF func(uint64), phaseF func(InstallPhase)) error
+	UpdateGameContext(ctx context.Context, game *Game, progressF func(uint64), phaseF func(InstallPhase)) error
+	RemoveGame(game *Game) error
+	RunGame(game *Game) error
+	// Subscribe adds function which is called with the events of the changed games and repositories
+	Subscribe(f func(Event)) (unsubscribe func())
+}
+
+var _ Backend = (*Manager)(nil)
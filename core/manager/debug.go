@@ -0,0 +1,193 @@
+package manager
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+const (
+	debugLogsDirName = "debug_logs"
+	// interpreterDebugArg enables debug mode of INSTEAD (Lua errors and debug output)
+	interpreterDebugArg = "-debug"
+	maxLuaErrors        = 100
+)
+
+var luaErrorRegexp = regexp.MustCompile(`(?i)(\berror\b|\.lua:\d+:|^\[string ".*"\]:\d+:)`)
+
+// LuaError is an error which INSTEAD has printed, Traceback are lines of its "stack traceback"
+type LuaError struct {
+	Message   string
+	Traceback []string
+}
+
+// DebugReport is a summary of the debug session. Crashed is true if INSTEAD has exited with error,
+// output of INSTEAD is kept in LogFile (it can be attached to the bug report).
+type DebugReport struct {
+	LogFile  string
+	ExitCode int
+	Crashed  bool
+	Errors   []LuaError
+}
+
+// DebugLogsDir returns directory of the debug session logs
+func (m *Manager) DebugLogsDir() string {
+	return filepath.Join(m.Config().CalculatedInsteadManPath, debugLogsDirName)
+}
+
+// DebugGame runs the installed game in debug mode of INSTEAD and waits for exit.
+// INSTEAD output is written to out (it can be nil) and to the log of the session.
+func (m *Manager) DebugGame(game *Game, out io.Writer) (*DebugReport, error) {
+	runner, e := m.GameRunner(game.Name)
+	if e != nil {
+		return nil, e
+	}
+	execRunner, ok := runner.(*ExecRunner)
+	if !ok {
+		return nil, ErrNoInterpreter
+	}
+
+	cmd, e := execRunner.command(game, interpreterDebugArg)
+	if e != nil {
+		return nil, e
+	}
+
+	return m.debugSession(game.Name, cmd, out)
+}
+
+// DebugGameDir runs the game from the directory (game in development) like DebugGame
+func (m *Manager) DebugGameDir(dir string, out io.Writer) (*DebugReport, error) {
+	cmd, e := m.gameDirCommand(dir, interpreterDebugArg)
+	if e != nil {
+		return nil, e
+	}
+
+	absDir, e := filepath.Abs(dir)
+	if e != nil {
+		return nil, e
+	}
+
+	return m.debugSession(filepath.Base(absDir), cmd, out)
+}
+
+func (m *Manager) debugSession(name string, cmd *exec.Cmd, out io.Writer) (*DebugReport, error) {
+	e := os.MkdirAll(m.DebugLogsDir(), os.ModePerm)
+	if e != nil {
+		return nil, e
+	}
+
+	now := time.Now()
+	report := &DebugReport{
+		LogFile: filepath.Join(m.DebugLogsDir(), name+"-"+now.Format("20060102-150405")+".log"),
+	}
+	logFile, e := os.Create(report.LogFile)
+	if e != nil {
+		return nil, e
+	}
+	defer logFile.Close()
+
+	fmt.Fprintf(logFile, "Date: %s\nCommand: %s\n\n", now.Format(time.RFC3339), strings.Join(cmd.Args, " "))
+
+	collector := &luaErrorsCollector{}
+	writers := []io.Writer{logFile, collector}
+	if out != nil {
+		writers = append(writers, out)
+	}
+	// The same writer for stdout and stderr keeps order of the lines
+	w := io.MultiWriter(writers...)
+	cmd.Stdout = w
+	cmd.Stderr = w
+
+	e = cmd.Start()
+	if e != nil {
+		return nil, e
+	}
+	m.CurrentRunningCmd = cmd
+
+	e = cmd.Wait()
+	if exitError, ok := e.(*exec.ExitError); ok {
+		report.ExitCode = exitError.ExitCode()
+		report.Crashed = true
+	} else if e != nil {
+		return nil, e
+	}
+
+	collector.flush()
+	report.Errors = collector.errors
+
+	fmt.Fprintf(logFile, "\nExit code: %d\n", report.ExitCode)
+
+	return report, logFile.Close()
+}
+
+// ParseLuaErrors returns Lua errors and their tracebacks from INSTEAD output
+func ParseLuaErrors(output string) []LuaError {
+	collector := &luaErrorsCollector{}
+	collector.Write([]byte(output))
+	collector.flush()
+
+	return collector.errors
+}
+
+// luaErrorsCollector finds Lua errors and their tracebacks in INSTEAD output
+type luaErrorsCollector struct {
+	errors      []LuaError
+	line        []byte
+	inTraceback bool
+}
+
+func (c *luaErrorsCollector) Write(p []byte) (int, error) {
+	c.line = append(c.line, p...)
+	for {
+		i := bytes.IndexByte(c.line, '\n')
+		if i < 0 {
+			break
+		}
+		c.parseLine(strings.TrimRight(string(c.line[:i]), "\r"))
+		c.line = c.line[i+1:]
+	}
+
+	return len(p), nil
+}
+
+func (c *luaErrorsCollector) flush() {
+	if len(c.line) > 0 {
+		c.parseLine(string(c.line))
+		c.line = nil
+	}
+}
+
+func (c *luaErrorsCollector) parseLine(line string) {
+	trimmedLine := strings.TrimSpace(line)
+
+	if trimmedLine == "stack traceback:" {
+		if len(c.errors) == 0 {
+			c.addError("")
+		}
+		c.inTraceback = true
+		return
+	}
+
+	if c.inTraceback && trimmedLine != "" && trimmedLine != line {
+		last := &c.errors[len(c.errors)-1]
+		last.Traceback = append(last.Traceback, trimmedLine)
+		return
+	}
+	c.inTraceback = false
+
+	if luaErrorRegexp.MatchString(trimmedLine) {
+		c.addError(trimmedLine)
+	}
+}
+
+func (c *luaErrorsCollector) addError(message string) {
+	if len(c.errors) < maxLuaErrors {
+		c.errors = append(c.errors, LuaError{Message: message})
+	}
+}
@@ -0,0 +1,106 @@
+package manager
+
+import (
+	"errors"
+	"io"
+	"os/exec"
+	"path/filepath"
+
+	"github.com/jhekasoft/insteadman3/core/interpreterfinder"
+	"github.com/jhekasoft/insteadman3/core/utils"
+)
+
+var (
+	// ErrNotGameDir is returned when there isn't main.lua (or main3.lua) in the game directory
+	ErrNotGameDir = errors.New("directory isn't INSTEAD game")
+	// ErrNoInterpreter is returned when INSTEAD hasn't found
+	ErrNoInterpreter = errors.New("INSTEAD has not found")
+)
+
+// IsGameDir checks that there is main.lua (or main3.lua) in the directory
+func IsGameDir(dir string) bool {
+	for _, mainFile := range gameMainFiles {
+		if utils.PathExist(filepath.Join(dir, mainFile)) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// RunGameDir runs the game from the directory which isn't installed (game in development) and waits
+// for INSTEAD exit. INSTEAD output is written to stdout and stderr (they can be nil).
+func (m *Manager) RunGameDir(dir string, stdout, stderr io.Writer) error {
+	cmd, e := m.StartGameDir(dir, stdout, stderr)
+	if e != nil {
+		return e
+	}
+
+	return cmd.Wait()
+}
+
+// StartGameDir starts the game from the directory like RunGameDir, it doesn't wait for INSTEAD exit
+// (the game is restarted after changes)
+func (m *Manager) StartGameDir(dir string, stdout, stderr io.Writer) (*exec.Cmd, error) {
+	cmd, e := m.gameDirCommand(dir)
+	if e != nil {
+		return nil, e
+	}
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+
+	e = cmd.Start()
+	if e != nil {
+		return nil, e
+	}
+	m.CurrentRunningCmd = cmd
+
+	return cmd, nil
+}
+
+// gameDirCommand returns INSTEAD command of the game from the directory, interpreterArgs are added to
+// the INSTEAD arguments
+func (m *Manager) gameDirCommand(dir string, interpreterArgs ...string) (*exec.Cmd, error) {
+	dir, e := filepath.Abs(dir)
+	if e != nil {
+		return nil, e
+	}
+	if !IsGameDir(dir) {
+		return nil, ErrNotGameDir
+	}
+
+	interpreterCommand := m.InterpreterCommand()
+	if interpreterCommand == "" {
+		return nil, ErrNoInterpreter
+	}
+
+	gamesPath, gameName := filepath.Split(dir)
+	gamesPath = filepath.Clean(gamesPath)
+
+	args := []string{"-gamespath", gamesPath, "-game", gameName}
+	if m.Config().CalculatedAppDataPath != "" {
+		args = append(args, "-appdata", m.Config().CalculatedAppDataPath)
+	}
+	args = append(args, interpreterArgs...)
+
+	var cmd *exec.Cmd
+	if interpreterfinder.IsCommandTemplate(interpreterCommand) {
+		parts, e := interpreterfinder.ExpandCommandTemplate(interpreterCommand, interpreterfinder.CommandData{
+			GameName:    gameName,
+			GamePath:    dir,
+			GamesPath:   gamesPath,
+			AppDataPath: m.Config().CalculatedAppDataPath,
+			Args:        args,
+		})
+		if e != nil {
+			return nil, e
+		}
+
+		cmd = exec.Command(parts[0], parts[1:]...)
+	} else {
+		cmd = interpreterfinder.Command(interpreterCommand, args...)
+		cmd.Dir = filepath.Dir(interpreterCommand)
+	}
+
+	return cmd, nil
+}
@@ -0,0 +1,205 @@
+package manager
+
+import (
+	"archive/zip"
+	"context"
+	"errors"
+	"hash/crc32"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+var (
+	// ErrVersionUnavailable is returned when the version isn't installed and it isn't in the repository
+	// (repositories have only the last version of the game)
+	ErrVersionUnavailable = errors.New("version of the game isn't installed and it isn't in the repository")
+	// ErrDiffUnsupported is returned when the version is .idf file (only directories and zip archives are compared)
+	ErrDiffUnsupported = errors.New("only game directories and zip archives can be compared")
+)
+
+// FileChange is a file of the game which is added, removed or changed by the new version
+type FileChange struct {
+	Path    string
+	OldSize int64
+	NewSize int64
+}
+
+// GameDiff is a difference between versions of the game, SizeDelta is a size change of the game files
+type GameDiff struct {
+	OldVersion string
+	NewVersion string
+	Added      []FileChange
+	Removed    []FileChange
+	Changed    []FileChange
+	SizeDelta  int64
+}
+
+// Empty checks that files of the versions are the same
+func (d *GameDiff) Empty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Changed) == 0
+}
+
+// gameFile is a size and checksum of the game file
+type gameFile struct {
+	size  int64
+	crc32 uint32
+}
+
+// DiffGameVersions compares files of the game versions. Available versions are the installed one and
+// the repository one (its archive is downloaded), empty oldVer is the installed version and empty newVer
+// is the repository version.
+func (m *Manager) DiffGameVersions(game *Game, oldVer, newVer string) (*GameDiff, error) {
+	if oldVer == "" {
+		oldVer = game.InstalledVersion
+	}
+	if newVer == "" {
+		newVer = game.Version
+	}
+
+	// The same version can be installed and in the repository, installed files are compared then
+	// with the repository archive
+	oldFiles, e := m.gameVersionFiles(game, oldVer, game.Installed)
+	if e != nil {
+		return nil, e
+	}
+	newFiles, e := m.gameVersionFiles(game, newVer, false)
+	if e != nil {
+		return nil, e
+	}
+
+	diff := &GameDiff{OldVersion: oldVer, NewVersion: newVer}
+	for file, newFile := range newFiles {
+		oldFile, ok := oldFiles[file]
+		switch {
+		case !ok:
+			diff.Added = append(diff.Added, FileChange{Path: file, NewSize: newFile.size})
+		case oldFile != newFile:
+			diff.Changed = append(diff.Changed, FileChange{Path: file, OldSize: oldFile.size, NewSize: newFile.size})
+		}
+		diff.SizeDelta += newFile.size
+	}
+	for file, oldFile := range oldFiles {
+		if _, ok := newFiles[file]; !ok {
+			diff.Removed = append(diff.Removed, FileChange{Path: file, OldSize: oldFile.size})
+		}
+		diff.SizeDelta -= oldFile.size
+	}
+
+	for _, changes := range [][]FileChange{diff.Added, diff.Removed, diff.Changed} {
+		sort.Slice(changes, func(i, j int) bool {
+			return changes[i].Path < changes[j].Path
+		})
+	}
+
+	return diff, nil
+}
+
+// gameVersionFiles returns files of the installed game (if installed is true and it's the installed version)
+// or files of the repository archive
+func (m *Manager) gameVersionFiles(game *Game, version string, installed bool) (map[string]gameFile, error) {
+	if installed && game.InstalledVersion == version {
+		gameDir, e := filepath.EvalSymlinks(filepath.Join(m.Config().CalculatedGamesPath, game.Name))
+		if e != nil {
+			return nil, e
+		}
+		info, e := os.Stat(gameDir)
+		if e != nil {
+			return nil, e
+		}
+		if !info.IsDir() {
+			return nil, ErrDiffUnsupported
+		}
+
+		return dirGameFiles(gameDir)
+	}
+
+	if game.Url == "" || game.Version != version {
+		return nil, ErrVersionUnavailable
+	}
+	if strings.ToLower(path.Ext(game.Url)) != ".zip" {
+		return nil, ErrDiffUnsupported
+	}
+
+	tempGamesDir := filepath.Join(m.CacheDir(), tempGamesDirName)
+	os.MkdirAll(tempGamesDir, os.ModePerm)
+
+	fileName := filepath.Join(tempGamesDir, path.Base(game.Url))
+	defer os.Remove(fileName)
+
+	e := downloadFile(context.Background(), fileName, game.Url, nil)
+	if e != nil {
+		return nil, e
+	}
+
+	return zipGameFiles(fileName)
+}
+
+// dirGameFiles returns files of the game directory (paths are relative and slash-separated)
+func dirGameFiles(dir string) (map[string]gameFile, error) {
+	files := make(map[string]gameFile)
+	e := filepath.Walk(dir, func(filePath string, info os.FileInfo, e error) error {
+		if e != nil || info.IsDir() {
+			return e
+		}
+
+		rel, e := filepath.Rel(dir, filePath)
+		if e != nil {
+			return e
+		}
+
+		f, e := os.Open(filePath)
+		if e != nil {
+			return e
+		}
+		defer f.Close()
+
+		hash := crc32.NewIEEE()
+		size, e := io.Copy(hash, f)
+		if e != nil {
+			return e
+		}
+		files[filepath.ToSlash(rel)] = gameFile{size: size, crc32: hash.Sum32()}
+
+		return nil
+	})
+
+	return files, e
+}
+
+// zipGameFiles returns files of the game in the zip archive, paths are relative to the directory
+// of the main file ("crossworlds/main.lua" is "main.lua")
+func zipGameFiles(fileName string) (map[string]gameFile, error) {
+	r, e := zip.OpenReader(fileName)
+	if e != nil {
+		return nil, e
+	}
+	defer r.Close()
+
+	prefix := ""
+	found := false
+	for _, f := range r.File {
+		dir, file := path.Split(f.Name)
+		if isGameMainFile(file) && strings.Count(strings.Trim(dir, "/"), "/") == 0 {
+			prefix = dir
+			found = true
+			break
+		}
+	}
+	if !found {
+		return nil, ErrNotGameArchive
+	}
+
+	files := make(map[string]gameFile)
+	for _, f := range r.File {
+		if f.FileInfo().IsDir() || !strings.HasPrefix(f.Name, prefix) {
+			continue
+		}
+		files[strings.TrimPrefix(f.Name, prefix)] = gameFile{size: int64(f.UncompressedSize64), crc32: f.CRC32}
+	}
+
+	return files, nil
+}
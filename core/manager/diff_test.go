@@ -0,0 +1,78 @@
+package manager
+
+import (
+	"archive/zip"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jhekasoft/insteadman3/core/configurator"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiffGameVersions(t *testing.T) {
+	dir, e := ioutil.TempDir("", "insteadman")
+	assert.NoError(t, e)
+	defer os.RemoveAll(dir)
+
+	// Installed version
+	gameDir := filepath.Join(dir, "games", "mygame")
+	assert.NoError(t, os.MkdirAll(filepath.Join(gameDir, "gfx"), os.ModePerm))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(gameDir, "main3.lua"), []byte("-- $Version: 0.1$\n"), 0644))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(gameDir, "gfx", "old.png"), []byte("old image"), 0644))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(gameDir, "intro.lua"), []byte("intro"), 0644))
+
+	// Repository version
+	archivePath := filepath.Join(dir, "mygame-0.2.zip")
+	f, e := os.Create(archivePath)
+	assert.NoError(t, e)
+	w := zip.NewWriter(f)
+	for name, data := range map[string]string{
+		"mygame/main3.lua":    "-- $Version: 0.2$\n",
+		"mygame/intro.lua":    "intro",
+		"mygame/gfx/new.png":  "new image",
+		"mygame/mus/song.ogg": "song",
+	} {
+		fw, e := w.Create(name)
+		assert.NoError(t, e)
+		fw.Write([]byte(data))
+	}
+	assert.NoError(t, w.Close())
+	assert.NoError(t, f.Close())
+
+	ts := httptest.NewServer(http.FileServer(http.Dir(dir)))
+	defer ts.Close()
+
+	config := &configurator.InsteadmanConfig{
+		CalculatedGamesPath: filepath.Join(dir, "games"),
+		CalculatedCachePath: filepath.Join(dir, "cache"),
+	}
+	man := Manager{config: configurator.NewHolder(config)}
+	game := &Game{Name: "mygame", Version: "0.2", InstalledVersion: "0.1", Installed: true,
+		Url: ts.URL + "/mygame-0.2.zip"}
+
+	diff, e := man.DiffGameVersions(game, "", "")
+	assert.NoError(t, e)
+	assert.Equal(t, "0.1", diff.OldVersion)
+	assert.Equal(t, "0.2", diff.NewVersion)
+	assert.Equal(t, []FileChange{{Path: "gfx/new.png", NewSize: 9}, {Path: "mus/song.ogg", NewSize: 4}}, diff.Added)
+	assert.Equal(t, []FileChange{{Path: "gfx/old.png", OldSize: 9}}, diff.Removed)
+	assert.Equal(t, []FileChange{{Path: "main3.lua", OldSize: 18, NewSize: 18}}, diff.Changed)
+	assert.Equal(t, int64(4), diff.SizeDelta)
+	assert.False(t, diff.Empty())
+	// Downloaded archive isn't kept
+	files, _ := ioutil.ReadDir(filepath.Join(dir, "cache", tempGamesDirName))
+	assert.Empty(t, files)
+
+	// Repository has only the last version
+	_, e = man.DiffGameVersions(game, "0.1", "0.3")
+	assert.Equal(t, ErrVersionUnavailable, e)
+
+	// The same version is compared with the repository archive
+	diff, e = man.DiffGameVersions(game, "0.2", "0.2")
+	assert.NoError(t, e)
+	assert.True(t, diff.Empty())
+}
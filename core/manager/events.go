@@ -0,0 +1,92 @@
+package manager
+
+import "sync"
+
+// Event is sent to the subscribers when games or repositories have changed, so front ends refresh
+// their views by events instead of refreshing after every operation. It's GameInstalled, GameRemoved,
+// RepositoriesUpdated or DownloadProgress.
+type Event interface {
+	event()
+}
+
+// GameInstalled is sent when the game has been installed or updated
+type GameInstalled struct {
+	Game Game
+}
+
+// GameRemoved is sent when the game has been removed
+type GameRemoved struct {
+	Game Game
+}
+
+// RepositoriesUpdated is sent when repositories have been updated, Errors are errors of the repositories
+// which haven't updated
+type RepositoriesUpdated struct {
+	Errors []error
+}
+
+// DownloadProgress is sent while the game archive is downloading
+type DownloadProgress struct {
+	Game       Game
+	Downloaded uint64
+	Total      uint64 // size from the repository, it's 0 if it's unknown
+}
+
+func (GameInstalled) event()       {}
+func (GameRemoved) event()         {}
+func (RepositoriesUpdated) event() {}
+func (DownloadProgress) event()    {}
+
+// Events sends events to the subscribers. It's used by Manager and by the client of the daemon.
+type Events struct {
+	mutex       sync.Mutex
+	subscribers map[int]func(Event)
+	lastId      int
+}
+
+// Subscribe adds function which is called with the events, it returns function which removes it.
+// Subscribers are called from the goroutine of the operation, so GUI calls glib.IdleAdd in them.
+func (ev *Events) Subscribe(f func(Event)) (unsubscribe func()) {
+	ev.mutex.Lock()
+	defer ev.mutex.Unlock()
+
+	if ev.subscribers == nil {
+		ev.subscribers = make(map[int]func(Event))
+	}
+	ev.lastId++
+	id := ev.lastId
+	ev.subscribers[id] = f
+
+	return func() {
+		ev.mutex.Lock()
+		defer ev.mutex.Unlock()
+
+		delete(ev.subscribers, id)
+	}
+}
+
+// Publish calls all the subscribers with the event
+func (ev *Events) Publish(event Event) {
+	ev.mutex.Lock()
+	subscribers := make([]func(Event), 0, len(ev.subscribers))
+	for _, f := range ev.subscribers {
+		subscribers = append(subscribers, f)
+	}
+	ev.mutex.Unlock()
+
+	for _, f := range subscribers {
+		f(event)
+	}
+}
+
+// downloadProgress returns progress function which calls progressF (it can be nil) and publishes
+// DownloadProgress of the game
+func (m *Manager) downloadProgress(game *Game, progressF func(uint64)) func(uint64) {
+	return func(downloaded uint64) {
+		if progressF != nil {
+			progressF(downloaded)
+		}
+
+		m.Publish(DownloadProgress{Game: *game, Downloaded: downloaded, Total: uint64(game.Size)})
+	}
+}
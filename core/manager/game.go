@@ -40,6 +40,15 @@ type RepositoryGame struct {
 	//IsUpdateExist    bool     `xml:"-"`
 	Languages []string `xml:"-"`
 	Id        string   `xml:"-"`
+
+	// Requirements of the installed game (see CheckGameRequirements)
+	Stead3                 bool   `xml:"-"`
+	RequiredInsteadVersion string `xml:"-"`
+
+	// User data of the game (see GameDB), LastPlayed is a Unix time
+	Favorite   bool     `xml:"-"`
+	Tags       []string `xml:"-"`
+	LastPlayed int64    `xml:"-"`
 }
 
 type Game RepositoryGame
@@ -139,6 +148,8 @@ func appendGameInfo(game Game, path string, info os.FileInfo) (newGame Game, e e
 		return
 	}
 
+	readGameRequirements(&newGame, filepath.Base(mainLuaFilePath), file)
+
 	// Title
 	r, e := regexp.Compile("(?i)--\\s*\\$Name:\\s*(.*)\\$")
 	if e == nil {
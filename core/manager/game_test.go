@@ -1,10 +1,12 @@
 package manager
 
 import (
-	"github.com/stretchr/testify/assert"
 	"os"
 	"path/filepath"
 	"testing"
+
+	"github.com/jhekasoft/insteadman3/core/interpreterfinder"
+	"github.com/stretchr/testify/assert"
 )
 
 const (
@@ -36,3 +38,28 @@ func TestReadLocalGameInfoStead3(t *testing.T) {
 	assert.Equal(t, "0.1", stead2Game.InstalledVersion)
 	assert.Equal(t, "0.1", stead2Game.Version)
 }
+
+func TestCheckGameRequirements(t *testing.T) {
+	stead2GameInfo, e := os.Stat(filepath.Join(gamesPath, stead2gameFileName))
+	assert.NoError(t, e)
+	stead2Game := ReadLocalGameInfo(gamesPath, stead2GameInfo)
+	assert.False(t, stead2Game.Stead3)
+	assert.Equal(t, "1.9.1", stead2Game.RequiredInsteadVersion)
+
+	stead3GameInfo, e := os.Stat(filepath.Join(gamesPath, stead3gameFileName))
+	assert.NoError(t, e)
+	stead3Game := ReadLocalGameInfo(gamesPath, stead3GameInfo)
+	assert.True(t, stead3Game.Stead3)
+
+	info := func(v interpreterfinder.Version) *interpreterfinder.Info {
+		return &interpreterfinder.Info{Version: v, Capabilities: interpreterfinder.VersionCapabilities(v)}
+	}
+
+	assert.NoError(t, CheckGameRequirements(&stead2Game, info(interpreterfinder.Version{Major: 2, Minor: 4})))
+	assert.NoError(t, CheckGameRequirements(&stead3Game, info(interpreterfinder.Version{Major: 3, Minor: 2})))
+
+	assert.EqualError(t, CheckGameRequirements(&stead2Game, info(interpreterfinder.Version{Major: 1, Minor: 8})),
+		"Game \"Stead2 Test game\" can't be run with INSTEAD 1.8.0: it needs INSTEAD 1.9.1 or newer")
+	assert.EqualError(t, CheckGameRequirements(&stead3Game, info(interpreterfinder.Version{Major: 2, Minor: 4, Patch: 1})),
+		"Game \"Stead3 Test game\" can't be run with INSTEAD 2.4.1: it's STEAD3 game which needs INSTEAD 3.0.0 or newer")
+}
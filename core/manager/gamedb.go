@@ -0,0 +1,634 @@
+package manager
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+const (
+	gameDBFileName = "games.db"
+	// gameDBTimeout is a time of waiting for the database which is opened by other process
+	gameDBTimeout = 5 * time.Second
+	// gameDBHistorySize is a count of the last plays which are kept in the history
+	gameDBHistorySize = 1000
+)
+
+// Catalog buckets are rebuilt by GameDB.Sync, index keys are "value\x00id"
+var (
+	bucketGames     = []byte("games")         // game ID: JSON of the game
+	bucketByName    = []byte("by_name")       // name\x00ID
+	bucketByRepo    = []byte("by_repository") // repository\x00ID
+	bucketByLang    = []byte("by_lang")       // lang\x00ID
+	bucketByTitle   = []byte("by_title")      // lowercase title\x00ID
+	bucketByDate    = []byte("by_date")       // timestamp\x00ID
+	bucketInstalled = []byte("installed")     // ID
+	bucketSearch    = []byte("search")        // game ID: lowercase title and name for the keyword search
+	bucketMeta      = []byte("meta")
+	metaSignature   = []byte("signature")
+)
+
+var catalogBuckets = [][]byte{bucketGames, bucketByName, bucketByRepo, bucketByLang, bucketByTitle, bucketByDate,
+	bucketInstalled, bucketSearch}
+
+// User data buckets are kept by the game names, so they aren't lost when the game is moved to another repository
+var (
+	bucketFavorites  = []byte("favorites")   // game name
+	bucketTags       = []byte("tags")        // game name: JSON of the tags
+	bucketByTag      = []byte("by_tag")      // tag\x00name
+	bucketHistory    = []byte("history")     // time of the play (Unix nanoseconds): game name
+	bucketLastPlayed = []byte("last_played") // game name: Unix time
+)
+
+// ErrNoGameDB is returned when favorites or tags are queried without the games database
+var ErrNoGameDB = errors.New("games database isn't enabled")
+
+// GameQuery are filters and sorting of the games, empty fields aren't used
+type GameQuery struct {
+	Keyword    string // part of the title or the name
+	Repository string
+	Lang       string
+	Installed  bool
+	Favorite   bool
+	Tag        string
+	SortBy     string // SortByTitleAsc (default) or SortByDateDesc
+	Offset     int
+	Limit      int
+}
+
+// Play is a run of the game from the history
+type Play struct {
+	Name string
+	Time time.Time
+}
+
+// GameDB is an embedded database of the games: catalog of the repositories and installed games with
+// indices for the queries and user data (favorites, tags and play history). Database is opened by every
+// operation, so the daemon and the front ends can use it at the same time.
+type GameDB struct {
+	FileName string
+}
+
+// NewGameDB returns database of the file, it's created by the first change
+func NewGameDB(fileName string) *GameDB {
+	return &GameDB{FileName: fileName}
+}
+
+// GameDBFile returns default file of the games database
+func (m *Manager) GameDBFile() string {
+	return filepath.Join(m.Config().CalculatedInsteadManPath, gameDBFileName)
+}
+
+func (d *GameDB) open() (*bolt.DB, error) {
+	e := os.MkdirAll(filepath.Dir(d.FileName), os.ModePerm)
+	if e != nil {
+		return nil, e
+	}
+
+	return bolt.Open(d.FileName, 0644, &bolt.Options{Timeout: gameDBTimeout})
+}
+
+func (d *GameDB) update(f func(tx *bolt.Tx) error) error {
+	db, e := d.open()
+	if e != nil {
+		return e
+	}
+	defer db.Close()
+
+	return db.Update(f)
+}
+
+func (d *GameDB) view(f func(tx *bolt.Tx) error) error {
+	db, e := d.open()
+	if e != nil {
+		return e
+	}
+	defer db.Close()
+
+	return db.View(f)
+}
+
+// Sync replaces catalog of the database by the games, signature is a state of the games sources
+func (d *GameDB) Sync(games []Game, signature string) error {
+	return d.update(func(tx *bolt.Tx) error {
+		for _, name := range catalogBuckets {
+			if tx.Bucket(name) != nil {
+				e := tx.DeleteBucket(name)
+				if e != nil {
+					return e
+				}
+			}
+			_, e := tx.CreateBucket(name)
+			if e != nil {
+				return e
+			}
+		}
+
+		for _, game := range games {
+			data, e := json.Marshal(game)
+			if e != nil {
+				return e
+			}
+			e = tx.Bucket(bucketGames).Put([]byte(game.Id), data)
+			if e != nil {
+				return e
+			}
+			e = tx.Bucket(bucketSearch).Put([]byte(game.Id), []byte(strings.ToLower(game.Title+"\n"+game.Name)))
+			if e != nil {
+				return e
+			}
+
+			keys := map[string][]byte{
+				string(bucketByName):  indexKey(game.Name, game.Id),
+				string(bucketByRepo):  indexKey(game.RepositoryName, game.Id),
+				string(bucketByTitle): indexKey(strings.ToLower(game.Title), game.Id),
+				string(bucketByDate):  indexKey(timestampKey(game.Timestamp), game.Id),
+			}
+			if game.Installed {
+				keys[string(bucketInstalled)] = []byte(game.Id)
+			}
+			for bucket, key := range keys {
+				e = tx.Bucket([]byte(bucket)).Put(key, nil)
+				if e != nil {
+					return e
+				}
+			}
+			for _, lang := range game.Languages {
+				e = tx.Bucket(bucketByLang).Put(indexKey(lang, game.Id), nil)
+				if e != nil {
+					return e
+				}
+			}
+		}
+
+		meta, e := tx.CreateBucketIfNotExists(bucketMeta)
+		if e != nil {
+			return e
+		}
+
+		return meta.Put(metaSignature, []byte(signature))
+	})
+}
+
+// Signature returns state of the games sources of the last Sync
+func (d *GameDB) Signature() (signature string, e error) {
+	e = d.view(func(tx *bolt.Tx) error {
+		if meta := tx.Bucket(bucketMeta); meta != nil {
+			signature = string(meta.Get(metaSignature))
+		}
+		return nil
+	})
+
+	return
+}
+
+// Query returns games of the catalog by the indices, user data of the games is filled
+func (d *GameDB) Query(q GameQuery) (games []Game, e error) {
+	e = d.view(func(tx *bolt.Tx) error {
+		// Candidates are IDs of the filters, nil is all games
+		var candidates map[string]bool
+		restrict := func(ids map[string]bool) {
+			if candidates == nil {
+				candidates = ids
+				return
+			}
+			for id := range candidates {
+				if !ids[id] {
+					delete(candidates, id)
+				}
+			}
+		}
+
+		if q.Repository != "" {
+			restrict(indexValues(tx.Bucket(bucketByRepo), q.Repository))
+		}
+		if q.Lang != "" {
+			restrict(indexValues(tx.Bucket(bucketByLang), q.Lang))
+		}
+		if q.Installed {
+			restrict(bucketKeys(tx.Bucket(bucketInstalled)))
+		}
+		if q.Favorite {
+			restrict(gameNamesIds(tx, bucketKeys(tx.Bucket(bucketFavorites))))
+		}
+		if q.Tag != "" {
+			restrict(gameNamesIds(tx, indexValues(tx.Bucket(bucketByTag), q.Tag)))
+		}
+
+		sortBucket := tx.Bucket(bucketByTitle)
+		if q.SortBy == SortByDateDesc {
+			sortBucket = tx.Bucket(bucketByDate)
+		}
+		if sortBucket == nil {
+			return nil
+		}
+
+		lowerKeyword := strings.ToLower(q.Keyword)
+		skipped := 0
+		c := sortBucket.Cursor()
+		k, _ := c.First()
+		next := c.Next
+		if q.SortBy == SortByDateDesc {
+			k, _ = c.Last()
+			next = c.Prev
+		}
+		for ; k != nil; k, _ = next() {
+			if q.Limit > 0 && len(games) >= q.Limit {
+				break
+			}
+
+			id := string(k[bytes.IndexByte(k, 0)+1:])
+			if candidates != nil && !candidates[id] {
+				continue
+			}
+
+			if lowerKeyword != "" && !bytes.Contains(tx.Bucket(bucketSearch).Get([]byte(id)), []byte(lowerKeyword)) {
+				continue
+			}
+			if skipped < q.Offset {
+				skipped++
+				continue
+			}
+
+			var game Game
+			e := json.Unmarshal(tx.Bucket(bucketGames).Get([]byte(id)), &game)
+			if e != nil {
+				return e
+			}
+
+			games = append(games, game)
+		}
+
+		for i := range games {
+			fillUserData(tx, &games[i])
+		}
+
+		return nil
+	})
+
+	return
+}
+
+// FillUserData adds favorite flag, tags and last play time to the game
+func (d *GameDB) FillUserData(game *Game) error {
+	return d.view(func(tx *bolt.Tx) error {
+		fillUserData(tx, game)
+		return nil
+	})
+}
+
+func fillUserData(tx *bolt.Tx, game *Game) {
+	name := []byte(game.Name)
+
+	if b := tx.Bucket(bucketFavorites); b != nil {
+		game.Favorite = b.Get(name) != nil
+	}
+	if b := tx.Bucket(bucketTags); b != nil {
+		game.Tags = nil
+		if data := b.Get(name); data != nil {
+			json.Unmarshal(data, &game.Tags)
+		}
+	}
+	if b := tx.Bucket(bucketLastPlayed); b != nil {
+		if data := b.Get(name); len(data) == 8 {
+			game.LastPlayed = int64(binary.BigEndian.Uint64(data))
+		}
+	}
+}
+
+// SetFavorite adds the game to the favorites or removes it
+func (d *GameDB) SetFavorite(name string, favorite bool) error {
+	return d.update(func(tx *bolt.Tx) error {
+		b, e := tx.CreateBucketIfNotExists(bucketFavorites)
+		if e != nil {
+			return e
+		}
+
+		if !favorite {
+			return b.Delete([]byte(name))
+		}
+		return b.Put([]byte(name), nil)
+	})
+}
+
+// SetTags replaces tags of the game (they are trimmed, empty and repeated tags are skipped)
+func (d *GameDB) SetTags(name string, tags []string) error {
+	tags = normalizeTags(tags)
+
+	return d.update(func(tx *bolt.Tx) error {
+		b, e := tx.CreateBucketIfNotExists(bucketTags)
+		if e != nil {
+			return e
+		}
+		byTag, e := tx.CreateBucketIfNotExists(bucketByTag)
+		if e != nil {
+			return e
+		}
+
+		var oldTags []string
+		if data := b.Get([]byte(name)); data != nil {
+			json.Unmarshal(data, &oldTags)
+		}
+		for _, tag := range oldTags {
+			e = byTag.Delete(indexKey(tag, name))
+			if e != nil {
+				return e
+			}
+		}
+
+		if len(tags) == 0 {
+			return b.Delete([]byte(name))
+		}
+		for _, tag := range tags {
+			e = byTag.Put(indexKey(tag, name), nil)
+			if e != nil {
+				return e
+			}
+		}
+		data, e := json.Marshal(tags)
+		if e != nil {
+			return e
+		}
+
+		return b.Put([]byte(name), data)
+	})
+}
+
+// GameTags returns tags of the game
+func (d *GameDB) GameTags(name string) ([]string, error) {
+	game := Game{Name: name}
+	e := d.FillUserData(&game)
+
+	return game.Tags, e
+}
+
+// Tags returns all tags of the games
+func (d *GameDB) Tags() (tags []string, e error) {
+	e = d.view(func(tx *bolt.Tx) error {
+		b := tx.Bucket(bucketByTag)
+		if b == nil {
+			return nil
+		}
+		return b.ForEach(func(k, _ []byte) error {
+			tag := string(k[:bytes.IndexByte(k, 0)])
+			if len(tags) == 0 || tags[len(tags)-1] != tag {
+				tags = append(tags, tag)
+			}
+			return nil
+		})
+	})
+
+	return
+}
+
+// AddPlay adds run of the game to the history, only last gameDBHistorySize plays are kept
+func (d *GameDB) AddPlay(name string, t time.Time) error {
+	return d.update(func(tx *bolt.Tx) error {
+		history, e := tx.CreateBucketIfNotExists(bucketHistory)
+		if e != nil {
+			return e
+		}
+		lastPlayed, e := tx.CreateBucketIfNotExists(bucketLastPlayed)
+		if e != nil {
+			return e
+		}
+
+		key := make([]byte, 8)
+		binary.BigEndian.PutUint64(key, uint64(t.UnixNano()))
+		e = history.Put(key, []byte(name))
+		if e != nil {
+			return e
+		}
+
+		unix := make([]byte, 8)
+		binary.BigEndian.PutUint64(unix, uint64(t.Unix()))
+		e = lastPlayed.Put([]byte(name), unix)
+		if e != nil {
+			return e
+		}
+
+		c := history.Cursor()
+		for count := history.Stats().KeyN; count > gameDBHistorySize; count-- {
+			k, _ := c.First()
+			e = c.Delete()
+			if e != nil || k == nil {
+				return e
+			}
+		}
+
+		return nil
+	})
+}
+
+// History returns last plays (the newest is the first), limit 0 is all plays
+func (d *GameDB) History(limit int) (plays []Play, e error) {
+	e = d.view(func(tx *bolt.Tx) error {
+		b := tx.Bucket(bucketHistory)
+		if b == nil {
+			return nil
+		}
+
+		c := b.Cursor()
+		for k, v := c.Last(); k != nil && (limit == 0 || len(plays) < limit); k, v = c.Prev() {
+			plays = append(plays, Play{
+				Name: string(v),
+				Time: time.Unix(0, int64(binary.BigEndian.Uint64(k))),
+			})
+		}
+		return nil
+	})
+
+	return
+}
+
+// QueryGames returns filtered and sorted games. Games database is used if it's set (Manager.DB), its catalog
+// is updated if repositories or installed games have changed. Favorites and tags are filtered only by the database.
+func (m *Manager) QueryGames(q GameQuery) ([]Game, error) {
+	if m.DB == nil {
+		return m.queryGamesInMemory(q)
+	}
+
+	signature, e := m.gamesSignature()
+	if e != nil {
+		return nil, e
+	}
+	dbSignature, e := m.DB.Signature()
+	if e != nil {
+		return nil, e
+	}
+	if signature != dbSignature {
+		games, e := m.GetMergedGames()
+		if e != nil {
+			return nil, e
+		}
+		e = m.DB.Sync(games, signature)
+		if e != nil {
+			return nil, e
+		}
+	}
+
+	return m.DB.Query(q)
+}
+
+func (m *Manager) queryGamesInMemory(q GameQuery) ([]Game, error) {
+	if q.Favorite || q.Tag != "" {
+		return nil, ErrNoGameDB
+	}
+
+	sortBy := q.SortBy
+	if sortBy == "" {
+		sortBy = SortByTitleAsc
+	}
+	games, e := m.GetSortedGamesBy(sortBy)
+	if e != nil {
+		return nil, e
+	}
+
+	games = FilterGames(games, optionalString(q.Keyword), optionalString(q.Repository), optionalString(q.Lang),
+		q.Installed)
+
+	if q.Offset > 0 {
+		if q.Offset >= len(games) {
+			return nil, nil
+		}
+		games = games[q.Offset:]
+	}
+	if q.Limit > 0 && q.Limit < len(games) {
+		games = games[:q.Limit]
+	}
+
+	return games, nil
+}
+
+// gamesSignature returns state of the repository files and installed games, catalog of the database
+// is synced when it has changed
+func (m *Manager) gamesSignature() (string, error) {
+	var state []string
+
+	files, e := filepath.Glob(filepath.Join(m.repositoriesDir(), "*.xml"))
+	if e != nil {
+		return "", e
+	}
+	for _, fileName := range files {
+		info, e := os.Stat(fileName)
+		if e != nil {
+			continue
+		}
+		state = append(state, "r:"+info.Name()+":"+strconv.FormatInt(info.Size(), 10)+":"+
+			strconv.FormatInt(info.ModTime().UnixNano(), 10))
+	}
+
+	infos, e := ioutil.ReadDir(m.Config().CalculatedGamesPath)
+	if e != nil {
+		return "", e
+	}
+	for _, info := range infos {
+		state = append(state, "g:"+info.Name()+":"+strconv.FormatInt(info.ModTime().UnixNano(), 10))
+	}
+	sort.Strings(state)
+
+	hash := sha256.Sum256([]byte(strings.Join(state, "\n")))
+
+	return hex.EncodeToString(hash[:]), nil
+}
+
+func indexKey(value, id string) []byte {
+	return []byte(value + "\x00" + id)
+}
+
+// timestampKey is sorted like the timestamp (negative timestamps are zero), it hasn't zero bytes of
+// the index keys separator
+func timestampKey(timestamp int64) string {
+	if timestamp < 0 {
+		timestamp = 0
+	}
+
+	return fmt.Sprintf("%020d", timestamp)
+}
+
+// indexValues returns IDs (or names) of the index keys with the value
+func indexValues(b *bolt.Bucket, value string) map[string]bool {
+	values := make(map[string]bool)
+	if b == nil {
+		return values
+	}
+
+	prefix := indexKey(value, "")
+	c := b.Cursor()
+	for k, _ := c.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, _ = c.Next() {
+		values[string(k[len(prefix):])] = true
+	}
+
+	return values
+}
+
+func bucketKeys(b *bolt.Bucket) map[string]bool {
+	keys := make(map[string]bool)
+	if b == nil {
+		return keys
+	}
+
+	b.ForEach(func(k, _ []byte) error {
+		keys[string(k)] = true
+		return nil
+	})
+
+	return keys
+}
+
+// gameNamesIds returns IDs of the games with the names
+func gameNamesIds(tx *bolt.Tx, names map[string]bool) map[string]bool {
+	ids := make(map[string]bool)
+	for name := range names {
+		for id := range indexValues(tx.Bucket(bucketByName), name) {
+			ids[id] = true
+		}
+	}
+
+	return ids
+}
+
+func normalizeTags(tags []string) []string {
+	var result []string
+	for _, tag := range tags {
+		tag = strings.TrimSpace(tag)
+		if tag != "" && !strings.Contains(tag, "\x00") {
+			result = append(result, tag)
+		}
+	}
+	sort.Strings(result)
+
+	return uniqueSortedStrings(result)
+}
+
+func uniqueSortedStrings(values []string) []string {
+	var result []string
+	for _, value := range values {
+		if len(result) == 0 || result[len(result)-1] != value {
+			result = append(result, value)
+		}
+	}
+
+	return result
+}
+
+func optionalString(value string) *string {
+	if value == "" {
+		return nil
+	}
+
+	return &value
+}
@@ -0,0 +1,175 @@
+package manager
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func gameIds(games []Game) []string {
+	var ids []string
+	for _, game := range games {
+		ids = append(ids, game.Id)
+	}
+
+	return ids
+}
+
+func TestGameDB(t *testing.T) {
+	dir, e := ioutil.TempDir("", "insteadman")
+	assert.NoError(t, e)
+	defer os.RemoveAll(dir)
+
+	db := NewGameDB(filepath.Join(dir, "games.db"))
+
+	// Empty database
+	games, e := db.Query(GameQuery{})
+	assert.NoError(t, e)
+	assert.Empty(t, games)
+
+	e = db.Sync([]Game{
+		{Id: "official/dark/ru", Name: "dark", Title: "Dark forest", RepositoryName: "official",
+			Languages: []string{"ru"}, Timestamp: 300},
+		{Id: "official/lost/en_ru", Name: "lost", Title: "lost", RepositoryName: "official",
+			Languages: []string{"en", "ru"}, Timestamp: 100, Installed: true},
+		{Id: "other/dark/en", Name: "dark", Title: "Dark forest", RepositoryName: "other",
+			Languages: []string{"en"}, Timestamp: 200},
+		{Id: "/local/", Name: "local", Title: "Local game", Installed: true},
+	}, "first")
+	assert.NoError(t, e)
+
+	signature, e := db.Signature()
+	assert.NoError(t, e)
+	assert.Equal(t, "first", signature)
+
+	// Sorting
+	games, e = db.Query(GameQuery{})
+	assert.NoError(t, e)
+	assert.Equal(t, []string{"official/dark/ru", "other/dark/en", "/local/", "official/lost/en_ru"}, gameIds(games))
+	games, e = db.Query(GameQuery{SortBy: SortByDateDesc})
+	assert.NoError(t, e)
+	assert.Equal(t, []string{"official/dark/ru", "other/dark/en", "official/lost/en_ru", "/local/"}, gameIds(games))
+
+	// Filters
+	games, e = db.Query(GameQuery{Repository: "official", Lang: "ru"})
+	assert.NoError(t, e)
+	assert.Equal(t, []string{"official/dark/ru", "official/lost/en_ru"}, gameIds(games))
+	games, e = db.Query(GameQuery{Installed: true, Keyword: "LOST"})
+	assert.NoError(t, e)
+	assert.Equal(t, []string{"official/lost/en_ru"}, gameIds(games))
+	games, e = db.Query(GameQuery{Offset: 1, Limit: 2})
+	assert.NoError(t, e)
+	assert.Equal(t, []string{"other/dark/en", "/local/"}, gameIds(games))
+
+	// User data is kept by the game names
+	assert.NoError(t, db.SetFavorite("dark", true))
+	assert.NoError(t, db.SetFavorite("local", true))
+	assert.NoError(t, db.SetFavorite("local", false))
+	assert.NoError(t, db.SetTags("dark", []string{" horror", "short", "horror", ""}))
+	assert.NoError(t, db.SetTags("lost", []string{"short"}))
+
+	games, e = db.Query(GameQuery{Favorite: true, Lang: "en"})
+	assert.NoError(t, e)
+	assert.Equal(t, []string{"other/dark/en"}, gameIds(games))
+	assert.True(t, games[0].Favorite)
+	assert.Equal(t, []string{"horror", "short"}, games[0].Tags)
+
+	games, e = db.Query(GameQuery{Tag: "short", SortBy: SortByDateDesc})
+	assert.NoError(t, e)
+	assert.Equal(t, []string{"official/dark/ru", "other/dark/en", "official/lost/en_ru"}, gameIds(games))
+
+	tags, e := db.Tags()
+	assert.NoError(t, e)
+	assert.Equal(t, []string{"horror", "short"}, tags)
+
+	// Tags are replaced
+	assert.NoError(t, db.SetTags("dark", nil))
+	games, e = db.Query(GameQuery{Tag: "horror"})
+	assert.NoError(t, e)
+	assert.Empty(t, games)
+
+	// Play history
+	now := time.Now()
+	assert.NoError(t, db.AddPlay("lost", now.Add(-time.Hour)))
+	assert.NoError(t, db.AddPlay("dark", now))
+	plays, e := db.History(0)
+	assert.NoError(t, e)
+	assert.Len(t, plays, 2)
+	assert.Equal(t, "dark", plays[0].Name)
+	assert.True(t, plays[0].Time.Equal(now))
+
+	games, e = db.Query(GameQuery{Keyword: "lost"})
+	assert.NoError(t, e)
+	assert.Equal(t, now.Add(-time.Hour).Unix(), games[0].LastPlayed)
+
+	// User data isn't changed by the sync
+	assert.NoError(t, db.Sync([]Game{{Id: "new/dark/ru", Name: "dark", Title: "Dark"}}, "second"))
+	games, e = db.Query(GameQuery{Favorite: true})
+	assert.NoError(t, e)
+	assert.Equal(t, []string{"new/dark/ru"}, gameIds(games))
+}
+
+func TestQueryGames(t *testing.T) {
+	man, cleanup := testIndexManager(t)
+	defer cleanup()
+
+	dir := filepath.Dir(man.repositoriesDir())
+	man.Config().CalculatedGamesPath = filepath.Join(dir, "games")
+	man.Config().CalculatedInsteadManPath = dir
+	assert.NoError(t, os.MkdirAll(filepath.Join(man.Config().CalculatedGamesPath, "game1"), os.ModePerm))
+	writeTestRepository(t, filepath.Join(man.repositoriesDir(), "official.xml"), 3)
+
+	// Games aren't filtered by favorites without the database
+	_, e := man.QueryGames(GameQuery{Favorite: true})
+	assert.Equal(t, ErrNoGameDB, e)
+	memoryGames, e := man.QueryGames(GameQuery{Installed: true})
+	assert.NoError(t, e)
+
+	man.DB = NewGameDB(man.GameDBFile())
+	games, e := man.QueryGames(GameQuery{Installed: true})
+	assert.NoError(t, e)
+	assert.Equal(t, gameIds(memoryGames), gameIds(games))
+	assert.Equal(t, "game1", games[0].Name)
+
+	// Catalog is synced when games have changed
+	assert.NoError(t, os.RemoveAll(filepath.Join(man.Config().CalculatedGamesPath, "game1")))
+	games, e = man.QueryGames(GameQuery{Installed: true})
+	assert.NoError(t, e)
+	assert.Empty(t, games)
+
+	games, e = man.QueryGames(GameQuery{Keyword: "game2"})
+	assert.NoError(t, e)
+	assert.Len(t, games, 1)
+
+	_, e = man.QueryGames(GameQuery{Favorite: true})
+	assert.NoError(t, e)
+}
+
+// BenchmarkQueryGames filters the games by the database
+func BenchmarkQueryGames(b *testing.B) {
+	man, cleanup := testIndexManager(b)
+	defer cleanup()
+
+	dir := filepath.Dir(man.repositoriesDir())
+	man.Config().CalculatedGamesPath = filepath.Join(dir, "games")
+	os.MkdirAll(man.Config().CalculatedGamesPath, os.ModePerm)
+	writeTestRepository(b, filepath.Join(man.repositoriesDir(), "official.xml"), benchmarkRepositoryGames)
+	man.DB = NewGameDB(filepath.Join(dir, "games.db"))
+
+	_, e := man.QueryGames(GameQuery{})
+	if e != nil {
+		b.Fatal(e)
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		games, e := man.QueryGames(GameQuery{Keyword: "game1", SortBy: SortByDateDesc, Limit: 20})
+		if e != nil || len(games) != 20 {
+			b.Fatal("games haven't queried")
+		}
+	}
+}
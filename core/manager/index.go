@@ -0,0 +1,88 @@
+package manager
+
+import (
+	"encoding/gob"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// repositoriesIndexFileName is a cache of the parsed repositories, so XML files aren't parsed by every command
+const repositoriesIndexFileName = "index.gob"
+
+// repositoryIndexEntry is a parsed repository file, it's valid while modification time and size of the file
+// are the same
+type repositoryIndexEntry struct {
+	ModTime time.Time
+	Size    int64
+	Games   []Game
+}
+
+// repositoriesIndex is a map of the parsed repositories (key is a repository file name)
+type repositoriesIndex map[string]repositoryIndexEntry
+
+func (m *Manager) repositoriesIndexFile() string {
+	return filepath.Join(m.repositoriesDir(), repositoriesIndexFileName)
+}
+
+// readRepositoriesIndex returns empty index if it doesn't exist or it's broken (repositories are parsed then)
+func (m *Manager) readRepositoriesIndex() repositoriesIndex {
+	index := make(repositoriesIndex)
+
+	f, e := os.Open(m.repositoriesIndexFile())
+	if e != nil {
+		return index
+	}
+	defer f.Close()
+
+	if gob.NewDecoder(f).Decode(&index) != nil {
+		return make(repositoriesIndex)
+	}
+
+	return index
+}
+
+// writeRepositoriesIndex writes the index to the temporary file and renames it, so other
+// processes don't read partially written index
+func (m *Manager) writeRepositoriesIndex(index repositoriesIndex) error {
+	fileName := m.repositoriesIndexFile()
+	tempFileName := fileName + ".part"
+
+	f, e := os.Create(tempFileName)
+	if e != nil {
+		return e
+	}
+
+	e = gob.NewEncoder(f).Encode(index)
+	closeErr := f.Close()
+	if e == nil {
+		e = closeErr
+	}
+	if e != nil {
+		os.Remove(tempFileName)
+		return e
+	}
+
+	return os.Rename(tempFileName, fileName)
+}
+
+// readRepositoryGames parses the repository file, repository name is a file name without extension
+func readRepositoryGames(fileName string) ([]Game, error) {
+	gameList, e := parseRepository(fileName)
+	if e != nil {
+		return nil, e
+	}
+
+	repositoryFileName := filepath.Base(fileName)
+	repositoryName := strings.TrimSuffix(repositoryFileName, filepath.Ext(repositoryFileName))
+
+	var games []Game = nil
+	for _, repositoryGame := range gameList.GameList {
+		game := Game(repositoryGame)
+		game.addGameAdditionalData(repositoryName)
+		games = append(games, game)
+	}
+
+	return games, nil
+}
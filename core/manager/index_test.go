@@ -0,0 +1,127 @@
+package manager
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jhekasoft/insteadman3/core/configurator"
+	"github.com/stretchr/testify/assert"
+)
+
+// writeTestRepository writes repository file with the count of games
+func writeTestRepository(t testing.TB, fileName string, count int) {
+	var xml strings.Builder
+	xml.WriteString("<?xml version=\"1.0\" encoding=\"UTF-8\"?>\n<game_list>\n")
+	for i := 0; i < count; i++ {
+		fmt.Fprintf(&xml, "<game><name>game%d</name><title>Game &amp; %d</title><version>1.%d</version>"+
+			"<url>http://example.com/game%d.zip</url><size>%d</size><lang>ru,en</lang><author>Author</author>"+
+			"<description>Description of the game %d</description><date>2020-01-02</date></game>\n",
+			i, i, i, i, 1000+i, i)
+	}
+	xml.WriteString("</game_list>\n")
+
+	e := ioutil.WriteFile(fileName, []byte(xml.String()), 0644)
+	if e != nil {
+		t.Fatal(e)
+	}
+}
+
+func testIndexManager(t testing.TB) (*Manager, func()) {
+	dir, e := ioutil.TempDir("", "insteadman")
+	if e != nil {
+		t.Fatal(e)
+	}
+
+	man := &Manager{config: configurator.NewHolder(&configurator.InsteadmanConfig{CalculatedCachePath: dir})}
+	e = os.MkdirAll(man.repositoriesDir(), os.ModePerm)
+	if e != nil {
+		t.Fatal(e)
+	}
+
+	return man, func() { os.RemoveAll(dir) }
+}
+
+func TestRepositoriesIndex(t *testing.T) {
+	man, cleanup := testIndexManager(t)
+	defer cleanup()
+
+	official := filepath.Join(man.repositoriesDir(), "official.xml")
+	writeTestRepository(t, official, 2)
+	writeTestRepository(t, filepath.Join(man.repositoriesDir(), "other.xml"), 1)
+
+	games, e := man.GetRepositoryGames()
+	assert.NoError(t, e)
+	assert.Len(t, games, 3)
+	assert.FileExists(t, man.repositoriesIndexFile())
+
+	// Games from the index are the same as parsed ones
+	cachedGames, e := man.GetRepositoryGames()
+	assert.NoError(t, e)
+	assert.Equal(t, games, cachedGames)
+	assert.Equal(t, "Game & 0", cachedGames[0].Title)
+	assert.Equal(t, "official/game0/ru_en", cachedGames[0].Id)
+
+	// Changed repository is parsed again
+	writeTestRepository(t, official, 5)
+	later := time.Now().Add(time.Minute)
+	assert.NoError(t, os.Chtimes(official, later, later))
+	games, e = man.GetRepositoryGames()
+	assert.NoError(t, e)
+	assert.Len(t, games, 6)
+
+	// Removed repository isn't kept in the index
+	assert.NoError(t, os.Remove(official))
+	games, e = man.GetRepositoryGames()
+	assert.NoError(t, e)
+	assert.Len(t, games, 1)
+	assert.Len(t, man.readRepositoriesIndex(), 1)
+
+	// Broken index is ignored
+	assert.NoError(t, ioutil.WriteFile(man.repositoriesIndexFile(), []byte("broken"), 0644))
+	games, e = man.GetRepositoryGames()
+	assert.NoError(t, e)
+	assert.Len(t, games, 1)
+}
+
+const benchmarkRepositoryGames = 5000
+
+// BenchmarkParseRepositories parses the repository by every call (behaviour without the index)
+func BenchmarkParseRepositories(b *testing.B) {
+	man, cleanup := testIndexManager(b)
+	defer cleanup()
+	fileName := filepath.Join(man.repositoriesDir(), "official.xml")
+	writeTestRepository(b, fileName, benchmarkRepositoryGames)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, e := readRepositoryGames(fileName)
+		if e != nil {
+			b.Fatal(e)
+		}
+	}
+}
+
+// BenchmarkGetRepositoryGames reads games from the index
+func BenchmarkGetRepositoryGames(b *testing.B) {
+	man, cleanup := testIndexManager(b)
+	defer cleanup()
+	writeTestRepository(b, filepath.Join(man.repositoriesDir(), "official.xml"), benchmarkRepositoryGames)
+
+	_, e := man.GetRepositoryGames()
+	if e != nil {
+		b.Fatal(e)
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		games, e := man.GetRepositoryGames()
+		if e != nil || len(games) != benchmarkRepositoryGames {
+			b.Fatal("games haven't read from the index")
+		}
+	}
+}
@@ -0,0 +1,241 @@
+package manager
+
+import (
+	"archive/zip"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jhekasoft/insteadman3/core/scheduler"
+)
+
+// Names of the periodic jobs of the manager
+const (
+	JobRepositories = "repositories"
+	JobCache        = "cache"
+	JobSavesBackup  = "saves_backup"
+	JobTelemetry    = "telemetry"
+)
+
+const (
+	RepositoriesRefreshInterval = 24 * time.Hour
+	CacheMaxAge                 = 30 * 24 * time.Hour
+
+	schedulerFileName = "scheduler.json"
+
+	savesDirName          = "saves"
+	savesBackupsDirName   = "saves_backups"
+	savesBackupsMaxCount  = 5
+	savesBackupTimeFormat = "20060102-150405"
+)
+
+// ErrNoSaves is returned when there is no INSTEAD saves directory
+var ErrNoSaves = errors.New("there are no saves")
+
+// Jobs returns periodic jobs of the manager for the scheduler of the daemon or GUI. Jobs which are disabled
+// in the config do nothing, so the config can be changed while the scheduler is running.
+func (m *Manager) Jobs() []scheduler.Job {
+	return []scheduler.Job{
+		{
+			Name:     JobRepositories,
+			Interval: RepositoriesRefreshInterval,
+			Jitter:   time.Hour,
+			Run: func(ctx context.Context) error {
+				return joinErrors(m.UpdateRepositories())
+			},
+		},
+		{
+			Name:     JobCache,
+			Interval: 24 * time.Hour,
+			Run: func(ctx context.Context) error {
+				return m.EvictCache(CacheMaxAge)
+			},
+		},
+		{
+			Name:     JobSavesBackup,
+			Interval: 24 * time.Hour,
+			Run: func(ctx context.Context) error {
+				if !m.Config().SavesBackup {
+					return nil
+				}
+
+				_, e := m.BackupSaves()
+				if e == ErrNoSaves {
+					return nil
+				}
+				return e
+			},
+		},
+		{
+			Name:     JobTelemetry,
+			Interval: 24 * time.Hour,
+			Jitter:   time.Hour,
+			Run: func(ctx context.Context) error {
+				return joinErrors(m.SendTelemetry())
+			},
+		},
+	}
+}
+
+// SchedulerStateFile returns path of the file with last runs of the jobs
+func (m *Manager) SchedulerStateFile() string {
+	return filepath.Join(m.Config().CalculatedInsteadManPath, schedulerFileName)
+}
+
+// EvictCache removes game images and downloaded archives which haven't changed for maxAge.
+// Repositories are kept, they are needed for the games list.
+func (m *Manager) EvictCache(maxAge time.Duration) error {
+	expired := time.Now().Add(-maxAge)
+
+	for _, dir := range []string{m.gameImagesDir(), filepath.Join(m.CacheDir(), tempGamesDirName)} {
+		files, e := filepath.Glob(filepath.Join(dir, "*"))
+		if e != nil {
+			return e
+		}
+
+		for _, file := range files {
+			info, e := os.Stat(file)
+			if e != nil || info.IsDir() || info.ModTime().After(expired) {
+				continue
+			}
+
+			e = os.Remove(file)
+			if e != nil {
+				return e
+			}
+		}
+	}
+
+	return nil
+}
+
+// appDataDir returns INSTEAD data directory (saves and settings of the games)
+func (m *Manager) appDataDir() string {
+	if m.Config().CalculatedAppDataPath != "" {
+		return m.Config().CalculatedAppDataPath
+	}
+
+	// Default INSTEAD data directory
+	if runtime.GOOS == "windows" {
+		return filepath.Join(os.Getenv("LOCALAPPDATA"), "instead")
+	}
+	if home := os.Getenv("HOME"); home != "" {
+		return filepath.Join(home, ".instead")
+	}
+
+	return ""
+}
+
+func (m *Manager) savesBackupsDir() string {
+	return filepath.Join(m.Config().CalculatedInsteadManPath, savesBackupsDirName)
+}
+
+// BackupSaves archives INSTEAD saves directory to the zip file, only last backups are kept.
+// It returns path of the backup.
+func (m *Manager) BackupSaves() (string, error) {
+	appDataDir := m.appDataDir()
+	if appDataDir == "" {
+		return "", ErrNoSaves
+	}
+
+	savesDir := filepath.Join(appDataDir, savesDirName)
+	info, e := os.Stat(savesDir)
+	if e != nil || !info.IsDir() {
+		return "", ErrNoSaves
+	}
+
+	backupsDir := m.savesBackupsDir()
+	e = os.MkdirAll(backupsDir, os.ModePerm)
+	if e != nil {
+		return "", e
+	}
+
+	backupPath := filepath.Join(backupsDir, time.Now().Format(savesBackupTimeFormat)+".zip")
+	e = zipDir(savesDir, backupPath)
+	if e != nil {
+		os.Remove(backupPath)
+		return "", e
+	}
+
+	m.removeOldSavesBackups()
+
+	return backupPath, nil
+}
+
+func (m *Manager) removeOldSavesBackups() {
+	backups, e := filepath.Glob(filepath.Join(m.savesBackupsDir(), "*.zip"))
+	if e != nil || len(backups) <= savesBackupsMaxCount {
+		return
+	}
+
+	// Names are times, so the oldest are first
+	sort.Strings(backups)
+	for _, path := range backups[:len(backups)-savesBackupsMaxCount] {
+		os.Remove(path)
+	}
+}
+
+func zipDir(dir, zipPath string) error {
+	out, e := os.Create(zipPath)
+	if e != nil {
+		return e
+	}
+	defer out.Close()
+
+	w := zip.NewWriter(out)
+
+	e = filepath.Walk(dir, func(path string, info os.FileInfo, e error) error {
+		if e != nil || info.IsDir() {
+			return e
+		}
+
+		relPath, e := filepath.Rel(dir, path)
+		if e != nil {
+			return e
+		}
+
+		f, e := w.Create(filepath.ToSlash(relPath))
+		if e != nil {
+			return e
+		}
+
+		in, e := os.Open(path)
+		if e != nil {
+			return e
+		}
+		defer in.Close()
+
+		_, e = io.Copy(f, in)
+		return e
+	})
+	if e != nil {
+		return e
+	}
+
+	e = w.Close()
+	if e != nil {
+		return e
+	}
+
+	return out.Close()
+}
+
+// joinErrors returns errors of the job as one error
+func joinErrors(errs []error) error {
+	if len(errs) == 0 {
+		return nil
+	}
+
+	var messages []string
+	for _, e := range errs {
+		messages = append(messages, e.Error())
+	}
+
+	return errors.New(strings.Join(messages, "; "))
+}
@@ -1,6 +1,7 @@
 package manager
 
 import (
+	"context"
 	"encoding/xml"
 	"errors"
 	"io"
@@ -12,15 +13,20 @@ import (
 	"path/filepath"
 	"regexp"
 	"sort"
+	"strconv"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/jhekasoft/insteadman3/core/configurator"
 	"github.com/jhekasoft/insteadman3/core/interpreterfinder"
+	"github.com/jhekasoft/insteadman3/core/notify"
+	"github.com/jhekasoft/insteadman3/core/shortcuts"
+	"github.com/jhekasoft/insteadman3/core/telemetry"
 	"github.com/jhekasoft/insteadman3/core/utils"
 )
 
 const (
-	//updateCheckUrl = "https://raw.githubusercontent.com/jhekasoft/insteadman/master/version.json"
 	cacheDirName        = "cache"
 	repositoriesDirName = "repositories"
 	tempGamesDirName    = "temp_games"
@@ -31,9 +37,49 @@ const (
 )
 
 type Manager struct {
-	Config            *configurator.InsteadmanConfig
 	InterpreterFinder *interpreterfinder.InterpreterFinder
 	CurrentRunningCmd *exec.Cmd
+	// Shortcuts creates menu shortcuts of the installed games if they are enabled in the config
+	Shortcuts *shortcuts.Creator
+	// Telemetry counts installs of the games if it's enabled in the config
+	Telemetry *telemetry.Stats
+	// Notifier alerts about finished background operations if notifications are enabled in the config
+	Notifier notify.Notifier
+	// DB is a database of the games for the queries, favorites, tags and play history (it can be nil)
+	DB *GameDB
+	// Events are sent to the subscribers when games or repositories have changed
+	Events
+
+	currentRunner Runner
+
+	// config is shared with the configurator, it's replaced by the changed copy while background
+	// operations use it, so it's read by Config()
+	config *configurator.Holder
+
+	imagesMutex sync.Mutex
+	imageLoads  map[string]*imageLoad // images which are downloading now (key is a file path)
+}
+
+// New returns manager of the config (see Configurator.Holder), games are changed by it and INSTEAD is found by the finder
+func New(config *configurator.Holder, finder *interpreterfinder.InterpreterFinder) *Manager {
+	return &Manager{config: config, InterpreterFinder: finder}
+}
+
+// Config returns current config, it mustn't be changed (see UpdateConfig). Config values which are read
+// together should be read from the same returned config.
+func (m *Manager) Config() *configurator.InsteadmanConfig {
+	return m.config.Config()
+}
+
+// UpdateConfig changes copy of the config by f and replaces config by it, config isn't replaced if f fails
+func (m *Manager) UpdateConfig(f func(config *configurator.InsteadmanConfig) error) (*configurator.InsteadmanConfig, error) {
+	return m.config.Update(f)
+}
+
+// imageLoad is a game image downloading which is shared by the concurrent GetGameImage calls
+type imageLoad struct {
+	done chan struct{}
+	e    error
 }
 
 func (m *Manager) HasDownloadedRepositories() bool {
@@ -61,7 +107,11 @@ func (m *Manager) UpdateRepositories() []error {
 	}
 
 	var errs []error = nil
-	for _, repo := range m.Config.Repositories {
+	for _, repo := range m.Config().Repositories {
+		if repo.Disabled {
+			continue
+		}
+
 		e := downloadFileSimple(filepath.Join(repositoriesDir, repo.Name+".xml"), repo.Url)
 
 		if e != nil {
@@ -69,9 +119,14 @@ func (m *Manager) UpdateRepositories() []error {
 		}
 	}
 
+	m.notifyRepositories(errs)
+	m.Publish(RepositoriesUpdated{Errors: errs})
+
 	return errs
 }
 
+// GetRepositoryGames returns games of the downloaded repositories. Parsed repositories are cached
+// in the index, only changed repository files are parsed.
 func (m *Manager) GetRepositoryGames() ([]Game, error) {
 	repositoriesDir := m.repositoriesDir()
 	files, e := filepath.Glob(filepath.Join(repositoriesDir, "*.xml"))
@@ -79,38 +134,55 @@ func (m *Manager) GetRepositoryGames() ([]Game, error) {
 		return nil, e
 	}
 
+	index := m.readRepositoriesIndex()
+	newIndex := make(repositoriesIndex)
+	changed := false
+
 	var games []Game = nil
 	for _, fileName := range files {
-		// fmt.Printf("File: %v\n", fileName)
+		info, e := os.Stat(fileName)
+		if e != nil {
+			continue
+		}
 
-		gameList, e := parseRepository(fileName)
-		if e == nil {
-			repositoryFileName := filepath.Base(fileName)
-			repositoryName := strings.TrimSuffix(repositoryFileName, filepath.Ext(repositoryFileName))
-
-			var repositoryGames []Game = nil
-			for _, repositoryGame := range gameList.GameList {
-				game := Game(repositoryGame)
-				game.addGameAdditionalData(repositoryName)
-				repositoryGames = append(repositoryGames, game)
+		repositoryFileName := filepath.Base(fileName)
+		entry, ok := index[repositoryFileName]
+		if !ok || !entry.ModTime.Equal(info.ModTime()) || entry.Size != info.Size() {
+			repositoryGames, e := readRepositoryGames(fileName)
+			if e != nil {
+				continue
 			}
-			games = append(games, repositoryGames...)
+			entry = repositoryIndexEntry{ModTime: info.ModTime(), Size: info.Size(), Games: repositoryGames}
+			changed = true
 		}
+
+		newIndex[repositoryFileName] = entry
+		games = append(games, entry.Games...)
+	}
+
+	// Index is only a cache, games are returned without it too
+	if changed || len(newIndex) != len(index) {
+		m.writeRepositoriesIndex(newIndex)
 	}
 
 	return games, nil
 }
 
 func (m *Manager) CacheDir() string {
-	return filepath.Join(m.Config.CalculatedInsteadManPath, cacheDirName)
+	config := m.Config()
+	if config.CalculatedCachePath != "" {
+		return config.CalculatedCachePath
+	}
+
+	return filepath.Join(config.CalculatedInsteadManPath, cacheDirName)
 }
 
 func (m *Manager) repositoriesDir() string {
-	return filepath.Join(m.Config.CalculatedInsteadManPath, cacheDirName, repositoriesDirName)
+	return filepath.Join(m.CacheDir(), repositoriesDirName)
 }
 
 func (m *Manager) gameImagesDir() string {
-	return filepath.Join(m.Config.CalculatedInsteadManPath, cacheDirName, gameImagesDirName)
+	return filepath.Join(m.CacheDir(), gameImagesDirName)
 }
 
 func parseRepository(fileName string) (*RepositoryGameList, error) {
@@ -130,7 +202,7 @@ func parseRepository(fileName string) (*RepositoryGameList, error) {
 }
 
 func (m *Manager) GetInstalledGames() ([]Game, error) {
-	files, e := ioutil.ReadDir(m.Config.CalculatedGamesPath)
+	files, e := ioutil.ReadDir(m.Config().CalculatedGamesPath)
 	if e != nil {
 		return nil, e
 	}
@@ -141,7 +213,7 @@ func (m *Manager) GetInstalledGames() ([]Game, error) {
 			continue
 		}
 
-		game := ReadLocalGameInfo(m.Config.CalculatedGamesPath, file)
+		game := ReadLocalGameInfo(m.Config().CalculatedGamesPath, file)
 		games = append(games, game)
 	}
 
@@ -279,61 +351,84 @@ func FindGamesByName(games []Game, name string) (foundGames []Game) {
 	return
 }
 
+// RunGame runs installed game by the native INSTEAD interpreter or by the web runner
+// if there isn't native interpreter (see GameRunner)
 func (m *Manager) RunGame(game *Game) error {
+	return m.RunGameArgs(game, nil)
+}
+
+// RunGameArgs runs the game with extra INSTEAD arguments (they are ignored by the web runner)
+func (m *Manager) RunGameArgs(game *Game, args []string) error {
 	if game == nil {
 		return nil
 	}
 
-	// Absolute games path
-	gamesPath, e := filepath.Abs(m.Config.CalculatedGamesPath)
+	runner, e := m.GameRunner(game.Name)
 	if e != nil {
 		return e
 	}
+	if execRunner, ok := runner.(*ExecRunner); ok {
+		execRunner.Args = args
+	}
 
-	interpreterCommand := m.InterpreterCommand()
-
-	// todo: idf
-	cmd := exec.Command(interpreterCommand, "-gamespath", gamesPath, "-game", game.Name)
-	cmd.Dir = filepath.Dir(interpreterCommand)
-	e = cmd.Start()
-
-	// Current running cmd
+	e = runner.Run(game)
 	if e == nil {
-		m.CurrentRunningCmd = cmd
+		m.currentRunner = runner
+
+		// History isn't required for running
+		if m.DB != nil {
+			m.DB.AddPlay(game.Name, time.Now())
+		}
 	}
 
 	return e
 }
 
 func (m *Manager) StopRunningGame() error {
-	if m.CurrentRunningCmd == nil {
+	if m.currentRunner == nil {
 		return nil
 	}
 
-	e := m.CurrentRunningCmd.Process.Kill()
+	return m.currentRunner.Stop()
+}
 
-	return e
+// CurrentRunner returns runner of the last running game
+func (m *Manager) CurrentRunner() Runner {
+	return m.currentRunner
 }
 
+// downloadFileSimple downloads file. The file isn't changed if downloading fails, partly downloaded file
+// isn't visible for the readers.
 func downloadFileSimple(fileName, url string) error {
-	// Create the file
-	out, e := os.Create(fileName)
+	// Download the data
+	resp, e := http.Get(url)
 	if e != nil {
 		return e
 	}
-	defer out.Close()
+	defer resp.Body.Close()
 
-	// Download the data
-	resp, e := http.Get(url)
+	if resp.StatusCode != http.StatusOK {
+		return errors.New(url + ": " + resp.Status)
+	}
+
+	// Write the data to the temp file
+	partFileName := fileName + ".part"
+	out, e := os.Create(partFileName)
 	if e != nil {
 		return e
 	}
-	defer resp.Body.Close()
 
-	// Write the data to the file
 	_, e = io.Copy(out, resp.Body)
+	closeErr := out.Close()
+	if e == nil {
+		e = closeErr
+	}
+	if e != nil {
+		os.Remove(partFileName)
+		return e
+	}
 
-	return e
+	return os.Rename(partFileName, fileName)
 }
 
 type WriteCounter struct {
@@ -350,7 +445,7 @@ func (wc *WriteCounter) Write(p []byte) (int, error) {
 	return n, nil
 }
 
-func downloadFile(fileName, url string, progressF func(uint64)) error {
+func downloadFile(ctx context.Context, fileName, url string, progressF func(uint64)) error {
 	// Create the file
 	out, e := os.Create(fileName)
 	if e != nil {
@@ -359,7 +454,11 @@ func downloadFile(fileName, url string, progressF func(uint64)) error {
 	defer out.Close()
 
 	// Download the data
-	resp, e := http.Get(url)
+	req, e := http.NewRequest(http.MethodGet, url, nil)
+	if e != nil {
+		return e
+	}
+	resp, e := http.DefaultClient.Do(req.WithContext(ctx))
 	if e != nil {
 		return e
 	}
@@ -395,7 +494,7 @@ func (m *Manager) GetGameImage(game *Game) (imagePath string, e error) {
 		return imagePath, e
 	}
 
-	e = downloadFileSimple(imagePath, game.Image)
+	e = m.downloadImage(imagePath, game.Image)
 	if e != nil {
 		return "", e
 	}
@@ -403,57 +502,266 @@ func (m *Manager) GetGameImage(game *Game) (imagePath string, e error) {
 	return imagePath, e
 }
 
+// downloadImage downloads image once if it's requested several times at the same time
+// (user switches games quickly)
+func (m *Manager) downloadImage(imagePath, url string) error {
+	m.imagesMutex.Lock()
+	if load, ok := m.imageLoads[imagePath]; ok {
+		m.imagesMutex.Unlock()
+		<-load.done
+		return load.e
+	}
+
+	load := &imageLoad{done: make(chan struct{})}
+	if m.imageLoads == nil {
+		m.imageLoads = make(map[string]*imageLoad)
+	}
+	m.imageLoads[imagePath] = load
+	m.imagesMutex.Unlock()
+
+	load.e = downloadFileSimple(imagePath, url)
+
+	m.imagesMutex.Lock()
+	delete(m.imageLoads, imagePath)
+	m.imagesMutex.Unlock()
+	close(load.done)
+
+	return load.e
+}
+
+// InstallPhase is a stage of the game installing which is reported to the front ends
+type InstallPhase int
+
+const (
+	InstallPhaseDownload InstallPhase = iota
+	InstallPhaseExtract
+)
+
 func (m *Manager) InstallGame(game *Game, progressF func(uint64)) error {
+	return m.InstallGameContext(context.Background(), game, progressF, nil)
+}
+
+// InstallGameContext installs the game like InstallGame, installing is stopped when context is done.
+// phaseF (can be nil) is called when downloading and extracting are started.
+func (m *Manager) InstallGameContext(ctx context.Context, game *Game, progressF func(uint64),
+	phaseF func(InstallPhase)) error {
 	// todo: idf
 
-	tempGamesDir := filepath.Join(m.CacheDir(), tempGamesDirName)
-	os.MkdirAll(tempGamesDir, os.ModePerm)
+	// Game is checked and installed by the same interpreter (it can be overridden for the game)
+	interpreterCommand, e := m.GameInterpreterCommand(game.Name)
+	if e != nil {
+		return e
+	}
+
+	e = m.checkInstallRequirements(interpreterCommand)
+	if e != nil {
+		return e
+	}
+
+	e = m.installArchive(ctx, interpreterCommand, game.Name, game.Url, m.downloadProgress(game, progressF), phaseF)
+	if e == nil {
+		m.createShortcut(game)
+		m.countInstall(game)
+		m.notifyInstalled(game)
+		m.Publish(GameInstalled{Game: *game})
+	}
+
+	return e
+}
+
+// InstallArchiveContext installs the game from the archive file or URL (like "game.zip" argument of the front end)
+func (m *Manager) InstallArchiveContext(ctx context.Context, location string, progressF func(uint64),
+	phaseF func(InstallPhase)) error {
+
+	gameName := ArchiveGameName(location)
+	e := m.installArchive(ctx, m.InterpreterCommand(), gameName, location, progressF, phaseF)
+	if e == nil {
+		m.Publish(GameInstalled{Game: Game{Name: gameName}})
+	}
+
+	return e
+}
+
+// ArchiveGameName returns expected game name of the archive ("instead-crossworlds-0.7.zip" is "instead-crossworlds-0.7")
+func ArchiveGameName(location string) string {
+	name := path.Base(filepath.ToSlash(location))
+	return strings.TrimSuffix(name, path.Ext(name))
+}
+
+func isURL(location string) bool {
+	return strings.HasPrefix(location, "http://") || strings.HasPrefix(location, "https://")
+}
+
+// installArchive downloads the archive if it's URL and extracts it by the INSTEAD command. gameName is a directory
+// of the game which is removed if installing has cancelled.
+func (m *Manager) installArchive(ctx context.Context, interpreterCommand, gameName, location string,
+	progressF func(uint64), phaseF func(InstallPhase)) error {
+
+	if phaseF != nil {
+		phaseF(InstallPhaseDownload)
+	}
+
+	fileName := location
+	if isURL(location) {
+		tempGamesDir := filepath.Join(m.CacheDir(), tempGamesDirName)
+		os.MkdirAll(tempGamesDir, os.ModePerm)
+
+		fileName = filepath.Join(tempGamesDir, path.Base(location))
+
+		// Remove downloaded temp file (after installing or cancelling)
+		defer os.Remove(fileName)
+
+		e := downloadFile(ctx, fileName, location, progressF)
+		if e != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			return e
+		}
+	} else if !utils.PathExist(location) {
+		return errors.New("archive " + location + " hasn't found")
+	}
 
 	// Absolute filepath
-	fileName := filepath.Join(tempGamesDir, path.Base(game.Url))
 	fileNameAbs, e := filepath.Abs(fileName)
 	if e == nil {
 		fileName = fileNameAbs
 	}
 
-	e = downloadFile(fileName, game.Url, progressF)
+	// Absolute games path
+	gamesPath, e := filepath.Abs(m.Config().CalculatedGamesPath)
 	if e != nil {
 		return e
 	}
 
-	// Remove downloaded temp file (after installing)
-	defer os.Remove(fileName)
-
-	// Absolute games path
-	gamesPath, e := filepath.Abs(m.Config.CalculatedGamesPath)
-	if e != nil {
-		return e
+	if phaseF != nil {
+		phaseF(InstallPhaseExtract)
 	}
 
-	interpreterCommand := m.InterpreterCommand()
+	gameDir := filepath.Join(gamesPath, gameName)
+	gameExisted := utils.PathExist(gameDir)
 
-	cmd := exec.Command(interpreterCommand, "-gamespath", gamesPath, "-install", fileName, "-quit")
+	cmd := interpreterfinder.Command(interpreterCommand, "-gamespath", gamesPath, "-install", fileName, "-quit")
 	cmd.Dir = filepath.Dir(interpreterCommand)
-	out, e := cmd.CombinedOutput()
+	out, e := interpreterfinder.CombinedOutputContext(ctx, cmd)
+	if ctx.Err() != nil {
+		// Don't keep partly extracted game
+		if !gameExisted {
+			os.RemoveAll(gameDir)
+		}
+		return ctx.Err()
+	}
 	if e != nil {
-		return errors.New(e.Error() + "; " + strings.Replace(string(out), "\n", "", -1))
+		return &InstallError{Err: e, Output: string(out)}
 	}
 
 	return nil
 }
 
+// InstallError is returned when INSTEAD hasn't installed the game, Output is the INSTEAD output
+type InstallError struct {
+	Err    error
+	Output string
+}
+
+func (e *InstallError) Error() string {
+	return e.Err.Error() + "; " + strings.Replace(e.Output, "\n", "", -1)
+}
+
+// Unwrap returns error of the INSTEAD running
+func (e *InstallError) Unwrap() error {
+	return e.Err
+}
+
+// UpdateGame installs new version of the installed game. Installed version is kept and restored
+// if installing fails.
+func (m *Manager) UpdateGame(game *Game, progressF func(uint64)) error {
+	return m.UpdateGameContext(context.Background(), game, progressF, nil)
+}
+
+// UpdateGameContext updates the game like UpdateGame, installed version is restored if updating is cancelled
+func (m *Manager) UpdateGameContext(ctx context.Context, game *Game, progressF func(uint64),
+	phaseF func(InstallPhase)) error {
+	gameDir := filepath.Join(m.Config().CalculatedGamesPath, game.Name)
+	if !utils.PathExist(gameDir) {
+		return m.InstallGameContext(ctx, game, progressF, phaseF)
+	}
+
+	backupDir := filepath.Join(m.Config().CalculatedGamesPath, "."+game.Name+".update-backup")
+	os.RemoveAll(backupDir)
+
+	e := os.Rename(gameDir, backupDir)
+	if e != nil {
+		return e
+	}
+
+	e = m.InstallGameContext(ctx, game, progressF, phaseF)
+	if e != nil || !utils.PathExist(gameDir) {
+		os.RemoveAll(gameDir)
+		if restoreErr := os.Rename(backupDir, gameDir); restoreErr != nil {
+			return restoreErr
+		}
+		if e == nil {
+			e = errors.New("game " + game.Name + " hasn't installed")
+		}
+		return e
+	}
+
+	return os.RemoveAll(backupDir)
+}
+
 func (m *Manager) RemoveGame(game *Game) error {
 	// todo: idf
 
-	gameDir := filepath.Join(m.Config.CalculatedGamesPath, game.Name)
+	gameDir := filepath.Join(m.Config().CalculatedGamesPath, game.Name)
 
 	e := os.RemoveAll(gameDir)
+	if e == nil {
+		m.removeShortcut(game)
+		m.Publish(GameRemoved{Game: *game})
+	}
 
 	return e
 }
 
+// ReloadConfig replaces config by the new one. Background operations keep config which they have got
+// by Config(), so config isn't changed under them.
+func (m *Manager) ReloadConfig(config *configurator.InsteadmanConfig) {
+	if config == nil {
+		return
+	}
+
+	m.config.Replace(config)
+}
+
 func (m *Manager) GetRepositories() []configurator.Repository {
-	return m.Config.Repositories
+	return m.Config().Repositories
+}
+
+// TestRepository downloads and parses repository without saving it, returns count of the games
+func (m *Manager) TestRepository(url string) (int, error) {
+	resp, e := http.Get(url)
+	if e != nil {
+		return 0, e
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return 0, errors.New("Repository response: " + resp.Status)
+	}
+
+	data, e := ioutil.ReadAll(resp.Body)
+	if e != nil {
+		return 0, e
+	}
+
+	var gameList *RepositoryGameList
+	e = xml.Unmarshal(data, &gameList)
+	if e != nil {
+		return 0, e
+	}
+
+	return len(gameList.GameList), nil
 }
 
 func (m *Manager) FindLangs(games []Game) []string {
@@ -475,7 +783,7 @@ func (m *Manager) ClearCache() error {
 }
 
 func (m *Manager) IsBuiltinInterpreterCommand() bool {
-	if m.Config.UseBuiltinInterpreter {
+	if m.Config().UseBuiltinInterpreter {
 		return m.InterpreterFinder.HaveBuiltIn()
 	}
 
@@ -483,20 +791,90 @@ func (m *Manager) IsBuiltinInterpreterCommand() bool {
 }
 
 func (m *Manager) InterpreterCommand() string {
-	if m.Config.UseBuiltinInterpreter {
+	return m.interpreterCommand(m.Config())
+}
+
+func (m *Manager) interpreterCommand(config *configurator.InsteadmanConfig) string {
+	if config.UseBuiltinInterpreter {
 		builtInCmd := m.InterpreterFinder.FindBuiltIn()
 		if builtInCmd != "" {
 			return configurator.ExpandInterpreterCommand(builtInCmd)
 		}
 	}
 
-	if m.Config.InterpreterCommand != "" {
-		return configurator.ExpandInterpreterCommand(m.Config.InterpreterCommand)
+	if config.DefaultInterpreter != "" {
+		if interpreter := config.FindInterpreter(config.DefaultInterpreter); interpreter != nil {
+			return configurator.ExpandInterpreterCommand(interpreter.Command)
+		}
+	}
+
+	if config.InterpreterCommand != "" {
+		return configurator.ExpandInterpreterCommand(config.InterpreterCommand)
 	}
 
 	return ""
 }
 
+// GameInterpreterCommand returns interpreter command which is overridden for the game or the default one
+func (m *Manager) GameInterpreterCommand(gameName string) (string, error) {
+	config := m.Config()
+	gameConfig := config.GameConfig(gameName)
+
+	if gameConfig.InterpreterCommand != "" {
+		return configurator.ExpandInterpreterCommand(gameConfig.InterpreterCommand), nil
+	}
+
+	if gameConfig.Interpreter != "" {
+		interpreter := config.FindInterpreter(gameConfig.Interpreter)
+		if interpreter == nil {
+			return "", errors.New("interpreter " + gameConfig.Interpreter + " isn't registered")
+		}
+
+		return configurator.ExpandInterpreterCommand(interpreter.Command), nil
+	}
+
+	return m.interpreterCommand(config), nil
+}
+
+// RegisterInterpreters adds interpreters to the config (interpreters with the same command are skipped)
+func (m *Manager) RegisterInterpreters(interpreters []configurator.Interpreter) (added []configurator.Interpreter) {
+	m.UpdateConfig(func(config *configurator.InsteadmanConfig) error {
+		added = registerInterpreters(config, interpreters)
+		return nil
+	})
+
+	return
+}
+
+func registerInterpreters(config *configurator.InsteadmanConfig,
+	interpreters []configurator.Interpreter) (added []configurator.Interpreter) {
+	for _, interpreter := range interpreters {
+		registered := false
+		for i, existing := range config.Interpreters {
+			if existing.Command == interpreter.Command {
+				// Update version of the existing interpreter
+				config.Interpreters[i].Version = interpreter.Version
+				registered = true
+				break
+			}
+		}
+		if registered {
+			continue
+		}
+
+		// Name has to be unique
+		name := interpreter.Name
+		for n := 2; config.FindInterpreter(interpreter.Name) != nil; n++ {
+			interpreter.Name = name + "-" + strconv.Itoa(n)
+		}
+
+		config.Interpreters = append(config.Interpreters, interpreter)
+		added = append(added, interpreter)
+	}
+
+	return
+}
+
 func FilterRepositoryName(name string) (filteredName string, e error) {
 	r, e := regexp.Compile("[^a-zA-Z0-9\\-_.]+")
 	if e != nil {
@@ -506,7 +884,3 @@ func FilterRepositoryName(name string) (filteredName string, e error) {
 	filteredName = r.ReplaceAllString(name, "")
 	return
 }
-
-// func CheckAppNewVersion() {
-
-// }
@@ -1,10 +1,30 @@
 package manager
 
 import (
+	"archive/zip"
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/jhekasoft/insteadman3/core/configurator"
 	"github.com/jhekasoft/insteadman3/core/interpreterfinder"
+	"github.com/jhekasoft/insteadman3/core/notify"
+	"github.com/jhekasoft/insteadman3/core/shortcuts"
+	"github.com/jhekasoft/insteadman3/core/telemetry"
+	"github.com/jhekasoft/insteadman3/core/utils"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -23,7 +43,7 @@ func TestUpdateRepositories(t *testing.T) {
 	config, e := conf.GetConfig()
 	assert.NoError(t, e)
 
-	man := Manager{Config: config}
+	man := Manager{config: configurator.NewHolder(config)}
 	errors := man.UpdateRepositories()
 
 	assert.Empty(t, errors)
@@ -34,7 +54,7 @@ func TestGetSortedGamesAndFilterGames(t *testing.T) {
 	config, e := conf.GetConfig()
 	assert.NoError(t, e)
 
-	man := Manager{Config: config}
+	man := Manager{config: configurator.NewHolder(config)}
 
 	// Sorted games
 	games, e := man.GetSortedGames()
@@ -63,7 +83,7 @@ func TestInstallGame(t *testing.T) {
 	assert.NotNil(t, interpreterPath)
 	config.InterpreterCommand = *interpreterPath
 
-	man := Manager{Config: config, InterpreterFinder: finder}
+	man := Manager{config: configurator.NewHolder(config), InterpreterFinder: finder}
 
 	e = man.InstallGame(&Game{Name: testGameName, Url: testGameUrl}, nil)
 
@@ -91,7 +111,7 @@ func TestRunGame(t *testing.T) {
 	assert.NotNil(t, interpreterPath)
 	config.InterpreterCommand = *interpreterPath
 
-	man := Manager{Config: config, InterpreterFinder: finder}
+	man := Manager{config: configurator.NewHolder(config), InterpreterFinder: finder}
 
 	// Run game
 	e = man.RunGame(&Game{Name: testGameName, Url: testGameUrl})
@@ -107,7 +127,7 @@ func TestRemoveGame(t *testing.T) {
 	config, e := conf.GetConfig()
 	assert.NoError(t, e)
 
-	man := Manager{Config: config}
+	man := Manager{config: configurator.NewHolder(config)}
 	e = man.RemoveGame(&Game{Name: testGameName, Url: testGameUrl})
 
 	assert.NoError(t, e)
@@ -118,18 +138,52 @@ func TestRepositories(t *testing.T) {
 	config, e := conf.GetConfig()
 	assert.NoError(t, e)
 
-	man := Manager{Config: config}
+	man := Manager{config: configurator.NewHolder(config)}
 	repositories := man.GetRepositories()
 
 	assert.NotEmpty(t, repositories)
 }
 
+func TestTestRepositoryAndDisabled(t *testing.T) {
+	dir, e := ioutil.TempDir("", "insteadman")
+	assert.NoError(t, e)
+	defer os.RemoveAll(dir)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/games.xml" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte("<game_list><game><name>first</name></game><game><name>second</name></game></game_list>"))
+	}))
+	defer server.Close()
+
+	man := Manager{config: configurator.NewHolder(&configurator.InsteadmanConfig{
+		Repositories: []configurator.Repository{
+			{Name: "enabled", Url: server.URL + "/games.xml"},
+			{Name: "disabled", Url: server.URL + "/games.xml", Disabled: true},
+		},
+		CalculatedCachePath: dir,
+	})}
+
+	count, e := man.TestRepository(server.URL + "/games.xml")
+	assert.NoError(t, e)
+	assert.Equal(t, 2, count)
+
+	_, e = man.TestRepository(server.URL + "/wrong.xml")
+	assert.Error(t, e)
+
+	assert.Empty(t, man.UpdateRepositories())
+	assert.FileExists(t, filepath.Join(dir, repositoriesDirName, "enabled.xml"))
+	assert.False(t, utils.PathExist(filepath.Join(dir, repositoriesDirName, "disabled.xml")))
+}
+
 func TestLangs(t *testing.T) {
 	conf := configurator.Configurator{FilePath: configFilePath}
 	config, e := conf.GetConfig()
 	assert.NoError(t, e)
 
-	man := Manager{Config: config}
+	man := Manager{config: configurator.NewHolder(config)}
 
 	games, e := man.GetSortedGames()
 	assert.NoError(t, e)
@@ -149,7 +203,7 @@ func TestGetGameImage(t *testing.T) {
 	assert.NotNil(t, interpreterPath)
 	config.InterpreterCommand = *interpreterPath
 
-	man := Manager{Config: config, InterpreterFinder: finder}
+	man := Manager{config: configurator.NewHolder(config), InterpreterFinder: finder}
 
 	imageFilePath, e := man.GetGameImage(&Game{Id: testGameId, Image: testGameImage})
 
@@ -162,7 +216,7 @@ func TestClearCache(t *testing.T) {
 	config, e := conf.GetConfig()
 	assert.NoError(t, e)
 
-	man := Manager{Config: config}
+	man := Manager{config: configurator.NewHolder(config)}
 
 	e = man.ClearCache()
 	assert.NoError(t, e)
@@ -181,3 +235,962 @@ func TestFilterRepositoryName(t *testing.T) {
 		assert.Equal(t, result, mustBeName)
 	}
 }
+
+func TestRunGameWithGameConfig(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("shell script interpreter")
+	}
+
+	dir, e := ioutil.TempDir("", "insteadman")
+	assert.NoError(t, e)
+	defer os.RemoveAll(dir)
+
+	outPath := filepath.Join(dir, "out.txt")
+	interpreterPath := filepath.Join(dir, "legacy-instead")
+	script := "#!/bin/sh\necho \"$@ $TEST_GAME_ENV\" > " + outPath + "\n"
+	assert.NoError(t, ioutil.WriteFile(interpreterPath, []byte(script), 0755))
+
+	config := &configurator.InsteadmanConfig{
+		InterpreterCommand:  "/nonexistent/instead",
+		CalculatedGamesPath: gamesPath,
+		Games: map[string]configurator.GameConfig{
+			testGameName: {
+				InterpreterCommand: interpreterPath,
+				Args:               []string{"-nosound"},
+				Env:                map[string]string{"TEST_GAME_ENV": "legacy"},
+			},
+		},
+	}
+	man := Manager{config: configurator.NewHolder(config), InterpreterFinder: new(interpreterfinder.InterpreterFinder)}
+
+	e = man.RunGame(&Game{Name: testGameName})
+	assert.NoError(t, e)
+	assert.NoError(t, man.CurrentRunningCmd.Wait())
+
+	out, e := ioutil.ReadFile(outPath)
+	assert.NoError(t, e)
+	assert.True(t, strings.HasSuffix(strings.TrimSpace(string(out)), "-game "+testGameName+" -nosound legacy"))
+
+	// Default arguments are before the game arguments, arguments of the run are the last
+	config.InterpreterArgs = []string{"-hires"}
+	e = man.RunGameArgs(&Game{Name: testGameName}, []string{"-fullscreen"})
+	assert.NoError(t, e)
+	assert.NoError(t, man.CurrentRunningCmd.Wait())
+
+	out, e = ioutil.ReadFile(outPath)
+	assert.NoError(t, e)
+	assert.True(t, strings.HasSuffix(strings.TrimSpace(string(out)), "-game "+testGameName+" -hires -nosound -fullscreen legacy"))
+}
+
+func TestUseTempAppData(t *testing.T) {
+	config := &configurator.InsteadmanConfig{
+		InterpreterCommand:    "/usr/bin/instead",
+		CalculatedGamesPath:   gamesPath,
+		CalculatedAppDataPath: "/home/user/insteadman/appdata",
+	}
+	man := Manager{config: configurator.NewHolder(config), InterpreterFinder: new(interpreterfinder.InterpreterFinder)}
+
+	dir, e := man.UseTempAppData()
+	assert.NoError(t, e)
+	defer os.RemoveAll(dir)
+	_, e = os.Stat(dir)
+	assert.NoError(t, e)
+	assert.Equal(t, dir, man.appDataDir())
+
+	cmd, e := (&ExecRunner{Manager: &man}).command(&Game{Name: testGameName})
+	assert.NoError(t, e)
+	assert.Contains(t, strings.Join(cmd.Args, " "), "-appdata "+dir)
+}
+
+func TestGameInterpreterCommand(t *testing.T) {
+	config := &configurator.InsteadmanConfig{
+		InterpreterCommand: "/usr/bin/instead",
+		Interpreters: []configurator.Interpreter{
+			{Name: "flatpak", Command: "flatpak run --filesystem=home io.github.instead_hub.instead"},
+			{Name: "legacy", Command: "/opt/instead-1.9/bin/sdl-instead", Version: "1.9.1"},
+		},
+		Games: map[string]configurator.GameConfig{
+			"oldgame":  {Interpreter: "legacy"},
+			"lostgame": {Interpreter: "unknown"},
+		},
+	}
+	man := Manager{config: configurator.NewHolder(config), InterpreterFinder: new(interpreterfinder.InterpreterFinder)}
+
+	command, e := man.GameInterpreterCommand("oldgame")
+	assert.NoError(t, e)
+	assert.Equal(t, "/opt/instead-1.9/bin/sdl-instead", command)
+
+	_, e = man.GameInterpreterCommand("lostgame")
+	assert.Error(t, e)
+
+	command, e = man.GameInterpreterCommand("newgame")
+	assert.NoError(t, e)
+	assert.Equal(t, "/usr/bin/instead", command)
+
+	// Default interpreter is used instead of interpreter command
+	config.DefaultInterpreter = "flatpak"
+	command, e = man.GameInterpreterCommand("newgame")
+	assert.NoError(t, e)
+	assert.Equal(t, "flatpak run --filesystem=home io.github.instead_hub.instead", command)
+
+	// The same command isn't registered twice, names are unique
+	added := man.RegisterInterpreters([]configurator.Interpreter{
+		{Name: "legacy", Command: "/opt/instead-1.9/bin/sdl-instead", Version: "1.9.2"},
+		{Name: "legacy", Command: "/opt/instead-2.4/bin/sdl-instead"},
+	})
+	assert.Len(t, added, 1)
+	assert.Equal(t, "legacy-2", added[0].Name)
+	assert.Equal(t, "1.9.2", man.Config().FindInterpreter("legacy").Version)
+	// Config is replaced by the changed copy
+	assert.Equal(t, "1.9.1", config.FindInterpreter("legacy").Version)
+}
+
+func TestRelocateGamesDir(t *testing.T) {
+	dir, e := ioutil.TempDir("", "insteadman")
+	assert.NoError(t, e)
+	defer os.RemoveAll(dir)
+
+	oldGamesDir := filepath.Join(dir, "games")
+	gameDir := filepath.Join(oldGamesDir, "testgame")
+	assert.NoError(t, os.MkdirAll(filepath.Join(gameDir, "gfx"), os.ModePerm))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(gameDir, "main3.lua"), []byte("-- $Name: Test$\n"), 0644))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(gameDir, "gfx", "bg.png"), []byte("png"), 0644))
+
+	man := Manager{config: configurator.NewHolder(&configurator.InsteadmanConfig{CalculatedGamesPath: oldGamesDir})}
+
+	// Can't move inside itself
+	assert.Error(t, man.RelocateGamesDir(filepath.Join(gameDir, "games"), nil, nil))
+
+	// Copies are removed if config hasn't saved
+	newGamesDir := filepath.Join(dir, "new", "games")
+	e = man.RelocateGamesDir(newGamesDir, nil, func(*configurator.InsteadmanConfig) error {
+		return errors.New("read-only config")
+	})
+	assert.EqualError(t, e, "read-only config")
+	assert.Equal(t, oldGamesDir, man.Config().CalculatedGamesPath)
+	assert.True(t, utils.PathExist(gameDir))
+	assert.False(t, utils.PathExist(filepath.Join(newGamesDir, "testgame")))
+
+	// Relative path is saved as the absolute one
+	wd, e := os.Getwd()
+	assert.NoError(t, e)
+	defer os.Chdir(wd)
+	assert.NoError(t, os.Chdir(dir))
+
+	var copied, total uint64
+	var savedGamesPath string
+	e = man.RelocateGamesDir(filepath.Join("new", "games"), func(c, t uint64) {
+		copied, total = c, t
+	}, func(config *configurator.InsteadmanConfig) error {
+		savedGamesPath = config.GamesPath
+		return nil
+	})
+	assert.NoError(t, e)
+	assert.Equal(t, man.Config().GamesPath, savedGamesPath)
+	assert.Equal(t, uint64(len("-- $Name: Test$\n")+len("png")), total)
+	assert.Equal(t, total, copied)
+
+	newGamesDir, _ = filepath.EvalSymlinks(newGamesDir)
+	gamesPath, _ := filepath.EvalSymlinks(man.Config().GamesPath)
+	assert.True(t, filepath.IsAbs(man.Config().GamesPath))
+	assert.Equal(t, newGamesDir, gamesPath)
+	assert.Equal(t, man.Config().GamesPath, man.Config().CalculatedGamesPath)
+	assert.False(t, utils.PathExist(gameDir))
+
+	data, e := ioutil.ReadFile(filepath.Join(man.Config().GamesPath, "testgame", "gfx", "bg.png"))
+	assert.NoError(t, e)
+	assert.Equal(t, "png", string(data))
+
+	games, e := man.GetInstalledGames()
+	assert.NoError(t, e)
+	assert.Len(t, games, 1)
+}
+
+func TestWebRunner(t *testing.T) {
+	dir, e := ioutil.TempDir("", "insteadman")
+	assert.NoError(t, e)
+	defer os.RemoveAll(dir)
+
+	assert.NoError(t, os.MkdirAll(filepath.Join(dir, webRunnerDirName), os.ModePerm))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, webRunnerDirName, "index.html"), []byte("INSTEAD-EM"), 0644))
+
+	config := &configurator.InsteadmanConfig{CalculatedGamesPath: gamesPath}
+	man := Manager{config: configurator.NewHolder(config), InterpreterFinder: &interpreterfinder.InterpreterFinder{DataDir: dir}}
+
+	// Web runner is used when there isn't native interpreter
+	runner, e := man.GameRunner("stead3testgame")
+	assert.NoError(t, e)
+	webRunner, ok := runner.(*WebRunner)
+	assert.True(t, ok)
+
+	var openedURL string
+	webRunner.OpenURL = func(url string) error {
+		openedURL = url
+		return nil
+	}
+
+	assert.NoError(t, webRunner.Run(&Game{Name: "stead3testgame"}))
+	assert.Equal(t, webRunner.URL, openedURL)
+	assert.Contains(t, openedURL, "?game=%2Fgames%2Fstead3testgame%2F")
+
+	pageURL := strings.SplitN(openedURL, "?", 2)[0]
+	for url, content := range map[string]string{
+		pageURL: "INSTEAD-EM",
+		strings.Replace(pageURL, "index.html", "games/stead3testgame/main3.lua", 1): "$Name:",
+	} {
+		resp, e := http.Get(url)
+		assert.NoError(t, e)
+		body, e := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		assert.NoError(t, e)
+		assert.Contains(t, string(body), content)
+	}
+
+	assert.NoError(t, webRunner.Stop())
+	webRunner.Wait()
+}
+
+func TestSandboxCommand(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("sandboxes are available only on Linux")
+	}
+
+	dir, e := ioutil.TempDir("", "insteadman")
+	assert.NoError(t, e)
+	defer os.RemoveAll(dir)
+
+	defer os.Setenv("PATH", os.Getenv("PATH"))
+	os.Setenv("PATH", dir)
+
+	gamePath := filepath.Join(dir, "games", "crossworlds")
+	paths := sandboxPaths{readOnly: []string{gamePath}, writable: []string{filepath.Join(dir, "appdata")}}
+	cmd := exec.Command("/usr/bin/sdl-instead", "-game", "crossworlds")
+
+	// There isn't any sandbox
+	_, e = sandboxCommand(SandboxAuto, cmd, paths)
+	assert.Equal(t, ErrSandboxUnavailable, e)
+	_, e = sandboxCommand("chroot", cmd, paths)
+	assert.Error(t, e)
+
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, SandboxBwrap), []byte("#!/bin/sh\n"), 0755))
+	sandboxedCmd, e := sandboxCommand(SandboxAuto, cmd, paths)
+	assert.NoError(t, e)
+	assert.Equal(t, filepath.Join(dir, SandboxBwrap), sandboxedCmd.Path)
+	assert.Contains(t, sandboxedCmd.Args, "--unshare-net")
+	assert.Equal(t, []string{"/usr/bin/sdl-instead", "-game", "crossworlds"}, sandboxedCmd.Args[len(sandboxedCmd.Args)-3:])
+	assert.True(t, utils.PathExist(filepath.Join(dir, "appdata")))
+
+	// Firejail is preferred
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, SandboxFirejail), []byte("#!/bin/sh\n"), 0755))
+	sandboxedCmd, e = sandboxCommand(SandboxAuto, cmd, paths)
+	assert.NoError(t, e)
+	assert.Equal(t, filepath.Join(dir, SandboxFirejail), sandboxedCmd.Path)
+	assert.Contains(t, sandboxedCmd.Args, "--net=none")
+	assert.Contains(t, sandboxedCmd.Args, "--read-only="+gamePath)
+}
+
+func TestUpdateGame(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("shell script interpreter")
+	}
+
+	dir, e := ioutil.TempDir("", "insteadman")
+	assert.NoError(t, e)
+	defer os.RemoveAll(dir)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("zip"))
+	}))
+	defer server.Close()
+
+	gamesDir := filepath.Join(dir, "games")
+	mainPath := filepath.Join(gamesDir, "testgame", "main3.lua")
+	assert.NoError(t, os.MkdirAll(filepath.Dir(mainPath), os.ModePerm))
+	assert.NoError(t, ioutil.WriteFile(mainPath, []byte("-- $Version: 0.1$\n"), 0644))
+
+	interpreterPath := filepath.Join(dir, "instead")
+	config := &configurator.InsteadmanConfig{
+		InterpreterCommand:       interpreterPath,
+		CalculatedGamesPath:      gamesDir,
+		CalculatedInsteadManPath: dir,
+		CalculatedCachePath:      filepath.Join(dir, "cache"),
+	}
+	man := Manager{config: configurator.NewHolder(config), InterpreterFinder: new(interpreterfinder.InterpreterFinder)}
+	game := &Game{Name: "testgame", Url: server.URL + "/testgame.zip", Version: "0.2"}
+
+	// Installed version is restored if installing fails
+	assert.NoError(t, ioutil.WriteFile(interpreterPath, []byte("#!/bin/sh\nexit 1\n"), 0755))
+	assert.Error(t, man.UpdateGame(game, nil))
+	data, e := ioutil.ReadFile(mainPath)
+	assert.NoError(t, e)
+	assert.Equal(t, "-- $Version: 0.1$\n", string(data))
+
+	script := "#!/bin/sh\n" +
+		"if [ \"$1\" = \"-version\" ]; then echo 3.3.0; exit 0; fi\n" +
+		"mkdir -p \"$2/testgame\" && echo '-- $Version: 0.2$' > \"$2/testgame/main3.lua\"\n"
+	assert.NoError(t, ioutil.WriteFile(interpreterPath, []byte(script), 0755))
+	assert.NoError(t, man.UpdateGame(game, nil))
+
+	games, e := man.GetInstalledGames()
+	assert.NoError(t, e)
+	assert.Len(t, games, 1)
+	assert.Equal(t, "0.2", games[0].InstalledVersion)
+}
+
+func TestInstallGameInterpreter(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("shell script interpreter")
+	}
+
+	dir, e := ioutil.TempDir("", "insteadman")
+	assert.NoError(t, e)
+	defer os.RemoveAll(dir)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("zip"))
+	}))
+	defer server.Close()
+
+	writeInterpreter := func(name, version string) string {
+		path := filepath.Join(dir, name)
+		script := "#!/bin/sh\n" +
+			"if [ \"$1\" = \"-version\" ]; then echo " + version + "; exit 0; fi\n" +
+			"mkdir -p \"$2/testgame\" && echo '-- " + name + "' > \"$2/testgame/main3.lua\"\n"
+		assert.NoError(t, ioutil.WriteFile(path, []byte(script), 0755))
+		return path
+	}
+
+	gamesDir := filepath.Join(dir, "games")
+	config := &configurator.InsteadmanConfig{
+		InterpreterCommand:       writeInterpreter("instead", "3.3.0"),
+		CalculatedGamesPath:      gamesDir,
+		CalculatedInsteadManPath: dir,
+		CalculatedCachePath:      filepath.Join(dir, "cache"),
+		Games: map[string]configurator.GameConfig{
+			"testgame": {InterpreterCommand: writeInterpreter("legacy", "1.8.0")},
+		},
+	}
+	man := Manager{config: configurator.NewHolder(config), InterpreterFinder: new(interpreterfinder.InterpreterFinder)}
+	game := &Game{Name: "testgame", Url: server.URL + "/testgame.zip"}
+
+	// Interpreter of the game can't install it, the default one isn't used
+	assert.Error(t, man.InstallGame(game, nil))
+	assert.False(t, utils.PathExist(filepath.Join(gamesDir, "testgame")))
+
+	// Game is installed by the checked interpreter
+	man.UpdateConfig(func(config *configurator.InsteadmanConfig) error {
+		config.Games["testgame"] = configurator.GameConfig{InterpreterCommand: writeInterpreter("newer", "3.4.0")}
+		return nil
+	})
+	assert.NoError(t, man.InstallGame(game, nil))
+	data, e := ioutil.ReadFile(filepath.Join(gamesDir, "testgame", "main3.lua"))
+	assert.NoError(t, e)
+	assert.Equal(t, "-- newer\n", string(data))
+}
+
+func TestInstallGameContextCancel(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("shell script interpreter")
+	}
+
+	dir, e := ioutil.TempDir("", "insteadman")
+	assert.NoError(t, e)
+	defer os.RemoveAll(dir)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/slow.zip" {
+			<-r.Context().Done()
+			return
+		}
+		w.Write([]byte("zip"))
+	}))
+	defer server.Close()
+
+	interpreterPath := filepath.Join(dir, "instead")
+	script := "#!/bin/sh\n" +
+		"if [ \"$1\" = \"-version\" ]; then echo 3.3.0; exit 0; fi\n" +
+		"mkdir -p \"$2/testgame\" && sleep 10\n"
+	assert.NoError(t, ioutil.WriteFile(interpreterPath, []byte(script), 0755))
+
+	gamesDir := filepath.Join(dir, "games")
+	config := &configurator.InsteadmanConfig{
+		InterpreterCommand:       interpreterPath,
+		CalculatedGamesPath:      gamesDir,
+		CalculatedInsteadManPath: dir,
+		CalculatedCachePath:      filepath.Join(dir, "cache"),
+	}
+	man := Manager{config: configurator.NewHolder(config), InterpreterFinder: new(interpreterfinder.InterpreterFinder)}
+
+	for _, url := range []string{server.URL + "/slow.zip", server.URL + "/testgame.zip"} {
+		ctx, cancel := context.WithCancel(context.Background())
+		var phases []InstallPhase
+		phaseF := func(phase InstallPhase) {
+			phases = append(phases, phase)
+			time.AfterFunc(100*time.Millisecond, cancel)
+		}
+
+		e = man.InstallGameContext(ctx, &Game{Name: "testgame", Url: url}, nil, phaseF)
+		cancel()
+
+		assert.Equal(t, context.Canceled, e)
+		assert.False(t, utils.PathExist(filepath.Join(gamesDir, "testgame")))
+		assert.False(t, utils.PathExist(filepath.Join(dir, "cache", tempGamesDirName, path.Base(url))))
+		assert.Equal(t, InstallPhaseDownload, phases[0])
+	}
+}
+
+func TestInstallArchiveContext(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("shell script interpreter")
+	}
+
+	dir, e := ioutil.TempDir("", "insteadman")
+	assert.NoError(t, e)
+	defer os.RemoveAll(dir)
+
+	// Game is "installed" from the archive name
+	interpreterPath := filepath.Join(dir, "instead")
+	script := "#!/bin/sh\n" +
+		"name=$(basename \"$4\" .zip)\n" +
+		"mkdir -p \"$2/$name\"\n"
+	assert.NoError(t, ioutil.WriteFile(interpreterPath, []byte(script), 0755))
+
+	gamesDir := filepath.Join(dir, "games")
+	config := &configurator.InsteadmanConfig{
+		InterpreterCommand:       interpreterPath,
+		CalculatedGamesPath:      gamesDir,
+		CalculatedInsteadManPath: dir,
+		CalculatedCachePath:      filepath.Join(dir, "cache"),
+	}
+	man := Manager{config: configurator.NewHolder(config)}
+
+	archivePath := filepath.Join(dir, "localgame.zip")
+	assert.NoError(t, ioutil.WriteFile(archivePath, []byte("zip"), 0644))
+
+	assert.NoError(t, man.InstallArchiveContext(context.Background(), archivePath, nil, nil))
+	assert.True(t, utils.PathExist(filepath.Join(gamesDir, "localgame")))
+	// Local archive is kept
+	assert.True(t, utils.PathExist(archivePath))
+
+	e = man.InstallArchiveContext(context.Background(), filepath.Join(dir, "unknown.zip"), nil, nil)
+	assert.Error(t, e)
+
+	assert.Equal(t, "instead-crossworlds-0.7", ArchiveGameName(testGameUrl))
+	assert.Equal(t, "localgame", ArchiveGameName(archivePath))
+}
+
+func writeTestZip(t *testing.T, fileName string, files ...string) {
+	f, e := os.Create(fileName)
+	assert.NoError(t, e)
+	defer f.Close()
+
+	w := zip.NewWriter(f)
+	for _, file := range files {
+		_, e = w.Create(file)
+		assert.NoError(t, e)
+	}
+	assert.NoError(t, w.Close())
+}
+
+func TestGameArchiveName(t *testing.T) {
+	dir, e := ioutil.TempDir("", "insteadman")
+	assert.NoError(t, e)
+	defer os.RemoveAll(dir)
+
+	gameZip := filepath.Join(dir, "crossworlds-0.7.zip")
+	writeTestZip(t, gameZip, "crossworlds/", "crossworlds/main3.lua", "crossworlds/gfx/bg.png")
+	name, e := GameArchiveName(gameZip)
+	assert.NoError(t, e)
+	assert.Equal(t, "crossworlds", name)
+
+	rootZip := filepath.Join(dir, "rootgame.ZIP")
+	writeTestZip(t, rootZip, "main.lua")
+	name, e = GameArchiveName(rootZip)
+	assert.NoError(t, e)
+	assert.Equal(t, "rootgame", name)
+
+	name, e = GameArchiveName(filepath.Join(dir, "gamedata.idf"))
+	assert.NoError(t, e)
+	assert.Equal(t, "gamedata", name)
+
+	otherZip := filepath.Join(dir, "photos.zip")
+	writeTestZip(t, otherZip, "photos/main.jpg", "a/b/main.lua")
+	_, e = GameArchiveName(otherZip)
+	assert.Equal(t, ErrNotGameArchive, e)
+
+	_, e = GameArchiveName(filepath.Join(dir, "readme.txt"))
+	assert.Equal(t, ErrNotGameArchive, e)
+
+	man := Manager{config: configurator.NewHolder(&configurator.InsteadmanConfig{})}
+	e = man.InstallGameFromFile(context.Background(), otherZip, nil)
+	assert.Equal(t, ErrNotGameArchive, e)
+}
+
+func TestGameShortcuts(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("shell script interpreter")
+	}
+
+	dir, e := ioutil.TempDir("", "insteadman")
+	assert.NoError(t, e)
+	defer os.RemoveAll(dir)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("zip"))
+	}))
+	defer server.Close()
+
+	interpreterPath := filepath.Join(dir, "instead")
+	script := "#!/bin/sh\n" +
+		"mkdir -p \"$2/testgame\" && echo png > \"$2/testgame/icon.png\"\n"
+	assert.NoError(t, ioutil.WriteFile(interpreterPath, []byte(script), 0755))
+
+	config := &configurator.InsteadmanConfig{
+		InterpreterCommand:       interpreterPath,
+		CalculatedGamesPath:      filepath.Join(dir, "games"),
+		CalculatedInsteadManPath: dir,
+		CalculatedCachePath:      filepath.Join(dir, "cache"),
+		Shortcuts:                true,
+	}
+	creator := &shortcuts.Creator{
+		Executable: "/usr/bin/insteadman",
+		IconsDir:   filepath.Join(dir, "icons"),
+		MenuDir:    filepath.Join(dir, "menu"),
+	}
+	man := Manager{config: configurator.NewHolder(config), Shortcuts: creator}
+	game := &Game{Name: "testgame", Title: "Test game", Url: server.URL + "/testgame.zip"}
+
+	assert.NoError(t, man.InstallGame(game, nil))
+	assert.True(t, utils.PathExist(creator.Path("testgame")))
+	assert.True(t, utils.PathExist(filepath.Join(dir, "icons", "testgame.png")))
+
+	assert.NoError(t, man.RemoveGame(game))
+	assert.False(t, utils.PathExist(creator.Path("testgame")))
+
+	// Shortcuts are disabled in the config
+	config.Shortcuts = false
+	assert.NoError(t, man.InstallGame(game, nil))
+	assert.False(t, utils.PathExist(creator.Path("testgame")))
+}
+
+func TestTelemetryCount(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("shell script interpreter")
+	}
+
+	dir, e := ioutil.TempDir("", "insteadman")
+	assert.NoError(t, e)
+	defer os.RemoveAll(dir)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("zip"))
+	}))
+	defer server.Close()
+
+	interpreterPath := filepath.Join(dir, "instead")
+	assert.NoError(t, ioutil.WriteFile(interpreterPath, []byte("#!/bin/sh\nmkdir -p \"$2/testgame\"\n"), 0755))
+
+	config := &configurator.InsteadmanConfig{
+		InterpreterCommand:  interpreterPath,
+		CalculatedGamesPath: filepath.Join(dir, "games"),
+		CalculatedCachePath: filepath.Join(dir, "cache"),
+		Repositories:        []configurator.Repository{{Name: "test", StatsUrl: server.URL}},
+	}
+	stats := &telemetry.Stats{Dir: dir}
+	man := Manager{config: configurator.NewHolder(config), Telemetry: stats}
+	game := &Game{Name: "testgame", Url: server.URL + "/testgame.zip", RepositoryName: "test"}
+
+	// Telemetry is disabled by default
+	assert.NoError(t, man.InstallGame(game, nil))
+	assert.Empty(t, stats.Submissions(config.Repositories))
+
+	config.Telemetry = true
+	assert.NoError(t, man.InstallGame(game, nil))
+	submissions := stats.Submissions(config.Repositories)
+	assert.Len(t, submissions, 1)
+	assert.Equal(t, map[string]int{"testgame": 1}, submissions[0].Report.Installs)
+
+	assert.Empty(t, man.SendTelemetry())
+	assert.Empty(t, stats.Submissions(config.Repositories))
+}
+
+func TestNotifications(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("shell script interpreter")
+	}
+
+	dir, e := ioutil.TempDir("", "insteadman")
+	assert.NoError(t, e)
+	defer os.RemoveAll(dir)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/repo.xml" {
+			w.Write([]byte("<game_list><game><name>testgame</name><title>Test game</title><version>0.2</version>" +
+				"<url>http://" + r.Host + "/testgame.zip</url></game></game_list>"))
+			return
+		}
+		w.Write([]byte("zip"))
+	}))
+	defer server.Close()
+
+	interpreterPath := filepath.Join(dir, "instead")
+	script := "#!/bin/sh\n" +
+		"mkdir -p \"$2/testgame\" && echo '-- $Version: 0.1$' > \"$2/testgame/main3.lua\"\n"
+	assert.NoError(t, ioutil.WriteFile(interpreterPath, []byte(script), 0755))
+
+	var notified []notify.Notification
+	config := &configurator.InsteadmanConfig{
+		InterpreterCommand:  interpreterPath,
+		CalculatedGamesPath: filepath.Join(dir, "games"),
+		CalculatedCachePath: filepath.Join(dir, "cache"),
+		Repositories:        []configurator.Repository{{Name: "test", Url: server.URL + "/repo.xml"}},
+	}
+	man := Manager{config: configurator.NewHolder(config), Notifier: notify.Func(func(n notify.Notification) error {
+		notified = append(notified, n)
+		return nil
+	})}
+	game := &Game{Name: "testgame", Title: "Test game", Url: server.URL + "/testgame.zip"}
+
+	// Notifications are disabled by default
+	assert.NoError(t, man.InstallGame(game, nil))
+	assert.Empty(t, man.UpdateRepositories())
+	assert.Empty(t, notified)
+
+	config.Notifications = true
+	assert.NoError(t, man.InstallGame(game, nil))
+	assert.Empty(t, man.UpdateRepositories())
+	assert.Equal(t, []notify.Notification{notify.InstallFinished("Test game"), notify.UpdateAvailable(1)}, notified)
+
+	closedServer := httptest.NewServer(http.NotFoundHandler())
+	closedServer.Close()
+	config.Repositories[0].Url = closedServer.URL + "/repo.xml"
+	notified = nil
+	assert.Len(t, man.UpdateRepositories(), 1)
+	assert.Equal(t, []notify.Notification{notify.RepositoriesRefreshed(1)}, notified)
+}
+
+func TestJobs(t *testing.T) {
+	dir, e := ioutil.TempDir("", "insteadman")
+	assert.NoError(t, e)
+	defer os.RemoveAll(dir)
+
+	config := &configurator.InsteadmanConfig{
+		CalculatedCachePath:      filepath.Join(dir, "cache"),
+		CalculatedInsteadManPath: dir,
+		CalculatedAppDataPath:    filepath.Join(dir, "appdata"),
+	}
+	man := Manager{config: configurator.NewHolder(config)}
+
+	var names []string
+	for _, job := range man.Jobs() {
+		names = append(names, job.Name)
+	}
+	assert.Equal(t, []string{JobRepositories, JobCache, JobSavesBackup, JobTelemetry}, names)
+
+	// Old images and archives are evicted
+	assert.NoError(t, os.MkdirAll(man.gameImagesDir(), os.ModePerm))
+	oldImage := filepath.Join(man.gameImagesDir(), "old.png")
+	newImage := filepath.Join(man.gameImagesDir(), "new.png")
+	assert.NoError(t, ioutil.WriteFile(oldImage, []byte("png"), 0644))
+	assert.NoError(t, ioutil.WriteFile(newImage, []byte("png"), 0644))
+	old := time.Now().Add(-2 * CacheMaxAge)
+	assert.NoError(t, os.Chtimes(oldImage, old, old))
+
+	assert.NoError(t, man.EvictCache(CacheMaxAge))
+	assert.False(t, utils.PathExist(oldImage))
+	assert.True(t, utils.PathExist(newImage))
+
+	// Saves are backed up only if they exist
+	_, e = man.BackupSaves()
+	assert.Equal(t, ErrNoSaves, e)
+
+	savesDir := filepath.Join(dir, "appdata", "saves", "testgame")
+	assert.NoError(t, os.MkdirAll(savesDir, os.ModePerm))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(savesDir, "1"), []byte("save"), 0644))
+
+	backupPath, e := man.BackupSaves()
+	assert.NoError(t, e)
+	r, e := zip.OpenReader(backupPath)
+	assert.NoError(t, e)
+	assert.Len(t, r.File, 1)
+	assert.Equal(t, "testgame/1", r.File[0].Name)
+	r.Close()
+
+	// Only last backups are kept
+	for i := 0; i < savesBackupsMaxCount+2; i++ {
+		name := fmt.Sprintf("2019010%d-120000.zip", i)
+		assert.NoError(t, ioutil.WriteFile(filepath.Join(man.savesBackupsDir(), name), []byte("zip"), 0644))
+	}
+	man.removeOldSavesBackups()
+	backups, e := filepath.Glob(filepath.Join(man.savesBackupsDir(), "*.zip"))
+	assert.NoError(t, e)
+	assert.Len(t, backups, savesBackupsMaxCount)
+	assert.Contains(t, backups, backupPath)
+}
+
+func TestEvents(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("shell script interpreter")
+	}
+
+	dir, e := ioutil.TempDir("", "insteadman")
+	assert.NoError(t, e)
+	defer os.RemoveAll(dir)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("zip"))
+	}))
+	defer server.Close()
+
+	interpreterPath := filepath.Join(dir, "instead")
+	assert.NoError(t, ioutil.WriteFile(interpreterPath, []byte("#!/bin/sh\nmkdir -p \"$2/testgame\"\n"), 0755))
+
+	config := &configurator.InsteadmanConfig{
+		InterpreterCommand:  interpreterPath,
+		CalculatedGamesPath: filepath.Join(dir, "games"),
+		CalculatedCachePath: filepath.Join(dir, "cache"),
+		Repositories:        []configurator.Repository{{Name: "test", Url: server.URL + "/test.xml"}},
+	}
+	man := Manager{config: configurator.NewHolder(config)}
+	game := &Game{Name: "testgame", Url: server.URL + "/testgame.zip", Size: 3}
+
+	var events []Event
+	unsubscribe := man.Subscribe(func(event Event) {
+		events = append(events, event)
+	})
+
+	assert.Empty(t, man.UpdateRepositories())
+	assert.NoError(t, man.InstallGame(game, nil))
+	assert.NoError(t, man.RemoveGame(game))
+
+	assert.Equal(t, []Event{
+		RepositoriesUpdated{},
+		DownloadProgress{Game: *game, Downloaded: 3, Total: 3},
+		GameInstalled{Game: *game},
+		GameRemoved{Game: *game},
+	}, events)
+
+	// Unsubscribed function isn't called
+	unsubscribe()
+	assert.NoError(t, man.InstallGame(game, nil))
+	assert.Len(t, events, 4)
+}
+
+func TestRunGameDir(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("shell script interpreter")
+	}
+
+	dir, e := ioutil.TempDir("", "insteadman")
+	assert.NoError(t, e)
+	defer os.RemoveAll(dir)
+
+	interpreterPath := filepath.Join(dir, "instead")
+	script := "#!/bin/sh\necho \"$@\"\necho 'main3.lua:1: error' >&2\n"
+	assert.NoError(t, ioutil.WriteFile(interpreterPath, []byte(script), 0755))
+
+	gameDir := filepath.Join(dir, "projects", "mygame")
+	assert.NoError(t, os.MkdirAll(gameDir, os.ModePerm))
+
+	man := Manager{config: configurator.NewHolder(&configurator.InsteadmanConfig{InterpreterCommand: interpreterPath})}
+	assert.Equal(t, ErrNotGameDir, man.RunGameDir(gameDir, nil, nil))
+
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(gameDir, "main3.lua"), []byte("-- $Name: My game$"), 0644))
+
+	var stdout, stderr strings.Builder
+	assert.NoError(t, man.RunGameDir(gameDir, &stdout, &stderr))
+	assert.Equal(t, "-gamespath "+filepath.Join(dir, "projects")+" -game mygame\n", stdout.String())
+	assert.Equal(t, "main3.lua:1: error\n", stderr.String())
+}
+
+func TestDebugGameDir(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("shell script interpreter")
+	}
+
+	dir, e := ioutil.TempDir("", "insteadman")
+	assert.NoError(t, e)
+	defer os.RemoveAll(dir)
+
+	interpreterPath := filepath.Join(dir, "instead")
+	script := "#!/bin/sh\necho \"$@\"\n" +
+		"echo \"Error in main3.lua: main3.lua:5: attempt to call a nil value (global 'walk')\" >&2\n" +
+		"printf 'stack traceback:\\n\\t[C]: in ?\\n\\tmain3.lua:5: in main chunk\\n' >&2\n" +
+		"echo 'room.lua:12: syntax error' >&2\nexit 3\n"
+	assert.NoError(t, ioutil.WriteFile(interpreterPath, []byte(script), 0755))
+
+	gameDir := filepath.Join(dir, "projects", "mygame")
+	assert.NoError(t, os.MkdirAll(gameDir, os.ModePerm))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(gameDir, "main3.lua"), []byte("-- $Name: My game$"), 0644))
+
+	config := &configurator.InsteadmanConfig{InterpreterCommand: interpreterPath, CalculatedInsteadManPath: dir}
+	man := Manager{config: configurator.NewHolder(config)}
+
+	var out strings.Builder
+	report, e := man.DebugGameDir(gameDir, &out)
+	assert.NoError(t, e)
+	assert.True(t, report.Crashed)
+	assert.Equal(t, 3, report.ExitCode)
+	assert.Contains(t, out.String(), "-game mygame -debug\n")
+	assert.Equal(t, []LuaError{
+		{Message: "Error in main3.lua: main3.lua:5: attempt to call a nil value (global 'walk')",
+			Traceback: []string{"[C]: in ?", "main3.lua:5: in main chunk"}},
+		{Message: "room.lua:12: syntax error"},
+	}, report.Errors)
+
+	assert.Equal(t, man.DebugLogsDir(), filepath.Dir(report.LogFile))
+	log, e := ioutil.ReadFile(report.LogFile)
+	assert.NoError(t, e)
+	assert.Contains(t, string(log), out.String())
+	assert.Contains(t, string(log), "Exit code: 3")
+}
+
+func TestProcessQueue(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("shell script interpreter")
+	}
+
+	dir, e := ioutil.TempDir("", "insteadman")
+	assert.NoError(t, e)
+	defer os.RemoveAll(dir)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("zip"))
+	}))
+	defer server.Close()
+
+	// Game is "installed" from the archive name, "broken" game fails
+	interpreterPath := filepath.Join(dir, "instead")
+	script := "#!/bin/sh\n" +
+		"if [ \"$1\" = \"-version\" ]; then echo 3.3.0; exit 0; fi\n" +
+		"name=$(basename \"$4\" .zip)\n" +
+		"if [ \"$name\" = broken ]; then exit 1; fi\n" +
+		"mkdir -p \"$2/$name\" && echo '-- $Version: 0.1$' > \"$2/$name/main3.lua\"\n"
+	assert.NoError(t, ioutil.WriteFile(interpreterPath, []byte(script), 0755))
+
+	gamesDir := filepath.Join(dir, "games")
+	config := &configurator.InsteadmanConfig{
+		InterpreterCommand:       interpreterPath,
+		CalculatedGamesPath:      gamesDir,
+		CalculatedInsteadManPath: dir,
+		CalculatedCachePath:      filepath.Join(dir, "cache"),
+	}
+	man := Manager{config: configurator.NewHolder(config), InterpreterFinder: new(interpreterfinder.InterpreterFinder)}
+
+	var games []Game
+	for _, name := range []string{"first", "broken", "second"} {
+		games = append(games, Game{Name: name, Url: server.URL + "/" + name + ".zip", Size: 3})
+	}
+
+	var lastProgress QueueProgress
+	results := man.ProcessQueue(context.Background(), QueueInstall, games, func(p QueueProgress) {
+		lastProgress = p
+	})
+	assert.Len(t, results, 3)
+	assert.NoError(t, results[0].Error)
+	_, ok := results[1].Error.(*InstallError)
+	assert.True(t, ok)
+	assert.NoError(t, results[2].Error)
+	assert.Equal(t, 1.0, lastProgress.Fraction())
+	assert.True(t, utils.PathExist(filepath.Join(gamesDir, "second")))
+
+	// Rest games aren't processed after cancelling
+	ctx, cancel := context.WithCancel(context.Background())
+	results = man.ProcessQueue(ctx, QueueRemove, games[:1], nil)
+	assert.NoError(t, results[0].Error)
+	assert.False(t, utils.PathExist(filepath.Join(gamesDir, "first")))
+
+	cancel()
+	results = man.ProcessQueue(ctx, QueueRemove, games[2:], nil)
+	assert.Equal(t, context.Canceled, results[0].Error)
+	assert.True(t, utils.PathExist(filepath.Join(gamesDir, "second")))
+}
+
+func TestGetGameImageOnce(t *testing.T) {
+	dir, e := ioutil.TempDir("", "insteadman")
+	assert.NoError(t, e)
+	defer os.RemoveAll(dir)
+
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		if r.URL.Path != "/image.png" {
+			http.NotFound(w, r)
+			return
+		}
+		time.Sleep(50 * time.Millisecond)
+		w.Write([]byte("png"))
+	}))
+	defer server.Close()
+
+	man := Manager{config: configurator.NewHolder(&configurator.InsteadmanConfig{CalculatedCachePath: dir})}
+	game := &Game{Id: "repo/game", Image: server.URL + "/image.png"}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			imagePath, e := man.GetGameImage(game)
+			assert.NoError(t, e)
+			data, e := ioutil.ReadFile(imagePath)
+			assert.NoError(t, e)
+			assert.Equal(t, "png", string(data))
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, int32(1), atomic.LoadInt32(&requests))
+
+	// Error page isn't cached as image
+	imagePath, e := man.GetGameImage(&Game{Id: "repo/other", Image: server.URL + "/other.png"})
+	assert.Error(t, e)
+	assert.Empty(t, imagePath)
+	assert.False(t, utils.PathExist(filepath.Join(man.gameImagesDir(), "repo_other.png")))
+}
+
+func TestReloadConfig(t *testing.T) {
+	man := New(configurator.NewHolder(&configurator.InsteadmanConfig{Lang: "ru"}), nil)
+	oldConfig := man.Config()
+
+	// Background operation keeps its config
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 1000; i++ {
+			config := man.Config()
+			if config.Lang != "ru" && config.Lang != "uk" {
+				t.Error("config is changed under the reader")
+				return
+			}
+		}
+	}()
+
+	man.ReloadConfig(&configurator.InsteadmanConfig{Lang: "uk"})
+	<-done
+
+	assert.Equal(t, "uk", man.Config().Lang)
+	assert.Equal(t, "ru", oldConfig.Lang)
+
+	man.ReloadConfig(nil)
+	assert.Equal(t, "uk", man.Config().Lang)
+
+	// Concurrent changes aren't lost
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			man.RegisterInterpreters([]configurator.Interpreter{{Name: "system", Command: "instead"}})
+		}()
+	}
+	wg.Wait()
+	assert.Len(t, man.Config().Interpreters, 1)
+
+	reloadedConfig := man.Config()
+	man.UpdateConfig(func(config *configurator.InsteadmanConfig) error {
+		config.Lang = "en"
+		return nil
+	})
+	assert.Equal(t, "en", man.Config().Lang)
+	assert.Equal(t, "uk", reloadedConfig.Lang)
+}
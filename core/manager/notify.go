@@ -0,0 +1,48 @@
+package manager
+
+import "github.com/jhekasoft/insteadman3/core/notify"
+
+func (m *Manager) notificationsEnabled() bool {
+	return m.Notifier != nil && m.Config().Notifications
+}
+
+func (m *Manager) notifyInstalled(game *Game) {
+	if !m.notificationsEnabled() {
+		return
+	}
+
+	title := game.Title
+	if title == "" {
+		title = game.Name
+	}
+
+	m.sendNotification(notify.InstallFinished(title))
+}
+
+// notifyRepositories notifies about updates of the installed games which are found in the new repositories
+func (m *Manager) notifyRepositories(errs []error) {
+	if !m.notificationsEnabled() {
+		return
+	}
+
+	updates := 0
+	if len(errs) == 0 {
+		games, _ := m.GetSortedGames()
+		for _, game := range games {
+			if game.IsUpdateAvailable() {
+				updates++
+			}
+		}
+	}
+
+	if updates > 0 {
+		m.sendNotification(notify.UpdateAvailable(updates))
+	} else {
+		m.sendNotification(notify.RepositoriesRefreshed(len(errs)))
+	}
+}
+
+// sendNotification shows notification, its errors don't fail the operation
+func (m *Manager) sendNotification(n notify.Notification) {
+	m.Notifier.Notify(n)
+}
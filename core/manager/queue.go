@@ -0,0 +1,91 @@
+package manager
+
+import (
+	"context"
+)
+
+// QueueAction is an action which is applied to the games of the queue
+type QueueAction int
+
+const (
+	QueueInstall QueueAction = iota
+	QueueRemove
+)
+
+// QueueProgress is a progress of the queue processing: current game (Index of Count) and its downloaded size
+type QueueProgress struct {
+	Index      int
+	Count      int
+	Game       *Game
+	Downloaded uint64
+}
+
+// Fraction returns done part of the whole queue (from 0 to 1)
+func (p QueueProgress) Fraction() float64 {
+	if p.Count == 0 {
+		return 0
+	}
+
+	fraction := float64(p.Index)
+	if p.Game != nil && p.Game.Size > 0 && p.Downloaded <= uint64(p.Game.Size) {
+		fraction += float64(p.Downloaded) / float64(p.Game.Size)
+	}
+
+	return fraction / float64(p.Count)
+}
+
+// QueueResult is a result of the game processing, Error is nil if it has succeeded
+type QueueResult struct {
+	Game  Game
+	Error error
+}
+
+// ProcessQueue installs (or removes) games one by one, failed game doesn't stop the queue.
+// Processing is stopped when context is done, results of the rest games have context error.
+// progressF (can be nil) is called when the next game is started and when it's downloading.
+func (m *Manager) ProcessQueue(ctx context.Context, action QueueAction, games []Game,
+	progressF func(QueueProgress)) []QueueResult {
+
+	return ProcessBackendQueue(ctx, m, action, games, progressF)
+}
+
+// ProcessBackendQueue is like Manager.ProcessQueue, games are processed by the backend
+func ProcessBackendQueue(ctx context.Context, b Backend, action QueueAction, games []Game,
+	progressF func(QueueProgress)) []QueueResult {
+
+	results := make([]QueueResult, 0, len(games))
+	for i := range games {
+		game := &games[i]
+
+		if ctx.Err() != nil {
+			results = append(results, QueueResult{Game: *game, Error: ctx.Err()})
+			continue
+		}
+
+		progress := QueueProgress{Index: i, Count: len(games), Game: game}
+		if progressF != nil {
+			progressF(progress)
+		}
+
+		var e error
+		switch action {
+		case QueueInstall:
+			e = b.InstallGameContext(ctx, game, func(size uint64) {
+				if progressF != nil {
+					progress.Downloaded = size
+					progressF(progress)
+				}
+			}, nil)
+		case QueueRemove:
+			e = b.RemoveGame(game)
+		}
+
+		results = append(results, QueueResult{Game: *game, Error: e})
+	}
+
+	if progressF != nil {
+		progressF(QueueProgress{Index: len(games), Count: len(games)})
+	}
+
+	return results
+}
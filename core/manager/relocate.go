@@ -0,0 +1,267 @@
+package manager
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/jhekasoft/insteadman3/core/configurator"
+	"github.com/jhekasoft/insteadman3/core/utils"
+)
+
+// RelocateCleanupError is returned when games have moved and the config has saved, but some files of the old
+// games directory haven't removed
+type RelocateCleanupError struct {
+	Paths []string
+	Err   error
+}
+
+func (e *RelocateCleanupError) Error() string {
+	return "old games haven't removed (" + strings.Join(e.Paths, ", ") + "): " + e.Err.Error()
+}
+
+// Unwrap returns the first error of the removing
+func (e *RelocateCleanupError) Unwrap() error {
+	return e.Err
+}
+
+// RelocateGamesDir moves installed games to the new games directory and changes games path in the config,
+// saveConfigF saves the changed config right after the copying (nil doesn't save it), config isn't changed
+// if saving fails. Games are copied and verified before removing them from the old directory, so nothing
+// is lost if copying or saving fails. progressF is called with copied and total bytes. *RelocateCleanupError
+// is returned if games have moved but the old ones haven't removed.
+func (m *Manager) RelocateGamesDir(newPath string, progressF func(copied, total uint64),
+	saveConfigF func(config *configurator.InsteadmanConfig) error) error {
+	oldDir, e := filepath.Abs(m.Config().CalculatedGamesPath)
+	if e != nil {
+		return e
+	}
+
+	newDir, e := filepath.Abs(newPath)
+	if e != nil {
+		return e
+	}
+
+	if oldDir == newDir {
+		return nil
+	}
+
+	if isSubPath(oldDir, newDir) || isSubPath(newDir, oldDir) {
+		return errors.New("games directory can't be moved inside itself")
+	}
+
+	files, e := ioutil.ReadDir(oldDir)
+	if e != nil && !os.IsNotExist(e) {
+		return e
+	}
+
+	for _, file := range files {
+		if utils.PathExist(filepath.Join(newDir, file.Name())) {
+			return errors.New(file.Name() + " already exists in " + newDir)
+		}
+	}
+
+	e = os.MkdirAll(newDir, os.ModePerm)
+	if e != nil {
+		return e
+	}
+
+	var total uint64
+	for _, file := range files {
+		size, e := dirSize(filepath.Join(oldDir, file.Name()))
+		if e != nil {
+			return e
+		}
+		total += size
+	}
+
+	var copied uint64
+	progress := func(n uint64) {
+		copied += n
+		if progressF != nil {
+			progressF(copied, total)
+		}
+	}
+
+	// Roll back, old games directory is untouched
+	removeCopies := func(copiedFiles []os.FileInfo) {
+		for _, copiedFile := range copiedFiles {
+			os.RemoveAll(filepath.Join(newDir, copiedFile.Name()))
+		}
+	}
+
+	for i, file := range files {
+		src := filepath.Join(oldDir, file.Name())
+		dst := filepath.Join(newDir, file.Name())
+
+		e = copyPath(src, dst, progress)
+		if e == nil {
+			e = verifyCopy(src, dst)
+		}
+
+		if e != nil {
+			removeCopies(files[:i+1])
+			return e
+		}
+	}
+
+	// Changed config is used only if it has been saved. Path is saved relative in portable mode (see Configurator).
+	_, e = m.UpdateConfig(func(config *configurator.InsteadmanConfig) error {
+		config.GamesPath = newDir
+		config.CalculatedGamesPath = newDir
+
+		if saveConfigF != nil {
+			return saveConfigF(config)
+		}
+		return nil
+	})
+	if e != nil {
+		removeCopies(files)
+		return e
+	}
+
+	var cleanupErr *RelocateCleanupError
+	for _, file := range files {
+		path := filepath.Join(oldDir, file.Name())
+		e = os.RemoveAll(path)
+		if e != nil {
+			if cleanupErr == nil {
+				cleanupErr = &RelocateCleanupError{Err: e}
+			}
+			cleanupErr.Paths = append(cleanupErr.Paths, path)
+		}
+	}
+	if cleanupErr != nil {
+		return cleanupErr
+	}
+
+	return nil
+}
+
+func isSubPath(parent, path string) bool {
+	rel, e := filepath.Rel(parent, path)
+	if e != nil {
+		return false
+	}
+
+	return rel == "." || (rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)))
+}
+
+func dirSize(path string) (size uint64, e error) {
+	e = filepath.Walk(path, func(_ string, info os.FileInfo, e error) error {
+		if e != nil {
+			return e
+		}
+		if info.Mode().IsRegular() {
+			size += uint64(info.Size())
+		}
+		return nil
+	})
+
+	return
+}
+
+// copyPath copies file or directory recursively keeping permissions
+func copyPath(src, dst string, progressF func(uint64)) error {
+	return filepath.Walk(src, func(path string, info os.FileInfo, e error) error {
+		if e != nil {
+			return e
+		}
+
+		rel, e := filepath.Rel(src, path)
+		if e != nil {
+			return e
+		}
+		target := filepath.Join(dst, rel)
+
+		switch {
+		case info.IsDir():
+			return os.MkdirAll(target, info.Mode().Perm()|0700)
+		case info.Mode()&os.ModeSymlink != 0:
+			link, e := os.Readlink(path)
+			if e != nil {
+				return e
+			}
+			return os.Symlink(link, target)
+		}
+
+		return copyFile(path, target, info.Mode().Perm(), progressF)
+	})
+}
+
+func copyFile(src, dst string, perm os.FileMode, progressF func(uint64)) error {
+	in, e := os.Open(src)
+	if e != nil {
+		return e
+	}
+	defer in.Close()
+
+	out, e := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_EXCL, perm)
+	if e != nil {
+		return e
+	}
+
+	// WriteCounter reports total bytes of the file, progressF gets copied chunk size
+	var reported uint64
+	counter := &WriteCounter{progressF: func(total uint64) {
+		progressF(total - reported)
+		reported = total
+	}}
+
+	_, e = io.Copy(out, io.TeeReader(in, counter))
+	if closeErr := out.Close(); e == nil {
+		e = closeErr
+	}
+
+	return e
+}
+
+// verifyCopy compares content of the copied files with the original ones
+func verifyCopy(src, dst string) error {
+	return filepath.Walk(src, func(path string, info os.FileInfo, e error) error {
+		if e != nil || !info.Mode().IsRegular() {
+			return e
+		}
+
+		rel, e := filepath.Rel(src, path)
+		if e != nil {
+			return e
+		}
+
+		srcHash, e := fileHash(path)
+		if e != nil {
+			return e
+		}
+		dstHash, e := fileHash(filepath.Join(dst, rel))
+		if e != nil {
+			return e
+		}
+
+		if !bytes.Equal(srcHash, dstHash) {
+			return errors.New("copy of " + path + " is corrupted")
+		}
+
+		return nil
+	})
+}
+
+func fileHash(path string) ([]byte, error) {
+	f, e := os.Open(path)
+	if e != nil {
+		return nil, e
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	_, e = io.Copy(h, f)
+	if e != nil {
+		return nil, e
+	}
+
+	return h.Sum(nil), nil
+}
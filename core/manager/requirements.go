@@ -0,0 +1,106 @@
+package manager
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+
+	"github.com/jhekasoft/insteadman3/core/interpreterfinder"
+)
+
+var insteadVersionRegexp = regexp.MustCompile(`instead_version\s*\(?\s*["']([^"']+)["']`)
+
+// CheckGameRequirements validates requirements of the installed game against the interpreter
+// which is used for it. Interpreter which can't be checked isn't validated.
+func (m *Manager) CheckGameRequirements(game *Game) error {
+	info := m.gameInterpreterInfo(game)
+	if info == nil {
+		return nil
+	}
+
+	return CheckGameRequirements(game, info)
+}
+
+// CheckInstallRequirements validates that the interpreter of the game can install games
+func (m *Manager) CheckInstallRequirements(game *Game) error {
+	if game == nil {
+		return nil
+	}
+
+	command, e := m.GameInterpreterCommand(game.Name)
+	if e != nil {
+		return e
+	}
+
+	return m.checkInstallRequirements(command)
+}
+
+// checkInstallRequirements validates that the interpreter command can install games
+func (m *Manager) checkInstallRequirements(command string) error {
+	info := m.interpreterInfo(command)
+	if info == nil || info.Capabilities.Install {
+		return nil
+	}
+
+	return errors.New("INSTEAD " + info.Version.String() + " can't install games, please use newer INSTEAD")
+}
+
+func (m *Manager) gameInterpreterInfo(game *Game) *interpreterfinder.Info {
+	if game == nil {
+		return nil
+	}
+
+	command, e := m.GameInterpreterCommand(game.Name)
+	if e != nil {
+		return nil
+	}
+
+	return m.interpreterInfo(command)
+}
+
+func (m *Manager) interpreterInfo(command string) *interpreterfinder.Info {
+	if m.InterpreterFinder == nil || command == "" {
+		return nil
+	}
+
+	info, e := m.InterpreterFinder.CheckInfoCached(command)
+	if e != nil {
+		return nil
+	}
+
+	return info
+}
+
+// CheckGameRequirements validates game requirements against the interpreter info.
+// Error contains user-readable explanation of all the mismatches.
+func CheckGameRequirements(game *Game, info *interpreterfinder.Info) error {
+	var reasons []string
+
+	if game.Stead3 && !info.Capabilities.Stead3 {
+		reasons = append(reasons, "it's STEAD3 game which needs INSTEAD 3.0.0 or newer")
+	}
+
+	if game.RequiredInsteadVersion != "" {
+		required, e := interpreterfinder.ParseVersion(game.RequiredInsteadVersion)
+		if e == nil && info.Version.Compare(required) < 0 {
+			reasons = append(reasons, "it needs INSTEAD "+required.String()+" or newer")
+		}
+	}
+
+	if len(reasons) == 0 {
+		return nil
+	}
+
+	return errors.New("Game \"" + game.Title + "\" can't be run with INSTEAD " + info.Version.String() + ": " +
+		strings.Join(reasons, ", "))
+}
+
+// readGameRequirements reads requirements from the main file of the game
+func readGameRequirements(game *Game, mainFileName string, mainFile []byte) {
+	game.Stead3 = mainFileName == "main3.lua"
+
+	matches := insteadVersionRegexp.FindSubmatch(mainFile)
+	if len(matches) > 1 {
+		game.RequiredInsteadVersion = string(matches[1])
+	}
+}
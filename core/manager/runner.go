@@ -0,0 +1,166 @@
+package manager
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	"github.com/jhekasoft/insteadman3/core/configurator"
+	"github.com/jhekasoft/insteadman3/core/interpreterfinder"
+)
+
+// Runner runs installed games
+type Runner interface {
+	Run(game *Game) error
+	Stop() error
+}
+
+// GameRunner returns runner for the game: exec-based runner of the native INSTEAD interpreter or
+// web runner (INSTEAD-EM) if there isn't native interpreter
+func (m *Manager) GameRunner(gameName string) (Runner, error) {
+	interpreterCommand, e := m.GameInterpreterCommand(gameName)
+	if e != nil {
+		return nil, e
+	}
+
+	if interpreterCommand == "" {
+		if dir := m.WebRunnerDir(); dir != "" {
+			return &WebRunner{Dir: dir, GamesPath: m.Config().CalculatedGamesPath}, nil
+		}
+	}
+
+	return &ExecRunner{Manager: m}, nil
+}
+
+// ExecRunner runs games by the native INSTEAD interpreter process. Args are added after the arguments
+// from the config (arguments of the command line).
+type ExecRunner struct {
+	Manager *Manager
+	Args    []string
+}
+
+func (r *ExecRunner) Run(game *Game) error {
+	cmd, e := r.command(game)
+	if e != nil {
+		return e
+	}
+
+	e = cmd.Start()
+
+	// Current running cmd
+	if e == nil {
+		r.Manager.CurrentRunningCmd = cmd
+	}
+
+	return e
+}
+
+// command returns INSTEAD command of the game, interpreterArgs are added to the INSTEAD arguments ("-debug")
+func (r *ExecRunner) command(game *Game, interpreterArgs ...string) (*exec.Cmd, error) {
+	m := r.Manager
+
+	// Absolute games path
+	gamesPath, e := filepath.Abs(m.Config().CalculatedGamesPath)
+	if e != nil {
+		return nil, e
+	}
+
+	gameConfig := m.Config().GameConfig(game.Name)
+	extraArgs := append(append(append([]string(nil), m.Config().InterpreterArgs...), gameConfig.Args...), r.Args...)
+
+	interpreterCommand, e := m.GameInterpreterCommand(game.Name)
+	if e != nil {
+		return nil, e
+	}
+
+	e = m.CheckGameRequirements(game)
+	if e != nil {
+		return nil, e
+	}
+
+	args := []string{"-gamespath", gamesPath, "-game", game.Name}
+
+	// INSTEAD data (saves, settings) isn't stored in the user profile in portable mode
+	if m.Config().CalculatedAppDataPath != "" {
+		args = append(args, "-appdata", m.Config().CalculatedAppDataPath)
+	}
+	args = append(args, interpreterArgs...)
+
+	var cmd *exec.Cmd
+	if interpreterfinder.IsCommandTemplate(interpreterCommand) {
+		parts, e := interpreterfinder.ExpandCommandTemplate(interpreterCommand, interpreterfinder.CommandData{
+			GameName:    game.Name,
+			GamePath:    filepath.Join(gamesPath, game.Name),
+			GamesPath:   gamesPath,
+			AppDataPath: m.Config().CalculatedAppDataPath,
+			Args:        args,
+			ExtraArgs:   extraArgs,
+		})
+		if e != nil {
+			return nil, e
+		}
+
+		cmd = exec.Command(parts[0], parts[1:]...)
+	} else {
+		// todo: idf
+		cmd = interpreterfinder.Command(interpreterCommand, append(args, extraArgs...)...)
+		cmd.Dir = filepath.Dir(interpreterCommand)
+	}
+	if len(gameConfig.Env) > 0 {
+		cmd.Env = os.Environ()
+		for name, value := range gameConfig.Env {
+			cmd.Env = append(cmd.Env, name+"="+value)
+		}
+	}
+
+	if sandbox := m.Config().GameSandbox(game.Name); sandbox != "" && sandbox != SandboxNone {
+		cmd, e = sandboxCommand(sandbox, cmd, m.gameSandboxPaths(filepath.Join(gamesPath, game.Name), cmd.Path))
+		if e != nil {
+			return nil, e
+		}
+	}
+
+	return cmd, nil
+}
+
+// UseTempAppData creates temporary INSTEAD data directory (saves, settings) and uses it for the games
+// instead of the user's one, so the game is run like the first time. Directory should be removed by the caller.
+func (m *Manager) UseTempAppData() (string, error) {
+	dir, e := ioutil.TempDir("", "insteadman-appdata")
+	if e != nil {
+		return "", e
+	}
+	_, e = m.UpdateConfig(func(config *configurator.InsteadmanConfig) error {
+		config.CalculatedAppDataPath = dir
+		return nil
+	})
+	if e != nil {
+		os.RemoveAll(dir)
+		return "", e
+	}
+
+	return dir, nil
+}
+
+func (r *ExecRunner) Stop() error {
+	if r.Manager.CurrentRunningCmd == nil {
+		return nil
+	}
+
+	return r.Manager.CurrentRunningCmd.Process.Kill()
+}
+
+func (m *Manager) gameSandboxPaths(gamePath, interpreterPath string) (paths sandboxPaths) {
+	paths.readOnly = []string{gamePath}
+	if filepath.IsAbs(interpreterPath) {
+		paths.readOnly = append(paths.readOnly, filepath.Dir(interpreterPath))
+	}
+
+	appDataPath := m.appDataDir()
+	if appDataPath != "" {
+		paths.writable = []string{appDataPath}
+	}
+
+	return
+}
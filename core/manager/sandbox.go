@@ -0,0 +1,133 @@
+package manager
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+
+	"github.com/jhekasoft/insteadman3/core/utils"
+)
+
+// Sandbox values of the config (global "sandbox" or per-game one)
+const (
+	SandboxNone     = "none" // disables global sandbox for the game
+	SandboxAuto     = "auto" // firejail or bubblewrap, which is installed
+	SandboxFirejail = "firejail"
+	SandboxBwrap    = "bwrap"
+)
+
+// ErrSandboxUnavailable is returned when sandbox is enabled but it can't be used (game isn't run without it)
+var ErrSandboxUnavailable = errors.New("sandbox isn't available, please install firejail or bubblewrap " +
+	"or disable sandbox in config.yml")
+
+// sandboxPaths are paths which are accessible inside the sandbox, everything else in the home is hidden
+type sandboxPaths struct {
+	readOnly []string // game and interpreter
+	writable []string // INSTEAD data (saves, settings)
+}
+
+// SandboxAvailable checks that the sandbox can be used
+func SandboxAvailable(sandbox string) bool {
+	_, e := sandboxExecutable(sandbox)
+	return e == nil
+}
+
+func sandboxExecutable(sandbox string) (string, error) {
+	if runtime.GOOS != "linux" {
+		return "", ErrSandboxUnavailable
+	}
+
+	names := []string{sandbox}
+	switch sandbox {
+	case SandboxAuto:
+		names = []string{SandboxFirejail, SandboxBwrap}
+	case SandboxFirejail, SandboxBwrap:
+	default:
+		return "", errors.New("unknown sandbox: " + sandbox)
+	}
+
+	for _, name := range names {
+		if path, e := exec.LookPath(name); e == nil {
+			return path, nil
+		}
+	}
+
+	return "", ErrSandboxUnavailable
+}
+
+// sandboxCommand wraps command by the sandbox: network is disabled, home directory is hidden
+// except the game, interpreter and INSTEAD data
+func sandboxCommand(sandbox string, cmd *exec.Cmd, paths sandboxPaths) (*exec.Cmd, error) {
+	executable, e := sandboxExecutable(sandbox)
+	if e != nil {
+		return nil, e
+	}
+
+	for _, path := range paths.writable {
+		e = os.MkdirAll(path, os.ModePerm)
+		if e != nil {
+			return nil, e
+		}
+	}
+
+	var args []string
+	if filepath.Base(executable) == SandboxFirejail {
+		args = firejailArgs(paths)
+	} else {
+		args = bwrapArgs(paths)
+	}
+
+	sandboxedCmd := exec.Command(executable, append(append(args, cmd.Path), cmd.Args[1:]...)...)
+	sandboxedCmd.Dir = cmd.Dir
+	sandboxedCmd.Env = cmd.Env
+
+	return sandboxedCmd, nil
+}
+
+func firejailArgs(paths sandboxPaths) []string {
+	args := []string{"--quiet", "--net=none", "--private-tmp"}
+	for _, path := range paths.readOnly {
+		args = append(args, "--whitelist="+path, "--read-only="+path)
+	}
+	for _, path := range paths.writable {
+		args = append(args, "--whitelist="+path)
+	}
+
+	return args
+}
+
+func bwrapArgs(paths sandboxPaths) []string {
+	args := []string{"--ro-bind", "/", "/", "--dev", "/dev", "--proc", "/proc", "--tmpfs", "/tmp",
+		"--unshare-net", "--unshare-pid", "--die-with-parent"}
+
+	// X11 socket and authority file are needed for the window
+	hidden := []string{"/tmp"}
+	if home := os.Getenv("HOME"); home != "" {
+		args = append(args, "--tmpfs", home)
+		hidden = append(hidden, home)
+	}
+	readOnly := append([]string{"/tmp/.X11-unix", os.Getenv("XAUTHORITY")}, paths.readOnly...)
+
+	for _, path := range readOnly {
+		if path != "" && utils.PathExist(path) && isInside(hidden, path) {
+			args = append(args, "--ro-bind", path, path)
+		}
+	}
+	for _, path := range paths.writable {
+		args = append(args, "--bind", path, path)
+	}
+
+	return args
+}
+
+func isInside(dirs []string, path string) bool {
+	for _, dir := range dirs {
+		if isSubPath(dir, path) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,80 @@
+package manager
+
+import (
+	"sort"
+	"strings"
+)
+
+// Relevance of the game field for the keyword word (more is better)
+const (
+	relevanceNone = iota
+	relevanceDescription
+	relevanceContains
+	relevanceWordPrefix
+	relevancePrefix
+	relevanceExact = 100
+)
+
+// SearchGames returns games which match all words of the keyword (in the title, name, author or description).
+// More relevant games are first, games with the same relevance keep their order.
+func SearchGames(games []Game, keyword string) []Game {
+	words := strings.Fields(strings.ToLower(keyword))
+	if len(words) == 0 {
+		return games
+	}
+
+	lowerKeyword := strings.Join(words, " ")
+
+	foundGames := make([]Game, 0)
+	relevances := make(map[string]int)
+	for _, game := range games {
+		relevance := gameRelevance(game, lowerKeyword, words)
+		if relevance == relevanceNone {
+			continue
+		}
+
+		relevances[game.Id] = relevance
+		foundGames = append(foundGames, game)
+	}
+
+	sort.SliceStable(foundGames, func(i, j int) bool {
+		return relevances[foundGames[i].Id] > relevances[foundGames[j].Id]
+	})
+
+	return foundGames
+}
+
+func gameRelevance(game Game, lowerKeyword string, words []string) int {
+	title := strings.ToLower(game.Title)
+	name := strings.ToLower(game.Name)
+
+	if title == lowerKeyword || name == lowerKeyword {
+		return relevanceExact
+	}
+
+	relevance := relevanceNone
+	for _, word := range words {
+		wordRelevance := wordRelevance(word, title, name, strings.ToLower(game.Author+" "+game.Description))
+		if wordRelevance == relevanceNone {
+			return relevanceNone
+		}
+		relevance += wordRelevance
+	}
+
+	return relevance
+}
+
+func wordRelevance(word, title, name, text string) int {
+	switch {
+	case strings.HasPrefix(title, word) || strings.HasPrefix(name, word):
+		return relevancePrefix
+	case strings.Contains(title, " "+word):
+		return relevanceWordPrefix
+	case strings.Contains(title, word) || strings.Contains(name, word):
+		return relevanceContains
+	case strings.Contains(text, word):
+		return relevanceDescription
+	}
+
+	return relevanceNone
+}
@@ -0,0 +1,32 @@
+package manager
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSearchGames(t *testing.T) {
+	games := []Game{
+		{Id: "repo/dark", Name: "dark", Title: "Into the dark forest"},
+		{Id: "repo/lost", Name: "lost", Title: "Lost", Description: "Story about the forest"},
+		{Id: "repo/forest", Name: "forest", Title: "Forest"},
+		{Id: "repo/forester", Name: "forester", Title: "Forester's day"},
+		{Id: "repo/other", Name: "other", Title: "Other game"},
+	}
+
+	found := SearchGames(games, " Forest ")
+	var ids []string
+	for _, game := range found {
+		ids = append(ids, game.Id)
+	}
+	assert.Equal(t, []string{"repo/forest", "repo/forester", "repo/dark", "repo/lost"}, ids)
+
+	// All the words have to be found
+	found = SearchGames(games, "dark forest")
+	assert.Len(t, found, 1)
+	assert.Equal(t, "repo/dark", found[0].Id)
+
+	assert.Empty(t, SearchGames(games, "nothing"))
+	assert.Len(t, SearchGames(games, " "), len(games))
+}
@@ -0,0 +1,37 @@
+package manager
+
+import (
+	"path/filepath"
+
+	"github.com/jhekasoft/insteadman3/core/shortcuts"
+)
+
+// createShortcut creates menu shortcut of the installed game if shortcuts are enabled in the config.
+// Icon of the game directory is used, repository image is used if the game hasn't icon.
+// Shortcut isn't required for the game, so its errors don't fail installing.
+func (m *Manager) createShortcut(game *Game) {
+	if m.Shortcuts == nil || !m.Config().Shortcuts {
+		return
+	}
+
+	icon := shortcuts.FindGameIcon(filepath.Join(m.Config().CalculatedGamesPath, game.Name))
+	if icon == "" {
+		icon, _ = m.GetGameImage(game)
+	}
+
+	title := game.Title
+	if title == "" {
+		title = game.Name
+	}
+
+	m.Shortcuts.Create(shortcuts.Shortcut{Name: game.Name, Title: title, Icon: icon})
+}
+
+// removeShortcut removes shortcut of the removed game (it can be created before disabling in the config)
+func (m *Manager) removeShortcut(game *Game) {
+	if m.Shortcuts == nil {
+		return
+	}
+
+	m.Shortcuts.Remove(game.Name)
+}
@@ -0,0 +1,21 @@
+package manager
+
+// countInstall counts install of the game for the repository statistics if telemetry is enabled in the config.
+// Counting errors don't fail installing.
+func (m *Manager) countInstall(game *Game) {
+	if m.Telemetry == nil || !m.Config().Telemetry {
+		return
+	}
+
+	m.Telemetry.CountInstall(game.RepositoryName, game.Name)
+}
+
+// SendTelemetry sends install counts to the repositories if telemetry is enabled in the config and
+// the send interval has passed
+func (m *Manager) SendTelemetry() []error {
+	if m.Telemetry == nil || !m.Config().Telemetry {
+		return nil
+	}
+
+	return m.Telemetry.Send(m.Config().Repositories, false)
+}
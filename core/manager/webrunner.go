@@ -0,0 +1,116 @@
+package manager
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"net/url"
+	"path/filepath"
+
+	"github.com/jhekasoft/insteadman3/core/utils"
+)
+
+// webRunnerDirName is a directory of the INSTEAD-EM build near the executable or in the data directory
+const webRunnerDirName = "instead-em"
+
+// WebRunner runs games by the web build of INSTEAD (INSTEAD-EM) in the browser. It's useful when native
+// INSTEAD can't be installed (ChromeOS, locked-down machines). Runner and games are served on localhost,
+// runner page gets URL of the game directory in the "game" query parameter.
+type WebRunner struct {
+	// Dir is a directory of the INSTEAD-EM build (with index.html)
+	Dir       string
+	GamesPath string
+	// OpenURL opens runner page (utils.OpenBrowser by default)
+	OpenURL func(url string) error
+	// URL is a runner page of the running game
+	URL string
+
+	server *http.Server
+	done   chan struct{}
+}
+
+// WebRunnerDir returns directory of the bundled INSTEAD-EM or empty string if there isn't it
+func (m *Manager) WebRunnerDir() string {
+	if m.InterpreterFinder == nil {
+		return ""
+	}
+
+	for _, dir := range []string{m.InterpreterFinder.CurrentDir, m.InterpreterFinder.DataDir} {
+		if dir == "" {
+			continue
+		}
+
+		path := filepath.Join(dir, webRunnerDirName)
+		if utils.PathExist(filepath.Join(path, "index.html")) {
+			return path
+		}
+	}
+
+	return ""
+}
+
+func (r *WebRunner) Run(game *Game) error {
+	r.Stop()
+
+	if !utils.PathExist(filepath.Join(r.Dir, "index.html")) {
+		return errors.New("INSTEAD-EM hasn't found in " + r.Dir)
+	}
+
+	gamesPath, e := filepath.Abs(r.GamesPath)
+	if e != nil {
+		return e
+	}
+
+	// Only local connections are accepted
+	listener, e := net.Listen("tcp", "127.0.0.1:0")
+	if e != nil {
+		return e
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/", http.FileServer(http.Dir(r.Dir)))
+	mux.Handle("/games/", http.StripPrefix("/games/", http.FileServer(http.Dir(gamesPath))))
+
+	server := &http.Server{Handler: mux}
+	done := make(chan struct{})
+	go func() {
+		server.Serve(listener)
+		close(done)
+	}()
+	r.server, r.done = server, done
+
+	r.URL = "http://" + listener.Addr().String() + "/index.html?game=" +
+		url.QueryEscape("/games/"+url.PathEscape(game.Name)+"/")
+
+	openURL := r.OpenURL
+	if openURL == nil {
+		openURL = utils.OpenBrowser
+	}
+
+	e = openURL(r.URL)
+	if e != nil {
+		r.Stop()
+		return e
+	}
+
+	return nil
+}
+
+// Wait waits until runner is stopped. Game files have to be served while the game is played
+// in the browser.
+func (r *WebRunner) Wait() {
+	if r.done != nil {
+		<-r.done
+	}
+}
+
+func (r *WebRunner) Stop() error {
+	if r.server == nil {
+		return nil
+	}
+
+	e := r.server.Close()
+	r.server = nil
+
+	return e
+}
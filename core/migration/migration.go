@@ -0,0 +1,108 @@
+package migration
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+
+	"github.com/jhekasoft/insteadman3/core/configurator"
+	"github.com/jhekasoft/insteadman3/core/utils"
+)
+
+// insteadMan2ConfigName is a config file name of InsteadMan 2.
+// InsteadMan 2 keeps it in the same directory as InsteadMan 3 keeps config.yml
+const insteadMan2ConfigName = "config.json"
+
+// InsteadMan2Config is a configuration of InsteadMan 2 (GTK/Python)
+type InsteadMan2Config struct {
+	Repositories       []configurator.Repository `json:"repositories"`
+	InterpreterCommand string                    `json:"interpreter_command"`
+	Lang               string                    `json:"lang"`
+	GamesPath          string                    `json:"games_path"`
+	CheckUpdateOnStart *bool                     `json:"check_update_on_start"`
+}
+
+// FindInsteadMan2Config returns path of the InsteadMan 2 config in the InsteadMan directory
+// or empty string if there isn't InsteadMan 2 config
+func FindInsteadMan2Config(insteadManPath string) string {
+	path := filepath.Join(insteadManPath, insteadMan2ConfigName)
+	if !utils.PathExist(path) {
+		return ""
+	}
+
+	return path
+}
+
+// ReadInsteadMan2Config reads InsteadMan 2 config
+func ReadInsteadMan2Config(path string) (config *InsteadMan2Config, e error) {
+	data, e := ioutil.ReadFile(path)
+	if e != nil {
+		return
+	}
+
+	e = json.Unmarshal(data, &config)
+	return
+}
+
+// Import copies repositories, interpreter path, language and games directory from the InsteadMan 2 config.
+// Installed games are detected by the games directory so keeping InsteadMan 2 games path keeps them installed.
+func Import(oldConfig *InsteadMan2Config, config *configurator.InsteadmanConfig) {
+	if oldConfig == nil || config == nil {
+		return
+	}
+
+	for _, repo := range oldConfig.Repositories {
+		if repo.Name == "" || repo.Url == "" || hasRepository(config.Repositories, repo) {
+			continue
+		}
+		config.Repositories = append(config.Repositories, repo)
+	}
+
+	if oldConfig.InterpreterCommand != "" {
+		config.InterpreterCommand = oldConfig.InterpreterCommand
+		config.UseBuiltinInterpreter = false
+	}
+
+	if oldConfig.Lang != "" {
+		config.Lang = oldConfig.Lang
+	}
+
+	if oldConfig.GamesPath != "" && utils.PathExist(oldConfig.GamesPath) {
+		config.GamesPath = oldConfig.GamesPath
+		config.CalculatedGamesPath = oldConfig.GamesPath
+	}
+
+	if oldConfig.CheckUpdateOnStart != nil {
+		config.CheckUpdateOnStart = *oldConfig.CheckUpdateOnStart
+	}
+}
+
+// ImportFile reads InsteadMan 2 config by the path, imports it to the current config of the configurator
+// and saves InsteadMan 3 config
+func ImportFile(path string, c *configurator.Configurator) error {
+	oldConfig, e := ReadInsteadMan2Config(path)
+	if e != nil {
+		return e
+	}
+
+	if c.Holder() == nil {
+		return configurator.ErrNotRead
+	}
+
+	_, e = c.Holder().Update(func(config *configurator.InsteadmanConfig) error {
+		Import(oldConfig, config)
+		return c.SaveConfig(config)
+	})
+
+	return e
+}
+
+func hasRepository(repositories []configurator.Repository, repo configurator.Repository) bool {
+	for _, r := range repositories {
+		if r.Name == repo.Name || r.Url == repo.Url {
+			return true
+		}
+	}
+
+	return false
+}
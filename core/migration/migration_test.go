@@ -0,0 +1,37 @@
+package migration
+
+import (
+	"testing"
+
+	"github.com/jhekasoft/insteadman3/core/configurator"
+	"github.com/stretchr/testify/assert"
+)
+
+const insteadMan2Path = "../../resources/testdata/insteadman2"
+
+func TestFindInsteadMan2Config(t *testing.T) {
+	assert.NotEmpty(t, FindInsteadMan2Config(insteadMan2Path))
+	assert.Empty(t, FindInsteadMan2Config("../../resources/testdata/insteadman"))
+}
+
+func TestImport(t *testing.T) {
+	oldConfig, e := ReadInsteadMan2Config(FindInsteadMan2Config(insteadMan2Path))
+	assert.NoError(t, e)
+
+	config := &configurator.InsteadmanConfig{
+		Repositories: []configurator.Repository{
+			{Name: "instead-games", Url: "http://instead-games.ru/xml.php"},
+		},
+		UseBuiltinInterpreter: true,
+		CheckUpdateOnStart:    true,
+	}
+
+	Import(oldConfig, config)
+
+	assert.Len(t, config.Repositories, 2)
+	assert.Equal(t, "my-repo", config.Repositories[1].Name)
+	assert.Equal(t, "/usr/local/bin/instead", config.InterpreterCommand)
+	assert.False(t, config.UseBuiltinInterpreter)
+	assert.Equal(t, "uk", config.Lang)
+	assert.False(t, config.CheckUpdateOnStart)
+}
@@ -0,0 +1,84 @@
+// Package notify shows system notifications about background operations (installing by the daemon,
+// repositories updating), so user is alerted the same way whichever front end has started them.
+package notify
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/jhekasoft/insteadman3/core/i18n"
+)
+
+// Kind is a type of the notification
+type Kind string
+
+const (
+	KindInstallFinished       Kind = "install_finished"
+	KindUpdateAvailable       Kind = "update_available"
+	KindRepositoriesRefreshed Kind = "repositories_refreshed"
+)
+
+// ErrUnavailable is returned when the system notifications tool isn't installed
+var ErrUnavailable = errors.New("system notifications aren't available")
+
+type Notification struct {
+	Kind  Kind
+	Title string
+	Body  string
+}
+
+// Notifier shows notifications
+type Notifier interface {
+	Notify(n Notification) error
+}
+
+// Func is a Notifier function (for the front end notifications and tests)
+type Func func(n Notification) error
+
+func (f Func) Notify(n Notification) error {
+	return f(n)
+}
+
+// System shows notifications by libnotify (notify-send) on Linux and BSD, toast on Windows
+// and Notification Center on macOS
+type System struct {
+	AppName string
+}
+
+func (s *System) Notify(n Notification) error {
+	appName := s.AppName
+	if appName == "" {
+		appName = "InsteadMan"
+	}
+
+	return systemNotify(appName, n)
+}
+
+// InstallFinished is a notification of the installed game
+func InstallFinished(gameTitle string) Notification {
+	return Notification{
+		Kind:  KindInstallFinished,
+		Title: i18n.T("Game has installed"),
+		Body:  fmt.Sprintf(i18n.T("%s is ready to play."), gameTitle),
+	}
+}
+
+// UpdateAvailable is a notification of the installed games which have new versions
+func UpdateAvailable(count int) Notification {
+	return Notification{
+		Kind:  KindUpdateAvailable,
+		Title: i18n.T("Game updates are available"),
+		Body:  fmt.Sprintf(i18n.N("%d installed game has new version.", "%d installed games have new versions.", count), count),
+	}
+}
+
+// RepositoriesRefreshed is a notification of the updated repositories, errors count is shown if some have failed
+func RepositoriesRefreshed(errorsCount int) Notification {
+	body := i18n.T("Game lists are up to date.")
+	if errorsCount > 0 {
+		body = fmt.Sprintf(i18n.N("%d repository hasn't updated.", "%d repositories haven't updated.", errorsCount),
+			errorsCount)
+	}
+
+	return Notification{Kind: KindRepositoriesRefreshed, Title: i18n.T("Repositories have updated"), Body: body}
+}
@@ -0,0 +1,23 @@
+// +build darwin
+
+package notify
+
+import (
+	"os/exec"
+	"strings"
+)
+
+// Notifications are shown in the Notification Center by the AppleScript
+
+func systemNotify(appName string, n Notification) error {
+	script := "display notification " + appleScriptQuote(n.Body) +
+		" with title " + appleScriptQuote(appName) +
+		" subtitle " + appleScriptQuote(n.Title)
+
+	return exec.Command("osascript", "-e", script).Run()
+}
+
+func appleScriptQuote(s string) string {
+	replacer := strings.NewReplacer(`\`, `\\`, `"`, `\"`)
+	return `"` + replacer.Replace(s) + `"`
+}
@@ -0,0 +1,16 @@
+// +build !windows,!darwin
+
+package notify
+
+import "os/exec"
+
+// Notifications are sent by notify-send of the libnotify
+
+func systemNotify(appName string, n Notification) error {
+	path, e := exec.LookPath("notify-send")
+	if e != nil {
+		return ErrUnavailable
+	}
+
+	return exec.Command(path, "--app-name="+appName, "--icon=insteadman", "--", n.Title, n.Body).Run()
+}
@@ -0,0 +1,27 @@
+package notify
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNotifications(t *testing.T) {
+	n := InstallFinished("Cat Lady")
+	assert.Equal(t, KindInstallFinished, n.Kind)
+	assert.Equal(t, "Cat Lady is ready to play.", n.Body)
+
+	assert.Equal(t, "2 installed games have new versions.", UpdateAvailable(2).Body)
+	assert.Equal(t, "1 installed game has new version.", UpdateAvailable(1).Body)
+
+	assert.Equal(t, "Game lists are up to date.", RepositoriesRefreshed(0).Body)
+	assert.Equal(t, "1 repository hasn't updated.", RepositoriesRefreshed(1).Body)
+
+	var notified []Notification
+	var notifier Notifier = Func(func(n Notification) error {
+		notified = append(notified, n)
+		return nil
+	})
+	assert.NoError(t, notifier.Notify(n))
+	assert.Equal(t, []Notification{n}, notified)
+}
@@ -0,0 +1,51 @@
+// +build windows
+
+package notify
+
+import (
+	"errors"
+	"os/exec"
+	"strings"
+	"syscall"
+)
+
+// Toasts are shown by the PowerShell with the Windows Runtime API. Toast requires registered application ID,
+// so ID of the PowerShell is used.
+
+const powerShellAppID = `{1AC14E77-02E7-4E5D-B744-2EB1AE5198B7}\WindowsPowerShell\v1.0\powershell.exe`
+
+func systemNotify(appName string, n Notification) error {
+	toast := "<toast><visual><binding template=\"ToastGeneric\">" +
+		"<text>" + xmlEscape(n.Title) + "</text>" +
+		"<text>" + xmlEscape(n.Body) + "</text>" +
+		"<text placement=\"attribution\">" + xmlEscape(appName) + "</text>" +
+		"</binding></visual></toast>"
+
+	script := "[Windows.UI.Notifications.ToastNotificationManager, Windows.UI.Notifications, ContentType = WindowsRuntime] > $null; " +
+		"[Windows.Data.Xml.Dom.XmlDocument, Windows.Data.Xml.Dom.XmlDocument, ContentType = WindowsRuntime] > $null; " +
+		"$xml = New-Object Windows.Data.Xml.Dom.XmlDocument; " +
+		"$xml.LoadXml(" + psQuote(toast) + "); " +
+		"$toast = New-Object Windows.UI.Notifications.ToastNotification $xml; " +
+		"[Windows.UI.Notifications.ToastNotificationManager]::CreateToastNotifier(" + psQuote(powerShellAppID) + ").Show($toast)"
+
+	cmd := exec.Command("powershell", "-NoProfile", "-NonInteractive", "-Command", script)
+	// Console window isn't shown for the GTK version
+	cmd.SysProcAttr = &syscall.SysProcAttr{HideWindow: true}
+
+	out, e := cmd.CombinedOutput()
+	if e != nil {
+		return errors.New(e.Error() + "; " + strings.TrimSpace(string(out)))
+	}
+
+	return nil
+}
+
+func xmlEscape(s string) string {
+	replacer := strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;", `"`, "&quot;", "'", "&apos;")
+	return replacer.Replace(s)
+}
+
+// psQuote quotes PowerShell string
+func psQuote(s string) string {
+	return "'" + strings.Replace(s, "'", "''", -1) + "'"
+}
@@ -0,0 +1,159 @@
+// Package scheduler runs periodic jobs (repositories refresh, cache eviction, saves backups etc.) in the
+// background of the daemon or GUI. Last runs are kept in the state file, so intervals are counted between
+// the launches too.
+package scheduler
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Job is a periodic job, it's run when Interval (and random Jitter) has passed since its last run
+type Job struct {
+	Name     string
+	Interval time.Duration
+	// Jitter is a maximal random delay which is added to the interval, so many InsteadMan instances
+	// don't request repositories at the same time
+	Jitter time.Duration
+	Run    func(ctx context.Context) error
+}
+
+// Scheduler runs the jobs until it's stopped
+type Scheduler struct {
+	StateFile string
+	// OnError is called when the job has failed (errors are ignored if it's nil)
+	OnError func(job string, e error)
+
+	jobs   []Job
+	mutex  sync.Mutex
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+func New(stateFile string) *Scheduler {
+	return &Scheduler{StateFile: stateFile}
+}
+
+// Add adds the job, jobs are added before Start
+func (s *Scheduler) Add(job Job) {
+	s.jobs = append(s.jobs, job)
+}
+
+// Start starts running of the jobs in the background
+func (s *Scheduler) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	s.cancel = cancel
+
+	for _, job := range s.jobs {
+		s.wg.Add(1)
+		go s.loop(ctx, job)
+	}
+}
+
+// Stop cancels running jobs and waits for them
+func (s *Scheduler) Stop() {
+	if s.cancel == nil {
+		return
+	}
+
+	s.cancel()
+	s.wg.Wait()
+	s.cancel = nil
+}
+
+// LastRun returns time of the last run of the job (zero time if it hasn't run)
+func (s *Scheduler) LastRun(name string) time.Time {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return s.read()[name]
+}
+
+// Touch marks the job as run now, it's used when the same operation has been done by the user
+func (s *Scheduler) Touch(name string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	state := s.read()
+	state[name] = time.Now()
+
+	return s.write(state)
+}
+
+func (s *Scheduler) loop(ctx context.Context, job Job) {
+	defer s.wg.Done()
+
+	for {
+		delay := time.Until(s.LastRun(job.Name).Add(job.Interval))
+		if delay < 0 {
+			delay = 0
+		}
+		if job.Jitter > 0 {
+			delay += time.Duration(rand.Int63n(int64(job.Jitter)))
+		}
+
+		if delay > 0 {
+			timer := time.NewTimer(delay)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return
+			case <-timer.C:
+			}
+
+			// Job could be touched while waiting
+			if time.Since(s.LastRun(job.Name)) < job.Interval {
+				continue
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		default:
+		}
+
+		e := job.Run(ctx)
+		if ctx.Err() != nil {
+			// Cancelled job is run again on the next start
+			return
+		}
+		if e != nil && s.OnError != nil {
+			s.OnError(job.Name, e)
+		}
+
+		// Failed job is run on the next interval too, so it doesn't repeat errors
+		s.Touch(job.Name)
+	}
+}
+
+func (s *Scheduler) read() map[string]time.Time {
+	state := make(map[string]time.Time)
+
+	data, e := ioutil.ReadFile(s.StateFile)
+	if e == nil {
+		json.Unmarshal(data, &state)
+	}
+
+	return state
+}
+
+func (s *Scheduler) write(state map[string]time.Time) error {
+	e := os.MkdirAll(filepath.Dir(s.StateFile), os.ModePerm)
+	if e != nil {
+		return e
+	}
+
+	data, e := json.Marshal(state)
+	if e != nil {
+		return e
+	}
+
+	return ioutil.WriteFile(s.StateFile, data, 0644)
+}
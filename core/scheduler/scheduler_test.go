@@ -0,0 +1,75 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScheduler(t *testing.T) {
+	dir, e := ioutil.TempDir("", "insteadman")
+	assert.NoError(t, e)
+	defer os.RemoveAll(dir)
+
+	stateFile := filepath.Join(dir, "scheduler.json")
+
+	var runs, failures int32
+	var failedJob string
+	s := New(stateFile)
+	s.OnError = func(job string, e error) {
+		failedJob = job
+		atomic.AddInt32(&failures, 1)
+	}
+	s.Add(Job{Name: "refresh", Interval: 50 * time.Millisecond, Jitter: 10 * time.Millisecond,
+		Run: func(ctx context.Context) error {
+			atomic.AddInt32(&runs, 1)
+			return nil
+		}})
+	s.Add(Job{Name: "backup", Interval: time.Hour, Run: func(ctx context.Context) error {
+		return errors.New("backup has failed")
+	}})
+
+	// Jobs which haven't run are run at once
+	s.Start()
+	time.Sleep(170 * time.Millisecond)
+	s.Stop()
+
+	assert.True(t, atomic.LoadInt32(&runs) >= 2)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&failures))
+	assert.Equal(t, "backup", failedJob)
+	assert.False(t, s.LastRun("refresh").IsZero())
+	assert.False(t, s.LastRun("backup").IsZero())
+
+	// Last runs are kept between the launches, so the failed job isn't repeated until its interval
+	s = New(stateFile)
+	s.OnError = func(job string, e error) {
+		atomic.AddInt32(&failures, 1)
+	}
+	s.Add(Job{Name: "backup", Interval: time.Hour, Run: func(ctx context.Context) error {
+		return errors.New("backup has failed")
+	}})
+	s.Start()
+	time.Sleep(20 * time.Millisecond)
+	s.Stop()
+	assert.Equal(t, int32(1), atomic.LoadInt32(&failures))
+
+	// Touched job waits for the next interval
+	var touchedRuns int32
+	s = New(stateFile)
+	assert.NoError(t, s.Touch("check"))
+	s.Add(Job{Name: "check", Interval: time.Hour, Run: func(ctx context.Context) error {
+		atomic.AddInt32(&touchedRuns, 1)
+		return nil
+	}})
+	s.Start()
+	time.Sleep(20 * time.Millisecond)
+	s.Stop()
+	assert.Equal(t, int32(0), atomic.LoadInt32(&touchedRuns))
+}
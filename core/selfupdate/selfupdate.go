@@ -0,0 +1,208 @@
+package selfupdate
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/jhekasoft/insteadman3/core/interpreterfinder"
+)
+
+const (
+	releasesURL   = "https://api.github.com/repos/jhekasoft/insteadman3/releases/latest"
+	cacheFileName = "app-release.json"
+
+	// CheckInterval limits requests to the GitHub API, it has a rate limit for the anonymous clients
+	CheckInterval = 24 * time.Hour
+
+	requestTimeout = 10 * time.Second
+)
+
+// ErrRateLimited is returned when GitHub API rate limit is exceeded and there isn't cached release
+var ErrRateLimited = errors.New("GitHub API rate limit is exceeded, please try later")
+
+// Release is an InsteadMan release
+type Release struct {
+	Version string `json:"tag_name"`
+	Name    string `json:"name"`
+	Url     string `json:"html_url"`
+}
+
+// Update is a result of the new version checking
+type Update struct {
+	CurrentVersion string
+	Release        *Release
+	// Available is true if the release is newer than current version
+	Available bool
+	// Notified is true if user has been informed about the release (see MarkNotified)
+	Notified bool
+}
+
+// Checker checks the latest InsteadMan release. Response is cached in the CacheDir for the Interval.
+type Checker struct {
+	CacheDir string
+	// ReleasesURL is a URL of the latest release in the GitHub API format (InsteadMan releases by default)
+	ReleasesURL string
+	// Interval is CheckInterval by default
+	Interval time.Duration
+}
+
+type cache struct {
+	CheckedAt time.Time `json:"checked_at"`
+	// RetryAt is a reset time of the exceeded rate limit
+	RetryAt         time.Time `json:"retry_at,omitempty"`
+	Release         *Release  `json:"release,omitempty"`
+	NotifiedVersion string    `json:"notified_version,omitempty"`
+}
+
+// CheckForAppUpdate checks the latest InsteadMan release by the default Checker
+func CheckForAppUpdate(cacheDir, currentVersion string) (*Update, error) {
+	checker := Checker{CacheDir: cacheDir}
+	return checker.CheckForAppUpdate(currentVersion, false)
+}
+
+// CheckForAppUpdate returns the latest release and compares it with current version.
+// Cached release is used if it has been checked recently (force ignores it but not the rate limit).
+func (c *Checker) CheckForAppUpdate(currentVersion string, force bool) (*Update, error) {
+	cached := c.readCache()
+	now := time.Now()
+
+	fresh := !force && cached.Release != nil && now.Sub(cached.CheckedAt) < c.interval()
+	if !fresh && now.After(cached.RetryAt) {
+		release, retryAt, e := c.latestRelease()
+		if e == ErrRateLimited {
+			cached.RetryAt = retryAt
+			c.writeCache(cached)
+		}
+		if e != nil && cached.Release == nil {
+			return nil, e
+		}
+		if e == nil {
+			cached.Release = release
+			cached.CheckedAt = now
+			cached.RetryAt = time.Time{}
+			c.writeCache(cached)
+		}
+	}
+
+	if cached.Release == nil {
+		return nil, ErrRateLimited
+	}
+
+	return &Update{
+		CurrentVersion: currentVersion,
+		Release:        cached.Release,
+		Available:      IsNewer(cached.Release.Version, currentVersion),
+		Notified:       cached.NotifiedVersion == cached.Release.Version,
+	}, nil
+}
+
+// MarkNotified remembers that user has been informed about the release, so it isn't offered again
+func (c *Checker) MarkNotified(release *Release) error {
+	cached := c.readCache()
+	cached.NotifiedVersion = release.Version
+
+	return c.writeCache(cached)
+}
+
+// IsNewer returns true if version (like "v3.1.0") is newer than current one.
+// Unknown versions aren't newer.
+func IsNewer(version, current string) bool {
+	v, e := interpreterfinder.ParseVersion(version)
+	if e != nil {
+		return false
+	}
+
+	currentV, e := interpreterfinder.ParseVersion(current)
+	if e != nil {
+		return false
+	}
+
+	return v.Compare(currentV) > 0
+}
+
+func (c *Checker) interval() time.Duration {
+	if c.Interval > 0 {
+		return c.Interval
+	}
+	return CheckInterval
+}
+
+func (c *Checker) cachePath() string {
+	return filepath.Join(c.CacheDir, cacheFileName)
+}
+
+func (c *Checker) readCache() cache {
+	var cached cache
+
+	data, e := ioutil.ReadFile(c.cachePath())
+	if e == nil {
+		json.Unmarshal(data, &cached)
+	}
+
+	return cached
+}
+
+func (c *Checker) writeCache(cached cache) error {
+	data, e := json.Marshal(cached)
+	if e != nil {
+		return e
+	}
+
+	e = os.MkdirAll(c.CacheDir, os.ModePerm)
+	if e != nil {
+		return e
+	}
+
+	return ioutil.WriteFile(c.cachePath(), data, 0644)
+}
+
+// latestRelease requests the release, reset time of the rate limit is returned with ErrRateLimited
+func (c *Checker) latestRelease() (*Release, time.Time, error) {
+	url := c.ReleasesURL
+	if url == "" {
+		url = releasesURL
+	}
+
+	client := http.Client{Timeout: requestTimeout}
+	resp, e := client.Get(url)
+	if e != nil {
+		return nil, time.Time{}, e
+	}
+	defer resp.Body.Close()
+
+	if (resp.StatusCode == http.StatusForbidden || resp.StatusCode == http.StatusTooManyRequests) &&
+		resp.Header.Get("X-RateLimit-Remaining") == "0" {
+		return nil, rateLimitReset(resp.Header.Get("X-RateLimit-Reset")), ErrRateLimited
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, time.Time{}, errors.New("InsteadMan releases aren't available: " + resp.Status)
+	}
+
+	var release *Release
+	e = json.NewDecoder(resp.Body).Decode(&release)
+	if e != nil {
+		return nil, time.Time{}, e
+	}
+	if release == nil || release.Version == "" {
+		return nil, time.Time{}, errors.New("InsteadMan release hasn't found")
+	}
+
+	return release, time.Time{}, nil
+}
+
+// rateLimitReset parses Unix time of the X-RateLimit-Reset header (an hour later if it's unknown)
+func rateLimitReset(header string) time.Time {
+	reset, e := strconv.ParseInt(header, 10, 64)
+	if e != nil {
+		return time.Now().Add(time.Hour)
+	}
+
+	return time.Unix(reset, 0)
+}
@@ -0,0 +1,78 @@
+package selfupdate
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckForAppUpdate(t *testing.T) {
+	dir, e := ioutil.TempDir("", "insteadman")
+	assert.NoError(t, e)
+	defer os.RemoveAll(dir)
+
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Write([]byte(`{"tag_name": "v3.2.0", "name": "InsteadMan 3.2.0", "html_url": "http://example.com/v3.2.0"}`))
+	}))
+	defer server.Close()
+
+	checker := Checker{CacheDir: dir, ReleasesURL: server.URL}
+	update, e := checker.CheckForAppUpdate("3.1.0", false)
+	assert.NoError(t, e)
+	assert.True(t, update.Available)
+	assert.False(t, update.Notified)
+	assert.Equal(t, "http://example.com/v3.2.0", update.Release.Url)
+
+	// Cached release is used
+	update, e = checker.CheckForAppUpdate("3.2.0", false)
+	assert.NoError(t, e)
+	assert.False(t, update.Available)
+	assert.Equal(t, 1, requests)
+
+	assert.NoError(t, checker.MarkNotified(update.Release))
+	update, e = checker.CheckForAppUpdate("3.1.0", true)
+	assert.NoError(t, e)
+	assert.True(t, update.Notified)
+	assert.Equal(t, 2, requests)
+}
+
+func TestCheckForAppUpdateRateLimit(t *testing.T) {
+	dir, e := ioutil.TempDir("", "insteadman")
+	assert.NoError(t, e)
+	defer os.RemoveAll(dir)
+
+	requests := 0
+	reset := time.Now().Add(time.Hour).Unix()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Header().Set("X-RateLimit-Remaining", "0")
+		w.Header().Set("X-RateLimit-Reset", strconv.FormatInt(reset, 10))
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer server.Close()
+
+	checker := Checker{CacheDir: dir, ReleasesURL: server.URL}
+	_, e = checker.CheckForAppUpdate("3.1.0", true)
+	assert.Equal(t, ErrRateLimited, e)
+
+	// API isn't requested until the reset
+	_, e = checker.CheckForAppUpdate("3.1.0", true)
+	assert.Equal(t, ErrRateLimited, e)
+	assert.Equal(t, 1, requests)
+}
+
+func TestIsNewer(t *testing.T) {
+	assert.True(t, IsNewer("v3.1.0", "3.0.2"))
+	assert.True(t, IsNewer("3.0.10", "3.0.9"))
+	assert.False(t, IsNewer("v3.0.0", "3.0.0"))
+	assert.False(t, IsNewer("3.0.0", "3.1"))
+	assert.False(t, IsNewer("nightly", "3.0.0"))
+}
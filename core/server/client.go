@@ -0,0 +1,157 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/rpc"
+	"net/rpc/jsonrpc"
+	"os"
+	"time"
+
+	"github.com/jhekasoft/insteadman3/core/manager"
+)
+
+const (
+	dialTimeout = time.Second
+	// progressInterval is an interval of the installing progress requests
+	progressInterval = 300 * time.Millisecond
+)
+
+// Client is a manager.Backend which changes games by the running daemon. Events are sent to its subscribers
+// after the operations of this client.
+type Client struct {
+	manager.Events
+
+	rpc   *rpc.Client
+	token string
+}
+
+var _ manager.Backend = (*Client)(nil)
+
+// Dial connects to the daemon JSON-RPC service (DefaultRPCAddr if address is empty), calls are sent with
+// the token of TokenEnv. Error is returned if the daemon isn't running.
+func Dial(addr string) (*Client, error) {
+	if addr == "" {
+		addr = DefaultRPCAddr
+	}
+
+	conn, e := net.DialTimeout("tcp", addr, dialTimeout)
+	if e != nil {
+		return nil, e
+	}
+
+	return &Client{rpc: jsonrpc.NewClient(conn), token: os.Getenv(TokenEnv)}, nil
+}
+
+func (c *Client) Close() error {
+	return c.rpc.Close()
+}
+
+func (c *Client) auth() AuthArgs {
+	return AuthArgs{Token: c.token}
+}
+
+func (c *Client) call(method string, args interface{}, reply interface{}) error {
+	return callError(c.rpc.Call(rpcServiceName+"."+method, args, reply))
+}
+
+// callError converts error of the daemon to the plain error
+func callError(e error) error {
+	if serverErr, ok := e.(rpc.ServerError); ok {
+		return errors.New(string(serverErr))
+	}
+
+	return e
+}
+
+func (c *Client) UpdateRepositories() []error {
+	var reply []string
+	e := c.call("UpdateRepositories", c.auth(), &reply)
+	if e != nil {
+		return []error{e}
+	}
+
+	var errs []error
+	for _, txt := range reply {
+		errs = append(errs, errors.New(txt))
+	}
+	c.Publish(manager.RepositoriesUpdated{Errors: errs})
+
+	return errs
+}
+
+func (c *Client) InstallGameContext(ctx context.Context, game *manager.Game, progressF func(uint64),
+	phaseF func(manager.InstallPhase)) error {
+
+	return c.install(ctx, game, false, progressF, phaseF)
+}
+
+func (c *Client) UpdateGameContext(ctx context.Context, game *manager.Game, progressF func(uint64),
+	phaseF func(manager.InstallPhase)) error {
+
+	return c.install(ctx, game, true, progressF, phaseF)
+}
+
+// install waits for the daemon installing and requests its progress meanwhile
+func (c *Client) install(ctx context.Context, game *manager.Game, update bool, progressF func(uint64),
+	phaseF func(manager.InstallPhase)) error {
+
+	args := GameArgs{AuthArgs: c.auth(), Id: game.Id, Update: update}
+	call := c.rpc.Go(rpcServiceName+".Install", args, &Empty{}, nil)
+
+	if phaseF != nil {
+		phaseF(manager.InstallPhaseDownload)
+	}
+
+	ticker := time.NewTicker(progressInterval)
+	defer ticker.Stop()
+
+	phase := "download"
+	done := ctx.Done()
+	for {
+		select {
+		case <-call.Done:
+			if call.Error != nil && ctx.Err() != nil {
+				return ctx.Err()
+			}
+			if call.Error == nil {
+				c.Publish(manager.GameInstalled{Game: *game})
+			}
+			return callError(call.Error)
+
+		case <-done:
+			// Daemon removes partial game and replies to the Install call
+			c.call("Cancel", args, &Empty{})
+			done = nil
+
+		case <-ticker.C:
+			var progress InstallProgress
+			if c.call("Progress", args, &progress) != nil {
+				continue
+			}
+
+			if progressF != nil {
+				progressF(progress.Downloaded)
+			}
+			c.Publish(manager.DownloadProgress{Game: *game, Downloaded: progress.Downloaded, Total: progress.Total})
+			if phaseF != nil && progress.Phase != phase && progress.Phase == "extract" {
+				phaseF(manager.InstallPhaseExtract)
+			}
+			phase = progress.Phase
+		}
+	}
+}
+
+func (c *Client) RemoveGame(game *manager.Game) error {
+	e := c.call("Remove", GameArgs{AuthArgs: c.auth(), Id: game.Id}, &Empty{})
+	if e == nil {
+		c.Publish(manager.GameRemoved{Game: *game})
+	}
+
+	return e
+}
+
+func (c *Client) RunGame(game *manager.Game) error {
+	return c.call("Run", GameArgs{AuthArgs: c.auth(), Id: game.Id}, &Empty{})
+}
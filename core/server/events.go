@@ -0,0 +1,112 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+)
+
+// Event types of the /api/events stream
+const (
+	EventInstall      = "install"
+	EventRun          = "run"
+	EventRemove       = "remove"
+	EventRepositories = "repositories"
+)
+
+// eventBufferSize is a count of the events which are kept for the slow client, the rest are dropped
+const eventBufferSize = 64
+
+// InstallProgress is sent with EventInstall while the game is installing and after it (Done is true)
+type InstallProgress struct {
+	Id         string `json:"id"`
+	Name       string `json:"name"`
+	Phase      string `json:"phase"` // "download" or "extract"
+	Downloaded uint64 `json:"downloaded"`
+	Total      uint64 `json:"total"`
+	Done       bool   `json:"done"`
+	Error      string `json:"error,omitempty"`
+}
+
+type event struct {
+	Type string
+	Data []byte
+}
+
+// broker sends events to all the subscribed clients
+type broker struct {
+	mutex       sync.Mutex
+	subscribers map[chan event]bool
+}
+
+func newBroker() *broker {
+	return &broker{subscribers: make(map[chan event]bool)}
+}
+
+func (b *broker) subscribe() chan event {
+	ch := make(chan event, eventBufferSize)
+
+	b.mutex.Lock()
+	b.subscribers[ch] = true
+	b.mutex.Unlock()
+
+	return ch
+}
+
+func (b *broker) unsubscribe(ch chan event) {
+	b.mutex.Lock()
+	delete(b.subscribers, ch)
+	b.mutex.Unlock()
+}
+
+func (b *broker) publish(eventType string, value interface{}) {
+	data, e := json.Marshal(value)
+	if e != nil {
+		return
+	}
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	for ch := range b.subscribers {
+		// Slow client doesn't block the others
+		select {
+		case ch <- event{Type: eventType, Data: data}:
+		default:
+		}
+	}
+}
+
+// GET /api/events, stream of the Server-Sent Events
+func (s *Server) handleEvents(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeMethodNotAllowed(w)
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeError(w, http.StatusInternalServerError, errors.New("streaming isn't supported"))
+		return
+	}
+
+	ch := s.events.subscribe()
+	defer s.events.unsubscribe(ch)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case ev := <-ch:
+			fmt.Fprintf(w, "event: %s\ndata: %s\n\n", ev.Type, ev.Data)
+			flusher.Flush()
+		}
+	}
+}
@@ -0,0 +1,192 @@
+package server
+
+import (
+	"crypto/subtle"
+	"errors"
+	"net"
+	"net/rpc"
+	"net/rpc/jsonrpc"
+
+	"github.com/jhekasoft/insteadman3/core/manager"
+)
+
+// DefaultRPCAddr is a localhost address of the JSON-RPC service which is used by the front ends
+const DefaultRPCAddr = "127.0.0.1:8779"
+
+// rpcServiceName is a prefix of the JSON-RPC methods ("InsteadMan.Install")
+const rpcServiceName = "InsteadMan"
+
+// TokenEnv is an environment variable of the daemon token, the daemon and its clients read it
+// if the token isn't passed by the flag
+const TokenEnv = "INSTEADMAN_DAEMON_TOKEN"
+
+// ErrInsecureRPCAddr is returned when JSON-RPC is listened not on the localhost without the token
+var ErrInsecureRPCAddr = errors.New("JSON-RPC can be listened only on the localhost without the token")
+
+// AuthArgs are arguments of every RPC method, Token is checked if the server has the token
+type AuthArgs struct {
+	Token string `json:"token,omitempty"`
+}
+
+// GameArgs are arguments of the RPC methods of the game
+type GameArgs struct {
+	AuthArgs
+	Id     string `json:"id"` // game ID or the game name
+	Update bool   `json:"update,omitempty"`
+}
+
+// Empty is a reply of the RPC methods without it
+type Empty struct{}
+
+// RPCService is a JSON-RPC 1.0 service of the manager operations. Front ends call it instead
+// of changing games by their own manager when the daemon is running (see Client).
+type RPCService struct {
+	s *Server
+}
+
+// CheckRPCAddr returns ErrInsecureRPCAddr if the address isn't the localhost and the token isn't set
+func (s *Server) CheckRPCAddr(addr string) error {
+	if addr == "" || s.Token != "" || isLocalHost(addr) {
+		return nil
+	}
+
+	return ErrInsecureRPCAddr
+}
+
+// ServeRPC accepts JSON-RPC connections on the address (DefaultRPCAddr if it's empty)
+func (s *Server) ServeRPC(addr string) error {
+	if addr == "" {
+		addr = DefaultRPCAddr
+	}
+
+	e := s.CheckRPCAddr(addr)
+	if e != nil {
+		return e
+	}
+
+	listener, e := net.Listen("tcp", addr)
+	if e != nil {
+		return e
+	}
+	defer listener.Close()
+
+	return s.serveRPC(listener)
+}
+
+func (s *Server) serveRPC(listener net.Listener) error {
+	rpcServer := rpc.NewServer()
+	e := rpcServer.RegisterName(rpcServiceName, &RPCService{s: s})
+	if e != nil {
+		return e
+	}
+
+	for {
+		conn, e := listener.Accept()
+		if e != nil {
+			return e
+		}
+
+		go rpcServer.ServeCodec(jsonrpc.NewServerCodec(conn))
+	}
+}
+
+// authorize checks the token of the call
+func (r *RPCService) authorize(args AuthArgs) error {
+	if r.s.Token != "" && subtle.ConstantTimeCompare([]byte(args.Token), []byte(r.s.Token)) != 1 {
+		return ErrForbidden
+	}
+
+	return nil
+}
+
+// findGame authorizes the call and returns its game
+func (r *RPCService) findGame(args GameArgs) (*manager.Game, error) {
+	e := r.authorize(args.AuthArgs)
+	if e != nil {
+		return nil, e
+	}
+
+	return r.s.findGame(args.Id)
+}
+
+// UpdateRepositories replies errors of the repositories which haven't updated
+func (r *RPCService) UpdateRepositories(args AuthArgs, reply *[]string) error {
+	e := r.authorize(args)
+	if e != nil {
+		return e
+	}
+
+	*reply = r.s.updateRepositories()
+
+	return nil
+}
+
+// Install installs (or updates) the game, it replies after installing. Progress is got by Progress.
+func (r *RPCService) Install(args GameArgs, reply *Empty) error {
+	game, e := r.findGame(args)
+	if e != nil {
+		return e
+	}
+
+	inst, e := r.s.startInstalling(*game, args.Update)
+	if e != nil {
+		return e
+	}
+
+	<-inst.done
+	return inst.err
+}
+
+// Progress replies progress of the installing game
+func (r *RPCService) Progress(args GameArgs, reply *InstallProgress) error {
+	game, e := r.findGame(args)
+	if e != nil {
+		return e
+	}
+
+	r.s.mutex.Lock()
+	defer r.s.mutex.Unlock()
+
+	inst, ok := r.s.installing[game.Id]
+	if !ok {
+		return ErrGameNotInstalling
+	}
+	*reply = inst.progress
+
+	return nil
+}
+
+func (r *RPCService) Cancel(args GameArgs, reply *Empty) error {
+	game, e := r.findGame(args)
+	if e != nil {
+		return e
+	}
+
+	return r.s.cancelInstalling(game.Id)
+}
+
+func (r *RPCService) Remove(args GameArgs, reply *Empty) error {
+	game, e := r.findGame(args)
+	if e != nil {
+		return e
+	}
+
+	return r.s.Manager.RemoveGame(game)
+}
+
+func (r *RPCService) Run(args GameArgs, reply *Empty) error {
+	game, e := r.findGame(args)
+	if e != nil {
+		return e
+	}
+	if !game.Installed {
+		return ErrGameNotInstalled
+	}
+
+	e = r.s.Manager.RunGame(game)
+	if e == nil {
+		r.s.events.publish(EventRun, r.s.gameResponse(*game))
+	}
+
+	return e
+}
@@ -0,0 +1,621 @@
+package server
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/jhekasoft/insteadman3/core/configurator"
+	"github.com/jhekasoft/insteadman3/core/manager"
+)
+
+// DefaultAddr is a localhost address of the API
+const DefaultAddr = "127.0.0.1:8778"
+
+var (
+	ErrGameNotFound      = errors.New("game hasn't been found")
+	ErrGameNotInstalled  = errors.New("game isn't installed")
+	ErrGameIsInstalling  = errors.New("game is installing now")
+	ErrGameNotInstalling = errors.New("game isn't installing")
+	ErrForbidden         = errors.New("request isn't allowed")
+	ErrInsecureAddr      = errors.New("API can be listened only on the localhost without the token")
+)
+
+// Server is an HTTP API of the manager for scripts, web front ends and remote controls.
+// Games are installed in the background, progress is sent to the /api/events stream (Server-Sent Events).
+type Server struct {
+	Manager      *manager.Manager
+	Configurator *configurator.Configurator
+	// Token is required in the "Authorization: Bearer" header if it isn't empty
+	Token string
+
+	events *broker
+
+	mutex      sync.Mutex
+	installing map[string]*installing // installing games (key is a game ID)
+
+	configMutex sync.Mutex // config changes are serialized, so they aren't lost or saved partially
+}
+
+// installing is a game which is installing (or updating) in the background
+type installing struct {
+	cancel   context.CancelFunc
+	progress InstallProgress
+	done     chan struct{} // it's closed after installing, err is set then
+	err      error
+}
+
+func New(m *manager.Manager, c *configurator.Configurator) *Server {
+	s := &Server{
+		Manager:      m,
+		Configurator: c,
+		events:       newBroker(),
+		installing:   make(map[string]*installing),
+	}
+
+	// Changes of the manager are sent to the events stream (repositories are refreshed by the scheduler too)
+	if m != nil {
+		m.Subscribe(s.managerEvent)
+	}
+
+	return s
+}
+
+func (s *Server) managerEvent(event manager.Event) {
+	switch event := event.(type) {
+	case manager.GameRemoved:
+		s.events.publish(EventRemove, s.gameResponse(event.Game))
+	case manager.RepositoriesUpdated:
+		s.events.publish(EventRepositories, struct {
+			Errors []string `json:"errors"`
+		}{errorStrings(event.Errors)})
+	}
+}
+
+// CheckAddr returns ErrInsecureAddr if the API address isn't the localhost and the token isn't set
+func (s *Server) CheckAddr(addr string) error {
+	if addr == "" || s.Token != "" || isLocalHost(addr) {
+		return nil
+	}
+
+	return ErrInsecureAddr
+}
+
+// ListenAndServe serves API on the address (DefaultAddr if it's empty)
+func (s *Server) ListenAndServe(addr string) error {
+	if addr == "" {
+		addr = DefaultAddr
+	}
+
+	e := s.CheckAddr(addr)
+	if e != nil {
+		return e
+	}
+
+	return http.ListenAndServe(addr, s.Handler())
+}
+
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/games", s.handleGames)
+	mux.HandleFunc("/api/games/", s.handleGame)
+	mux.HandleFunc("/api/repositories", s.handleRepositories)
+	mux.HandleFunc("/api/repositories/update", s.handleRepositoriesUpdate)
+	mux.HandleFunc("/api/config", s.handleConfig)
+	mux.HandleFunc("/api/config/", s.handleConfigKey)
+	mux.HandleFunc("/api/events", s.handleEvents)
+
+	return s.protect(mux)
+}
+
+// protect rejects requests of the other sites (browser sends Origin header). If token isn't used,
+// requests are accepted only by the localhost listener and requests with the foreign Host (DNS rebinding) are rejected.
+func (s *Server) protect(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if s.Token != "" {
+			authorization := []byte(r.Header.Get("Authorization"))
+			if subtle.ConstantTimeCompare(authorization, []byte("Bearer "+s.Token)) != 1 {
+				writeError(w, http.StatusUnauthorized, ErrForbidden)
+				return
+			}
+		} else if !isLocalConn(r) || !isLocalHost(r.Host) {
+			writeError(w, http.StatusForbidden, ErrForbidden)
+			return
+		}
+
+		if origin := r.Header.Get("Origin"); origin != "" && origin != "http://"+r.Host {
+			writeError(w, http.StatusForbidden, ErrForbidden)
+			return
+		}
+
+		h.ServeHTTP(w, r)
+	})
+}
+
+// isLocalConn checks that request has been accepted on the localhost address
+func isLocalConn(r *http.Request) bool {
+	addr, ok := r.Context().Value(http.LocalAddrContextKey).(net.Addr)
+	return ok && isLocalHost(addr.String())
+}
+
+func isLocalHost(hostPort string) bool {
+	host, _, e := net.SplitHostPort(hostPort)
+	if e != nil {
+		host = hostPort
+	}
+
+	if host == "localhost" {
+		return true
+	}
+
+	ip := net.ParseIP(strings.Trim(host, "[]"))
+	return ip != nil && ip.IsLoopback()
+}
+
+// Game is a game in the API responses
+type Game struct {
+	Id               string   `json:"id"`
+	Name             string   `json:"name"`
+	Title            string   `json:"title"`
+	Version          string   `json:"version"`
+	InstalledVersion string   `json:"installed_version,omitempty"`
+	Size             int      `json:"size"`
+	Languages        []string `json:"languages,omitempty"`
+	Repository       string   `json:"repository,omitempty"`
+	Author           string   `json:"author,omitempty"`
+	Description      string   `json:"description,omitempty"`
+	Url              string   `json:"url,omitempty"`
+	Descurl          string   `json:"descurl,omitempty"`
+	Image            string   `json:"image,omitempty"`
+	Date             string   `json:"date,omitempty"`
+	Installed        bool     `json:"installed"`
+	UpdateAvailable  bool     `json:"update_available"`
+	Installing       bool     `json:"installing"`
+	Favorite         bool     `json:"favorite"`
+	Tags             []string `json:"tags,omitempty"`
+	LastPlayed       int64    `json:"last_played,omitempty"`
+}
+
+func (s *Server) gameResponse(g manager.Game) Game {
+	s.mutex.Lock()
+	_, installing := s.installing[g.Id]
+	s.mutex.Unlock()
+
+	return Game{
+		Id:               g.Id,
+		Name:             g.Name,
+		Title:            g.Title,
+		Version:          g.HumanVersion(),
+		InstalledVersion: g.InstalledVersion,
+		Size:             g.Size,
+		Languages:        g.Languages,
+		Repository:       g.RepositoryName,
+		Author:           g.Author,
+		Description:      g.Description,
+		Url:              g.Url,
+		Descurl:          g.Descurl,
+		Image:            g.Image,
+		Date:             g.Date,
+		Installed:        g.Installed,
+		UpdateAvailable:  g.IsUpdateAvailable(),
+		Installing:       installing,
+		Favorite:         g.Favorite,
+		Tags:             g.Tags,
+		LastPlayed:       g.LastPlayed,
+	}
+}
+
+// findGame returns game by the ID or by the name
+func (s *Server) findGame(id string) (*manager.Game, error) {
+	games, e := s.Manager.GetSortedGames()
+	if e != nil {
+		return nil, e
+	}
+
+	if g := manager.FindGameById(games, id); g != nil {
+		return s.fillUserData(g)
+	}
+
+	for i := range games {
+		if games[i].Name == id {
+			return s.fillUserData(&games[i])
+		}
+	}
+
+	return nil, ErrGameNotFound
+}
+
+// fillUserData adds favorite flag, tags and last play time from the games database
+func (s *Server) fillUserData(g *manager.Game) (*manager.Game, error) {
+	if s.Manager.DB == nil {
+		return g, nil
+	}
+
+	return g, s.Manager.DB.FillUserData(g)
+}
+
+// GET /api/games?keyword=&repository=&lang=&installed=true&favorite=true&tag=&sort=date|title&offset=&limit=
+func (s *Server) handleGames(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeMethodNotAllowed(w)
+		return
+	}
+
+	query := r.URL.Query()
+	q := manager.GameQuery{
+		Repository: query.Get("repository"),
+		Lang:       query.Get("lang"),
+		Tag:        query.Get("tag"),
+		SortBy:     manager.SortByDateDesc,
+	}
+	q.Installed, _ = strconv.ParseBool(query.Get("installed"))
+	q.Favorite, _ = strconv.ParseBool(query.Get("favorite"))
+	switch sortBy := query.Get("sort"); sortBy {
+	case "":
+	case manager.SortByTitleAsc, manager.SortByDateDesc:
+		q.SortBy = sortBy
+	default:
+		writeError(w, http.StatusBadRequest, errors.New("unknown sort: "+sortBy))
+		return
+	}
+	offset, _ := strconv.Atoi(query.Get("offset"))
+	limit, _ := strconv.Atoi(query.Get("limit"))
+
+	// Search results are sorted by relevance, so they are paginated after the search
+	keyword := query.Get("keyword")
+	if keyword == "" {
+		q.Offset, q.Limit = offset, limit
+	}
+
+	games, e := s.Manager.QueryGames(q)
+	if e == manager.ErrNoGameDB {
+		writeError(w, http.StatusNotImplemented, e)
+		return
+	}
+	if e != nil {
+		writeError(w, http.StatusInternalServerError, e)
+		return
+	}
+
+	if keyword != "" {
+		games = manager.SearchGames(games, keyword)
+		if offset > 0 {
+			if offset > len(games) {
+				offset = len(games)
+			}
+			games = games[offset:]
+		}
+		if limit > 0 && limit < len(games) {
+			games = games[:limit]
+		}
+	}
+
+	response := make([]Game, 0, len(games))
+	for _, g := range games {
+		response = append(response, s.gameResponse(g))
+	}
+
+	writeJSON(w, http.StatusOK, response)
+}
+
+// /api/games/{id}[/install|/update|/cancel|/run|/favorite], ID can contain slashes (the game name can be used instead)
+func (s *Server) handleGame(w http.ResponseWriter, r *http.Request) {
+	id := strings.TrimPrefix(r.URL.Path, "/api/games/")
+	action := ""
+	for _, a := range []string{"install", "update", "cancel", "run", "favorite"} {
+		if strings.HasSuffix(id, "/"+a) {
+			id, action = strings.TrimSuffix(id, "/"+a), a
+			break
+		}
+	}
+
+	game, e := s.findGame(id)
+	if e == ErrGameNotFound {
+		writeError(w, http.StatusNotFound, e)
+		return
+	}
+	if e != nil {
+		writeError(w, http.StatusInternalServerError, e)
+		return
+	}
+
+	switch {
+	case action == "" && r.Method == http.MethodGet:
+		writeJSON(w, http.StatusOK, s.gameResponse(*game))
+	case action == "" && r.Method == http.MethodDelete:
+		s.removeGame(w, game)
+	case (action == "install" || action == "update") && r.Method == http.MethodPost:
+		s.installGame(w, game, action == "update")
+	case action == "cancel" && r.Method == http.MethodPost:
+		s.cancelGame(w, game)
+	case action == "run" && r.Method == http.MethodPost:
+		s.runGame(w, game)
+	case action == "favorite" && (r.Method == http.MethodPost || r.Method == http.MethodDelete):
+		s.setFavorite(w, game, r.Method == http.MethodPost)
+	default:
+		writeMethodNotAllowed(w)
+	}
+}
+
+// setFavorite adds the game to the favorites (or removes it) in the games database
+func (s *Server) setFavorite(w http.ResponseWriter, game *manager.Game, favorite bool) {
+	if s.Manager.DB == nil {
+		writeError(w, http.StatusNotImplemented, manager.ErrNoGameDB)
+		return
+	}
+
+	e := s.Manager.DB.SetFavorite(game.Name, favorite)
+	if e != nil {
+		writeError(w, http.StatusInternalServerError, e)
+		return
+	}
+	game.Favorite = favorite
+
+	writeJSON(w, http.StatusOK, s.gameResponse(*game))
+}
+
+// installGame starts installing (or updating) in the background, progress is sent to the events
+func (s *Server) installGame(w http.ResponseWriter, game *manager.Game, update bool) {
+	_, e := s.startInstalling(*game, update)
+	if e == ErrGameIsInstalling {
+		writeError(w, http.StatusConflict, e)
+		return
+	}
+
+	writeJSON(w, http.StatusAccepted, s.gameResponse(*game))
+}
+
+func (s *Server) startInstalling(g manager.Game, update bool) (*installing, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if _, ok := s.installing[g.Id]; ok {
+		return nil, ErrGameIsInstalling
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	inst := &installing{
+		cancel:   cancel,
+		progress: InstallProgress{Id: g.Id, Name: g.Name, Total: uint64(g.Size), Phase: "download"},
+		done:     make(chan struct{}),
+	}
+	s.installing[g.Id] = inst
+
+	go func() {
+		defer cancel()
+
+		progressF := func(size uint64) {
+			s.publishProgress(inst, func(p *InstallProgress) { p.Downloaded = size })
+		}
+		phaseF := func(phase manager.InstallPhase) {
+			s.publishProgress(inst, func(p *InstallProgress) {
+				if phase == manager.InstallPhaseExtract {
+					p.Phase = "extract"
+				}
+			})
+		}
+
+		var e error
+		if update {
+			e = s.Manager.UpdateGameContext(ctx, &g, progressF, phaseF)
+		} else {
+			e = s.Manager.InstallGameContext(ctx, &g, progressF, phaseF)
+		}
+
+		s.mutex.Lock()
+		delete(s.installing, g.Id)
+		s.mutex.Unlock()
+
+		inst.err = e
+		s.publishProgress(inst, func(p *InstallProgress) {
+			p.Done = true
+			if e != nil {
+				p.Error = e.Error()
+			}
+		})
+		close(inst.done)
+	}()
+
+	return inst, nil
+}
+
+// publishProgress changes progress of the installing game and sends it to the events
+func (s *Server) publishProgress(inst *installing, change func(p *InstallProgress)) {
+	s.mutex.Lock()
+	change(&inst.progress)
+	progress := inst.progress
+	s.mutex.Unlock()
+
+	s.events.publish(EventInstall, progress)
+}
+
+func (s *Server) cancelGame(w http.ResponseWriter, game *manager.Game) {
+	e := s.cancelInstalling(game.Id)
+	if e != nil {
+		writeError(w, http.StatusConflict, e)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) cancelInstalling(id string) error {
+	s.mutex.Lock()
+	inst, ok := s.installing[id]
+	s.mutex.Unlock()
+
+	if !ok {
+		return ErrGameNotInstalling
+	}
+
+	inst.cancel()
+	return nil
+}
+
+func (s *Server) runGame(w http.ResponseWriter, game *manager.Game) {
+	if !game.Installed {
+		writeError(w, http.StatusConflict, ErrGameNotInstalled)
+		return
+	}
+
+	e := s.Manager.RunGame(game)
+	if e != nil {
+		writeError(w, http.StatusInternalServerError, e)
+		return
+	}
+
+	s.events.publish(EventRun, s.gameResponse(*game))
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) removeGame(w http.ResponseWriter, game *manager.Game) {
+	if !game.Installed {
+		writeError(w, http.StatusConflict, ErrGameNotInstalled)
+		return
+	}
+
+	e := s.Manager.RemoveGame(game)
+	if e != nil {
+		writeError(w, http.StatusInternalServerError, e)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GET /api/repositories
+func (s *Server) handleRepositories(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeMethodNotAllowed(w)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, s.Manager.GetRepositories())
+}
+
+// POST /api/repositories/update, response has errors of the repositories which haven't updated
+func (s *Server) handleRepositoriesUpdate(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeMethodNotAllowed(w)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, struct {
+		Errors []string `json:"errors"`
+	}{s.updateRepositories()})
+}
+
+// updateRepositories returns errors of the repositories which haven't updated
+func (s *Server) updateRepositories() []string {
+	return errorStrings(s.Manager.UpdateRepositories())
+}
+
+func errorStrings(errs []error) []string {
+	txts := []string{}
+	for _, e := range errs {
+		txts = append(txts, e.Error())
+	}
+
+	return txts
+}
+
+// GET /api/config
+func (s *Server) handleConfig(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeMethodNotAllowed(w)
+		return
+	}
+
+	values := make(map[string]interface{})
+	for _, key := range configurator.Keys() {
+		value, e := s.Configurator.Get(key)
+		if e != nil {
+			writeError(w, http.StatusInternalServerError, e)
+			return
+		}
+		values[key] = value
+	}
+
+	writeJSON(w, http.StatusOK, values)
+}
+
+// GET|PUT /api/config/{key}, PUT body is a JSON value, config is saved after changing
+func (s *Server) handleConfigKey(w http.ResponseWriter, r *http.Request) {
+	key := strings.TrimPrefix(r.URL.Path, "/api/config/")
+
+	switch r.Method {
+	case http.MethodGet:
+		value, e := s.Configurator.Get(key)
+		if e != nil {
+			writeError(w, http.StatusNotFound, e)
+			return
+		}
+		writeJSON(w, http.StatusOK, value)
+
+	case http.MethodPut:
+		body, e := ioutil.ReadAll(r.Body)
+		if e != nil {
+			writeError(w, http.StatusBadRequest, e)
+			return
+		}
+
+		s.configMutex.Lock()
+		defer s.configMutex.Unlock()
+
+		// Set parses strings to the type of the key (lists and maps are JSON)
+		value := strings.TrimSpace(string(body))
+		var str string
+		if json.Unmarshal(body, &str) == nil {
+			value = str
+		}
+
+		e = s.Configurator.Set(key, value)
+		if e != nil {
+			writeError(w, http.StatusBadRequest, e)
+			return
+		}
+
+		e = s.Configurator.SaveConfig(s.Manager.Config())
+		if e != nil {
+			writeError(w, http.StatusInternalServerError, e)
+			return
+		}
+
+		newValue, _ := s.Configurator.Get(key)
+		writeJSON(w, http.StatusOK, newValue)
+
+	default:
+		writeMethodNotAllowed(w)
+	}
+}
+
+func queryValue(value string) *string {
+	if value == "" {
+		return nil
+	}
+
+	return &value
+}
+
+func writeJSON(w http.ResponseWriter, status int, value interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(value)
+}
+
+func writeError(w http.ResponseWriter, status int, e error) {
+	writeJSON(w, status, struct {
+		Error string `json:"error"`
+	}{e.Error()})
+}
+
+func writeMethodNotAllowed(w http.ResponseWriter) {
+	writeError(w, http.StatusMethodNotAllowed, errors.New("method isn't allowed"))
+}
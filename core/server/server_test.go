@@ -0,0 +1,332 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jhekasoft/insteadman3/core/configurator"
+	"github.com/jhekasoft/insteadman3/core/interpreterfinder"
+	"github.com/jhekasoft/insteadman3/core/manager"
+	"github.com/jhekasoft/insteadman3/core/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestServer(t *testing.T, dir string) (*Server, *httptest.Server) {
+	repoServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/repo.xml" {
+			w.Write([]byte("<game_list><game><name>first</name><title>First game</title><version>0.1</version>" +
+				"<url>http://" + r.Host + "/first.zip</url><size>3</size><lang>en</lang></game></game_list>"))
+			return
+		}
+		w.Write([]byte("zip"))
+	}))
+
+	// Game is "installed" from the archive name
+	interpreterPath := filepath.Join(dir, "instead")
+	script := "#!/bin/sh\n" +
+		"if [ \"$1\" = \"-version\" ]; then echo 3.3.0; exit 0; fi\n" +
+		"name=$(basename \"$4\" .zip)\n" +
+		"mkdir -p \"$2/$name\" && echo '-- $Version: 0.1$' > \"$2/$name/main3.lua\"\n"
+	assert.NoError(t, ioutil.WriteFile(interpreterPath, []byte(script), 0755))
+
+	assert.NoError(t, os.MkdirAll(filepath.Join(dir, "games"), os.ModePerm))
+
+	configPath := filepath.Join(dir, "config.yml")
+	config := "games_path: " + filepath.Join(dir, "games") + "\n" +
+		"insteadman_path: " + dir + "\n" +
+		"cache_path: " + filepath.Join(dir, "cache") + "\n" +
+		"interpreter_command: " + interpreterPath + "\n" +
+		"repositories:\n- name: test\n  url: " + repoServer.URL + "/repo.xml\n"
+	assert.NoError(t, ioutil.WriteFile(configPath, []byte(config), 0644))
+
+	c := &configurator.Configurator{FilePath: configPath}
+	_, e := c.GetConfig()
+	assert.NoError(t, e)
+
+	m := manager.New(c.Holder(), new(interpreterfinder.InterpreterFinder))
+
+	return New(m, c), repoServer
+}
+
+// withLocalAddr sets address of the listener which has accepted the request
+func withLocalAddr(r *http.Request, addr string) *http.Request {
+	tcpAddr, _ := net.ResolveTCPAddr("tcp", addr)
+	return r.WithContext(context.WithValue(r.Context(), http.LocalAddrContextKey, tcpAddr))
+}
+
+func request(t *testing.T, h http.Handler, method, path, body string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(method, path, strings.NewReader(body))
+	r.Host = "127.0.0.1:8778"
+	r = withLocalAddr(r, r.Host)
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+
+	return w
+}
+
+func TestServer(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("shell script interpreter")
+	}
+
+	dir, e := ioutil.TempDir("", "insteadman")
+	assert.NoError(t, e)
+	defer os.RemoveAll(dir)
+
+	s, repoServer := newTestServer(t, dir)
+	defer repoServer.Close()
+	h := s.Handler()
+
+	w := request(t, h, http.MethodPost, "/api/repositories/update", "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "{\"errors\":[]}\n", w.Body.String())
+
+	var games []Game
+	w = request(t, h, http.MethodGet, "/api/games?keyword=first", "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &games))
+	assert.Len(t, games, 1)
+	assert.Equal(t, "First game", games[0].Title)
+	assert.False(t, games[0].Installed)
+
+	w = request(t, h, http.MethodGet, "/api/games/unknown", "")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	// Installing is finished by the event
+	events := s.events.subscribe()
+	defer s.events.unsubscribe(events)
+
+	w = request(t, h, http.MethodPost, "/api/games/"+games[0].Id+"/install", "")
+	assert.Equal(t, http.StatusAccepted, w.Code)
+
+	var progress InstallProgress
+	timeout := time.After(10 * time.Second)
+	for !progress.Done {
+		select {
+		case ev := <-events:
+			assert.Equal(t, EventInstall, ev.Type)
+			assert.NoError(t, json.Unmarshal(ev.Data, &progress))
+		case <-timeout:
+			t.Fatal("game hasn't installed")
+		}
+	}
+	assert.Empty(t, progress.Error)
+	assert.True(t, utils.PathExist(filepath.Join(dir, "games", "first")))
+
+	w = request(t, h, http.MethodGet, "/api/games?installed=true", "")
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &games))
+	assert.Len(t, games, 1)
+	assert.True(t, games[0].Installed)
+
+	w = request(t, h, http.MethodPost, "/api/games/first/cancel", "")
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	// Favorites are kept by the games database
+	w = request(t, h, http.MethodPost, "/api/games/first/favorite", "")
+	assert.Equal(t, http.StatusNotImplemented, w.Code)
+	s.Manager.DB = manager.NewGameDB(s.Manager.GameDBFile())
+
+	w = request(t, h, http.MethodPost, "/api/games/first/favorite", "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	w = request(t, h, http.MethodGet, "/api/games?favorite=true&sort=title&limit=10", "")
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &games))
+	assert.Len(t, games, 1)
+	assert.True(t, games[0].Favorite)
+
+	w = request(t, h, http.MethodDelete, "/api/games/first/favorite", "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	w = request(t, h, http.MethodGet, "/api/games/first", "")
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &games[0]))
+	assert.False(t, games[0].Favorite)
+
+	w = request(t, h, http.MethodGet, "/api/games?sort=unknown", "")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = request(t, h, http.MethodDelete, "/api/games/first", "")
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	assert.False(t, utils.PathExist(filepath.Join(dir, "games", "first")))
+}
+
+func TestServerConfig(t *testing.T) {
+	dir, e := ioutil.TempDir("", "insteadman")
+	assert.NoError(t, e)
+	defer os.RemoveAll(dir)
+
+	s, repoServer := newTestServer(t, dir)
+	defer repoServer.Close()
+	h := s.Handler()
+
+	w := request(t, h, http.MethodPut, "/api/config/lang", `"uk"`)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "uk", s.Manager.Config().Lang)
+
+	w = request(t, h, http.MethodPut, "/api/config/gtk.main_width", `640`)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, 640, s.Manager.Config().Gtk.MainWidth)
+
+	w = request(t, h, http.MethodGet, "/api/config/lang", "")
+	assert.Equal(t, "\"uk\"\n", w.Body.String())
+
+	saved, e := ioutil.ReadFile(s.Configurator.FilePath)
+	assert.NoError(t, e)
+	assert.Contains(t, string(saved), "lang: uk")
+
+	w = request(t, h, http.MethodPut, "/api/config/unknown", `1`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	// Concurrent changes are saved
+	var wg sync.WaitGroup
+	for _, key := range []string{"shortcuts", "telemetry", "notifications", "saves_backup"} {
+		wg.Add(1)
+		go func(key string) {
+			defer wg.Done()
+			request(t, h, http.MethodPut, "/api/config/"+key, `true`)
+		}(key)
+	}
+	wg.Wait()
+
+	saved, e = ioutil.ReadFile(s.Configurator.FilePath)
+	assert.NoError(t, e)
+	for _, key := range []string{"shortcuts", "telemetry", "notifications", "saves_backup"} {
+		assert.Contains(t, string(saved), key+": true")
+	}
+}
+
+func TestServerProtect(t *testing.T) {
+	s := New(nil, nil)
+	h := s.Handler()
+
+	// Other sites and DNS rebinding
+	r := httptest.NewRequest(http.MethodPost, "/api/repositories/update", nil)
+	r.Host = "127.0.0.1:8778"
+	r.Header.Set("Origin", "http://example.com")
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, withLocalAddr(r, "127.0.0.1:8778"))
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	r = httptest.NewRequest(http.MethodGet, "/api/repositories", nil)
+	r.Host = "example.com"
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, withLocalAddr(r, "127.0.0.1:8778"))
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	// LAN client can't pretend to be local by the Host header
+	r = httptest.NewRequest(http.MethodGet, "/api/repositories", nil)
+	r.Host = "localhost:8778"
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, withLocalAddr(r, "192.168.0.2:8778"))
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	s.Token = "secret"
+	r = httptest.NewRequest(http.MethodGet, "/api/repositories", nil)
+	r.Host = "192.168.0.2:8778"
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, withLocalAddr(r, r.Host))
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	// Request with the token is passed to the API (method isn't allowed there)
+	r = httptest.NewRequest(http.MethodPost, "/api/config", nil)
+	r.Host = "192.168.0.2:8778"
+	r.Header.Set("Authorization", "Bearer secret")
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, withLocalAddr(r, r.Host))
+	assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+
+	assert.True(t, isLocalHost("localhost:8778"))
+	assert.True(t, isLocalHost("[::1]:8778"))
+	assert.False(t, isLocalHost("192.168.0.2"))
+}
+
+func TestCheckAddr(t *testing.T) {
+	s := New(nil, nil)
+	assert.NoError(t, s.CheckAddr(DefaultAddr))
+	assert.NoError(t, s.CheckAddr("localhost:8778"))
+	assert.Equal(t, ErrInsecureAddr, s.CheckAddr("0.0.0.0:8778"))
+	assert.Equal(t, ErrInsecureAddr, s.CheckAddr(":8778"))
+	assert.Equal(t, ErrInsecureAddr, s.ListenAndServe("0.0.0.0:8778"))
+
+	s.Token = "secret"
+	assert.NoError(t, s.CheckAddr("0.0.0.0:8778"))
+}
+
+func TestRPCClient(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("shell script interpreter")
+	}
+
+	dir, e := ioutil.TempDir("", "insteadman")
+	assert.NoError(t, e)
+	defer os.RemoveAll(dir)
+
+	s, repoServer := newTestServer(t, dir)
+	defer repoServer.Close()
+
+	listener, e := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, e)
+	defer listener.Close()
+	go s.serveRPC(listener)
+
+	client, e := Dial(listener.Addr().String())
+	assert.NoError(t, e)
+	defer client.Close()
+
+	assert.Empty(t, client.UpdateRepositories())
+
+	games, e := s.Manager.GetSortedGames()
+	assert.NoError(t, e)
+	assert.Len(t, games, 1)
+
+	var phases []manager.InstallPhase
+	e = client.InstallGameContext(context.Background(), &games[0], nil, func(phase manager.InstallPhase) {
+		phases = append(phases, phase)
+	})
+	assert.NoError(t, e)
+	assert.Equal(t, []manager.InstallPhase{manager.InstallPhaseDownload}, phases[:1])
+	assert.True(t, utils.PathExist(filepath.Join(dir, "games", "first")))
+
+	// Manager errors are passed to the client
+	e = client.RunGame(&manager.Game{Id: "unknown"})
+	assert.EqualError(t, e, ErrGameNotFound.Error())
+
+	e = client.RemoveGame(&games[0])
+	assert.NoError(t, e)
+	assert.False(t, utils.PathExist(filepath.Join(dir, "games", "first")))
+}
+
+func TestRPCToken(t *testing.T) {
+	s := New(nil, nil)
+	s.Token = "secret"
+
+	listener, e := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, e)
+	defer listener.Close()
+	go s.serveRPC(listener)
+
+	defer os.Setenv(TokenEnv, os.Getenv(TokenEnv))
+	os.Setenv(TokenEnv, "wrong")
+	client, e := Dial(listener.Addr().String())
+	assert.NoError(t, e)
+	defer client.Close()
+
+	e = client.RemoveGame(&manager.Game{Id: "first"})
+	assert.EqualError(t, e, ErrForbidden.Error())
+	assert.Equal(t, []error{ErrForbidden}, client.UpdateRepositories())
+
+	// Not localhost address requires the token
+	assert.NoError(t, s.CheckRPCAddr("0.0.0.0:8779"))
+	s.Token = ""
+	assert.Equal(t, ErrInsecureRPCAddr, s.CheckRPCAddr("0.0.0.0:8779"))
+	assert.Equal(t, ErrInsecureRPCAddr, s.CheckRPCAddr(":8779"))
+	assert.NoError(t, s.CheckRPCAddr("localhost:8779"))
+}
@@ -0,0 +1,150 @@
+package shortcuts
+
+import (
+	"errors"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+
+	"github.com/jhekasoft/insteadman3/core/utils"
+)
+
+// cliName is a name of the CLI executable which runs games of the shortcuts
+const cliName = "insteadman"
+
+// ErrNoExecutable is returned when there isn't CLI executable for the shortcuts
+var ErrNoExecutable = errors.New("insteadman executable hasn't found")
+
+// gameIconPaths are relative paths of the icon in the game directory
+var gameIconPaths = []string{"icon.png", "icon.ico", "icon.jpg", "gfx/icon.png", "img/icon.png", "images/icon.png"}
+
+// Shortcut is a launcher of the installed game in the menu
+type Shortcut struct {
+	Name  string // game name, file name of the shortcut
+	Title string
+	Icon  string // path of the image (it's copied to the IconsDir), it can be empty
+}
+
+// Creator creates shortcuts which run "Executable run <game name>": .desktop files on Linux and BSD,
+// Start Menu shortcuts on Windows and launchers in ~/Applications on macOS
+type Creator struct {
+	Executable string // CLI executable
+	IconsDir   string // directory of the copied icons (game icons are removed with the game)
+	MenuDir    string // platform menu directory by default
+}
+
+// Create creates (or replaces) shortcut of the game and returns its path
+func (c *Creator) Create(s Shortcut) (string, error) {
+	if c.Executable == "" {
+		return "", ErrNoExecutable
+	}
+
+	e := os.MkdirAll(c.menuDir(), os.ModePerm)
+	if e != nil {
+		return "", e
+	}
+
+	icon := ""
+	if s.Icon != "" {
+		icon, e = c.copyIcon(s.Name, s.Icon)
+		if e != nil {
+			return "", e
+		}
+	}
+
+	path := c.Path(s.Name)
+	return path, createShortcut(path, c.Executable, s, icon)
+}
+
+// Remove removes shortcut of the game and its icon, missing shortcut isn't an error
+func (c *Creator) Remove(name string) error {
+	e := os.Remove(c.Path(name))
+	if e != nil && !os.IsNotExist(e) {
+		return e
+	}
+
+	icons, _ := filepath.Glob(filepath.Join(c.IconsDir, name+".*"))
+	for _, icon := range icons {
+		os.Remove(icon)
+	}
+
+	return nil
+}
+
+// Path returns path of the game shortcut
+func (c *Creator) Path(name string) string {
+	return filepath.Join(c.menuDir(), shortcutFileName(name))
+}
+
+func (c *Creator) menuDir() string {
+	if c.MenuDir != "" {
+		return c.MenuDir
+	}
+	return defaultMenuDir()
+}
+
+// copyIcon keeps the icon in the IconsDir, so it isn't removed with the cache
+func (c *Creator) copyIcon(name, iconPath string) (string, error) {
+	e := os.MkdirAll(c.IconsDir, os.ModePerm)
+	if e != nil {
+		return "", e
+	}
+
+	src, e := os.Open(iconPath)
+	if e != nil {
+		return "", e
+	}
+	defer src.Close()
+
+	path := filepath.Join(c.IconsDir, name+filepath.Ext(iconPath))
+	dst, e := os.Create(path)
+	if e != nil {
+		return "", e
+	}
+
+	_, e = io.Copy(dst, src)
+	if closeErr := dst.Close(); e == nil {
+		e = closeErr
+	}
+
+	return path, e
+}
+
+// FindGameIcon returns icon of the game directory, it's empty if the game hasn't icon
+func FindGameIcon(gameDir string) string {
+	for _, relPath := range gameIconPaths {
+		path := filepath.Join(gameDir, filepath.FromSlash(relPath))
+		if utils.PathExist(path) {
+			return path
+		}
+	}
+
+	return ""
+}
+
+// FindExecutable returns CLI executable near the front end (in the dir) or in the PATH
+func FindExecutable(dir string) string {
+	name := cliName
+	if runtime.GOOS == "windows" {
+		name += ".exe"
+	}
+
+	path := filepath.Join(dir, name)
+	if utils.PathExist(path) {
+		return path
+	}
+
+	path, e := exec.LookPath(name)
+	if e != nil {
+		return ""
+	}
+
+	absPath, e := filepath.Abs(path)
+	if e != nil {
+		return path
+	}
+
+	return absPath
+}
@@ -0,0 +1,33 @@
+// +build darwin
+
+package shortcuts
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Games are launched by the executable .command scripts in ~/Applications/InsteadMan Games,
+// Finder aliases can't pass arguments to the executable
+
+func defaultMenuDir() string {
+	return filepath.Join(os.Getenv("HOME"), "Applications", "InsteadMan Games")
+}
+
+func shortcutFileName(name string) string {
+	return name + ".command"
+}
+
+func createShortcut(path, executable string, s Shortcut, icon string) error {
+	script := "#!/bin/sh\n" +
+		"# " + strings.Replace(s.Title, "\n", " ", -1) + "\n" +
+		"exec " + shellQuote(executable) + " run " + shellQuote(s.Name) + "\n"
+
+	return ioutil.WriteFile(path, []byte(script), 0755)
+}
+
+func shellQuote(arg string) string {
+	return "'" + strings.Replace(arg, "'", `'\''`, -1) + "'"
+}
@@ -0,0 +1,39 @@
+// +build !windows,!darwin
+
+package shortcuts
+
+import (
+	"io/ioutil"
+	"path/filepath"
+
+	"github.com/jhekasoft/insteadman3/core/desktopentry"
+)
+
+// Desktop entries of the games are kept in the XDG applications directory
+
+func defaultMenuDir() string {
+	return filepath.Join(desktopentry.DataDir(), "applications")
+}
+
+func shortcutFileName(name string) string {
+	return "insteadman-game-" + name + ".desktop"
+}
+
+func createShortcut(path, executable string, s Shortcut, icon string) error {
+	if icon == "" {
+		icon = "insteadman"
+	}
+
+	entry := "[Desktop Entry]\n" +
+		"Version=1.0\n" +
+		"Type=Application\n" +
+		"Name=" + desktopentry.Value(s.Title) + "\n" +
+		"Comment=INSTEAD game\n" +
+		"Exec=" + desktopentry.ExecArg(executable) + " run " + desktopentry.ExecArg(s.Name) + "\n" +
+		"Icon=" + desktopentry.Value(icon) + "\n" +
+		"Terminal=false\n" +
+		"Categories=Game;AdventureGame;\n"
+
+	// Some desktops launch only executable entries of the user
+	return ioutil.WriteFile(path, []byte(entry), 0755)
+}
@@ -0,0 +1,61 @@
+package shortcuts
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/jhekasoft/insteadman3/core/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCreator(t *testing.T) {
+	dir, e := ioutil.TempDir("", "insteadman")
+	assert.NoError(t, e)
+	defer os.RemoveAll(dir)
+
+	gameDir := filepath.Join(dir, "games", "testgame")
+	assert.NoError(t, os.MkdirAll(filepath.Join(gameDir, "gfx"), os.ModePerm))
+	assert.Equal(t, "", FindGameIcon(gameDir))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(gameDir, "gfx", "icon.png"), []byte("png"), 0644))
+	icon := FindGameIcon(gameDir)
+	assert.Equal(t, filepath.Join(gameDir, "gfx", "icon.png"), icon)
+
+	if runtime.GOOS == "windows" {
+		t.Skip("Start Menu shortcuts are created by PowerShell")
+	}
+
+	creator := Creator{
+		Executable: "/usr/bin/insteadman",
+		IconsDir:   filepath.Join(dir, "icons"),
+		MenuDir:    filepath.Join(dir, "menu"),
+	}
+
+	path, e := creator.Create(Shortcut{Name: "testgame", Title: "Test game", Icon: icon})
+	assert.NoError(t, e)
+	assert.Equal(t, creator.Path("testgame"), path)
+	assert.FileExists(t, filepath.Join(dir, "icons", "testgame.png"))
+
+	data, e := ioutil.ReadFile(path)
+	assert.NoError(t, e)
+	if runtime.GOOS == "darwin" {
+		assert.Contains(t, string(data), "exec '/usr/bin/insteadman' run 'testgame'")
+	} else {
+		assert.Contains(t, string(data), "Name=Test game\n")
+		assert.Contains(t, string(data), "Exec=\"/usr/bin/insteadman\" run \"testgame\"\n")
+		assert.Contains(t, string(data), "Icon="+filepath.Join(dir, "icons", "testgame.png")+"\n")
+	}
+
+	assert.NoError(t, creator.Remove("testgame"))
+	assert.False(t, utils.PathExist(path))
+	assert.False(t, utils.PathExist(filepath.Join(dir, "icons", "testgame.png")))
+
+	// Removed shortcut isn't an error
+	assert.NoError(t, creator.Remove("testgame"))
+
+	creator.Executable = ""
+	_, e = creator.Create(Shortcut{Name: "testgame"})
+	assert.Equal(t, ErrNoExecutable, e)
+}
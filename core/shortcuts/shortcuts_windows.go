@@ -0,0 +1,49 @@
+// +build windows
+
+package shortcuts
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// Start Menu shortcuts are created by the WScript.Shell of the PowerShell
+
+func defaultMenuDir() string {
+	return filepath.Join(os.Getenv("APPDATA"), "Microsoft", "Windows", "Start Menu", "Programs", "InsteadMan Games")
+}
+
+func shortcutFileName(name string) string {
+	return name + ".lnk"
+}
+
+func createShortcut(path, executable string, s Shortcut, icon string) error {
+	// Shortcut icon can be only .ico (or executable)
+	if !strings.EqualFold(filepath.Ext(icon), ".ico") {
+		icon = executable
+	}
+
+	script := "$s = (New-Object -ComObject WScript.Shell).CreateShortcut(" + psQuote(path) + "); " +
+		"$s.TargetPath = " + psQuote(executable) + "; " +
+		"$s.Arguments = " + psQuote(`run "`+s.Name+`"`) + "; " +
+		"$s.Description = " + psQuote(s.Title) + "; " +
+		"$s.IconLocation = " + psQuote(icon) + "; " +
+		"$s.WindowStyle = 7; " + // console of the CLI is minimized
+		"$s.Save()"
+
+	cmd := exec.Command("powershell", "-NoProfile", "-NonInteractive", "-Command", script)
+	out, e := cmd.CombinedOutput()
+	if e != nil {
+		return errors.New(e.Error() + "; " + strings.TrimSpace(string(out)))
+	}
+
+	return nil
+}
+
+// psQuote quotes PowerShell string
+func psQuote(value string) string {
+	return "'" + strings.Replace(value, "'", "''", -1) + "'"
+}
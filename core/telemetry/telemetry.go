@@ -0,0 +1,215 @@
+// Package telemetry counts installs of the games locally and sends them to the repositories which have
+// the statistics URL. It's used only if user has enabled it ("telemetry" config value).
+package telemetry
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/jhekasoft/insteadman3/core/configurator"
+)
+
+const (
+	statsFileName = "telemetry.json"
+
+	// SendInterval is a minimal interval between the reports, so sending doesn't show when games are installed
+	SendInterval = 7 * 24 * time.Hour
+
+	requestTimeout = 10 * time.Second
+)
+
+// Report is all the data which is sent to the repository: counts of its games installs since the last
+// sending, InsteadMan version and OS. There aren't user IDs, paths or games of other repositories.
+type Report struct {
+	AppVersion string         `json:"app_version"`
+	OS         string         `json:"os"`
+	Installs   map[string]int `json:"installs"` // game name is a key
+}
+
+// Submission is a report which is sent to the statistics URL of the repository
+type Submission struct {
+	Repository string `json:"repository"`
+	URL        string `json:"url"`
+	Report     Report `json:"report"`
+}
+
+// Stats keeps counts of the installs in the Dir until they are sent
+type Stats struct {
+	Dir        string
+	AppVersion string
+	// Interval is SendInterval by default
+	Interval time.Duration
+
+	mutex sync.Mutex
+}
+
+type statsFile struct {
+	SentAt   time.Time                 `json:"sent_at"`
+	Installs map[string]map[string]int `json:"installs"` // repository name and game name are keys
+}
+
+// CountInstall counts install (or update) of the game
+func (s *Stats) CountInstall(repository, game string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	stats := s.read()
+	if stats.Installs[repository] == nil {
+		stats.Installs[repository] = make(map[string]int)
+	}
+	stats.Installs[repository][game]++
+
+	return s.write(stats)
+}
+
+// Submissions returns reports which would be sent now, they are shown to the user before enabling
+func (s *Stats) Submissions(repositories []configurator.Repository) []Submission {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return s.submissions(s.read(), repositories)
+}
+
+// Preview returns Submissions as indented JSON, exactly as they are sent
+func (s *Stats) Preview(repositories []configurator.Repository) string {
+	submissions := s.Submissions(repositories)
+	if submissions == nil {
+		submissions = []Submission{}
+	}
+
+	data, _ := json.MarshalIndent(submissions, "", "  ")
+	return string(data)
+}
+
+// Send sends reports if Interval has passed since the last sending (force ignores it).
+// Sent counts are removed, counts of the failed repositories are kept for the next sending.
+func (s *Stats) Send(repositories []configurator.Repository, force bool) []error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	stats := s.read()
+	if !force && time.Since(stats.SentAt) < s.interval() {
+		return nil
+	}
+
+	var errs []error
+	for _, submission := range s.submissions(stats, repositories) {
+		e := post(submission)
+		if e != nil {
+			errs = append(errs, e)
+			continue
+		}
+
+		delete(stats.Installs, submission.Repository)
+	}
+
+	stats.SentAt = time.Now()
+	e := s.write(stats)
+	if e != nil {
+		errs = append(errs, e)
+	}
+
+	return errs
+}
+
+// Clear removes all the counts (when telemetry is disabled)
+func (s *Stats) Clear() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	e := os.Remove(s.path())
+	if os.IsNotExist(e) {
+		return nil
+	}
+
+	return e
+}
+
+func (s *Stats) submissions(stats statsFile, repositories []configurator.Repository) (submissions []Submission) {
+	for _, repo := range repositories {
+		installs := stats.Installs[repo.Name]
+		if repo.StatsUrl == "" || repo.Disabled || len(installs) == 0 {
+			continue
+		}
+
+		submissions = append(submissions, Submission{
+			Repository: repo.Name,
+			URL:        repo.StatsUrl,
+			Report: Report{
+				AppVersion: s.AppVersion,
+				OS:         runtime.GOOS,
+				Installs:   installs,
+			},
+		})
+	}
+
+	return
+}
+
+func post(submission Submission) error {
+	data, e := json.Marshal(submission.Report)
+	if e != nil {
+		return e
+	}
+
+	client := http.Client{Timeout: requestTimeout}
+	resp, e := client.Post(submission.URL, "application/json", bytes.NewReader(data))
+	if e != nil {
+		return e
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return errors.New(submission.Repository + " statistics haven't sent, status " + strconv.Itoa(resp.StatusCode))
+	}
+
+	return nil
+}
+
+func (s *Stats) interval() time.Duration {
+	if s.Interval > 0 {
+		return s.Interval
+	}
+	return SendInterval
+}
+
+func (s *Stats) path() string {
+	return filepath.Join(s.Dir, statsFileName)
+}
+
+func (s *Stats) read() statsFile {
+	var stats statsFile
+
+	data, e := ioutil.ReadFile(s.path())
+	if e == nil {
+		json.Unmarshal(data, &stats)
+	}
+	if stats.Installs == nil {
+		stats.Installs = make(map[string]map[string]int)
+	}
+
+	return stats
+}
+
+func (s *Stats) write(stats statsFile) error {
+	e := os.MkdirAll(s.Dir, os.ModePerm)
+	if e != nil {
+		return e
+	}
+
+	data, e := json.Marshal(stats)
+	if e != nil {
+		return e
+	}
+
+	return ioutil.WriteFile(s.path(), data, 0644)
+}
@@ -0,0 +1,73 @@
+package telemetry
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/jhekasoft/insteadman3/core/configurator"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStats(t *testing.T) {
+	dir, e := ioutil.TempDir("", "insteadman")
+	assert.NoError(t, e)
+	defer os.RemoveAll(dir)
+
+	var received []Report
+	status := http.StatusOK
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var report Report
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&report))
+		received = append(received, report)
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	repositories := []configurator.Repository{
+		{Name: "official", Url: "http://example.com/official.xml", StatsUrl: server.URL},
+		{Name: "other", Url: "http://example.com/other.xml"},
+	}
+
+	stats := Stats{Dir: dir, AppVersion: "3.1.0"}
+	assert.Empty(t, stats.Submissions(repositories))
+	assert.Equal(t, "[]", stats.Preview(repositories))
+
+	assert.NoError(t, stats.CountInstall("official", "cat-lady"))
+	assert.NoError(t, stats.CountInstall("official", "cat-lady"))
+	assert.NoError(t, stats.CountInstall("other", "lifter"))
+
+	// Repository without the statistics URL doesn't get the report
+	expected := Report{AppVersion: "3.1.0", OS: runtime.GOOS, Installs: map[string]int{"cat-lady": 2}}
+	submissions := stats.Submissions(repositories)
+	assert.Equal(t, []Submission{{Repository: "official", URL: server.URL, Report: expected}}, submissions)
+	assert.True(t, strings.Contains(stats.Preview(repositories), "\"cat-lady\": 2"))
+	assert.False(t, strings.Contains(stats.Preview(repositories), "lifter"))
+
+	// Failed report is kept
+	status = http.StatusInternalServerError
+	assert.Len(t, stats.Send(repositories, false), 1)
+	assert.Len(t, stats.Submissions(repositories), 1)
+
+	// Reports aren't sent until the interval passes
+	status = http.StatusOK
+	assert.Empty(t, stats.Send(repositories, false))
+	assert.Len(t, received, 1)
+
+	assert.Empty(t, stats.Send(repositories, true))
+	assert.Equal(t, []Report{expected, expected}, received)
+	assert.Empty(t, stats.Submissions(repositories))
+
+	// Counts of the other repository are kept
+	repositories[1].StatsUrl = server.URL
+	assert.Len(t, stats.Submissions(repositories), 1)
+
+	assert.NoError(t, stats.Clear())
+	assert.Empty(t, stats.Submissions(repositories))
+	assert.NoError(t, stats.Clear())
+}
@@ -3,7 +3,9 @@ package utils
 import (
 	"fmt"
 	"os"
+	"os/exec"
 	"path/filepath"
+	"runtime"
 )
 
 func BinAbsDir(executablePath string) (path string, e error) {
@@ -41,3 +43,18 @@ func Percents(value, total uint64) string {
 	percents := int(float64(value) / float64(total) * float64(100))
 	return fmt.Sprintf("%d", percents) + "%"
 }
+
+// OpenBrowser opens URL in the default browser
+func OpenBrowser(url string) error {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "windows":
+		cmd = exec.Command("rundll32", "url.dll,FileProtocolHandler", url)
+	case "darwin":
+		cmd = exec.Command("open", url)
+	default:
+		cmd = exec.Command("xdg-open", url)
+	}
+
+	return cmd.Start()
+}
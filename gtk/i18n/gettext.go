@@ -2,9 +2,11 @@ package i18n
 
 import (
 	"github.com/gosexy/gettext"
+	corei18n "github.com/jhekasoft/insteadman3/core/i18n"
 )
 
-// Init initializes gettext with domain and language
+// Init initializes translates with domain and language. Code messages are translated by the core catalog,
+// gettext translates GtkBuilder files.
 func Init(localeDir, domain, language string) {
 	if language != "" {
 		SetGettextLanguage(language)
@@ -14,9 +16,24 @@ func Init(localeDir, domain, language string) {
 	gettext.BindTextdomain(domain, localeDir)
 	gettext.BindTextdomainCodeset(domain, "UTF-8")
 	gettext.Textdomain(domain)
+
+	corei18n.Init(localeDir, domain, language)
+}
+
+// SetLanguage changes language of the translates after Init
+func SetLanguage(language string) {
+	SetGettextLanguage(language)
+	gettext.SetLocale(gettext.LcAll, "")
+
+	corei18n.SetLanguage(language)
 }
 
 // T translates message and returns translated string
 func T(message string) string {
-	return gettext.Gettext(message)
+	return corei18n.T(message)
+}
+
+// N translates message with plural forms by the count
+func N(singular, plural string, n int) string {
+	return corei18n.N(singular, plural, n)
 }
@@ -10,10 +10,12 @@ func TestT(t *testing.T) {
 		"uk": {
 			"About":            "Про програму",
 			"%s Installing...": "%s Встановлення...",
+			"Found games: %d":  "Знайдено ігор: %d",
 		},
 		"ru": {
 			"About":            "О программе",
 			"%s Installing...": "%s Установка...",
+			"Found games: %d":  "Найдено игр: %d",
 		},
 	}
 
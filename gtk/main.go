@@ -1,14 +1,30 @@
 package main
 
 import (
+	"context"
+	"io"
 	"log"
+	"net/url"
 	"os"
+	"path/filepath"
 	"runtime"
+	"strings"
+	"time"
 
+	"github.com/gotk3/gotk3/glib"
 	"github.com/gotk3/gotk3/gtk"
 	"github.com/jhekasoft/insteadman3/core/configurator"
+	"github.com/jhekasoft/insteadman3/core/crashreport"
+	"github.com/jhekasoft/insteadman3/core/fileassoc"
+	"github.com/jhekasoft/insteadman3/core/instance"
 	"github.com/jhekasoft/insteadman3/core/interpreterfinder"
+	"github.com/jhekasoft/insteadman3/core/interpreterinstaller"
 	"github.com/jhekasoft/insteadman3/core/manager"
+	"github.com/jhekasoft/insteadman3/core/migration"
+	"github.com/jhekasoft/insteadman3/core/notify"
+	"github.com/jhekasoft/insteadman3/core/server"
+	"github.com/jhekasoft/insteadman3/core/shortcuts"
+	"github.com/jhekasoft/insteadman3/core/telemetry"
 	"github.com/jhekasoft/insteadman3/core/utils"
 	"github.com/jhekasoft/insteadman3/gtk/i18n"
 	"github.com/jhekasoft/insteadman3/gtk/osintegration"
@@ -21,7 +37,16 @@ const (
 	envDataPath   = "DATA_PATH"
 	envLocalePath = "LOCALE_PATH"
 
+	argPortable = "--portable"
+
 	i18nDomain = "insteadman"
+
+	configWatchInterval = 2 * time.Second
+
+	logFileName = "insteadman-gtk.log"
+
+	// instanceName is a name of the lock of the running GTK instance in the InsteadMan dir
+	instanceName = "insteadman-gtk"
 )
 
 var (
@@ -49,44 +74,224 @@ func main() {
 	dataPath := os.Getenv(envDataPath)
 	localePath := os.Getenv(envLocalePath)
 
+	portable := utils.ExistsString(os.Args[1:], argPortable)
+
 	cf := &configurator.Configurator{FilePath: "", CurrentDir: currentDir, DataPath: dataPath,
-		LocalePath: localePath, Version: version}
+		LocalePath: localePath, Version: version, Portable: portable}
 
 	config, e := cf.GetConfig()
 	if e != nil {
 		ui.ShowErrorDlgFatal(e.Error(), nil)
 	}
 
-	finder := &interpreterfinder.InterpreterFinder{CurrentDir: currentDir}
+	// Second launch activates the running instance, so they don't change the same config and games.
+	// It's locked before opening the log file which is rewritten by the new instance.
+	args := absArgs(os.Args[1:])
+	inst, e := lockInstance(config.CalculatedInsteadManPath, args)
+	if e == instance.ErrRunning {
+		log.Print("InsteadMan is running, it has been activated")
+		return
+	}
+	if e != nil {
+		log.Printf("Instance lock error: %v", e)
+	} else {
+		defer inst.Close()
+	}
+
+	logFile, e := openLogFile(config.CalculatedInsteadManPath)
+	if e != nil {
+		log.Printf("Log file error: %v", e)
+	} else {
+		defer logFile.Close()
+	}
+
+	finder := &interpreterfinder.InterpreterFinder{CurrentDir: currentDir, DataDir: config.CalculatedInsteadManPath}
 
-	mn := &manager.Manager{Config: config, InterpreterFinder: finder}
+	mn := manager.New(cf.Holder(), finder)
+
+	mn.Telemetry = &telemetry.Stats{Dir: config.CalculatedInsteadManPath, AppVersion: version}
+	// Play history is kept by the games database
+	mn.DB = manager.NewGameDB(mn.GameDBFile())
+	mn.Notifier = &notify.System{}
+
+	// Shortcuts run games by the CLI which is placed near InsteadMan or in the PATH
+	if cliPath := shortcuts.FindExecutable(currentDir); cliPath != "" {
+		mn.Shortcuts = &shortcuts.Creator{
+			Executable: cliPath,
+			IconsDir:   filepath.Join(config.CalculatedInsteadManPath, "shortcuts"),
+		}
+	}
+
+	reporter := crashreport.New("insteadman-gtk", version, mn)
+	reporter.CaptureLog()
+	defer reporter.Handle()
 
 	// I18n init
 	i18n.Init(cf.DataLocalePath(), i18nDomain, config.Lang)
 
+	offerCrashReports(reporter)
+
+	if cf.FirstRun {
+		importInsteadMan2Config(cf)
+
+		// Main window is shown after the assistant (it can change language)
+		ui.ShowFirstRunAssistant(mn, cf, title, func(repositoriesUpdated bool) {
+			showMainWindow(mn, cf, !repositoriesUpdated, args)
+		})
+	} else {
+		showMainWindow(mn, cf, true, args)
+	}
+
+	gtk.Main()
+}
+
+func showMainWindow(mn *manager.Manager, cf *configurator.Configurator, updateRepositories bool, args []string) {
 	mainWindow := ui.GetMain(mn, cf, title, version)
 
-	if mn.InterpreterCommand() == "" {
+	// Games are changed by the running daemon, so front ends don't change the same files
+	client, e := server.Dial("")
+	daemon := e == nil
+	if daemon {
+		log.Print("Connected to the InsteadMan daemon")
+		mainWindow.UseBackend(client)
+	}
+
+	if mn.InterpreterCommand() == "" && !cf.FirstRun {
 		findInterpreter(mn, cf, mainWindow.Window)
 	}
 
-	ui.ShowExistingMainWindow(true)
+	// Apply config changes which are made by CLI or by editing config file
+	watcher := cf.Watch(configWatchInterval)
+	watcher.Subscribe(func(*configurator.InsteadmanConfig) {
+		glib.IdleAdd(func() {
+			// Reloaded config has replaced config of the configurator and of the manager
+			log.Print("Config has been changed, reloading...")
+			ui.ConfigReloaded()
+		})
+	})
 
-	gtk.Main()
+	if !updateRepositories {
+		mainWindow.Refresh()
+	}
+	ui.ShowExistingMainWindow(updateRepositories)
+	ui.UpdateStatusIcon()
+	ui.CheckForAppUpdateOnStart(mn, version, mainWindow.Window)
+
+	// Periodic jobs are run by the daemon if it's running
+	if !daemon {
+		ui.StartScheduler(mn)
+	}
+
+	mainWindow.OpenArgs(args)
+}
+
+func lockInstance(dir string, args []string) (*instance.Instance, error) {
+	e := os.MkdirAll(dir, os.ModePerm)
+	if e != nil {
+		return nil, e
+	}
+
+	return instance.Lock(dir, instanceName, args, func(args []string) {
+		glib.IdleAdd(func() {
+			log.Print("InsteadMan has launched again, activating...")
+			ui.ActivateMainWindow(args)
+		})
+	})
+}
+
+// absArgs makes archive paths absolute, the running instance can have other working dir.
+// File manager can pass file:// URL of the archive.
+func absArgs(args []string) []string {
+	result := make([]string, 0, len(args))
+	for _, arg := range args {
+		if fileURL, e := url.Parse(arg); e == nil && fileURL.Scheme == "file" {
+			arg = filepath.FromSlash(fileURL.Path)
+		}
+
+		if !strings.HasPrefix(arg, "-") && !strings.Contains(arg, "://") && !fileassoc.IsLink(arg) {
+			if absArg, e := filepath.Abs(arg); e == nil {
+				arg = absArg
+			}
+		}
+		result = append(result, arg)
+	}
+
+	return result
+}
+
+// openLogFile writes log to the file (besides stderr), the file is offered in the error dialog for the bug reports
+func openLogFile(dir string) (*os.File, error) {
+	e := os.MkdirAll(dir, os.ModePerm)
+	if e != nil {
+		return nil, e
+	}
+
+	path := filepath.Join(dir, logFileName)
+	f, e := os.Create(path)
+	if e != nil {
+		return nil, e
+	}
+
+	log.SetOutput(io.MultiWriter(os.Stderr, f))
+	ui.LogFilePath = path
+
+	return f, nil
 }
 
+// findInterpreter looks for INSTEAD in the background (finder and package managers run processes),
+// dialogs are shown by the main loop
 func findInterpreter(m *manager.Manager, c *configurator.Configurator, wnd *gtk.Window) {
-	path := m.InterpreterFinder.Find()
+	go func() {
+		path := m.InterpreterFinder.Find()
+
+		var validateErr error
+		if path != nil {
+			_, validateErr = m.InterpreterFinder.Validate(*path)
+		}
+
+		glib.IdleAdd(func() {
+			interpreterFound(m, c, wnd, path, validateErr)
+		})
+	}()
+}
 
+func interpreterFound(m *manager.Manager, c *configurator.Configurator, wnd *gtk.Window, path *string,
+	validateErr error) {
 	if path == nil {
-		ui.ShowErrorDlg(i18n.T("INSTEAD has not found. Please add INSTEAD in the Settings."), wnd)
+		if ui.ShowQuestionDlg(i18n.T("INSTEAD has not found. Download and install INSTEAD?"), wnd) {
+			installInterpreter(m, c, wnd)
+			return
+		}
+
+		go func() {
+			suggestions := m.InterpreterFinder.SuggestInstall(context.Background())
+
+			glib.IdleAdd(func() {
+				txt := i18n.T("INSTEAD has not found. Please add INSTEAD in the Settings.")
+				if len(suggestions) > 0 {
+					txt += "\n\n" + i18n.T("INSTEAD can be installed by the package manager:")
+					for _, suggestion := range suggestions {
+						txt += "\n" + suggestion.Command()
+					}
+				}
+
+				ui.ShowErrorDlg(txt, wnd)
+			})
+		}()
 		return
 	}
 
 	log.Printf("INSTEAD has found: %s", *path)
 
-	m.Config.InterpreterCommand = *path
-	e := c.SaveConfig(m.Config)
+	if validateErr != nil {
+		ui.ShowErrorDlg(ui.InterpreterErrorText(validateErr), wnd)
+		return
+	}
+
+	e := c.Set("interpreter_command", *path)
+	if e == nil {
+		e = c.SaveConfig(m.Config())
+	}
 	if e != nil {
 		ui.ShowErrorDlgFatal(e.Error(), wnd)
 		return
@@ -94,3 +299,72 @@ func findInterpreter(m *manager.Manager, c *configurator.Configurator, wnd *gtk.
 
 	log.Print("Path has saved")
 }
+
+func installInterpreter(m *manager.Manager, c *configurator.Configurator, wnd *gtk.Window) {
+	installer := interpreterinstaller.Installer{DataDir: m.Config().CalculatedInsteadManPath}
+
+	log.Print("Downloading and installing INSTEAD...")
+	path, e := installer.Install(nil)
+	if e != nil {
+		ui.ShowErrorDlg(e.Error(), wnd)
+		return
+	}
+
+	log.Printf("INSTEAD has installed: %s", path)
+
+	c.Set("use_builtin_interpreter", true)
+	e = c.SaveConfig(m.Config())
+	if e != nil {
+		ui.ShowErrorDlgFatal(e.Error(), wnd)
+	}
+}
+
+// offerCrashReports offers to open pre-filled GitHub issue if the last run has crashed
+func offerCrashReports(r *crashreport.Reporter) {
+	if !r.Enabled() {
+		return
+	}
+
+	paths, e := r.Pending()
+	if e != nil || len(paths) == 0 {
+		return
+	}
+
+	// All reports are offered once
+	defer func() {
+		for _, path := range paths {
+			crashreport.Dismiss(path)
+		}
+	}()
+
+	if !ui.ShowQuestionDlg(i18n.T("InsteadMan has crashed last time. Open GitHub issue with the crash report?"), nil) {
+		return
+	}
+
+	issue, e := crashreport.IssueURL(paths[0])
+	if e == nil {
+		e = utils.OpenBrowser(issue)
+	}
+	if e != nil {
+		ui.ShowErrorDlg(e.Error(), nil)
+	}
+}
+
+func importInsteadMan2Config(c *configurator.Configurator) {
+	path := migration.FindInsteadMan2Config(c.LegacyInsteadManDir())
+	if path == "" {
+		return
+	}
+
+	if !ui.ShowQuestionDlg(i18n.T("InsteadMan 2 configuration has found. Import repositories, INSTEAD path and games path?"), nil) {
+		return
+	}
+
+	e := migration.ImportFile(path, c)
+	if e != nil {
+		ui.ShowErrorDlg(e.Error(), nil)
+		return
+	}
+
+	log.Printf("InsteadMan 2 configuration has imported: %s", path)
+}
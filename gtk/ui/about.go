@@ -0,0 +1,148 @@
+package ui
+
+import (
+	"fmt"
+	"log"
+	"runtime"
+	"strings"
+
+	"github.com/gotk3/gotk3/gdk"
+	"github.com/gotk3/gotk3/glib"
+	"github.com/gotk3/gotk3/gtk"
+	"github.com/jhekasoft/insteadman3/core/configurator"
+	"github.com/jhekasoft/insteadman3/core/manager"
+	"github.com/jhekasoft/insteadman3/core/selfupdate"
+	"github.com/jhekasoft/insteadman3/gtk/i18n"
+	"github.com/jhekasoft/insteadman3/gtk/osintegration"
+)
+
+const (
+	websiteUrl      = "http://jhekasoft.github.io/insteadman/"
+	contributorsUrl = "https://github.com/jhekasoft/insteadman3/graphs/contributors"
+
+	responseCopySystemInfo gtk.ResponseType = 1
+	responseCheckUpdate    gtk.ResponseType = 2
+)
+
+func ShowAboutWin(manager *manager.Manager, configurator *configurator.Configurator, version string,
+	parent *gtk.Window) {
+
+	dlg, e := gtk.AboutDialogNew()
+	if e != nil {
+		ShowErrorDlg(e.Error(), parent)
+		return
+	}
+
+	dlg.SetProgramName("InsteadMan")
+	dlg.SetVersion(version)
+	dlg.SetLogoIconName("insteadman")
+	dlg.SetComments(i18n.T("Manager of the INSTEAD games"))
+	dlg.SetWebsite(websiteUrl)
+	dlg.SetCopyright("© 2015-2018 InsteadMan")
+	dlg.SetLicenseType(gtk.LICENSE_MIT_X11)
+	dlg.SetAuthors([]string{"Evgeniy (jhekasoft) https://github.com/jhekasoft"})
+	dlg.AddCreditSection(i18n.T("Contributors"), []string{"GitHub " + contributorsUrl})
+	// "translator-credits" is translated to the list of translators of the current language
+	if translators := i18n.T("translator-credits"); translators != "translator-credits" {
+		dlg.SetTranslatorCredits(translators)
+	}
+	dlg.AddButton(i18n.T("Copy system info"), responseCopySystemInfo)
+	dlg.AddButton(i18n.T("Check for updates"), responseCheckUpdate)
+
+	dlg.SetModal(true)
+	if parent != nil {
+		dlg.SetTransientFor(parent)
+	}
+
+	// OS integrations for window
+	osintegration.OsIntegrateDialog(&dlg.Dialog)
+
+	closed := false
+	for {
+		response := dlg.Run()
+		if response == int(responseCopySystemInfo) {
+			copySystemInfo(manager, configurator, version)
+		} else if response == int(responseCheckUpdate) {
+			checkAppUpdate(dlg, manager, version, &closed)
+		} else {
+			break
+		}
+	}
+	closed = true
+	dlg.Destroy()
+}
+
+// checkAppUpdate shows result of the new version checking in the dialog, download page is set as website
+func checkAppUpdate(dlg *gtk.AboutDialog, manager *manager.Manager, version string, closed *bool) {
+	dlg.SetComments(i18n.T("Checking for updates..."))
+	checker := &selfupdate.Checker{CacheDir: manager.CacheDir()}
+
+	go func() {
+		update, updateErr := checker.CheckForAppUpdate(version, true)
+
+		_, e := glib.IdleAdd(func() {
+			if *closed {
+				return
+			}
+
+			if updateErr != nil {
+				dlg.SetComments(updateErr.Error())
+			} else if update.Available {
+				dlg.SetComments(fmt.Sprintf(i18n.T("New InsteadMan %s is available."), update.Release.Version))
+				dlg.SetWebsite(update.Release.Url)
+				dlg.SetWebsiteLabel(i18n.T("Download page"))
+				checker.MarkNotified(update.Release)
+			} else {
+				dlg.SetComments(i18n.T("InsteadMan is up to date."))
+			}
+		})
+
+		if e != nil {
+			log.Fatal("New version checking. IdleAdd() failed:", e)
+		}
+	}()
+}
+
+// copySystemInfo copies information for the bug reports to the clipboard
+func copySystemInfo(manager *manager.Manager, configurator *configurator.Configurator, version string) {
+	go func() {
+		info := systemInfo(manager, configurator, version)
+
+		_, e := glib.IdleAdd(func() {
+			clipboard, e := gtk.ClipboardGet(gdk.SELECTION_CLIPBOARD)
+			if e != nil {
+				log.Printf("Error: %v", e)
+				return
+			}
+			clipboard.SetText(info)
+			log.Print("System info has copied.")
+		})
+
+		if e != nil {
+			log.Fatal("System info. IdleAdd() failed:", e)
+		}
+	}()
+}
+
+func systemInfo(manager *manager.Manager, configurator *configurator.Configurator, version string) string {
+	interpreterCommand := manager.InterpreterCommand()
+	interpreterVersion := "-"
+	if interpreterCommand != "" {
+		var e error
+		interpreterVersion, e = manager.InterpreterFinder.Check(interpreterCommand)
+		if e != nil {
+			interpreterVersion = e.Error()
+		}
+	}
+
+	lines := []string{
+		fmt.Sprintf("InsteadMan: %s (GTK)", version),
+		fmt.Sprintf("OS: %s/%s", runtime.GOOS, runtime.GOARCH),
+		fmt.Sprintf("GTK: %d.%d.%d", gtk.GetMajorVersion(), gtk.GetMinorVersion(), gtk.GetMicroVersion()),
+		fmt.Sprintf("INSTEAD: %s (%s)", interpreterVersion, interpreterCommand),
+		fmt.Sprintf("Config: %s", configurator.FilePath),
+		fmt.Sprintf("Language: %s", manager.Config().Lang),
+	}
+
+	return strings.Join(lines, "\n") + "\n"
+}
@@ -0,0 +1,90 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/gotk3/gotk3/glib"
+	"github.com/jhekasoft/insteadman3/core/fileassoc"
+	"github.com/jhekasoft/insteadman3/core/manager"
+	"github.com/jhekasoft/insteadman3/gtk/i18n"
+)
+
+// ActivateMainWindow presents main window to the next launch of InsteadMan. Its arguments
+// (archive files or URLs of the games, files and insteadman:// links are opened from the file manager
+// and the browser) are offered for installing.
+func ActivateMainWindow(args []string) {
+	if MainWin == nil {
+		// First run assistant is shown
+		return
+	}
+
+	ShowExistingMainWindow(false)
+	MainWin.OpenArgs(args)
+}
+
+// OpenArgs offers installing of the games from archive files or URLs and opens insteadman:// links,
+// flags (like "--portable") are skipped
+func (win *MainWindow) OpenArgs(args []string) {
+	for _, arg := range args {
+		if strings.HasPrefix(arg, "-") {
+			continue
+		}
+
+		if fileassoc.IsLink(arg) {
+			win.openLink(arg)
+		} else {
+			win.installArchive(arg)
+		}
+	}
+}
+
+func (win *MainWindow) installArchive(location string) {
+	if win.Manager.InterpreterCommand() == "" {
+		ShowErrorDlg(i18n.T("INSTEAD has not found. Please add INSTEAD in the Settings."), win.Window)
+		return
+	}
+
+	// Local file is opened from the file manager, it's checked before installing
+	local := !strings.HasPrefix(location, "http://") && !strings.HasPrefix(location, "https://")
+	question := fmt.Sprintf(i18n.T("Install game from %s?"), location)
+	if local {
+		gameName, e := manager.GameArchiveName(location)
+		if e != nil {
+			ShowErrorDetailsDlg(fmt.Sprintf(i18n.T("%s isn't INSTEAD game."), location), e, win.Window)
+			return
+		}
+		question = fmt.Sprintf(i18n.T("Install game %s from the file %s?"), gameName, location)
+	}
+
+	if !ShowQuestionDlg(question, win.Window) {
+		return
+	}
+
+	log.Printf("Installing game from %s...", location)
+
+	go func() {
+		var instErr error
+		if local {
+			instErr = win.Manager.InstallGameFromFile(context.Background(), location, nil)
+		} else {
+			instErr = win.Manager.InstallArchiveContext(context.Background(), location, nil, nil)
+		}
+		if instErr == nil {
+			log.Print("Game has installed.")
+			// Game is refreshed by the GameInstalled event
+			return
+		}
+
+		_, e := glib.IdleAdd(func() {
+			ShowErrorDetailsDlg(i18n.T("Game hasn't installed. Please check INSTEAD in the Settings."),
+				instErr, win.Window)
+		})
+
+		if e != nil {
+			log.Fatal("Installing game from archive. IdleAdd() failed:", e)
+		}
+	}()
+}
@@ -0,0 +1,125 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/gotk3/gotk3/glib"
+	"github.com/gotk3/gotk3/gtk"
+	"github.com/jhekasoft/insteadman3/core/manager"
+	"github.com/jhekasoft/insteadman3/gtk/i18n"
+	"github.com/jhekasoft/insteadman3/gtk/osintegration"
+)
+
+// showBatchDlg installs (or removes) several games with the aggregate progress. Results of the games
+// are shown when the queue is finished.
+func showBatchDlg(action manager.QueueAction, games []manager.Game, b manager.Backend, parent *gtk.Window) {
+
+	title := i18n.T("Installing games")
+	if action == manager.QueueRemove {
+		title = i18n.T("Removing games")
+	}
+
+	dlg, _ := gtk.DialogNew()
+	dlg.SetTitle(title)
+	btn, _ := dlg.AddButton(i18n.T("Cancel"), gtk.RESPONSE_CANCEL)
+	dlgBox, _ := dlg.GetContentArea()
+	dlgBox.SetSpacing(6)
+
+	prgrssBar, _ := gtk.ProgressBarNew()
+	prgrssBar.SetShowText(true)
+	prgrssBar.SetMarginStart(6)
+	prgrssBar.SetMarginEnd(6)
+	dlgBox.Add(prgrssBar)
+	prgrssBar.Show()
+
+	scrWnd, _ := gtk.ScrolledWindowNew(nil, nil)
+	scrWnd.SetSizeRequest(400, 150)
+	scrWnd.SetMarginStart(6)
+	scrWnd.SetMarginEnd(6)
+	lbl, _ := gtk.LabelNew("")
+	lbl.SetHAlign(gtk.ALIGN_START)
+	lbl.SetVAlign(gtk.ALIGN_START)
+	lbl.SetLineWrap(true)
+	lbl.SetSelectable(true)
+	scrWnd.Add(lbl)
+	dlgBox.PackStart(scrWnd, true, true, 0)
+
+	dlg.SetModal(true)
+	dlg.SetPosition(gtk.WIN_POS_CENTER_ON_PARENT)
+	if parent != nil {
+		dlg.SetTransientFor(parent)
+	}
+
+	// OS integrations for window
+	osintegration.OsIntegrateDialog(dlg)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	finished := false
+	dlg.Connect("response", func() {
+		if !finished {
+			cancel()
+			btn.SetSensitive(false)
+			prgrssBar.SetText(i18n.T("Cancelling..."))
+			return
+		}
+		dlg.Destroy()
+	})
+
+	progressF := func(p manager.QueueProgress) {
+		glib.IdleAdd(func() {
+			if finished || ctx.Err() != nil {
+				return
+			}
+			prgrssBar.SetFraction(p.Fraction())
+			if p.Game != nil {
+				prgrssBar.SetText(fmt.Sprintf("%s (%d/%d)", p.Game.Title, p.Index+1, p.Count))
+			}
+		})
+	}
+
+	dlg.Show()
+
+	go func() {
+		results := manager.ProcessBackendQueue(ctx, b, action, games, progressF)
+		cancel()
+
+		_, e := glib.IdleAdd(func() {
+			finished = true
+
+			prgrssBar.SetFraction(1)
+			prgrssBar.SetText(i18n.T("Done"))
+			lbl.SetText(batchResultsText(action, results))
+			scrWnd.ShowAll()
+			btn.SetLabel(i18n.T("Close"))
+			btn.SetSensitive(true)
+		})
+
+		if e != nil {
+			log.Fatal("Batch games. IdleAdd() failed:", e)
+		}
+	}()
+}
+
+func batchResultsText(action manager.QueueAction, results []manager.QueueResult) string {
+	doneTxt := i18n.T("%s: installed")
+	if action == manager.QueueRemove {
+		doneTxt = i18n.T("%s: removed")
+	}
+
+	var lines []string
+	for _, result := range results {
+		switch {
+		case result.Error == context.Canceled:
+			lines = append(lines, fmt.Sprintf(i18n.T("%s: cancelled"), result.Game.Title))
+		case result.Error != nil:
+			lines = append(lines, fmt.Sprintf(i18n.T("%s: error (%s)"), result.Game.Title, result.Error))
+		default:
+			lines = append(lines, fmt.Sprintf(doneTxt, result.Game.Title))
+		}
+	}
+
+	return strings.Join(lines, "\n")
+}
@@ -1,27 +1,57 @@
 package ui
 
 import (
+	"fmt"
 	"log"
 	"os"
+	"strings"
 
+	"github.com/gotk3/gotk3/gdk"
 	"github.com/gotk3/gotk3/gtk"
+	"github.com/jhekasoft/insteadman3/core/interpreterfinder"
+	"github.com/jhekasoft/insteadman3/core/utils"
 	"github.com/jhekasoft/insteadman3/gtk/i18n"
 	"github.com/jhekasoft/insteadman3/gtk/osintegration"
 )
 
+const (
+	responseCopyDetails gtk.ResponseType = 1
+	responseOpenLog     gtk.ResponseType = 2
+)
+
+// LogFilePath is a path of the log file which is offered in the error dialog (empty if there isn't log file)
+var LogFilePath string
+
 func ShowErrorDlgFatal(txt string, parent *gtk.Window) {
-	showErrorDlg(txt, true, parent)
+	showErrorDlg(txt, nil, true, parent)
 }
 
 func ShowErrorDlg(txt string, parent *gtk.Window) {
-	showErrorDlg(txt, false, parent)
+	showErrorDlg(txt, nil, false, parent)
 }
 
-func showErrorDlg(txt string, fatal bool, parent *gtk.Window) {
-	log.Printf("Error: %v", txt)
+// ShowErrorDetailsDlg shows short message, the error (with errors which are wrapped by it)
+// is shown in the details
+func ShowErrorDetailsDlg(txt string, e error, parent *gtk.Window) {
+	showErrorDlg(txt, e, false, parent)
+}
+
+func showErrorDlg(txt string, err error, fatal bool, parent *gtk.Window) {
+	details := errorDetails(err)
+	if details != "" {
+		log.Printf("Error: %v\n%s", txt, details)
+	} else {
+		log.Printf("Error: %v", txt)
+	}
 
 	dlg, _ := gtk.DialogNew()
 	dlg.SetTitle(i18n.T("InsteadMan error"))
+	if details != "" {
+		dlg.AddButton(i18n.T("Copy details"), responseCopyDetails)
+		if LogFilePath != "" {
+			dlg.AddButton(i18n.T("Open log"), responseOpenLog)
+		}
+	}
 	dlg.AddButton(i18n.T("Close"), gtk.RESPONSE_ACCEPT)
 	dlgBox, _ := dlg.GetContentArea()
 	dlgBox.SetSpacing(6)
@@ -33,6 +63,21 @@ func showErrorDlg(txt string, fatal bool, parent *gtk.Window) {
 	dlgBox.Add(lbl)
 	lbl.Show()
 
+	if details != "" {
+		expander, _ := gtk.ExpanderNew(i18n.T("Details"))
+		expander.SetMarginStart(6)
+		expander.SetMarginEnd(6)
+
+		detailsLbl, _ := gtk.LabelNew(details)
+		detailsLbl.SetLineWrap(true)
+		detailsLbl.SetSelectable(true)
+		detailsLbl.SetHAlign(gtk.ALIGN_START)
+		expander.Add(detailsLbl)
+
+		dlgBox.Add(expander)
+		expander.ShowAll()
+	}
+
 	dlg.SetModal(true)
 	dlg.SetPosition(gtk.WIN_POS_CENTER)
 	dlg.SetResizable(false)
@@ -45,9 +90,57 @@ func showErrorDlg(txt string, fatal bool, parent *gtk.Window) {
 	// OS integrations for window
 	osintegration.OsIntegrateDialog(dlg)
 
-	dlg.Run()
+	for {
+		response := dlg.Run()
+		if response == int(responseCopyDetails) {
+			clipboard, e := gtk.ClipboardGet(gdk.SELECTION_CLIPBOARD)
+			if e == nil {
+				clipboard.SetText(txt + "\n" + details)
+			}
+		} else if response == int(responseOpenLog) {
+			if e := utils.OpenBrowser(LogFilePath); e != nil {
+				log.Printf("Error: %v", e)
+			}
+		} else {
+			break
+		}
+	}
 	dlg.Destroy()
 	if fatal {
 		os.Exit(1)
 	}
 }
+
+// errorDetails returns chain of the errors: error and errors which are wrapped by it
+func errorDetails(e error) string {
+	var lines []string
+	for e != nil {
+		lines = append(lines, fmt.Sprintf("%T: %s", e, e.Error()))
+
+		wrapper, ok := e.(interface{ Unwrap() error })
+		if !ok {
+			break
+		}
+		e = wrapper.Unwrap()
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// InterpreterErrorText explains why INSTEAD can't be used
+func InterpreterErrorText(e error) string {
+	switch e {
+	case interpreterfinder.ErrNotExist:
+		return i18n.T("INSTEAD file doesn't exist.")
+	case interpreterfinder.ErrNotExecutable:
+		return i18n.T("INSTEAD file isn't executable.")
+	case interpreterfinder.ErrWrongArchitecture:
+		return i18n.T("INSTEAD is built for another architecture (32-bit or 64-bit). Please install INSTEAD for your system.")
+	case interpreterfinder.ErrNotRespondVersion:
+		return i18n.T("File doesn't respond to -version, it isn't INSTEAD.")
+	case interpreterfinder.ErrCheckTimeout:
+		return i18n.T("INSTEAD hasn't answered in time.")
+	}
+
+	return e.Error()
+}
@@ -0,0 +1,365 @@
+package ui
+
+import (
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/gotk3/gotk3/glib"
+	"github.com/gotk3/gotk3/gtk"
+	"github.com/jhekasoft/insteadman3/core/configurator"
+	"github.com/jhekasoft/insteadman3/core/interpreterinstaller"
+	"github.com/jhekasoft/insteadman3/core/manager"
+	"github.com/jhekasoft/insteadman3/core/utils"
+	"github.com/jhekasoft/insteadman3/gtk/i18n"
+	"github.com/jhekasoft/insteadman3/gtk/osintegration"
+)
+
+const (
+	firstRunPageLanguage = iota
+	firstRunPageInterpreter
+	firstRunPageGames
+	firstRunPageRepositories
+	firstRunPageSummary
+)
+
+// FirstRunAssistant is shown when config file hasn't existed: it asks language, INSTEAD and games directory
+// and updates repositories. Finished function is called after closing (or cancelling) the assistant.
+type FirstRunAssistant struct {
+	Assistant *gtk.Assistant
+
+	CmbBoxLanguage        *gtk.ComboBoxText
+	LblInterpreterInf     *gtk.Label
+	BtnInterpreterDetect  *gtk.Button
+	BtnInterpreterInstall *gtk.Button
+	FlChsrBtnGames        *gtk.FileChooserButton
+	SpinnerRepositories   *gtk.Spinner
+	LblRepositoriesInf    *gtk.Label
+	LblSummary            *gtk.Label
+
+	pages []*gtk.Box
+
+	// Repositories are updated by the assistant, so main window doesn't update them
+	repositoriesUpdated bool
+	finished            func(repositoriesUpdated bool)
+
+	Manager      *manager.Manager
+	Configurator *configurator.Configurator
+}
+
+func ShowFirstRunAssistant(manager *manager.Manager, configurator *configurator.Configurator, title string,
+	finished func(repositoriesUpdated bool)) {
+
+	a, e := FirstRunAssistantNew(manager, configurator, title, finished)
+	if e != nil {
+		log.Printf("First run assistant error: %v", e)
+		finished(false)
+		return
+	}
+
+	a.Assistant.ShowAll()
+	a.LblInterpreterInf.Hide()
+}
+
+func FirstRunAssistantNew(manager *manager.Manager, configurator *configurator.Configurator, title string,
+	finished func(repositoriesUpdated bool)) (*FirstRunAssistant, error) {
+
+	a := &FirstRunAssistant{Manager: manager, Configurator: configurator, finished: finished}
+
+	var e error
+	a.Assistant, e = gtk.AssistantNew()
+	if e != nil {
+		return nil, e
+	}
+	a.Assistant.SetTitle(fmt.Sprintf(i18n.T("Welcome to %s"), title))
+	a.Assistant.SetDefaultSize(560, 360)
+	a.Assistant.SetPosition(gtk.WIN_POS_CENTER)
+	a.Assistant.SetIconName("insteadman")
+
+	// Language
+	page, e := a.appendPage(i18n.T("Language"), gtk.ASSISTANT_PAGE_INTRO,
+		fmt.Sprintf(i18n.T("%s will help you to install and run INSTEAD games. Choose the language:"), title))
+	if e != nil {
+		return nil, e
+	}
+	a.CmbBoxLanguage, e = gtk.ComboBoxTextNew()
+	if e != nil {
+		return nil, e
+	}
+	a.CmbBoxLanguage.Append("", i18n.T("System language"))
+	a.CmbBoxLanguage.Append("en", i18n.T("English"))
+	a.CmbBoxLanguage.Append("ru", i18n.T("Russian (русский)"))
+	a.CmbBoxLanguage.Append("uk", i18n.T("Ukrainian (українська)"))
+	a.CmbBoxLanguage.SetActiveID(manager.Config().Lang)
+	page.PackStart(a.CmbBoxLanguage, false, false, 0)
+
+	// INSTEAD
+	page, e = a.appendPage(i18n.T("INSTEAD"), gtk.ASSISTANT_PAGE_CONTENT,
+		i18n.T("Games are run by INSTEAD interpreter. Detect installed INSTEAD or download it."))
+	if e != nil {
+		return nil, e
+	}
+	a.LblInterpreterInf, e = gtk.LabelNew("")
+	if e != nil {
+		return nil, e
+	}
+	a.LblInterpreterInf.SetLineWrap(true)
+	a.LblInterpreterInf.SetHAlign(gtk.ALIGN_START)
+	page.PackStart(a.LblInterpreterInf, false, false, 0)
+
+	btnBox, e := gtk.BoxNew(gtk.ORIENTATION_HORIZONTAL, 6)
+	if e != nil {
+		return nil, e
+	}
+	a.BtnInterpreterDetect, e = gtk.ButtonNewWithLabel(i18n.T("Detect"))
+	if e != nil {
+		return nil, e
+	}
+	btnBox.PackStart(a.BtnInterpreterDetect, false, false, 0)
+	a.BtnInterpreterInstall, e = gtk.ButtonNewWithLabel(i18n.T("Download and install"))
+	if e != nil {
+		return nil, e
+	}
+	btnBox.PackStart(a.BtnInterpreterInstall, false, false, 0)
+	page.PackStart(btnBox, false, false, 0)
+
+	// Games
+	page, e = a.appendPage(i18n.T("Games"), gtk.ASSISTANT_PAGE_CONFIRM,
+		i18n.T("Choose directory for the installed games:"))
+	if e != nil {
+		return nil, e
+	}
+	a.FlChsrBtnGames, e = gtk.FileChooserButtonNew(i18n.T("Choose games directory"),
+		gtk.FILE_CHOOSER_ACTION_SELECT_FOLDER)
+	if e != nil {
+		return nil, e
+	}
+	os.MkdirAll(manager.Config().CalculatedGamesPath, os.ModePerm)
+	a.FlChsrBtnGames.SetCurrentFolder(manager.Config().CalculatedGamesPath)
+	page.PackStart(a.FlChsrBtnGames, false, false, 0)
+
+	// Repositories
+	page, e = a.appendPage(i18n.T("Repositories"), gtk.ASSISTANT_PAGE_PROGRESS,
+		i18n.T("Downloading game lists of the repositories..."))
+	if e != nil {
+		return nil, e
+	}
+	a.SpinnerRepositories, e = gtk.SpinnerNew()
+	if e != nil {
+		return nil, e
+	}
+	page.PackStart(a.SpinnerRepositories, false, false, 0)
+	a.LblRepositoriesInf, e = gtk.LabelNew("")
+	if e != nil {
+		return nil, e
+	}
+	a.LblRepositoriesInf.SetLineWrap(true)
+	a.LblRepositoriesInf.SetHAlign(gtk.ALIGN_START)
+	page.PackStart(a.LblRepositoriesInf, false, false, 0)
+
+	// Summary
+	page, e = a.appendPage(i18n.T("Done"), gtk.ASSISTANT_PAGE_SUMMARY,
+		i18n.T("InsteadMan is ready. Settings can be changed later in the Settings window."))
+	if e != nil {
+		return nil, e
+	}
+	a.LblSummary, e = gtk.LabelNew("")
+	if e != nil {
+		return nil, e
+	}
+	a.LblSummary.SetLineWrap(true)
+	a.LblSummary.SetHAlign(gtk.ALIGN_START)
+	page.PackStart(a.LblSummary, false, false, 0)
+
+	a.BtnInterpreterDetect.Connect("clicked", a.interpreterDetectClicked)
+	a.BtnInterpreterInstall.Connect("clicked", a.interpreterInstallClicked)
+	a.Assistant.Connect("prepare", a.prepared)
+	a.Assistant.Connect("apply", a.applied)
+	a.Assistant.Connect("cancel", a.closed)
+	a.Assistant.Connect("close", a.closed)
+	a.Assistant.Connect("destroy", a.closed)
+
+	// OS integrations for window
+	osintegration.OsIntegrateWindow(&a.Assistant.Window)
+
+	return a, nil
+}
+
+func (a *FirstRunAssistant) appendPage(title string, pageType gtk.AssistantPageType, txt string) (*gtk.Box, error) {
+	page, e := gtk.BoxNew(gtk.ORIENTATION_VERTICAL, 12)
+	if e != nil {
+		return nil, e
+	}
+	page.SetBorderWidth(12)
+
+	lbl, e := gtk.LabelNew(txt)
+	if e != nil {
+		return nil, e
+	}
+	lbl.SetLineWrap(true)
+	lbl.SetHAlign(gtk.ALIGN_START)
+	page.PackStart(lbl, false, false, 0)
+
+	a.Assistant.AppendPage(page)
+	a.Assistant.SetPageTitle(page, title)
+	a.Assistant.SetPageType(page, pageType)
+	// Progress page is completed after updating repositories
+	a.Assistant.SetPageComplete(page, pageType != gtk.ASSISTANT_PAGE_PROGRESS)
+	a.pages = append(a.pages, page)
+
+	return page, nil
+}
+
+func (a *FirstRunAssistant) interpreterText() string {
+	command := a.Manager.InterpreterCommand()
+	if command == "" {
+		return i18n.T("INSTEAD has not found. Games can't be run without it, but it can be added later in the Settings.")
+	}
+
+	return fmt.Sprintf(i18n.T("INSTEAD: %s"), command)
+}
+
+func (a *FirstRunAssistant) showInterpreterInf(txt string) {
+	a.LblInterpreterInf.SetText(txt)
+	a.LblInterpreterInf.Show()
+}
+
+func (a *FirstRunAssistant) setInterpreterButtonsSensitive(sensitive bool) {
+	a.BtnInterpreterDetect.SetSensitive(sensitive)
+	a.BtnInterpreterInstall.SetSensitive(sensitive)
+}
+
+func (a *FirstRunAssistant) interpreterDetectClicked() {
+	a.setInterpreterButtonsSensitive(false)
+	a.showInterpreterInf(i18n.T("Detecting INSTEAD..."))
+
+	go func() {
+		a.Manager.InterpreterFinder.ClearCache()
+		command := a.Manager.InterpreterFinder.Find()
+
+		var validateErr error
+		if command != nil {
+			_, validateErr = a.Manager.InterpreterFinder.Validate(*command)
+		}
+
+		_, e := glib.IdleAdd(func() {
+			if command != nil && validateErr != nil {
+				a.showInterpreterInf(InterpreterErrorText(validateErr))
+			} else if command != nil {
+				a.Configurator.Set("interpreter_command", *command)
+				a.showInterpreterInf(a.interpreterText())
+			} else {
+				a.showInterpreterInf(i18n.T("INSTEAD hasn't detected!"))
+			}
+
+			a.setInterpreterButtonsSensitive(true)
+		})
+
+		if e != nil {
+			log.Fatal("First run INSTEAD detect. IdleAdd() failed:", e)
+		}
+	}()
+}
+
+func (a *FirstRunAssistant) interpreterInstallClicked() {
+	a.setInterpreterButtonsSensitive(false)
+	a.showInterpreterInf(i18n.T("Downloading INSTEAD..."))
+
+	go func() {
+		installer := interpreterinstaller.Installer{DataDir: a.Manager.Config().CalculatedInsteadManPath}
+		path, installErr := installer.Install(func(downloaded, total uint64) {
+			glib.IdleAdd(func() {
+				a.showInterpreterInf(fmt.Sprintf(i18n.T("Downloading INSTEAD... %s"), utils.Percents(downloaded, total)))
+			})
+		})
+
+		_, e := glib.IdleAdd(func() {
+			if installErr != nil {
+				a.showInterpreterInf(installErr.Error())
+			} else {
+				log.Printf("INSTEAD has installed: %s", path)
+				a.Configurator.Set("use_builtin_interpreter", true)
+				a.showInterpreterInf(a.interpreterText())
+			}
+
+			a.setInterpreterButtonsSensitive(true)
+		})
+
+		if e != nil {
+			log.Fatal("First run INSTEAD install. IdleAdd() failed:", e)
+		}
+	}()
+}
+
+func (a *FirstRunAssistant) prepared() {
+	switch a.Assistant.GetCurrentPage() {
+	case firstRunPageInterpreter:
+		a.showInterpreterInf(a.interpreterText())
+	case firstRunPageRepositories:
+		a.updateRepositories()
+	case firstRunPageSummary:
+		a.LblSummary.SetText(a.interpreterText() + "\n" +
+			fmt.Sprintf(i18n.T("Games directory: %s"), a.Manager.Config().CalculatedGamesPath))
+	}
+}
+
+// applied saves settings (it's called after the confirmation page)
+func (a *FirstRunAssistant) applied() {
+	lang := a.CmbBoxLanguage.GetActiveID()
+	a.Configurator.Set("lang", lang)
+	if lang != "" {
+		// Main window is translated to the chosen language
+		i18n.SetLanguage(lang)
+	}
+
+	gamesPath := a.FlChsrBtnGames.GetFilename()
+	if gamesPath != "" && gamesPath != a.Manager.Config().CalculatedGamesPath {
+		a.Configurator.Set("games_path", gamesPath)
+	}
+
+	e := a.Configurator.SaveConfig(a.Manager.Config())
+	if e != nil {
+		ShowErrorDlg(e.Error(), &a.Assistant.Window)
+	}
+}
+
+func (a *FirstRunAssistant) updateRepositories() {
+	page := a.pages[firstRunPageRepositories]
+	a.SpinnerRepositories.Start()
+
+	go func() {
+		errors := a.Manager.UpdateRepositories()
+		for _, e := range errors {
+			log.Printf("Update repository error: %s", e.Error())
+		}
+
+		_, e := glib.IdleAdd(func() {
+			a.SpinnerRepositories.Stop()
+			a.SpinnerRepositories.Hide()
+			if len(errors) > 0 {
+				a.LblRepositoriesInf.SetText(fmt.Sprintf(i18n.T("Repositories have updated with errors: %d"),
+					len(errors)))
+			} else {
+				a.LblRepositoriesInf.SetText(i18n.T("Repositories have updated."))
+			}
+
+			a.repositoriesUpdated = true
+			a.Assistant.SetPageComplete(page, true)
+		})
+
+		if e != nil {
+			log.Fatal("First run updating repositories. IdleAdd() failed:", e)
+		}
+	}()
+}
+
+func (a *FirstRunAssistant) closed() {
+	if a.finished == nil {
+		return
+	}
+
+	finished := a.finished
+	a.finished = nil
+	a.Assistant.Destroy()
+	finished(a.repositoriesUpdated)
+}
@@ -0,0 +1,84 @@
+package ui
+
+import (
+	"fmt"
+
+	"github.com/gotk3/gotk3/gtk"
+	"github.com/jhekasoft/insteadman3/core/fileassoc"
+	"github.com/jhekasoft/insteadman3/core/manager"
+	"github.com/jhekasoft/insteadman3/gtk/i18n"
+)
+
+// openLink installs or runs the game of the insteadman:// link (it's opened from the website) after the confirmation
+func (win *MainWindow) openLink(link string) {
+	l, e := fileassoc.ParseLink(link)
+	if e != nil {
+		ShowErrorDetailsDlg(fmt.Sprintf(i18n.T("Link %s hasn't opened."), link), e, win.Window)
+		return
+	}
+
+	foundGames := manager.FindGamesByName(win.Games, l.Game)
+	if len(foundGames) < 1 {
+		ShowErrorDlg(fmt.Sprintf(i18n.T("Game %s hasn't found. Please update the repositories."), l.Game),
+			win.Window)
+		return
+	}
+
+	// Installed game is used if there are several repositories with the game
+	game := foundGames[0]
+	for _, g := range foundGames {
+		if g.Installed {
+			game = g
+			break
+		}
+	}
+
+	win.selectGame(game.Id)
+
+	if l.Action == fileassoc.ActionRun && game.Installed {
+		if ShowQuestionDlg(fmt.Sprintf(i18n.T("Run game %s?"), game.Title), win.Window) {
+			win.runGame(&game)
+		}
+		return
+	}
+
+	if game.Installed {
+		ShowErrorDlg(fmt.Sprintf(i18n.T("Game %s is installed already."), game.Title), win.Window)
+		return
+	}
+
+	if ShowQuestionDlg(fmt.Sprintf(i18n.T("Install game %s (%s)?"), game.Title, game.HumanSize()), win.Window) {
+		win.installGame(win.CurGame)
+	}
+}
+
+// selectGame selects the game in the list, filter is cleared if the game isn't shown
+func (win *MainWindow) selectGame(id string) {
+	iter := win.findGameIter(id)
+	if iter == nil {
+		win.clearFilter()
+		iter = win.findGameIter(id)
+	}
+	if iter == nil {
+		return
+	}
+
+	win.GamesSelection.UnselectAll()
+	win.GamesSelection.SelectIter(iter)
+}
+
+func (win *MainWindow) findGameIter(id string) *gtk.TreeIter {
+	iter, ok := win.ListStoreGames.GetIterFirst()
+	for ok {
+		value, e := win.ListStoreGames.GetValue(iter, gameColumnId)
+		if e == nil {
+			if gameId, e := value.GetString(); e == nil && gameId == id {
+				return iter
+			}
+		}
+
+		ok = win.ListStoreGames.IterNext(iter)
+	}
+
+	return nil
+}
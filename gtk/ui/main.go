@@ -1,6 +1,7 @@
 package ui
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"strings"
@@ -31,6 +32,7 @@ const (
 	gameColumnSizeHuman  = 3
 	gameColumnFontWeight = 4
 	gameColumnSize       = 5
+	gameColumnUpdate     = 6
 
 	fontWeightNormal = pango.WEIGHT_NORMAL
 	fontWeightBold   = pango.WEIGHT_BOLD
@@ -65,11 +67,27 @@ func GetMain(manager *manager.Manager, configurator *configurator.Configurator,
 	if MainWin != nil && MainWin.Window.IsVisible() {
 		return MainWin
 	}
+	if MainWin != nil {
+		MainWin.unsubscribe()
+	}
 	MainWin = MainWindowNew(manager, configurator, title, version)
 
 	return MainWin
 }
 
+// ConfigReloaded refreshes opened windows after config has been reloaded
+func ConfigReloaded() {
+	if MainWin != nil {
+		MainWin.Refresh()
+	}
+
+	UpdateStatusIcon()
+
+	if SettingsWin != nil && SettingsWin.Window.IsVisible() {
+		SettingsWin.readSettings()
+	}
+}
+
 type MainWindow struct {
 	Window *gtk.Window
 
@@ -80,7 +98,11 @@ type MainWindow struct {
 	GamesSelection *gtk.TreeSelection
 
 	BtnUpdate        *gtk.Button
-	EntryKeyword     *gtk.Entry
+	HdrBar           *gtk.HeaderBar
+	TgglBtnSearch    *gtk.ToggleButton
+	BtnInstallSlctd  *gtk.Button
+	BtnRemoveSlctd   *gtk.Button
+	EntryKeyword     *gtk.SearchEntry
 	CmbBoxRepo       *gtk.ComboBox
 	CmbBoxLang       *gtk.ComboBox
 	ChckBtnInstalled *gtk.CheckButton
@@ -100,6 +122,9 @@ type MainWindow struct {
 	BtnGameInstall *gtk.Button
 	BtnGameUpdate  *gtk.Button
 	BtnGameRemove  *gtk.Button
+	PrgrssBarGame  *gtk.ProgressBar
+	BtnGameCancel  *gtk.Button
+	BxGameProgress *gtk.Box
 
 	SprtrSideBox *gtk.Separator
 	BxSideBox    *gtk.Box
@@ -110,17 +135,31 @@ type MainWindow struct {
 	MenuItmAbout        *gtk.MenuItem
 
 	PixBufGameDefaultImage *gdk.Pixbuf
-	PixBufGameImage        *gdk.Pixbuf
 
 	Games        []manager.Game
 	CurGame      *manager.Game // current selected game
 	IsRefreshing bool
 
+	installings map[string]*gameInstalling // games which are installing now (key is a game ID)
+	gameImages  map[string]*gdk.Pixbuf     // loaded game images (key is a game ID)
+	imageLoads  map[string]bool            // games whose images are loading now
+
 	Title   string
 	Version string
 
-	Manager      *manager.Manager
+	Manager *manager.Manager
+	// Backend changes games and repositories (Manager or client of the running daemon)
+	Backend      manager.Backend
 	Configurator *configurator.Configurator
+
+	unsubscribes []func() // unsubscribe from the events of the manager and the backend
+}
+
+// gameInstalling is a progress of the game installing (or updating) in the background
+type gameInstalling struct {
+	cancel   context.CancelFunc
+	fraction float64
+	text     string
 }
 
 func MainWindowNew(manager *manager.Manager, configurator *configurator.Configurator,
@@ -129,9 +168,13 @@ func MainWindowNew(manager *manager.Manager, configurator *configurator.Configur
 	win := new(MainWindow)
 
 	win.Manager = manager
+	win.UseBackend(manager)
 	win.Configurator = configurator
 	win.Title = title
 	win.Version = version
+	win.installings = make(map[string]*gameInstalling)
+	win.gameImages = make(map[string]*gdk.Pixbuf)
+	win.imageLoads = make(map[string]bool)
 
 	b, e := gtk.BuilderNew()
 	if e != nil {
@@ -151,7 +194,7 @@ func MainWindowNew(manager *manager.Manager, configurator *configurator.Configur
 	var ok bool
 	win.Window, ok = obj.(*gtk.Window)
 	if !ok {
-		ShowErrorDlgFatal("No main window", win.Window)
+		ShowErrorDlgFatal(i18n.T("No main window"), win.Window)
 	}
 
 	win.ListStoreRepo = gtkutils.GetListStore(b, "liststore_repo")
@@ -159,7 +202,11 @@ func MainWindowNew(manager *manager.Manager, configurator *configurator.Configur
 	win.ListStoreGames = gtkutils.GetListStore(b, "liststore_games")
 
 	win.BtnUpdate = gtkutils.GetButton(b, "button_update")
-	win.EntryKeyword = gtkutils.GetEntry(b, "entry_keyword")
+	win.HdrBar = gtkutils.GetHeaderBar(b, "headerbar_main")
+	win.TgglBtnSearch = gtkutils.GetToggleButton(b, "togglebutton_search")
+	win.BtnInstallSlctd = gtkutils.GetButton(b, "button_install_selected")
+	win.BtnRemoveSlctd = gtkutils.GetButton(b, "button_remove_selected")
+	win.EntryKeyword = gtkutils.GetSearchEntry(b, "searchentry_keyword")
 	win.CmbBoxRepo = gtkutils.GetComboBox(b, "combobox_repo")
 	win.CmbBoxLang = gtkutils.GetComboBox(b, "combobox_lang")
 	win.ChckBtnInstalled = gtkutils.GetCheckButton(b, "checkutton_installed")
@@ -187,6 +234,9 @@ func MainWindowNew(manager *manager.Manager, configurator *configurator.Configur
 	win.BtnGameInstall = gtkutils.GetButton(b, "button_game_install")
 	win.BtnGameUpdate = gtkutils.GetButton(b, "button_game_update")
 	win.BtnGameRemove = gtkutils.GetButton(b, "button_game_remove")
+	win.PrgrssBarGame = gtkutils.GetProgressBar(b, "progressbar_game")
+	win.BtnGameCancel = gtkutils.GetButton(b, "button_game_cancel")
+	win.BxGameProgress = gtkutils.GetBox(b, "box_game_progress")
 
 	win.SprtrSideBox = gtkutils.GetSeparator(b, "separator_side")
 	win.BxSideBox = gtkutils.GetBox(b, "box_side")
@@ -204,7 +254,7 @@ func MainWindowNew(manager *manager.Manager, configurator *configurator.Configur
 		ShowErrorDlgFatal(e.Error(), win.Window)
 	}
 
-	showSideBar := !manager.Config.Gtk.HideSidebar
+	showSideBar := !manager.Config().Gtk.HideSidebar
 	win.ChckMenuItmSideBar.SetActive(showSideBar)
 	win.toggleSideBar(showSideBar)
 
@@ -213,7 +263,11 @@ func MainWindowNew(manager *manager.Manager, configurator *configurator.Configur
 	// Handlers
 	handlers := &MainWindowHandlers{win: win}
 	win.BtnUpdate.Connect("clicked", handlers.updateClicked)
-	win.EntryKeyword.Connect("changed", handlers.keywordChanged)
+	win.TgglBtnSearch.Connect("toggled", handlers.searchToggled)
+	win.BtnInstallSlctd.Connect("clicked", handlers.installSelectedClicked)
+	win.BtnRemoveSlctd.Connect("clicked", handlers.removeSelectedClicked)
+	win.EntryKeyword.Connect("search-changed", handlers.keywordChanged)
+	win.EntryKeyword.Connect("stop-search", handlers.searchStopped)
 	win.CmbBoxRepo.Connect("changed", handlers.repoChanged)
 	win.CmbBoxLang.Connect("changed", handlers.langChanged)
 	win.ChckBtnInstalled.Connect("clicked", handlers.installedClicked)
@@ -223,6 +277,7 @@ func MainWindowNew(manager *manager.Manager, configurator *configurator.Configur
 	win.BtnGameRun.Connect("clicked", handlers.runGameClicked)
 	win.BtnGameInstall.Connect("clicked", handlers.installGameClicked)
 	win.BtnGameUpdate.Connect("clicked", handlers.updateGameClicked)
+	win.BtnGameCancel.Connect("clicked", handlers.cancelGameClicked)
 	win.BtnGameRemove.Connect("clicked", handlers.removeGameClicked)
 	win.MenuItmSortingReset.Connect("activate", handlers.sortingResetActivated)
 	win.ChckMenuItmSideBar.Connect("toggled", handlers.sideBarToggled)
@@ -230,8 +285,9 @@ func MainWindowNew(manager *manager.Manager, configurator *configurator.Configur
 	win.MenuItmAbout.Connect("activate", handlers.aboutActivated)
 	win.Window.Connect("destroy", handlers.windowDestroyed)
 	win.Window.Connect("delete_event", handlers.mainDeleted)
+	win.Window.Connect("window-state-event", handlers.windowStateChanged)
 
-	width, height := win.getDefaultWindowSize(manager.Config)
+	width, height := win.getDefaultWindowSize(manager.Config())
 	win.Window.SetDefaultSize(width, height)
 
 	win.Window.SetTitle(title)
@@ -242,6 +298,44 @@ func MainWindowNew(manager *manager.Manager, configurator *configurator.Configur
 	return win
 }
 
+// UseBackend sets backend which changes games (client of the running daemon), the games list is refreshed
+// by its events. Events of the manager are received too, games are installed from files by it.
+func (win *MainWindow) UseBackend(b manager.Backend) {
+	win.Backend = b
+	win.unsubscribes = append(win.unsubscribes, b.Subscribe(win.managerEvent))
+}
+
+func (win *MainWindow) unsubscribe() {
+	for _, unsubscribe := range win.unsubscribes {
+		unsubscribe()
+	}
+	win.unsubscribes = nil
+}
+
+// managerEvent refreshes changed games or the whole list after updating repositories
+func (win *MainWindow) managerEvent(event manager.Event) {
+	var refresh func()
+	switch event := event.(type) {
+	case manager.GameInstalled:
+		refresh = func() { win.refreshSeveralGames([]manager.Game{event.Game}) }
+		if event.Game.Id == "" {
+			// Game has been installed from the archive, it can be a new local game
+			refresh = win.Refresh
+		}
+	case manager.GameRemoved:
+		refresh = func() { win.refreshSeveralGames([]manager.Game{event.Game}) }
+	case manager.RepositoriesUpdated:
+		refresh = win.Refresh
+	default:
+		return
+	}
+
+	_, e := glib.IdleAdd(refresh)
+	if e != nil {
+		log.Fatal("Refreshing games. IdleAdd() failed:", e)
+	}
+}
+
 func (win *MainWindow) toggleSideBar(show bool) {
 	if show {
 		win.SprtrSideBox.Show()
@@ -277,6 +371,75 @@ func (win *MainWindow) resetGameInfo() {
 	win.BtnGameInstall.Hide()
 	win.BtnGameUpdate.Hide()
 	win.BtnGameRemove.Hide()
+	win.BxGameProgress.Hide()
+	win.BtnInstallSlctd.Hide()
+	win.BtnRemoveSlctd.Hide()
+}
+
+// selectedGames returns all the selected games of the list
+func (win *MainWindow) selectedGames() (games []manager.Game) {
+	rows := win.GamesSelection.GetSelectedRows(win.ListStoreGames)
+	for l := rows; l != nil; l = l.Next() {
+		iter, e := win.ListStoreGames.GetIter(l.Data().(*gtk.TreePath))
+		if e != nil {
+			log.Printf("Error: %v", e)
+			continue
+		}
+
+		value, e := win.ListStoreGames.GetValue(iter, gameColumnId)
+		if e != nil {
+			log.Printf("Error: %v", e)
+			continue
+		}
+
+		id, _ := value.GetString()
+		if g := manager.FindGameById(win.Games, id); g != nil {
+			games = append(games, *g)
+		}
+	}
+
+	return
+}
+
+// updateSelectedActions shows batch actions if several games are selected
+func (win *MainWindow) updateSelectedActions() {
+	if win.GamesSelection.CountSelectedRows() > 1 {
+		win.BtnInstallSlctd.Show()
+		win.BtnRemoveSlctd.Show()
+	} else {
+		win.BtnInstallSlctd.Hide()
+		win.BtnRemoveSlctd.Hide()
+	}
+}
+
+// processSelectedGames installs or removes selected games (which aren't installing now) by the queue
+func (win *MainWindow) processSelectedGames(action manager.QueueAction) {
+	if action == manager.QueueInstall && win.Manager.InterpreterCommand() == "" {
+		ShowErrorDlg(i18n.T("INSTEAD has not found. Please add INSTEAD in the Settings."), win.Window)
+		return
+	}
+
+	var games []manager.Game
+	for _, g := range win.selectedGames() {
+		if _, ok := win.installings[g.Id]; ok {
+			continue
+		}
+		if (action == manager.QueueInstall) != g.Installed {
+			games = append(games, g)
+		}
+	}
+
+	if len(games) == 0 {
+		return
+	}
+
+	if action == manager.QueueRemove &&
+		!ShowQuestionDlg(fmt.Sprintf(i18n.N("Remove %d game?", "Remove %d games?", len(games)), len(games)), win.Window) {
+		return
+	}
+
+	// Games are refreshed by the events of the backend
+	showBatchDlg(action, games, win.Backend, win.Window)
 }
 
 func (win *MainWindow) clearFilterValues() {
@@ -314,7 +477,7 @@ func (win *MainWindow) refreshFilterValues() {
 
 func (win *MainWindow) gameListStoreColumns() []int {
 	return []int{gameColumnId, gameColumnTitle, gameColumnVersion, gameColumnSizeHuman, gameColumnFontWeight,
-		gameColumnSize}
+		gameColumnSize, gameColumnUpdate}
 }
 
 func (win *MainWindow) gameListStoreValues(g manager.Game) []interface{} {
@@ -323,7 +486,7 @@ func (win *MainWindow) gameListStoreValues(g manager.Game) []interface{} {
 		fontWeight = fontWeightBold
 	}
 
-	return []interface{}{g.Id, g.Title, g.HumanVersion(), g.HumanSize(), fontWeight, g.Size}
+	return []interface{}{g.Id, g.Title, g.HumanVersion(), g.HumanSize(), fontWeight, g.Size, g.IsUpdateAvailable()}
 }
 
 func (win *MainWindow) refreshGames() {
@@ -337,10 +500,18 @@ func (win *MainWindow) refreshGames() {
 		return
 	}
 
-	keywordP, repoP, langP, onlyInstalled := gtkutils.GetFilterValues(win.EntryKeyword, win.CmbBoxRepo,
+	keywordP, repoP, langP, onlyInstalled := gtkutils.GetFilterValues(&win.EntryKeyword.Entry, win.CmbBoxRepo,
 		win.CmbBoxLang, win.ChckBtnInstalled)
 
-	filteredGames := manager.FilterGames(win.Games, keywordP, repoP, langP, onlyInstalled)
+	filteredGames := manager.FilterGames(win.Games, nil, repoP, langP, onlyInstalled)
+
+	// Keyword search sorts games by the relevance
+	if keywordP != nil {
+		filteredGames = manager.SearchGames(filteredGames, *keywordP)
+		win.HdrBar.SetSubtitle(fmt.Sprintf(i18n.T("Found games: %d"), len(filteredGames)))
+	} else {
+		win.HdrBar.SetSubtitle("")
+	}
 
 	win.IsRefreshing = true
 
@@ -414,9 +585,11 @@ func (win *MainWindow) clearFilter() {
 	win.ChckBtnInstalled.SetSensitive(false)
 
 	win.EntryKeyword.SetText("")
+	win.TgglBtnSearch.SetActive(false)
 	win.CmbBoxRepo.SetActiveID("")
 	win.CmbBoxLang.SetActiveID("")
 	win.ChckBtnInstalled.SetActive(false)
+	win.rememberFilter()
 
 	win.refreshGames()
 
@@ -426,6 +599,35 @@ func (win *MainWindow) clearFilter() {
 	win.ChckBtnInstalled.SetSensitive(true)
 }
 
+// restoreFilter selects the last used filter values (they are kept in the config)
+func (win *MainWindow) restoreFilter() {
+	config := win.Manager.Config().Gtk
+	if config.FilterRepository == "" && config.FilterLang == "" && !config.FilterInstalled {
+		return
+	}
+
+	win.CmbBoxRepo.SetSensitive(false)
+	win.CmbBoxLang.SetSensitive(false)
+	win.ChckBtnInstalled.SetSensitive(false)
+
+	win.CmbBoxRepo.SetActiveID(config.FilterRepository)
+	win.CmbBoxLang.SetActiveID(config.FilterLang)
+	win.ChckBtnInstalled.SetActive(config.FilterInstalled)
+
+	win.refreshGames()
+
+	win.CmbBoxRepo.SetSensitive(true)
+	win.CmbBoxLang.SetSensitive(true)
+	win.ChckBtnInstalled.SetSensitive(true)
+}
+
+// rememberFilter keeps filter values in the config, it's saved on closing window
+func (win *MainWindow) rememberFilter() {
+	win.Configurator.Set("gtk.filter_repository", win.CmbBoxRepo.GetActiveID())
+	win.Configurator.Set("gtk.filter_lang", win.CmbBoxLang.GetActiveID())
+	win.Configurator.Set("gtk.filter_installed", win.ChckBtnInstalled.GetActive())
+}
+
 func (win *MainWindow) updateGameInfo(g *manager.Game) {
 	if g == nil {
 		return
@@ -477,61 +679,139 @@ func (win *MainWindow) updateGameInfo(g *manager.Game) {
 		win.BtnGameUpdate.Hide()
 	}
 
-	// Image
-	go func() {
-		win.updateGameImage(g)
-	}()
+	win.updateGameProgress(g)
+
+	win.updateGameImage(g)
 }
 
-func (win *MainWindow) updateGameImage(g *manager.Game) {
-	gameImagePath, e := win.Manager.GetGameImage(g)
-	if e == nil && gameImagePath != "" {
-		win.PixBufGameImage, e = gdk.PixbufNewFromFileAtScale(gameImagePath, 210, 210, true) // todo: size to constants
-		if e == nil {
-			_, e := glib.IdleAdd(func() {
-				// Set image if there is current game (user hasn't changed selected game)
-				if win.CurGame != nil && g.Id == win.CurGame.Id {
-					win.ImgGame.SetFromPixbuf(win.PixBufGameImage)
-				}
-			})
+// updateGameProgress shows installing progress of the game if it's installing now
+func (win *MainWindow) updateGameProgress(g *manager.Game) {
+	installing, ok := win.installings[g.Id]
 
-			if e != nil {
-				log.Fatal("Change game image. IdleAdd() failed:", e)
-			}
-		}
+	win.BtnGameInstall.SetSensitive(!ok)
+	win.BtnGameUpdate.SetSensitive(!ok)
+	win.BtnGameRemove.SetSensitive(!ok)
+
+	if !ok {
+		win.BxGameProgress.Hide()
+		return
 	}
 
-	if e != nil {
-		log.Printf("Image error: %s", e)
+	win.PrgrssBarGame.SetFraction(installing.fraction)
+	win.PrgrssBarGame.SetText(installing.text)
+	win.BtnGameCancel.SetSensitive(installing.cancel != nil)
+	win.BxGameProgress.Show()
+}
+
+// setGameProgress changes installing progress of the game, it's called in the main loop
+func (win *MainWindow) setGameProgress(g *manager.Game, fraction float64, text string) {
+	installing, ok := win.installings[g.Id]
+	if !ok {
+		return
+	}
+
+	if fraction >= 0 {
+		installing.fraction = fraction
 	}
+	if text != "" {
+		installing.text = text
+	}
+
+	if win.CurGame != nil && win.CurGame.Id == g.Id {
+		win.updateGameProgress(g)
+	}
+}
+
+// updateGameImage shows the game image. Image is downloaded and loaded in the background,
+// default image is shown till then.
+func (win *MainWindow) updateGameImage(g *manager.Game) {
+	if pixbuf, ok := win.gameImages[g.Id]; ok {
+		win.ImgGame.SetFromPixbuf(pixbuf)
+		return
+	}
+
+	win.ImgGame.SetFromPixbuf(win.PixBufGameDefaultImage)
+
+	if win.imageLoads[g.Id] {
+		return
+	}
+	win.imageLoads[g.Id] = true
+
+	go func() {
+		// Image is decoded and scaled here, main loop only shows it
+		var pixbuf *gdk.Pixbuf
+		gameImagePath, imageErr := win.Manager.GetGameImage(g)
+		if imageErr == nil && gameImagePath != "" {
+			pixbuf, imageErr = gdk.PixbufNewFromFileAtScale(gameImagePath, 210, 210, true) // todo: size to constants
+		}
 
-	if e != nil || gameImagePath == "" {
 		_, e := glib.IdleAdd(func() {
-			win.ImgGame.SetFromPixbuf(win.PixBufGameDefaultImage)
+			delete(win.imageLoads, g.Id)
+
+			if imageErr != nil || pixbuf == nil {
+				pixbuf = win.PixBufGameDefaultImage
+			}
+
+			if imageErr != nil {
+				log.Printf("Image error: %s", imageErr)
+			} else {
+				win.cacheGameImage(g.Id, pixbuf)
+			}
+
+			// Set image if there is current game (user hasn't changed selected game)
+			if win.CurGame != nil && g.Id == win.CurGame.Id {
+				win.ImgGame.SetFromPixbuf(pixbuf)
+			}
 		})
 
 		if e != nil {
 			log.Fatal("Change game image. IdleAdd() failed:", e)
 		}
+	}()
+}
+
+// cacheGameImage keeps loaded image, cache is cleared when it's too big
+func (win *MainWindow) cacheGameImage(id string, pixbuf *gdk.Pixbuf) {
+	const maxCachedImages = 100
+
+	if len(win.gameImages) >= maxCachedImages {
+		win.gameImages = make(map[string]*gdk.Pixbuf)
 	}
+	win.gameImages[id] = pixbuf
 }
 
 func (win *MainWindow) runGame(g *manager.Game) {
-	if win.Manager.InterpreterCommand() == "" {
+	if win.Manager.InterpreterCommand() == "" && win.Manager.WebRunnerDir() == "" {
 		ShowErrorDlg(i18n.T("INSTEAD has not found. Please add INSTEAD in the Settings."), win.Window)
 		return
 	}
 
-	if win.CurGame == nil {
+	if g == nil {
 		ShowErrorDlg(i18n.T("No running. No game selected."), win.Window)
 		return
 	}
 
-	win.Manager.RunGame(g)
+	e := win.Backend.RunGame(g)
+	if e != nil {
+		ShowErrorDetailsDlg(i18n.T("Game hasn't run."), e, win.Window)
+		return
+	}
 	log.Printf("Running %s (%s) game...", g.Title, g.Name)
+
+	win.Configurator.Set("gtk.recent_games", addRecentGame(win.Manager.Config().Gtk.RecentGames, g.Id))
+	win.Configurator.SaveConfig(win.Manager.Config())
 }
 
-func (win *MainWindow) installGame(g *manager.Game, instBtn *gtk.Button) {
+func (win *MainWindow) installGame(g *manager.Game) {
+	win.installOrUpdateGame(g, false)
+}
+
+// updateGame installs new version of the game, installed version is kept if updating fails
+func (win *MainWindow) updateGame(g *manager.Game) {
+	win.installOrUpdateGame(g, true)
+}
+
+func (win *MainWindow) installOrUpdateGame(g *manager.Game, update bool) {
 	if win.Manager.InterpreterCommand() == "" {
 		ShowErrorDlg(i18n.T("INSTEAD has not found. Please add INSTEAD in the Settings."), win.Window)
 		return
@@ -542,9 +822,10 @@ func (win *MainWindow) installGame(g *manager.Game, instBtn *gtk.Button) {
 		return
 	}
 
-	if instBtn != nil {
-		instBtn.SetSensitive(false)
+	if _, ok := win.installings[g.Id]; ok {
+		return
 	}
+
 	log.Printf("Installing %s (%s) game...", g.Title, g.Name)
 
 	// Set installing status in the list
@@ -552,35 +833,67 @@ func (win *MainWindow) installGame(g *manager.Game, instBtn *gtk.Button) {
 	if e != nil {
 		log.Fatalf("Error: %v", e)
 	}
-	win.ListStoreGames.SetValue(iter, gameColumnSizeHuman, fmt.Sprintf(i18n.T("%s Installing..."),
-		g.HumanSize()))
+	statusTxt, progressTxt := i18n.T("%s Installing..."), i18n.T("%s %s Installing...")
+	if update {
+		statusTxt, progressTxt = i18n.T("%s Updating..."), i18n.T("%s %s Updating...")
+	}
+	win.ListStoreGames.SetValue(iter, gameColumnSizeHuman, fmt.Sprintf(statusTxt, g.HumanSize()))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	win.installings[g.Id] = &gameInstalling{cancel: cancel}
+	win.setGameProgress(g, 0, fmt.Sprintf(i18n.T("Downloading %s..."), g.Title))
 
 	installProgress := func(size uint64) {
 		percents := utils.Percents(size, uint64(g.Size))
 		glib.IdleAdd(func() {
-			win.ListStoreGames.SetValue(iter, gameColumnSizeHuman, fmt.Sprintf(i18n.T("%s %s Installing..."),
-				g.HumanSize(), percents))
+			win.ListStoreGames.SetValue(iter, gameColumnSizeHuman, fmt.Sprintf(progressTxt, g.HumanSize(), percents))
+			if g.Size > 0 {
+				win.setGameProgress(g, float64(size)/float64(g.Size), "")
+			}
+		})
+	}
+
+	installPhase := func(phase manager.InstallPhase) {
+		if phase != manager.InstallPhaseExtract {
+			return
+		}
+		glib.IdleAdd(func() {
+			win.setGameProgress(g, 1, fmt.Sprintf(i18n.T("Extracting %s..."), g.Title))
 		})
 	}
 
 	go func() {
 		instGame := g
-		instErr := win.Manager.InstallGame(instGame, installProgress)
+		var instErr error
+		if update {
+			instErr = win.Backend.UpdateGameContext(ctx, instGame, installProgress, installPhase)
+		} else {
+			instErr = win.Backend.InstallGameContext(ctx, instGame, installProgress, installPhase)
+		}
+		cancel()
 
 		if instErr == nil {
 			log.Print("Game has installed.")
+		} else if instErr == context.Canceled {
+			log.Print("Game installing has cancelled.")
 		}
 
 		_, e := glib.IdleAdd(func() {
-			if instErr != nil {
-				ShowErrorDlg(
-					fmt.Sprintf(i18n.T("Game hasn't installed (%s). Please check INSTEAD in the Settings."),
-						instErr.Error()), win.Window)
+			delete(win.installings, instGame.Id)
+			if win.CurGame != nil && win.CurGame.Id == instGame.Id {
+				win.updateGameProgress(win.CurGame)
 			}
-			win.refreshSeveralGames([]manager.Game{*instGame})
 
-			if instBtn != nil {
-				instBtn.SetSensitive(true)
+			if instErr != nil && instErr != context.Canceled && update {
+				ShowErrorDetailsDlg(i18n.T("Game hasn't updated. Installed version is kept."), instErr, win.Window)
+			} else if instErr != nil && instErr != context.Canceled {
+				ShowErrorDetailsDlg(i18n.T("Game hasn't installed. Please check INSTEAD in the Settings."),
+					instErr, win.Window)
+			}
+
+			// Installed game is refreshed by the GameInstalled event, progress is removed from the failed one
+			if instErr != nil {
+				win.refreshSeveralGames([]manager.Game{*instGame})
 			}
 		})
 
@@ -590,6 +903,27 @@ func (win *MainWindow) installGame(g *manager.Game, instBtn *gtk.Button) {
 	}()
 }
 
+// cancelGameInstalling stops installing (or updating) of the game
+func (win *MainWindow) cancelGameInstalling(g *manager.Game) {
+	installing, ok := win.installings[g.Id]
+	if !ok || installing.cancel == nil {
+		return
+	}
+
+	log.Printf("Cancelling installing of %s (%s) game...", g.Title, g.Name)
+	installing.cancel()
+	installing.cancel = nil
+	win.setGameProgress(g, -1, fmt.Sprintf(i18n.T("Cancelling %s..."), g.Title))
+}
+
+// Refresh reads games and filter values again (after updating repositories or changing config)
+func (win *MainWindow) Refresh() {
+	win.clearFilterValues()
+	win.refreshGames()
+	win.refreshFilterValues()
+	win.restoreFilter()
+}
+
 func (win *MainWindow) updateRepositories() {
 	win.ScrWndGames.Hide()
 	win.SpinnerGames.Show()
@@ -597,18 +931,20 @@ func (win *MainWindow) updateRepositories() {
 
 	log.Print("Updating repositories...")
 
+	// Scheduler doesn't refresh repositories again until the next interval
+	if jobs != nil {
+		jobs.Touch(manager.JobRepositories)
+	}
+
 	go func() {
-		errors := win.Manager.UpdateRepositories()
+		errors := win.Backend.UpdateRepositories()
 		for _, e := range errors {
 			log.Printf("Update repository error: %s", e.Error())
 		}
 		log.Print("Repositories have updated.")
 
+		// Games are refreshed by the RepositoriesUpdated event
 		_, e := glib.IdleAdd(func() {
-			win.clearFilterValues()
-			win.refreshGames()
-			win.refreshFilterValues()
-
 			win.ScrWndGames.Show()
 			win.SpinnerGames.Hide()
 			win.BtnUpdate.SetSensitive(true)
@@ -629,17 +965,33 @@ func (h *MainWindowHandlers) updateClicked() {
 	h.win.updateRepositories()
 }
 
-func (h *MainWindowHandlers) keywordChanged(s *gtk.Entry) {
+func (h *MainWindowHandlers) searchToggled(s *gtk.ToggleButton) {
+	if s.GetActive() {
+		h.win.EntryKeyword.Show()
+		h.win.EntryKeyword.GrabFocus()
+		return
+	}
+
+	h.win.EntryKeyword.Hide()
+	h.win.EntryKeyword.SetText("")
+}
+
+func (h *MainWindowHandlers) keywordChanged(s *gtk.SearchEntry) {
 	if !s.IsSensitive() {
 		return
 	}
 	h.win.refreshGames()
 }
 
+func (h *MainWindowHandlers) searchStopped(s *gtk.SearchEntry) {
+	h.win.TgglBtnSearch.SetActive(false)
+}
+
 func (h *MainWindowHandlers) repoChanged(s *gtk.ComboBox) {
 	if !s.IsSensitive() {
 		return
 	}
+	h.win.rememberFilter()
 	h.win.refreshGames()
 }
 
@@ -647,6 +999,7 @@ func (h *MainWindowHandlers) langChanged(s *gtk.ComboBox) {
 	if !s.IsSensitive() {
 		return
 	}
+	h.win.rememberFilter()
 	h.win.refreshGames()
 }
 
@@ -654,6 +1007,7 @@ func (h *MainWindowHandlers) installedClicked(s *gtk.CheckButton) {
 	if !s.IsSensitive() {
 		return
 	}
+	h.win.rememberFilter()
 	h.win.refreshGames()
 }
 
@@ -668,11 +1022,11 @@ func (h *MainWindowHandlers) gameRowActivated() {
 
 	if !h.win.CurGame.Installed {
 		if h.win.BtnGameInstall.IsSensitive() {
-			h.win.installGame(h.win.CurGame, h.win.BtnGameInstall)
+			h.win.installGame(h.win.CurGame)
 		}
 	} else if h.win.CurGame.IsUpdateAvailable() {
 		if h.win.BtnGameUpdate.IsSensitive() {
-			h.win.installGame(h.win.CurGame, h.win.BtnGameUpdate)
+			h.win.updateGame(h.win.CurGame)
 		}
 	} else {
 		h.win.runGame(h.win.CurGame)
@@ -684,6 +1038,8 @@ func (h *MainWindowHandlers) gameChanged(s *gtk.TreeSelection) {
 		return
 	}
 
+	h.win.updateSelectedActions()
+
 	iter, e := gtkutils.FindFirstIterInTreeSelection(h.win.ListStoreGames, s)
 	if e != nil {
 		log.Printf("Error: %v", e)
@@ -721,11 +1077,27 @@ func (h *MainWindowHandlers) runGameClicked() {
 func (h *MainWindowHandlers) installGameClicked(s *gtk.Button) {
 	// todo: CurGame as parameter
 
-	h.win.installGame(h.win.CurGame, s)
+	h.win.installGame(h.win.CurGame)
 }
 
 func (h *MainWindowHandlers) updateGameClicked(s *gtk.Button) {
-	h.win.installGame(h.win.CurGame, s)
+	h.win.updateGame(h.win.CurGame)
+}
+
+func (h *MainWindowHandlers) installSelectedClicked(s *gtk.Button) {
+	h.win.processSelectedGames(manager.QueueInstall)
+}
+
+func (h *MainWindowHandlers) removeSelectedClicked(s *gtk.Button) {
+	h.win.processSelectedGames(manager.QueueRemove)
+}
+
+func (h *MainWindowHandlers) cancelGameClicked(s *gtk.Button) {
+	if h.win.CurGame == nil {
+		return
+	}
+
+	h.win.cancelGameInstalling(h.win.CurGame)
 }
 
 func (h *MainWindowHandlers) removeGameClicked(s *gtk.Button) {
@@ -745,11 +1117,18 @@ func (h *MainWindowHandlers) removeGameClicked(s *gtk.Button) {
 
 	go func() {
 		rmGame := h.win.CurGame
-		h.win.Manager.RemoveGame(rmGame)
-		log.Print("Game has removed.")
+		rmErr := h.win.Backend.RemoveGame(rmGame)
+		if rmErr == nil {
+			log.Print("Game has removed.")
+		} else {
+			log.Printf("Removing game error: %v", rmErr)
+		}
 
+		// Removed game is refreshed by the GameRemoved event
 		_, e := glib.IdleAdd(func() {
-			h.win.refreshSeveralGames([]manager.Game{*rmGame})
+			if rmErr != nil {
+				h.win.refreshSeveralGames([]manager.Game{*rmGame})
+			}
 			s.SetSensitive(true)
 		})
 
@@ -767,8 +1146,8 @@ func (h *MainWindowHandlers) sortingResetActivated() {
 func (h *MainWindowHandlers) sideBarToggled(s *gtk.CheckMenuItem) {
 	showSideBar := s.GetActive()
 	h.win.toggleSideBar(showSideBar)
-	h.win.Manager.Config.Gtk.HideSidebar = !showSideBar
-	h.win.Configurator.SaveConfig(h.win.Manager.Config)
+	h.win.Configurator.Set("gtk.hide_sidebar", !showSideBar)
+	h.win.Configurator.SaveConfig(h.win.Manager.Config())
 }
 
 func (h *MainWindowHandlers) settingsActivated() {
@@ -783,10 +1162,31 @@ func (h *MainWindowHandlers) windowDestroyed() {
 	gtk.MainQuit()
 }
 
-func (h *MainWindowHandlers) mainDeleted() {
+func (h *MainWindowHandlers) mainDeleted() bool {
 	width, height := h.win.Window.GetSize()
 
-	h.win.Manager.Config.Gtk.MainWidth = width
-	h.win.Manager.Config.Gtk.MainHeight = height
-	h.win.Configurator.SaveConfig(h.win.Manager.Config)
+	h.win.Configurator.Set("gtk.main_width", width)
+	h.win.Configurator.Set("gtk.main_height", height)
+	h.win.Configurator.SaveConfig(h.win.Manager.Config())
+
+	// Keep running in the tray
+	if h.win.Manager.Config().Gtk.MinimizeToTray && TrayIcon.available() {
+		h.win.Window.Hide()
+		return true
+	}
+
+	return false
+}
+
+func (h *MainWindowHandlers) windowStateChanged(s *gtk.Window, event *gdk.Event) bool {
+	stateEvent := gdk.EventWindowStateNewFromEvent(event)
+	iconified := stateEvent.ChangedMask()&gdk.WINDOW_STATE_ICONIFIED != 0 &&
+		stateEvent.NewWindowState()&gdk.WINDOW_STATE_ICONIFIED != 0
+
+	if iconified && h.win.Manager.Config().Gtk.MinimizeToTray && TrayIcon.available() {
+		s.Hide()
+		s.Deiconify()
+	}
+
+	return false
 }
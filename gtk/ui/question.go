@@ -0,0 +1,42 @@
+package ui
+
+import (
+	"github.com/gotk3/gotk3/gtk"
+	"github.com/jhekasoft/insteadman3/gtk/i18n"
+	"github.com/jhekasoft/insteadman3/gtk/osintegration"
+)
+
+// ShowQuestionDlg shows dialog with Yes/No buttons and returns true if Yes has been pressed
+func ShowQuestionDlg(txt string, parent *gtk.Window) bool {
+	dlg, _ := gtk.DialogNew()
+	dlg.SetTitle("InsteadMan")
+	dlg.AddButton(i18n.T("No"), gtk.RESPONSE_NO)
+	dlg.AddButton(i18n.T("Yes"), gtk.RESPONSE_YES)
+	dlg.SetDefaultResponse(gtk.RESPONSE_YES)
+	dlgBox, _ := dlg.GetContentArea()
+	dlgBox.SetSpacing(6)
+
+	lbl, _ := gtk.LabelNew(txt)
+	lbl.SetMarginStart(6)
+	lbl.SetMarginEnd(6)
+	lbl.SetLineWrap(true)
+	dlgBox.Add(lbl)
+	lbl.Show()
+
+	dlg.SetModal(true)
+	dlg.SetPosition(gtk.WIN_POS_CENTER)
+	dlg.SetResizable(false)
+
+	if parent != nil {
+		dlg.SetTransientFor(parent)
+	}
+	dlg.SetKeepAbove(true)
+
+	// OS integrations for window
+	osintegration.OsIntegrateDialog(dlg)
+
+	response := dlg.Run()
+	dlg.Destroy()
+
+	return response == int(gtk.RESPONSE_YES)
+}
@@ -0,0 +1,38 @@
+package ui
+
+import (
+	"context"
+	"log"
+
+	"github.com/gotk3/gotk3/glib"
+	"github.com/jhekasoft/insteadman3/core/manager"
+	"github.com/jhekasoft/insteadman3/core/scheduler"
+)
+
+var jobs *scheduler.Scheduler
+
+// StartScheduler runs periodic jobs of the manager (repositories refresh, cache eviction, saves backups
+// and statistics) while InsteadMan is running. Repositories are refreshed by the main window, so
+// the games list is updated too.
+func StartScheduler(m *manager.Manager) {
+	jobs = scheduler.New(m.SchedulerStateFile())
+	jobs.OnError = func(job string, e error) {
+		log.Printf("Job %s error: %v", job, e)
+	}
+
+	for _, job := range m.Jobs() {
+		if job.Name == manager.JobRepositories {
+			job.Run = func(ctx context.Context) error {
+				_, e := glib.IdleAdd(func() {
+					if MainWin != nil {
+						MainWin.updateRepositories()
+					}
+				})
+				return e
+			}
+		}
+		jobs.Add(job)
+	}
+
+	jobs.Start()
+}
@@ -0,0 +1,54 @@
+package ui
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/gotk3/gotk3/glib"
+	"github.com/gotk3/gotk3/gtk"
+	"github.com/jhekasoft/insteadman3/core/manager"
+	"github.com/jhekasoft/insteadman3/core/selfupdate"
+	"github.com/jhekasoft/insteadman3/core/utils"
+	"github.com/jhekasoft/insteadman3/gtk/i18n"
+)
+
+// CheckForAppUpdateOnStart checks the new InsteadMan release in the background (if it's enabled in the config)
+// and offers to open its page. Every release is offered once.
+func CheckForAppUpdateOnStart(m *manager.Manager, version string, parent *gtk.Window) {
+	if !m.Config().CheckUpdateOnStart {
+		return
+	}
+
+	checker := &selfupdate.Checker{CacheDir: m.CacheDir()}
+
+	go func() {
+		update, updateErr := checker.CheckForAppUpdate(version, false)
+		if updateErr != nil {
+			log.Printf("New version checking error: %v", updateErr)
+			return
+		}
+		if !update.Available || update.Notified {
+			return
+		}
+
+		log.Printf("New version %s is available", update.Release.Version)
+
+		_, e := glib.IdleAdd(func() {
+			checker.MarkNotified(update.Release)
+
+			txt := fmt.Sprintf(i18n.T("New InsteadMan %s is available. Open download page?"), update.Release.Version)
+			if !ShowQuestionDlg(txt, parent) {
+				return
+			}
+
+			e := utils.OpenBrowser(update.Release.Url)
+			if e != nil {
+				ShowErrorDlg(e.Error(), parent)
+			}
+		})
+
+		if e != nil {
+			log.Fatal("New version checking. IdleAdd() failed:", e)
+		}
+	}()
+}
@@ -1,23 +1,27 @@
 package ui
 
 import (
+	"errors"
 	"fmt"
 	"log"
+	"os"
 
 	"github.com/gotk3/gotk3/glib"
 	"github.com/gotk3/gotk3/gtk"
 	"github.com/jhekasoft/insteadman3/core/configurator"
+	"github.com/jhekasoft/insteadman3/core/fileassoc"
 	"github.com/jhekasoft/insteadman3/core/manager"
+	"github.com/jhekasoft/insteadman3/core/utils"
 	"github.com/jhekasoft/insteadman3/gtk/i18n"
 	"github.com/jhekasoft/insteadman3/gtk/osintegration"
 	gtkutils "github.com/jhekasoft/insteadman3/gtk/utils"
 )
 
 const (
-	settingsFormFilePath = "resources/gtk/settings.glade"
-	aboutTabNum          = 2
-	RepositoryColumnName = 0
-	RepositoryColumnUrl  = 1
+	settingsFormFilePath    = "resources/gtk/settings.glade"
+	RepositoryColumnName    = 0
+	RepositoryColumnUrl     = 1
+	RepositoryColumnEnabled = 2
 )
 
 var (
@@ -35,19 +39,6 @@ func ShowSettingWin(manager *manager.Manager, configurator *configurator.Configu
 	SettingsWin.Window.Present()
 }
 
-func ShowAboutWin(manager *manager.Manager, configurator *configurator.Configurator, version string,
-	parent *gtk.Window) {
-
-	GetSettings(manager, configurator, version)
-	if parent != nil {
-		SettingsWin.Window.SetTransientFor(parent)
-	}
-	SettingsWin.Window.Show()
-	SettingsWin.Window.Present()
-
-	SettingsWin.NtbkCategories.SetCurrentPage(aboutTabNum)
-}
-
 // Singleton
 func GetSettings(manager *manager.Manager, configurator *configurator.Configurator, version string) *SettingsWindow {
 	if SettingsWin != nil && SettingsWin.Window.IsVisible() {
@@ -73,22 +64,41 @@ type SettingsWindow struct {
 	ListStoreLanguage *gtk.ListStore
 	CmbBoxLanguage    *gtk.ComboBox
 
-	BtnCacheClear *gtk.Button
-	LblCacheInf   *gtk.Label
+	BtnCacheClear  *gtk.Button
+	BtnCacheChange *gtk.Button
+	LblCacheInf    *gtk.Label
+
+	LblGamesPath *gtk.Label
+	BtnGamesMove *gtk.Button
+	LblGamesInf  *gtk.Label
 
 	LblConfigPath *gtk.Label
 
+	ChckBtnStatusIcon     *gtk.CheckButton
+	ChckBtnMinimizeToTray *gtk.CheckButton
+	ChckBtnCrashReports   *gtk.CheckButton
+	ChckBtnCheckUpdate    *gtk.CheckButton
+	ChckBtnShortcuts      *gtk.CheckButton
+	ChckBtnFileAssoc      *gtk.CheckButton
+	ChckBtnTelemetry      *gtk.CheckButton
+	BtnTelemetryPreview   *gtk.Button
+	ChckBtnNotifications  *gtk.CheckButton
+	ChckBtnSavesBackup    *gtk.CheckButton
+
 	LblVersion *gtk.Label
 
 	ListStoreRepositories   *gtk.ListStore
 	TrSlctnRepositories     *gtk.TreeSelection
 	CllRndrTxtName          *gtk.CellRendererText
 	CllRndrTxtUrl           *gtk.CellRendererText
+	CllRndrTgglEnabled      *gtk.CellRendererToggle
 	BtnRepositoriesAdd      *gtk.Button
 	BtnRepositoriesRemove   *gtk.Button
 	BtnRepositoriesUp       *gtk.Button
 	BtnRepositoriesDown     *gtk.Button
 	BtnRepositoriesDefaults *gtk.Button
+	BtnRepositoriesTest     *gtk.Button
+	LblRepositoriesInf      *gtk.Label
 
 	BtnClose *gtk.Button
 
@@ -136,10 +146,26 @@ func SettingsWindowNew(manager *manager.Manager, configurator *configurator.Conf
 	win.CmbBoxLanguage = gtkutils.GetComboBox(b, "combobox_language")
 
 	win.BtnCacheClear = gtkutils.GetButton(b, "button_cache_clear")
+	win.BtnCacheChange = gtkutils.GetButton(b, "button_cache_change")
 	win.LblCacheInf = gtkutils.GetLabel(b, "label_cache_inf")
 
+	win.LblGamesPath = gtkutils.GetLabel(b, "label_games_path")
+	win.BtnGamesMove = gtkutils.GetButton(b, "button_games_move")
+	win.LblGamesInf = gtkutils.GetLabel(b, "label_games_inf")
+
 	win.LblConfigPath = gtkutils.GetLabel(b, "label_config_path")
 
+	win.ChckBtnStatusIcon = gtkutils.GetCheckButton(b, "checkbutton_status_icon")
+	win.ChckBtnMinimizeToTray = gtkutils.GetCheckButton(b, "checkbutton_minimize_to_tray")
+	win.ChckBtnCrashReports = gtkutils.GetCheckButton(b, "checkbutton_crash_reports")
+	win.ChckBtnCheckUpdate = gtkutils.GetCheckButton(b, "checkbutton_check_update")
+	win.ChckBtnShortcuts = gtkutils.GetCheckButton(b, "checkbutton_shortcuts")
+	win.ChckBtnFileAssoc = gtkutils.GetCheckButton(b, "checkbutton_file_associations")
+	win.ChckBtnTelemetry = gtkutils.GetCheckButton(b, "checkbutton_telemetry")
+	win.BtnTelemetryPreview = gtkutils.GetButton(b, "button_telemetry_preview")
+	win.ChckBtnNotifications = gtkutils.GetCheckButton(b, "checkbutton_notifications")
+	win.ChckBtnSavesBackup = gtkutils.GetCheckButton(b, "checkbutton_saves_backup")
+
 	// Repositories tab
 	win.ListStoreRepositories = gtkutils.GetListStore(b, "liststore_repositories")
 	win.CllRndrTxtName = gtkutils.GetCellRendererText(b, "cellrenderertext_repositories_name")
@@ -148,7 +174,10 @@ func SettingsWindowNew(manager *manager.Manager, configurator *configurator.Conf
 	win.BtnRepositoriesRemove = gtkutils.GetButton(b, "button_repositories_remove")
 	win.BtnRepositoriesUp = gtkutils.GetButton(b, "button_repositories_up")
 	win.BtnRepositoriesDown = gtkutils.GetButton(b, "button_repositories_down")
+	win.CllRndrTgglEnabled = gtkutils.GetCellRendererToggle(b, "cellrenderertoggle_repositories_enabled")
 	win.BtnRepositoriesDefaults = gtkutils.GetButton(b, "button_repositories_defaults")
+	win.BtnRepositoriesTest = gtkutils.GetButton(b, "button_repositories_test")
+	win.LblRepositoriesInf = gtkutils.GetLabel(b, "label_repositories_inf")
 	treeViewRepositories := gtkutils.GetTreeView(b, "treeview_repositories")
 	win.TrSlctnRepositories, e = treeViewRepositories.GetSelection()
 	if e != nil {
@@ -171,15 +200,29 @@ func SettingsWindowNew(manager *manager.Manager, configurator *configurator.Conf
 	win.BtnInsteadDetect.Connect("clicked", handlers.insteadDetectClicked)
 	win.BtnInsteadCheck.Connect("clicked", handlers.insteadCheckClicked)
 	win.BtnCacheClear.Connect("clicked", handlers.cacheClearClicked)
+	win.BtnCacheChange.Connect("clicked", handlers.cacheChangeClicked)
+	win.BtnGamesMove.Connect("clicked", handlers.gamesMoveClicked)
 	win.CmbBoxLanguage.Connect("changed", handlers.languageChanged)
+	win.ChckBtnStatusIcon.Connect("toggled", handlers.statusIconToggled)
+	win.ChckBtnMinimizeToTray.Connect("toggled", handlers.minimizeToTrayToggled)
+	win.ChckBtnCrashReports.Connect("toggled", handlers.crashReportsToggled)
+	win.ChckBtnCheckUpdate.Connect("toggled", handlers.checkUpdateToggled)
+	win.ChckBtnShortcuts.Connect("toggled", handlers.shortcutsToggled)
+	win.ChckBtnFileAssoc.Connect("toggled", handlers.fileAssocToggled)
+	win.ChckBtnTelemetry.Connect("toggled", handlers.telemetryToggled)
+	win.BtnTelemetryPreview.Connect("clicked", handlers.telemetryPreviewClicked)
+	win.ChckBtnNotifications.Connect("toggled", handlers.notificationsToggled)
+	win.ChckBtnSavesBackup.Connect("toggled", handlers.savesBackupToggled)
 	//win.TrSlctnRepositories.Connect("changed", handlers.repositoriesChanged)
 	win.CllRndrTxtName.Connect("edited", handlers.repositoriesNameEdited)
 	win.CllRndrTxtUrl.Connect("edited", handlers.repositoriesUrlEdited)
+	win.CllRndrTgglEnabled.Connect("toggled", handlers.repositoriesEnabledToggled)
 	win.BtnRepositoriesAdd.Connect("clicked", handlers.repositoryAddClicked)
 	win.BtnRepositoriesRemove.Connect("clicked", handlers.repositoryRemoveClicked)
 	win.BtnRepositoriesUp.Connect("clicked", handlers.repositoryUpClicked)
 	win.BtnRepositoriesDown.Connect("clicked", handlers.repositoryDownClicked)
 	win.BtnRepositoriesDefaults.Connect("clicked", handlers.repositoryDefaultsClicked)
+	win.BtnRepositoriesTest.Connect("clicked", handlers.repositoryTestClicked)
 	win.BtnClose.Connect("clicked", handlers.closeClicked)
 	win.Window.Connect("delete_event", handlers.settingsDeleted)
 
@@ -192,14 +235,14 @@ func SettingsWindowNew(manager *manager.Manager, configurator *configurator.Conf
 }
 
 func (win *SettingsWindow) readSettings() {
-	config := win.Manager.Config
+	config := win.Manager.Config()
 
 	// INSTEAD
 	win.EntryInstead.SetText(config.InterpreterCommand)
 	haveBuiltInInstead := win.Manager.InterpreterFinder.HaveBuiltIn()
 	win.TglBtnInsteadBuiltin.SetSensitive(haveBuiltInInstead)
 	if !haveBuiltInInstead {
-		win.TglBtnInsteadBuiltin.SetTooltipText("Built-in INSTEAD hasn't found")
+		win.TglBtnInsteadBuiltin.SetTooltipText(i18n.T("Built-in INSTEAD hasn't found"))
 	}
 	win.TglBtnInsteadBuiltin.SetActive(config.UseBuiltinInterpreter)
 	win.toggleBuiltin(!config.UseBuiltinInterpreter || !win.Manager.InterpreterFinder.HaveBuiltIn())
@@ -212,13 +255,42 @@ func (win *SettingsWindow) readSettings() {
 	// Cache
 	win.BtnCacheClear.SetTooltipText(fmt.Sprintf(i18n.T("Cache directory: %s"), win.Manager.CacheDir()))
 
+	// Games
+	win.LblGamesPath.SetText(config.CalculatedGamesPath)
+	win.LblGamesPath.SetTooltipText(config.CalculatedGamesPath)
+
 	// Config path
 	win.LblConfigPath.SetText(win.Configurator.FilePath)
 
+	// Tray
+	win.ChckBtnStatusIcon.SetActive(config.Gtk.StatusIcon)
+	win.ChckBtnMinimizeToTray.SetActive(config.Gtk.MinimizeToTray)
+	win.ChckBtnMinimizeToTray.SetSensitive(config.Gtk.StatusIcon)
+
+	// Crash reports
+	win.ChckBtnCrashReports.SetActive(config.CrashReports)
+
+	// Updates
+	win.ChckBtnCheckUpdate.SetActive(config.CheckUpdateOnStart)
+
+	// Shortcuts and files
+	win.ChckBtnShortcuts.SetActive(config.Shortcuts)
+	win.ChckBtnFileAssoc.SetActive(config.FileAssociations)
+	win.ChckBtnFileAssoc.SetSensitive(fileassoc.Supported())
+
+	// Statistics
+	win.ChckBtnTelemetry.SetActive(config.Telemetry)
+
+	// Notifications
+	win.ChckBtnNotifications.SetActive(config.Notifications)
+
+	// Saves backups
+	win.ChckBtnSavesBackup.SetActive(config.SavesBackup)
+
 	// Repositories
 	win.ListStoreRepositories.Clear()
-	for _, repo := range win.Manager.Config.Repositories {
-		addToListStoreRepositories(win.ListStoreRepositories, repo.Name, repo.Url)
+	for _, repo := range config.Repositories {
+		addToListStoreRepositories(win.ListStoreRepositories, repo.Name, repo.Url, !repo.Disabled)
 	}
 }
 
@@ -228,12 +300,26 @@ func (win *SettingsWindow) setRepositoriesConfigFromListStore() {
 		ShowErrorDlg(e.Error(), win.Window)
 		return
 	}
-	win.Manager.Config.Repositories = repos
+
+	// Statistics URLs aren't edited in the list, they are kept by the repository URL
+	for i := range repos {
+		for _, repo := range win.Manager.Config().Repositories {
+			if repo.Url == repos[i].Url {
+				repos[i].StatsUrl = repo.StatsUrl
+			}
+		}
+	}
+
+	e = win.Configurator.Set("repositories", repos)
+	if e != nil {
+		ShowErrorDlg(e.Error(), win.Window)
+	}
 }
 
-func addToListStoreRepositories(ls *gtk.ListStore, name, url string) (iter *gtk.TreeIter) {
+func addToListStoreRepositories(ls *gtk.ListStore, name, url string, enabled bool) (iter *gtk.TreeIter) {
 	iter = new(gtk.TreeIter)
-	ls.InsertWithValues(iter, -1, []int{RepositoryColumnName, RepositoryColumnUrl}, []interface{}{name, url})
+	ls.InsertWithValues(iter, -1, []int{RepositoryColumnName, RepositoryColumnUrl, RepositoryColumnEnabled},
+		[]interface{}{name, url, enabled})
 	return iter
 }
 
@@ -249,6 +335,7 @@ func configRepositoriesFromListStore(ls *gtk.ListStore) (repositories []configur
 	var (
 		value     *glib.Value
 		name, url string
+		enabled   interface{}
 	)
 
 	// Collect repositories from list store
@@ -271,9 +358,18 @@ func configRepositoriesFromListStore(ls *gtk.ListStore) (repositories []configur
 			return
 		}
 
+		value, e = ls.GetValue(iter, RepositoryColumnEnabled)
+		if e != nil {
+			return
+		}
+		enabled, e = value.GoValue()
+		if e != nil {
+			return
+		}
+
 		// Add non-empty repositories
 		if name != "" && url != "" {
-			repositories = append(repositories, configurator.Repository{Name: name, Url: url})
+			repositories = append(repositories, configurator.Repository{Name: name, Url: url, Disabled: enabled != true})
 		}
 
 		if !ls.IterNext(iter) {
@@ -291,7 +387,7 @@ type SettingsWindowHandlers struct {
 func (h *SettingsWindowHandlers) insteadChanged(s *gtk.Entry) {
 	value, e := s.GetText()
 	if e == nil {
-		h.win.Manager.Config.InterpreterCommand = value
+		h.win.Configurator.Set("interpreter_command", value)
 	}
 }
 
@@ -317,7 +413,7 @@ func (h *SettingsWindowHandlers) insteadBrowseClicked(s *gtk.Button) {
 
 /* Handlers */
 func (h *SettingsWindowHandlers) insteadBuiltinClicked(s *gtk.ToggleButton) {
-	h.win.Manager.Config.UseBuiltinInterpreter = s.GetActive()
+	h.win.Configurator.Set("use_builtin_interpreter", s.GetActive())
 	h.win.readSettings()
 }
 
@@ -326,10 +422,20 @@ func (h *SettingsWindowHandlers) insteadDetectClicked(s *gtk.Button) {
 	h.win.LblInsteadInf.Hide()
 
 	go func() {
+		// Explicit detection doesn't use discovery cache
+		h.win.Manager.InterpreterFinder.ClearCache()
 		command := h.win.Manager.InterpreterFinder.Find()
 
+		var validateErr error
+		if command != nil {
+			_, validateErr = h.win.Manager.InterpreterFinder.Validate(*command)
+		}
+
 		_, e := glib.IdleAdd(func() {
-			if command != nil {
+			if command != nil && validateErr != nil {
+				h.win.LblInsteadInf.SetText(InterpreterErrorText(validateErr))
+				h.win.LblInsteadInf.Show()
+			} else if command != nil {
 				h.win.EntryInstead.SetText(*command)
 				h.win.LblInsteadInf.SetText(i18n.T("INSTEAD has detected!"))
 				h.win.LblInsteadInf.Show()
@@ -352,7 +458,7 @@ func (h *SettingsWindowHandlers) insteadCheckClicked(s *gtk.Button) {
 	h.win.LblInsteadInf.Hide()
 
 	go func() {
-		version, checkErr := h.win.Manager.InterpreterFinder.Check(h.win.Manager.InterpreterCommand())
+		version, checkErr := h.win.Manager.InterpreterFinder.Validate(h.win.Manager.InterpreterCommand())
 
 		_, e := glib.IdleAdd(func() {
 			var txt string
@@ -362,7 +468,7 @@ func (h *SettingsWindowHandlers) insteadCheckClicked(s *gtk.Button) {
 				} else {
 					txt = i18n.T("INSTEAD check failed!")
 				}
-
+				txt += " " + InterpreterErrorText(checkErr)
 			} else {
 				txt = fmt.Sprintf(i18n.T("INSTEAD %s has found!"), version)
 			}
@@ -386,7 +492,7 @@ func (h *SettingsWindowHandlers) cacheClearClicked(s *gtk.Button) {
 		cacheErr := h.win.Manager.ClearCache()
 		_, e := glib.IdleAdd(func() {
 			if cacheErr != nil {
-				ShowErrorDlg(cacheErr.Error(), h.win.Window)
+				ShowErrorDetailsDlg(i18n.T("Cache hasn't cleared."), cacheErr, h.win.Window)
 			} else {
 				h.win.LblCacheInf.SetText(i18n.T("Cache has been cleared!"))
 			}
@@ -400,8 +506,157 @@ func (h *SettingsWindowHandlers) cacheClearClicked(s *gtk.Button) {
 		}
 	}()
 }
+
+// chooseDir shows folder chooser dialog and returns empty string if it's cancelled
+func (h *SettingsWindowHandlers) chooseDir(title string) string {
+	dlg, e := gtk.FileChooserNativeDialogNew(title, h.win.Window, gtk.FILE_CHOOSER_ACTION_SELECT_FOLDER,
+		i18n.T("Select"), i18n.T("Cancel"))
+	if e != nil {
+		ShowErrorDlg(e.Error(), h.win.Window)
+		return ""
+	}
+	defer dlg.Destroy()
+
+	if dlg.Run() != int(gtk.RESPONSE_ACCEPT) {
+		return ""
+	}
+
+	return dlg.GetFilename()
+}
+
+func (h *SettingsWindowHandlers) cacheChangeClicked(s *gtk.Button) {
+	path := h.chooseDir(i18n.T("Choose cache directory"))
+	if path == "" {
+		return
+	}
+
+	// Cache is downloaded again, so it isn't moved (calculated path is changed by Set)
+	e := h.win.Configurator.Set("cache_path", path)
+	if e != nil {
+		ShowErrorDlg(e.Error(), h.win.Window)
+		return
+	}
+
+	h.win.readSettings()
+	h.win.LblCacheInf.SetText(i18n.T("Cache directory has been changed!"))
+	h.win.LblCacheInf.Show()
+}
+
+func (h *SettingsWindowHandlers) gamesMoveClicked(s *gtk.Button) {
+	path := h.chooseDir(i18n.T("Choose games directory"))
+	if path == "" {
+		return
+	}
+
+	s.SetSensitive(false)
+	h.win.LblGamesInf.SetText(i18n.T("Moving games..."))
+	h.win.LblGamesInf.Show()
+
+	go func() {
+		// Changed config is saved right after the copying
+		moveErr := h.win.Manager.RelocateGamesDir(path, func(copied, total uint64) {
+			glib.IdleAdd(func() {
+				h.win.LblGamesInf.SetText(fmt.Sprintf(i18n.T("Moving games... %s"), utils.Percents(copied, total)))
+			})
+		}, h.win.Configurator.SaveConfig)
+
+		_, e := glib.IdleAdd(func() {
+			var cleanupErr *manager.RelocateCleanupError
+			if moveErr != nil && !errors.As(moveErr, &cleanupErr) {
+				h.win.LblGamesInf.Hide()
+				ShowErrorDetailsDlg(i18n.T("Games haven't moved."), moveErr, h.win.Window)
+			} else {
+				if cleanupErr != nil {
+					ShowErrorDetailsDlg(i18n.T("Games have been moved, but old games haven't been removed."),
+						cleanupErr, h.win.Window)
+				}
+
+				h.win.readSettings()
+				h.win.LblGamesInf.SetText(i18n.T("Games have been moved!"))
+				if MainWin != nil {
+					MainWin.refreshGames()
+				}
+			}
+
+			s.SetSensitive(true)
+		})
+
+		if e != nil {
+			log.Fatal("Games move. IdleAdd() failed:", e)
+		}
+	}()
+}
+
 func (h *SettingsWindowHandlers) languageChanged(s *gtk.ComboBox) {
-	h.win.Manager.Config.Lang = s.GetActiveID()
+	h.win.Configurator.Set("lang", s.GetActiveID())
+}
+
+func (h *SettingsWindowHandlers) statusIconToggled(s *gtk.CheckButton) {
+	h.win.Configurator.Set("gtk.status_icon", s.GetActive())
+	h.win.ChckBtnMinimizeToTray.SetSensitive(s.GetActive())
+	UpdateStatusIcon()
+}
+
+func (h *SettingsWindowHandlers) minimizeToTrayToggled(s *gtk.CheckButton) {
+	h.win.Configurator.Set("gtk.minimize_to_tray", s.GetActive())
+}
+
+func (h *SettingsWindowHandlers) crashReportsToggled(s *gtk.CheckButton) {
+	h.win.Configurator.Set("crash_reports", s.GetActive())
+}
+
+func (h *SettingsWindowHandlers) checkUpdateToggled(s *gtk.CheckButton) {
+	h.win.Configurator.Set("check_update_on_start", s.GetActive())
+}
+
+func (h *SettingsWindowHandlers) shortcutsToggled(s *gtk.CheckButton) {
+	h.win.Configurator.Set("shortcuts", s.GetActive())
+}
+
+func (h *SettingsWindowHandlers) fileAssocToggled(s *gtk.CheckButton) {
+	if s.GetActive() == h.win.Manager.Config().FileAssociations {
+		return
+	}
+
+	var e error
+	if s.GetActive() {
+		var executable string
+		executable, e = os.Executable()
+		if e == nil {
+			e = fileassoc.Register(executable)
+		}
+	} else {
+		e = fileassoc.Unregister()
+	}
+
+	if e != nil {
+		ShowErrorDetailsDlg(i18n.T("File associations haven't changed."), e, h.win.Window)
+		s.SetActive(h.win.Manager.Config().FileAssociations)
+		return
+	}
+
+	h.win.Configurator.Set("file_associations", s.GetActive())
+}
+
+func (h *SettingsWindowHandlers) telemetryToggled(s *gtk.CheckButton) {
+	h.win.Configurator.Set("telemetry", s.GetActive())
+
+	// Counts aren't kept when user doesn't want to send them
+	if !s.GetActive() && h.win.Manager.Telemetry != nil {
+		h.win.Manager.Telemetry.Clear()
+	}
+}
+
+func (h *SettingsWindowHandlers) telemetryPreviewClicked(s *gtk.Button) {
+	showTelemetryPreview(h.win.Manager, h.win.Window)
+}
+
+func (h *SettingsWindowHandlers) notificationsToggled(s *gtk.CheckButton) {
+	h.win.Configurator.Set("notifications", s.GetActive())
+}
+
+func (h *SettingsWindowHandlers) savesBackupToggled(s *gtk.CheckButton) {
+	h.win.Configurator.Set("saves_backup", s.GetActive())
 }
 
 //func (h *SettingsWindowHandlers) repositoriesChanged(s *gtk.TreeSelection) {
@@ -437,8 +692,27 @@ func (h *SettingsWindowHandlers) repositoriesUrlEdited(s *gtk.CellRendererText,
 	h.win.setRepositoriesConfigFromListStore()
 }
 
+func (h *SettingsWindowHandlers) repositoriesEnabledToggled(s *gtk.CellRendererToggle, path string) {
+	iter, e := gtkutils.GetIterFromTextPathInListStore(h.win.ListStoreRepositories, path)
+	if e != nil {
+		ShowErrorDlg(e.Error(), h.win.Window)
+		return
+	}
+
+	value, e := h.win.ListStoreRepositories.GetValue(iter, RepositoryColumnEnabled)
+	if e != nil {
+		ShowErrorDlg(e.Error(), h.win.Window)
+		return
+	}
+	enabled, _ := value.GoValue()
+
+	h.win.ListStoreRepositories.SetValue(iter, RepositoryColumnEnabled, enabled != true)
+
+	h.win.setRepositoriesConfigFromListStore()
+}
+
 func (h *SettingsWindowHandlers) repositoryAddClicked() {
-	iter := addToListStoreRepositories(h.win.ListStoreRepositories, "", "")
+	iter := addToListStoreRepositories(h.win.ListStoreRepositories, "", "", true)
 	h.win.TrSlctnRepositories.SelectIter(iter)
 
 	h.win.setRepositoriesConfigFromListStore()
@@ -500,18 +774,59 @@ func (h *SettingsWindowHandlers) repositoryDefaultsClicked() {
 		return
 	}
 
-	h.win.Manager.Config.Repositories = skeletonConfig.Repositories
+	h.win.Configurator.Set("repositories", skeletonConfig.Repositories)
 
 	h.win.readSettings()
 }
 
+func (h *SettingsWindowHandlers) repositoryTestClicked(s *gtk.Button) {
+	iter, e := gtkutils.FindFirstIterInTreeSelection(h.win.ListStoreRepositories, h.win.TrSlctnRepositories)
+	if e != nil {
+		log.Printf("Error: %v", e)
+		return
+	}
+
+	value, e := h.win.ListStoreRepositories.GetValue(iter, RepositoryColumnUrl)
+	if e != nil {
+		ShowErrorDlg(e.Error(), h.win.Window)
+		return
+	}
+	url, e := value.GetString()
+	if e != nil || url == "" {
+		return
+	}
+
+	s.SetSensitive(false)
+	h.win.LblRepositoriesInf.SetText(i18n.T("Checking..."))
+	h.win.LblRepositoriesInf.SetTooltipText(url)
+	h.win.LblRepositoriesInf.Show()
+
+	go func() {
+		count, testErr := h.win.Manager.TestRepository(url)
+
+		_, e := glib.IdleAdd(func() {
+			if testErr != nil {
+				h.win.LblRepositoriesInf.SetText(fmt.Sprintf(i18n.T("Repository error: %s"), testErr))
+			} else {
+				h.win.LblRepositoriesInf.SetText(fmt.Sprintf(i18n.T("Repository is OK, games: %d"), count))
+			}
+
+			s.SetSensitive(true)
+		})
+
+		if e != nil {
+			log.Fatal("Repository test. IdleAdd() failed:", e)
+		}
+	}()
+}
+
 func (h *SettingsWindowHandlers) closeClicked() {
 	h.win.Window.Close()
 }
 
 func (h *SettingsWindowHandlers) settingsDeleted() {
 	// Auto save
-	e := h.win.Configurator.SaveConfig(h.win.Manager.Config)
+	e := h.win.Configurator.SaveConfig(h.win.Manager.Config())
 	if e != nil {
 		ShowErrorDlg(e.Error(), h.win.Window)
 		return
@@ -0,0 +1,179 @@
+package ui
+
+import (
+	"log"
+
+	"github.com/gotk3/gotk3/gdk"
+	"github.com/gotk3/gotk3/gtk"
+	"github.com/jhekasoft/insteadman3/core/manager"
+	"github.com/jhekasoft/insteadman3/gtk/i18n"
+)
+
+const (
+	statusIconName = "insteadman"
+	maxRecentGames = 5
+)
+
+var (
+	TrayIcon *StatusIcon
+)
+
+// StatusIcon is an icon in the notification area (tray) with the menu of quick actions.
+// It's a GtkStatusIcon which is shown by the desktops with XEmbed tray or by the AppIndicator
+// extensions (through the StatusNotifier proxy).
+type StatusIcon struct {
+	Icon *gtk.StatusIcon
+	Menu *gtk.Menu
+
+	win *MainWindow
+}
+
+// UpdateStatusIcon shows or hides status icon by the gtk.status_icon config value
+func UpdateStatusIcon() {
+	if MainWin == nil {
+		return
+	}
+
+	show := MainWin.Manager.Config().Gtk.StatusIcon
+	if TrayIcon == nil {
+		if !show {
+			return
+		}
+
+		var e error
+		TrayIcon, e = StatusIconNew(MainWin)
+		if e != nil {
+			log.Printf("Status icon error: %v", e)
+			return
+		}
+	}
+
+	TrayIcon.Icon.SetVisible(show)
+
+	// Window can't be restored without the icon
+	if !show && !MainWin.Window.IsVisible() {
+		ShowExistingMainWindow(false)
+	}
+}
+
+func StatusIconNew(win *MainWindow) (*StatusIcon, error) {
+	icon, e := gtk.StatusIconNewFromIconName(statusIconName)
+	if e != nil {
+		return nil, e
+	}
+
+	menu, e := gtk.MenuNew()
+	if e != nil {
+		return nil, e
+	}
+
+	statusIcon := &StatusIcon{Icon: icon, Menu: menu, win: win}
+
+	icon.SetTitle(win.Title)
+	icon.SetTooltipText(win.Title)
+	icon.Connect("activate", statusIcon.activated)
+	icon.Connect("popup-menu", statusIcon.popupMenu)
+
+	return statusIcon, nil
+}
+
+// available returns true if icon is shown and window can be restored by it
+func (s *StatusIcon) available() bool {
+	return s != nil && s.Icon.GetVisible() && s.Icon.IsEmbedded()
+}
+
+// refreshMenu fills the menu (recent games are changed after running)
+func (s *StatusIcon) refreshMenu() {
+	if items := s.Menu.GetChildren(); items != nil {
+		items.Foreach(func(item interface{}) {
+			item.(*gtk.Widget).Destroy()
+		})
+	}
+
+	s.addMenuItem(i18n.T("Open InsteadMan"), func() {
+		ShowExistingMainWindow(false)
+	})
+	s.addMenuItem(i18n.T("Update repositories"), func() {
+		s.win.updateRepositories()
+	})
+
+	games := s.recentGames()
+	if len(games) > 0 {
+		s.addSeparator()
+		for i := range games {
+			g := games[i]
+			s.addMenuItem(g.Title, func() {
+				s.win.runGame(&g)
+			})
+		}
+	}
+
+	s.addSeparator()
+	s.addMenuItem(i18n.T("Quit"), func() {
+		gtk.MainQuit()
+	})
+
+	s.Menu.ShowAll()
+}
+
+// recentGames returns installed games of the gtk.recent_games config value
+func (s *StatusIcon) recentGames() (games []manager.Game) {
+	for _, id := range s.win.Manager.Config().Gtk.RecentGames {
+		for _, g := range s.win.Games {
+			if g.Id == id && g.Installed {
+				games = append(games, g)
+				break
+			}
+		}
+	}
+
+	return
+}
+
+func (s *StatusIcon) addMenuItem(label string, f func()) {
+	item, e := gtk.MenuItemNewWithLabel(label)
+	if e != nil {
+		log.Printf("Status icon menu error: %v", e)
+		return
+	}
+
+	item.Connect("activate", f)
+	s.Menu.Append(item)
+}
+
+func (s *StatusIcon) addSeparator() {
+	item, e := gtk.SeparatorMenuItemNew()
+	if e != nil {
+		log.Printf("Status icon menu error: %v", e)
+		return
+	}
+
+	s.Menu.Append(item)
+}
+
+// activated toggles main window
+func (s *StatusIcon) activated() {
+	if s.win.Window.IsVisible() && s.win.Window.IsActive() {
+		s.win.Window.Hide()
+		return
+	}
+
+	ShowExistingMainWindow(false)
+}
+
+func (s *StatusIcon) popupMenu(icon *gtk.StatusIcon, button uint, activateTime uint32) {
+	s.refreshMenu()
+	s.Menu.PopupAtStatusIcon(icon, gdk.Button(button), activateTime)
+}
+
+// addRecentGame puts game ID to the beginning of the recent games
+func addRecentGame(recent []string, id string) []string {
+	games := []string{id}
+	for _, recentID := range recent {
+		if recentID != id && len(games) < maxRecentGames {
+			games = append(games, recentID)
+		}
+	}
+
+	return games
+}
@@ -0,0 +1,54 @@
+package ui
+
+import (
+	"github.com/gotk3/gotk3/gtk"
+	"github.com/jhekasoft/insteadman3/core/manager"
+	"github.com/jhekasoft/insteadman3/gtk/i18n"
+	"github.com/jhekasoft/insteadman3/gtk/osintegration"
+)
+
+// showTelemetryPreview shows reports exactly as they would be sent to the repositories
+func showTelemetryPreview(m *manager.Manager, parent *gtk.Window) {
+	if m.Telemetry == nil {
+		return
+	}
+
+	dlg, _ := gtk.DialogNew()
+	dlg.SetTitle(i18n.T("Statistics preview"))
+	dlg.AddButton(i18n.T("Close"), gtk.RESPONSE_ACCEPT)
+	dlg.SetDefaultSize(480, 360)
+	dlgBox, _ := dlg.GetContentArea()
+	dlgBox.SetSpacing(6)
+
+	lbl, _ := gtk.LabelNew(i18n.T("Only install counts of the games, InsteadMan version and OS are sent " +
+		"to the repositories which collect statistics."))
+	lbl.SetMarginStart(6)
+	lbl.SetMarginEnd(6)
+	lbl.SetLineWrap(true)
+	dlgBox.Add(lbl)
+
+	scrolled, _ := gtk.ScrolledWindowNew(nil, nil)
+	scrolled.SetVExpand(true)
+	scrolled.SetMarginStart(6)
+	scrolled.SetMarginEnd(6)
+
+	previewLbl, _ := gtk.LabelNew(m.Telemetry.Preview(m.Config().Repositories))
+	previewLbl.SetSelectable(true)
+	previewLbl.SetHAlign(gtk.ALIGN_START)
+	previewLbl.SetVAlign(gtk.ALIGN_START)
+	scrolled.Add(previewLbl)
+	dlgBox.Add(scrolled)
+	dlgBox.ShowAll()
+
+	dlg.SetModal(true)
+	dlg.SetPosition(gtk.WIN_POS_CENTER)
+	if parent != nil {
+		dlg.SetTransientFor(parent)
+	}
+
+	// OS integrations for window
+	osintegration.OsIntegrateDialog(dlg)
+
+	dlg.Run()
+	dlg.Destroy()
+}
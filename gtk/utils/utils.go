@@ -94,6 +94,28 @@ func GetEntry(b *gtk.Builder, id string) (treeView *gtk.Entry) {
 	return
 }
 
+func GetSearchEntry(b *gtk.Builder, id string) (el *gtk.SearchEntry) {
+	obj, e := b.GetObject(id)
+	if e != nil {
+		log.Printf("SearchEntry error: %s", e)
+		return nil
+	}
+
+	el, _ = obj.(*gtk.SearchEntry)
+	return
+}
+
+func GetHeaderBar(b *gtk.Builder, id string) (el *gtk.HeaderBar) {
+	obj, e := b.GetObject(id)
+	if e != nil {
+		log.Printf("HeaderBar error: %s", e)
+		return nil
+	}
+
+	el, _ = obj.(*gtk.HeaderBar)
+	return
+}
+
 func GetComboBox(b *gtk.Builder, id string) (combobox *gtk.ComboBox) {
 	obj, e := b.GetObject(id)
 	if e != nil {
@@ -193,6 +215,28 @@ func GetCellRendererText(b *gtk.Builder, id string) (el *gtk.CellRendererText) {
 	return
 }
 
+func GetCellRendererToggle(b *gtk.Builder, id string) (el *gtk.CellRendererToggle) {
+	obj, e := b.GetObject(id)
+	if e != nil {
+		log.Printf("CellRendererToggle error: %s", e)
+		return nil
+	}
+
+	el, _ = obj.(*gtk.CellRendererToggle)
+	return
+}
+
+func GetProgressBar(b *gtk.Builder, id string) (el *gtk.ProgressBar) {
+	obj, e := b.GetObject(id)
+	if e != nil {
+		log.Printf("ProgressBar error: %s", e)
+		return nil
+	}
+
+	el, _ = obj.(*gtk.ProgressBar)
+	return
+}
+
 func GetFilterValues(entryKeyword *gtk.Entry, cmbBoxRepo *gtk.ComboBox, cmbBoxLang *gtk.ComboBox,
 	chckBtnInstalled *gtk.CheckButton) (keywordP, repoP, langP *string, onlyInstalled bool) {
 	var e error
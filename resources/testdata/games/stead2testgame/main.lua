@@ -1,6 +1,8 @@
 -- $Name: Stead2 Test game$
 -- $Version: 0.4$
 
+instead_version "1.9.1"
+
 game.act = 'Не понимаю, что это.';
 game.inv = 'Странный предмет.';
 game.use = 'Не получится.';
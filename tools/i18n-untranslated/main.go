@@ -0,0 +1,100 @@
+// i18n-untranslated prints messages of the sources (i18n.T, i18n.N calls and translatable GtkBuilder
+// properties) which aren't translated in the .po catalogs. Output is in the .po format, so missing
+// messages can be added to the catalog. Run it from the repository root:
+//
+//	go run ./tools/i18n-untranslated [-locale resources/locale] [-lang ru]
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/jhekasoft/insteadman3/core/i18n"
+)
+
+const domain = "insteadman"
+
+var (
+	goSources    = []string{"cli/*.go", "gtk/*.go", "gtk/ui/*.go", "core/notify/*.go"}
+	gladeSources = []string{"resources/gtk/*.glade"}
+)
+
+func main() {
+	localeDir := flag.String("locale", "resources/locale", "locale directory")
+	lang := flag.String("lang", "", "language of the catalog (all the languages by default)")
+	flag.Parse()
+
+	var messages i18n.Messages
+	exitIfError(messages.ExtractGo(glob(goSources)...))
+	exitIfError(messages.ExtractGlade(glob(gladeSources)...))
+
+	langs := []string{*lang}
+	if *lang == "" {
+		dirs, e := ioutil.ReadDir(*localeDir)
+		exitIfError(e)
+
+		langs = nil
+		for _, dir := range dirs {
+			if dir.IsDir() {
+				langs = append(langs, dir.Name())
+			}
+		}
+	}
+
+	total := 0
+	for _, lang := range langs {
+		data, e := ioutil.ReadFile(filepath.Join(*localeDir, lang, "LC_MESSAGES", domain+".po"))
+		exitIfError(e)
+
+		entries, e := i18n.ReadPO(data)
+		exitIfError(e)
+
+		untranslated := messages.Untranslated(entries)
+		total += len(untranslated)
+
+		fmt.Printf("# %s: %d of %d messages are untranslated\n\n", lang, len(untranslated), len(messages.List))
+		for _, msg := range untranslated {
+			printEntry(msg)
+		}
+	}
+
+	if total > 0 {
+		os.Exit(1)
+	}
+}
+
+// printEntry prints message in the .po format
+func printEntry(msg *i18n.Message) {
+	fmt.Printf("#: %s\n", strings.Join(msg.Refs, " "))
+	fmt.Printf("msgid %s\n", strconv.Quote(msg.Id))
+	if msg.Plural != "" {
+		fmt.Printf("msgid_plural %s\n", strconv.Quote(msg.Plural))
+		fmt.Print("msgstr[0] \"\"\n\n")
+		return
+	}
+	fmt.Print("msgstr \"\"\n\n")
+}
+
+func glob(patterns []string) (paths []string) {
+	for _, pattern := range patterns {
+		matches, e := filepath.Glob(pattern)
+		exitIfError(e)
+		paths = append(paths, matches...)
+	}
+	sort.Strings(paths)
+
+	return
+}
+
+func exitIfError(e error) {
+	if e != nil {
+		fmt.Fprintln(os.Stderr, e)
+		os.Exit(2)
+	}
+}